@@ -0,0 +1,51 @@
+package eventbus
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+)
+
+// InboxSchema creates the table Dedupe records handled events in. Each
+// consuming service runs it as part of its own schema initialisation.
+const InboxSchema = `
+	CREATE TABLE IF NOT EXISTS processed_events (
+		consumer_group VARCHAR(100) NOT NULL,
+		event_id VARCHAR(36) NOT NULL,
+		processed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (consumer_group, event_id)
+	);
+`
+
+// Dedupe wraps handler so each event ID is handled once per group, however
+// often the bus delivers it. The event is claimed in a transaction that stays
+// open while the handler runs: a concurrent delivery of the same event waits
+// on the uncommitted claim, and the claim is only committed once the handler
+// has succeeded. If the handler fails or the process dies, the transaction
+// rolls back and the event is retried. A crash between the handler finishing
+// and the commit also means a retry, so handlers must be idempotent.
+func Dedupe(db *sql.DB, group string, handler Handler) Handler {
+	return func(ctx context.Context, env *eventspb.Envelope) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		result, err := tx.ExecContext(ctx,
+			`INSERT INTO processed_events (consumer_group, event_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			group, env.GetId())
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+
+		if err := handler(ctx, env); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+}
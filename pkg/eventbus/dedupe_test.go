@@ -0,0 +1,170 @@
+package eventbus
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+)
+
+// inbox is a database/sql driver holding processed_events in memory. An
+// insert is visible to other connections only once its transaction commits,
+// which is the part of Postgres Dedupe relies on.
+type inbox struct {
+	mu        sync.Mutex
+	committed map[string]bool
+}
+
+func openInbox() (*sql.DB, *inbox) {
+	in := &inbox{committed: make(map[string]bool)}
+	return sql.OpenDB(in), in
+}
+
+func (in *inbox) Connect(context.Context) (driver.Conn, error) {
+	return &inboxConn{inbox: in}, nil
+}
+
+func (in *inbox) Driver() driver.Driver { return nil }
+
+func (in *inbox) rows() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return len(in.committed)
+}
+
+type inboxConn struct {
+	inbox   *inbox
+	pending map[string]bool
+}
+
+func (c *inboxConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("inbox: statements are not supported")
+}
+
+func (c *inboxConn) Close() error { return nil }
+
+func (c *inboxConn) Begin() (driver.Tx, error) {
+	c.pending = make(map[string]bool)
+	return c, nil
+}
+
+func (c *inboxConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if !strings.HasPrefix(query, "INSERT INTO processed_events") || len(args) != 2 {
+		return nil, errors.New("inbox: unexpected statement: " + query)
+	}
+	key := args[0].Value.(string) + "/" + args[1].Value.(string)
+
+	c.inbox.mu.Lock()
+	defer c.inbox.mu.Unlock()
+	if c.inbox.committed[key] || c.pending[key] {
+		return driver.RowsAffected(0), nil
+	}
+	c.pending[key] = true
+	return driver.RowsAffected(1), nil
+}
+
+func (c *inboxConn) Commit() error {
+	c.inbox.mu.Lock()
+	defer c.inbox.mu.Unlock()
+	for key := range c.pending {
+		c.inbox.committed[key] = true
+	}
+	c.pending = nil
+	return nil
+}
+
+func (c *inboxConn) Rollback() error {
+	c.pending = nil
+	return nil
+}
+
+func TestDedupeHandlesRedeliveryOnce(t *testing.T) {
+	db, inbox := openInbox()
+	defer db.Close()
+
+	var handler recorder
+	dedupe := Dedupe(db, "orders", handler.handle)
+	env := testEnvelope(t, "evt-1", UserCreated, &eventspb.UserCreated{})
+
+	for range 3 {
+		if err := dedupe(context.Background(), env); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if handler.calls != 1 {
+		t.Errorf("handler called %d times, want 1", handler.calls)
+	}
+	if got := inbox.rows(); got != 1 {
+		t.Errorf("%d inbox rows, want 1", got)
+	}
+
+	// Other groups handle the same event themselves.
+	var other recorder
+	if err := Dedupe(db, "notifications", other.handle)(context.Background(), env); err != nil {
+		t.Fatal(err)
+	}
+	if other.calls != 1 {
+		t.Errorf("other group's handler called %d times, want 1", other.calls)
+	}
+}
+
+func TestDedupeRollsBackOnHandlerError(t *testing.T) {
+	db, inbox := openInbox()
+	defer db.Close()
+
+	handler := &recorder{failures: 1}
+	dedupe := Dedupe(db, "orders", handler.handle)
+	env := testEnvelope(t, "evt-1", UserCreated, &eventspb.UserCreated{})
+
+	if err := dedupe(context.Background(), env); err == nil {
+		t.Fatal("handler error was not returned")
+	}
+	if got := inbox.rows(); got != 0 {
+		t.Fatalf("%d inbox rows after a failed handler, want the claim rolled back", got)
+	}
+
+	// The redelivery is handled, and only that once.
+	for range 2 {
+		if err := dedupe(context.Background(), env); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if handler.calls != 2 || len(handler.handled()) != 1 {
+		t.Errorf("handler called %d times and succeeded %d, want 2 and 1", handler.calls, len(handler.handled()))
+	}
+	if got := inbox.rows(); got != 1 {
+		t.Errorf("%d inbox rows, want 1", got)
+	}
+}
+
+// TestDedupeOnMemoryBus publishes an event twice, as a bus redelivering it
+// after a lost ack would, to a handler that fails its first attempt.
+func TestDedupeOnMemoryBus(t *testing.T) {
+	db, _ := openInbox()
+	defer db.Close()
+
+	bus := NewMemoryBus()
+	handler := &recorder{failures: 1}
+	if err := bus.Subscribe(context.Background(), "orders", []string{UserCreated}, Dedupe(db, "orders", handler.handle)); err != nil {
+		t.Fatal(err)
+	}
+
+	env := testEnvelope(t, "evt-1", UserCreated, &eventspb.UserCreated{})
+	for range 2 {
+		if err := bus.Publish(context.Background(), env); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := handler.handled(); len(got) != 1 {
+		t.Errorf("handled %v, want evt-1 once", got)
+	}
+	if handler.calls != 2 {
+		t.Errorf("handler called %d times, want 2: the failed attempt and its retry", handler.calls)
+	}
+}
@@ -0,0 +1,117 @@
+// Package eventbus carries domain events between services. Events travel in
+// an eventspb.Envelope whose payload is one of the typed messages in
+// proto/events.proto; Bus implementations deliver each event to every
+// consumer group subscribed to its type, and to one member of each group.
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Event types carried on the bus. They match the outbox event types of the
+// publishing services.
+const (
+	UserCreated     = "user.created"
+	UserUpdated     = "user.updated"
+	UserDeleted     = "user.deleted"
+	OrderCreated    = "order.created"
+	OrderUpdated    = "order.updated"
+	OrderExpired    = "order.expired"
+	PaymentCreated  = "payment.created"
+	PaymentUpdated  = "payment.updated"
+	PaymentRefunded = "payment.refunded"
+)
+
+var (
+	ErrUnknownEventType = errors.New("unknown event type")
+	ErrSchemaMismatch   = errors.New("event payload does not match its type")
+	ErrClosed           = errors.New("event bus closed")
+)
+
+// schema is the current payload message and version of an event type.
+// Additive (wire compatible) changes to a payload bump the version; older
+// consumers still decode newer events, ignoring fields they do not know.
+type schema struct {
+	version uint32
+	message protoreflect.FullName
+}
+
+var schemas = map[string]schema{
+	UserCreated:     {1, (&eventspb.UserCreated{}).ProtoReflect().Descriptor().FullName()},
+	UserUpdated:     {1, (&eventspb.UserUpdated{}).ProtoReflect().Descriptor().FullName()},
+	UserDeleted:     {1, (&eventspb.UserDeleted{}).ProtoReflect().Descriptor().FullName()},
+	OrderCreated:    {3, (&eventspb.OrderCreated{}).ProtoReflect().Descriptor().FullName()},
+	OrderUpdated:    {3, (&eventspb.OrderUpdated{}).ProtoReflect().Descriptor().FullName()},
+	OrderExpired:    {3, (&eventspb.OrderExpired{}).ProtoReflect().Descriptor().FullName()},
+	PaymentCreated:  {2, (&eventspb.PaymentCreated{}).ProtoReflect().Descriptor().FullName()},
+	PaymentUpdated:  {2, (&eventspb.PaymentUpdated{}).ProtoReflect().Descriptor().FullName()},
+	PaymentRefunded: {2, (&eventspb.PaymentRefunded{}).ProtoReflect().Descriptor().FullName()},
+}
+
+// Handler processes one event. Returning an error asks the bus to redeliver
+// it later, so handlers must be idempotent; see Dedupe.
+type Handler func(ctx context.Context, env *eventspb.Envelope) error
+
+// Bus publishes envelopes and delivers them to consumer groups.
+type Bus interface {
+	Publish(ctx context.Context, env *eventspb.Envelope) error
+	// Subscribe registers handler as a member of group for the given event
+	// types. Each event is delivered once per group; members of the same
+	// group share the load and must subscribe to the same types. Delivery
+	// stops when ctx is cancelled.
+	Subscribe(ctx context.Context, group string, eventTypes []string, handler Handler) error
+	Close() error
+}
+
+// NewEnvelope wraps msg, which must be the registered payload for eventType.
+// id should be stable across retries (the outbox event ID) so brokers and
+// consumers can deduplicate.
+func NewEnvelope(id, eventType, source, aggregateID string, occurredAt time.Time, msg proto.Message) (*eventspb.Envelope, error) {
+	s, ok := schemas[eventType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+	if msg.ProtoReflect().Descriptor().FullName() != s.message {
+		return nil, fmt.Errorf("%w: %s is not %s", ErrSchemaMismatch, msg.ProtoReflect().Descriptor().FullName(), s.message)
+	}
+
+	payload, err := anypb.New(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &eventspb.Envelope{
+		Id:            id,
+		Type:          eventType,
+		SchemaVersion: s.version,
+		Source:        source,
+		AggregateId:   aggregateID,
+		OccurredAt:    timestamppb.New(occurredAt),
+		Payload:       payload,
+	}, nil
+}
+
+// Decode returns the typed payload of env.
+func Decode(env *eventspb.Envelope) (proto.Message, error) {
+	s, ok := schemas[env.GetType()]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, env.GetType())
+	}
+	if env.GetPayload().MessageName() != s.message {
+		return nil, fmt.Errorf("%w: %s carries %s", ErrSchemaMismatch, env.GetType(), env.GetPayload().MessageName())
+	}
+	return env.GetPayload().UnmarshalNew()
+}
+
+// Subject returns the broker subject an event type is published on.
+func Subject(eventType string) string {
+	return "events." + eventType
+}
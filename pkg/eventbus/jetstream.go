@@ -0,0 +1,118 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"google.golang.org/protobuf/proto"
+)
+
+type JetStreamConfig struct {
+	URL    string
+	Stream string
+	// Name identifies the connection in NATS monitoring.
+	Name string
+	// MaxDeliver bounds redeliveries of an event a handler keeps failing.
+	MaxDeliver int
+	AckWait    time.Duration
+	RetryDelay time.Duration
+}
+
+// JetStreamBus is a Bus backed by a NATS JetStream stream. Every consumer
+// group is a durable pull consumer, so events published while a service is
+// down are delivered when it comes back.
+type JetStreamBus struct {
+	nc  *nats.Conn
+	js  jetstream.JetStream
+	cfg JetStreamConfig
+}
+
+// NewJetStreamBus connects to cfg.URL and creates or updates the stream that
+// holds all event subjects.
+func NewJetStreamBus(ctx context.Context, cfg JetStreamConfig) (*JetStreamBus, error) {
+	nc, err := nats.Connect(cfg.URL, nats.Name(cfg.Name), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     cfg.Stream,
+		Subjects: []string{Subject(">")},
+		Storage:  jetstream.FileStorage,
+		// Publish sets the envelope ID as the message ID, so relays that
+		// retry within this window do not store the event twice.
+		Duplicates: 2 * time.Minute,
+	})
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("create stream %s: %w", cfg.Stream, err)
+	}
+
+	return &JetStreamBus{nc: nc, js: js, cfg: cfg}, nil
+}
+
+func (b *JetStreamBus) Publish(ctx context.Context, env *eventspb.Envelope) error {
+	data, err := proto.Marshal(env)
+	if err != nil {
+		return err
+	}
+	_, err = b.js.Publish(ctx, Subject(env.GetType()), data, jetstream.WithMsgID(env.GetId()))
+	return err
+}
+
+func (b *JetStreamBus) Subscribe(ctx context.Context, group string, eventTypes []string, handler Handler) error {
+	subjects := make([]string, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		subjects = append(subjects, Subject(t))
+	}
+
+	consumer, err := b.js.CreateOrUpdateConsumer(ctx, b.cfg.Stream, jetstream.ConsumerConfig{
+		Durable:        group,
+		FilterSubjects: subjects,
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		AckWait:        b.cfg.AckWait,
+		MaxDeliver:     b.cfg.MaxDeliver,
+	})
+	if err != nil {
+		return fmt.Errorf("create consumer %s: %w", group, err)
+	}
+
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		env := &eventspb.Envelope{}
+		if err := proto.Unmarshal(msg.Data(), env); err != nil {
+			log.Printf("eventbus: %s dropping undecodable message on %s: %v", group, msg.Subject(), err)
+			msg.Term()
+			return
+		}
+
+		if err := handler(ctx, env); err != nil {
+			log.Printf("eventbus: %s handling %s %s: %v", group, env.GetType(), env.GetId(), err)
+			msg.NakWithDelay(b.cfg.RetryDelay)
+			return
+		}
+		msg.Ack()
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		consumeCtx.Stop()
+	}()
+	return nil
+}
+
+func (b *JetStreamBus) Close() error {
+	return b.nc.Drain()
+}
@@ -0,0 +1,129 @@
+package eventbus
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+	"google.golang.org/protobuf/proto"
+)
+
+// MemoryBus is an in-process Bus for tests and single-process setups.
+// Publish delivers synchronously: each subscribed group gets the event once,
+// rotating between the group's members. Handler errors are logged and the
+// event is retried up to MaxAttempts times before being dropped.
+type MemoryBus struct {
+	MaxAttempts int
+
+	mu     sync.Mutex
+	groups map[string]*memoryGroup
+	closed bool
+}
+
+type memoryGroup struct {
+	types   map[string]bool
+	members []*memoryMember
+	next    int
+}
+
+type memoryMember struct {
+	ctx     context.Context
+	handler Handler
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{MaxAttempts: 3, groups: make(map[string]*memoryGroup)}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, env *eventspb.Envelope) error {
+	type delivery struct {
+		group  string
+		member *memoryMember
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	var deliveries []delivery
+	for name, g := range b.groups {
+		if !g.types[env.GetType()] {
+			continue
+		}
+		if m := g.pick(); m != nil {
+			deliveries = append(deliveries, delivery{name, m})
+		}
+	}
+	b.mu.Unlock()
+
+	for _, d := range deliveries {
+		b.deliver(d.group, d.member, env)
+	}
+	return nil
+}
+
+func (b *MemoryBus) deliver(group string, m *memoryMember, env *eventspb.Envelope) {
+	for attempt := 1; attempt <= b.MaxAttempts; attempt++ {
+		err := m.handler(m.ctx, proto.Clone(env).(*eventspb.Envelope))
+		if err == nil {
+			return
+		}
+		log.Printf("eventbus: %s handling %s %s (attempt %d/%d): %v", group, env.GetType(), env.GetId(), attempt, b.MaxAttempts, err)
+	}
+}
+
+func (b *MemoryBus) Subscribe(ctx context.Context, group string, eventTypes []string, handler Handler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+
+	g, ok := b.groups[group]
+	if !ok {
+		g = &memoryGroup{types: make(map[string]bool)}
+		b.groups[group] = g
+	}
+	for _, t := range eventTypes {
+		g.types[t] = true
+	}
+	m := &memoryMember{ctx: ctx, handler: handler}
+	g.members = append(g.members, m)
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		g.remove(m)
+	}()
+	return nil
+}
+
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.groups = make(map[string]*memoryGroup)
+	return nil
+}
+
+// pick returns the next member in rotation, or nil if the group is empty.
+func (g *memoryGroup) pick() *memoryMember {
+	if len(g.members) == 0 {
+		return nil
+	}
+	m := g.members[g.next%len(g.members)]
+	g.next++
+	return m
+}
+
+func (g *memoryGroup) remove(m *memoryMember) {
+	for i, member := range g.members {
+		if member == m {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+	"google.golang.org/protobuf/proto"
+)
+
+func testEnvelope(t *testing.T, id, eventType string, msg proto.Message) *eventspb.Envelope {
+	t.Helper()
+	env, err := NewEnvelope(id, eventType, "test", "aggregate-1", time.Now(), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+// recorder is a Handler that records the IDs of the events it handled and
+// fails the first failures calls.
+type recorder struct {
+	mu       sync.Mutex
+	ids      []string
+	calls    int
+	failures int
+}
+
+func (r *recorder) handle(_ context.Context, env *eventspb.Envelope) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if r.calls <= r.failures {
+		return errors.New("handler failed")
+	}
+	r.ids = append(r.ids, env.GetId())
+	return nil
+}
+
+func (r *recorder) handled() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.ids...)
+}
+
+func TestMemoryBusDeliversOncePerGroup(t *testing.T) {
+	bus := NewMemoryBus()
+	ctx := context.Background()
+
+	var first, second, other recorder
+	for _, handler := range []*recorder{&first, &second} {
+		if err := bus.Subscribe(ctx, "orders", []string{UserCreated}, handler.handle); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bus.Subscribe(ctx, "notifications", []string{UserCreated}, other.handle); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"evt-1", "evt-2", "evt-3", "evt-4"} {
+		if err := bus.Publish(ctx, testEnvelope(t, id, UserCreated, &eventspb.UserCreated{})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := len(first.handled()) + len(second.handled()); got != 4 {
+		t.Errorf("orders group handled %d events, want 4", got)
+	}
+	if len(first.handled()) != 2 || len(second.handled()) != 2 {
+		t.Errorf("orders members handled %v and %v, want the events shared between them", first.handled(), second.handled())
+	}
+	if got := other.handled(); len(got) != 4 {
+		t.Errorf("notifications group handled %v, want every event", got)
+	}
+}
+
+func TestMemoryBusFiltersByType(t *testing.T) {
+	bus := NewMemoryBus()
+	ctx := context.Background()
+
+	var users recorder
+	if err := bus.Subscribe(ctx, "users", []string{UserCreated}, users.handle); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(ctx, testEnvelope(t, "evt-1", OrderCreated, &eventspb.OrderCreated{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(ctx, testEnvelope(t, "evt-2", UserCreated, &eventspb.UserCreated{})); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := users.handled(); len(got) != 1 || got[0] != "evt-2" {
+		t.Errorf("handled %v, want [evt-2]", got)
+	}
+}
+
+func TestMemoryBusRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		wantCalls int
+		handled   bool
+	}{
+		{"succeeds first time", 0, 1, true},
+		{"succeeds on retry", 2, 3, true},
+		{"dropped after MaxAttempts", 5, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewMemoryBus()
+			handler := &recorder{failures: tt.failures}
+			if err := bus.Subscribe(context.Background(), "users", []string{UserCreated}, handler.handle); err != nil {
+				t.Fatal(err)
+			}
+			if err := bus.Publish(context.Background(), testEnvelope(t, "evt-1", UserCreated, &eventspb.UserCreated{})); err != nil {
+				t.Fatal(err)
+			}
+			if handler.calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", handler.calls, tt.wantCalls)
+			}
+			if got := len(handler.handled()) == 1; got != tt.handled {
+				t.Errorf("handled = %v, want %v", got, tt.handled)
+			}
+		})
+	}
+}
+
+func TestMemoryBusDeliversCopies(t *testing.T) {
+	bus := NewMemoryBus()
+	ctx := context.Background()
+
+	var seen []string
+	mutate := func(_ context.Context, env *eventspb.Envelope) error {
+		seen = append(seen, env.GetId())
+		env.Id = "changed"
+		return nil
+	}
+	for _, group := range []string{"a", "b"} {
+		if err := bus.Subscribe(ctx, group, []string{UserCreated}, mutate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env := testEnvelope(t, "evt-1", UserCreated, &eventspb.UserCreated{})
+	if err := bus.Publish(ctx, env); err != nil {
+		t.Fatal(err)
+	}
+
+	if env.GetId() != "evt-1" || len(seen) != 2 || seen[0] != "evt-1" || seen[1] != "evt-1" {
+		t.Errorf("published %s, handlers saw %v; want evt-1 everywhere", env.GetId(), seen)
+	}
+}
+
+func TestMemoryBusStopsDeliveryWhenContextCancelled(t *testing.T) {
+	bus := NewMemoryBus()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var cancelled, active recorder
+	if err := bus.Subscribe(ctx, "users", []string{UserCreated}, cancelled.handle); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Subscribe(context.Background(), "users", []string{UserCreated}, active.handle); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	// The member leaves its group asynchronously.
+	deadline := time.Now().Add(time.Second)
+	for {
+		bus.mu.Lock()
+		members := len(bus.groups["users"].members)
+		bus.mu.Unlock()
+		if members == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cancelled member was not removed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, id := range []string{"evt-1", "evt-2"} {
+		if err := bus.Publish(context.Background(), testEnvelope(t, id, UserCreated, &eventspb.UserCreated{})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := cancelled.handled(); len(got) != 0 {
+		t.Errorf("cancelled member handled %v", got)
+	}
+	if got := active.handled(); len(got) != 2 {
+		t.Errorf("remaining member handled %v, want both events", got)
+	}
+}
+
+func TestMemoryBusClosed(t *testing.T) {
+	bus := NewMemoryBus()
+	var handler recorder
+	if err := bus.Subscribe(context.Background(), "users", []string{UserCreated}, handler.handle); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bus.Publish(context.Background(), testEnvelope(t, "evt-1", UserCreated, &eventspb.UserCreated{})); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish: err = %v, want ErrClosed", err)
+	}
+	if err := bus.Subscribe(context.Background(), "users", []string{UserCreated}, handler.handle); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe: err = %v, want ErrClosed", err)
+	}
+	if handler.calls != 0 {
+		t.Errorf("handler called %d times after Close", handler.calls)
+	}
+}
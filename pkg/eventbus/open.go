@@ -0,0 +1,39 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	DriverMemory = "memory"
+	DriverNATS   = "nats"
+)
+
+type Config struct {
+	// Driver selects the implementation: "memory" or "nats".
+	Driver string
+	URL    string
+	Stream string
+	Name   string
+}
+
+// Open returns the Bus selected by cfg.Driver.
+func Open(ctx context.Context, cfg Config) (Bus, error) {
+	switch cfg.Driver {
+	case DriverMemory:
+		return NewMemoryBus(), nil
+	case DriverNATS:
+		return NewJetStreamBus(ctx, JetStreamConfig{
+			URL:        cfg.URL,
+			Stream:     cfg.Stream,
+			Name:       cfg.Name,
+			MaxDeliver: 10,
+			AckWait:    30 * time.Second,
+			RetryDelay: 5 * time.Second,
+		})
+	default:
+		return nil, fmt.Errorf("unknown event bus driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,30 @@
+package eventbus
+
+import (
+	"context"
+
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
+	"google.golang.org/protobuf/proto"
+)
+
+// Translator converts an outbox event into its bus payload. Returning a nil
+// message skips the event.
+type Translator func(event outbox.Event) (proto.Message, error)
+
+// OutboxPublisher returns an outbox.Publisher that publishes relayed events
+// on bus. The envelope ID is the outbox event ID, so redelivered events can
+// be recognised downstream.
+func OutboxPublisher(bus Bus, source string, translate Translator) outbox.Publisher {
+	return outbox.PublisherFunc(func(ctx context.Context, event outbox.Event) error {
+		msg, err := translate(event)
+		if err != nil || msg == nil {
+			return err
+		}
+
+		env, err := NewEnvelope(event.ID, event.Type, source, event.AggregateID, event.CreatedAt, msg)
+		if err != nil {
+			return err
+		}
+		return bus.Publish(ctx, env)
+	})
+}
@@ -0,0 +1,91 @@
+// Package fx converts money between currencies. Currencies are ISO 4217
+// codes and rates say how many units of the target currency one unit of the
+// source buys.
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// RatePrecision is the number of decimal places rates are quoted to, and
+// stored with.
+const RatePrecision = 8
+
+var (
+	ErrInvalidCurrency = errors.New("invalid currency code")
+	ErrUnknownRate     = errors.New("no exchange rate for currency pair")
+)
+
+// RateProvider quotes exchange rates.
+type RateProvider interface {
+	// Rate returns how many units of to one unit of from buys. It fails with
+	// ErrUnknownRate for a pair it cannot quote.
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// NormalizeCurrency upper-cases code and checks that it looks like an ISO
+// 4217 code.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+		}
+	}
+	return code, nil
+}
+
+// minorUnits lists the ISO 4217 currencies whose minor unit is not a
+// hundredth, with the number of decimal places their amounts have.
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// MinorUnits returns the number of decimal places amounts in currency have:
+// 0 for JPY, 3 for KWD and 2 for most currencies.
+func MinorUnits(currency string) int {
+	if units, ok := minorUnits[currency]; ok {
+		return units
+	}
+	return 2
+}
+
+// Round rounds amount to currency's minor unit, half away from zero. The
+// amount is first snapped to a millionth so that values such as 1.005, which
+// float64 holds as 1.00499..., round the way they read.
+func Round(amount float64, currency string) float64 {
+	units := MinorUnits(currency)
+	return math.Round(math.Round(amount*1e6)/math.Pow10(6-units)) / math.Pow10(units)
+}
+
+// ToMinor converts amount to a whole number of currency's minor unit, such
+// as cents for USD or yen for JPY, rounding as Round does.
+func ToMinor(amount float64, currency string) int64 {
+	return int64(math.Round(Round(amount, currency) * math.Pow10(MinorUnits(currency))))
+}
+
+// FromMinor converts a whole number of currency's minor unit to an amount.
+func FromMinor(minor int64, currency string) float64 {
+	return float64(minor) / math.Pow10(MinorUnits(currency))
+}
+
+// Convert converts amount at rate and rounds the result to the minor unit of
+// currency, the currency converted to.
+func Convert(amount, rate float64, currency string) float64 {
+	return Round(amount*rate, currency)
+}
+
+func roundRate(rate float64) float64 {
+	scale := math.Pow10(RatePrecision)
+	return math.Round(rate*scale) / scale
+}
@@ -0,0 +1,93 @@
+package fx
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestRound compares Round's results with testdata/round.golden. Run with
+// -update to rewrite it after an intended change.
+func TestRound(t *testing.T) {
+	cases := []struct {
+		amount   float64
+		currency string
+	}{
+		{0, "USD"},
+		{1.005, "USD"},
+		{1.015, "USD"},
+		{2.675, "USD"},
+		{1.004999, "USD"},
+		{-1.005, "USD"},
+		{0.125, "EUR"},
+		{19.99 * 3, "EUR"},
+		{0.1 + 0.2, "EUR"},
+		{1234.5, "JPY"},
+		{1234.4999, "JPY"},
+		{-0.5, "JPY"},
+		{1.0005, "KWD"},
+		{1.2345, "BHD"},
+		{1.005, "XYZ"},
+	}
+
+	var got bytes.Buffer
+	for _, tc := range cases {
+		fmt.Fprintf(&got, "%s %s -> %s\n", strconv.FormatFloat(tc.amount, 'f', -1, 64), tc.currency,
+			strconv.FormatFloat(Round(tc.amount, tc.currency), 'f', -1, 64))
+	}
+
+	path := filepath.Join("testdata", "round.golden")
+	if *update {
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("%s differs from the golden file:\ngot:\n%s\nwant:\n%s", path, got.Bytes(), want)
+	}
+}
+
+func TestMinorUnitConversions(t *testing.T) {
+	cases := []struct {
+		amount   float64
+		currency string
+		minor    int64
+	}{
+		{19.99, "USD", 1999},
+		{1.005, "USD", 101},
+		{-0.5, "EUR", -50},
+		{1234, "JPY", 1234},
+		{1234.5, "JPY", 1235},
+		{1.234, "KWD", 1234},
+		{1.2345, "CLF", 12345},
+		{16250000, "IDR", 1625000000},
+	}
+	for _, tc := range cases {
+		if got := ToMinor(tc.amount, tc.currency); got != tc.minor {
+			t.Errorf("ToMinor(%v, %s) = %d, want %d", tc.amount, tc.currency, got, tc.minor)
+		}
+		if got, want := FromMinor(tc.minor, tc.currency), Round(tc.amount, tc.currency); got != want {
+			t.Errorf("FromMinor(%d, %s) = %v, want %v", tc.minor, tc.currency, got, want)
+		}
+	}
+}
+
+func TestConvertRoundsToTargetCurrency(t *testing.T) {
+	if got := Convert(10, 155.237, "JPY"); got != 1552 {
+		t.Errorf("Convert(10, 155.237, JPY) = %v, want 1552", got)
+	}
+	if got := Convert(10, 0.92345, "EUR"); got != 9.23 {
+		t.Errorf("Convert(10, 0.92345, EUR) = %v, want 9.23", got)
+	}
+}
@@ -0,0 +1,80 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// TableConfig is a rates file: the value of one unit of Base in every other
+// currency, e.g. {"base": "USD", "rates": {"EUR": 0.92, "IDR": 16250}}.
+type TableConfig struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Table is a RateProvider with fixed rates against one base currency, for
+// local use and tests. Pairs that do not involve the base are crossed
+// through it.
+type Table struct {
+	base  string
+	rates map[string]float64
+}
+
+// LoadTable reads a TableConfig from a JSON file.
+func LoadTable(path string) (*Table, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fx rates: %w", err)
+	}
+
+	var cfg TableConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("parse fx rates %s: %w", path, err)
+	}
+	return NewTable(cfg)
+}
+
+func NewTable(cfg TableConfig) (*Table, error) {
+	base, err := NormalizeCurrency(cfg.Base)
+	if err != nil {
+		return nil, fmt.Errorf("fx rates: base: %w", err)
+	}
+
+	t := &Table{base: base, rates: map[string]float64{base: 1}}
+	for code, rate := range cfg.Rates {
+		currency, err := NormalizeCurrency(code)
+		if err != nil {
+			return nil, fmt.Errorf("fx rates: %w", err)
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("fx rates: rate for %s must be positive", currency)
+		}
+		if currency == base && rate != 1 {
+			return nil, fmt.Errorf("fx rates: rate for the base currency must be 1")
+		}
+		t.rates[currency] = rate
+	}
+	return t, nil
+}
+
+// Base returns the currency the table's rates are quoted against.
+func (t *Table) Base() string {
+	return t.base
+}
+
+func (t *Table) Rate(ctx context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	fromRate, ok := t.rates[from]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s/%s", ErrUnknownRate, from, to)
+	}
+	toRate, ok := t.rates[to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s/%s", ErrUnknownRate, from, to)
+	}
+	return roundRate(toRate / fromRate), nil
+}
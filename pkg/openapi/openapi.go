@@ -0,0 +1,249 @@
+// Package openapi publishes a service's hand-written HTTP API as an OpenAPI
+// 3 document. Each service lists its operations with the request and
+// response types its handlers use; schemas are derived from those types, so
+// they follow the code, and Mount refuses to start a service whose routes
+// and documented operations differ.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+// Version is the OpenAPI version of the documents built here.
+const Version = "3.0.3"
+
+// API describes a service's HTTP API.
+type API struct {
+	Title       string
+	Version     string
+	Description string
+	Operations  []Operation
+}
+
+// Operation documents one route.
+type Operation struct {
+	Method string
+	// Path in Gin syntax, e.g. /users/:id.
+	Path    string
+	Tag     string
+	Summary string
+	// Query is a struct whose form-tagged fields are the query parameters.
+	Query any
+	// Body is the JSON request body; BodyOptional lets clients omit it.
+	Body         any
+	BodyOptional bool
+	// Rules is the proto request the body is validated as; its buf.validate
+	// rules are added to the body's schema.
+	Rules proto.Message
+	// Status is the success status; 200 if zero.
+	Status int
+	// Response is the JSON response body, or nil if there is none.
+	Response any
+	// Auth marks routes that need a bearer access token.
+	Auth bool
+}
+
+// ErrorResponse is the body of every error response. Violations lists the
+// broken rules of a request that failed validation.
+type ErrorResponse struct {
+	Error      string               `json:"error"`
+	Violations []validate.Violation `json:"violations,omitempty"`
+}
+
+// HealthResponse is the body of every service's GET /health.
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Service string `json:"service"`
+}
+
+// Document builds the OpenAPI document for api.
+func Document(api API) (map[string]any, error) {
+	s := newSchemas()
+	paths := map[string]map[string]any{}
+	for _, op := range api.Operations {
+		path, params := pathParams(op.Path)
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		method := strings.ToLower(op.Method)
+		if _, ok := paths[path][method]; ok {
+			return nil, fmt.Errorf("openapi: %s %s documented twice", op.Method, op.Path)
+		}
+
+		query, err := s.queryParams(op.Query)
+		if err != nil {
+			return nil, fmt.Errorf("openapi: %s %s: %w", op.Method, op.Path, err)
+		}
+		operation := map[string]any{
+			"operationId": operationID(op.Method, op.Path),
+			"parameters":  append(params, query...),
+			"responses":   s.responses(op),
+		}
+		if op.Summary != "" {
+			operation["summary"] = op.Summary
+		}
+		if op.Tag != "" {
+			operation["tags"] = []string{op.Tag}
+		}
+		if op.Body != nil {
+			body := s.schema(reflect.TypeOf(op.Body))
+			if op.Rules != nil {
+				if err := s.rules(body, op.Rules.ProtoReflect().Descriptor()); err != nil {
+					return nil, fmt.Errorf("openapi: %s %s: %w", op.Method, op.Path, err)
+				}
+			}
+			operation["requestBody"] = map[string]any{
+				"required": !op.BodyOptional,
+				"content":  jsonContent(body),
+			}
+		}
+		if op.Auth {
+			operation["security"] = []map[string][]string{{"bearerAuth": {}}}
+		}
+		paths[path][method] = operation
+	}
+
+	return map[string]any{
+		"openapi": Version,
+		"info": map[string]any{
+			"title":       api.Title,
+			"version":     api.Version,
+			"description": api.Description,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": s.components,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}, nil
+}
+
+func (s *schemas) responses(op Operation) map[string]any {
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]any{"description": http.StatusText(status)}
+	if op.Response != nil {
+		success["content"] = jsonContent(s.schema(reflect.TypeOf(op.Response)))
+	}
+	return map[string]any{
+		fmt.Sprint(status): success,
+		"default": map[string]any{
+			"description": "Error",
+			"content":     jsonContent(s.schema(reflect.TypeOf(ErrorResponse{}))),
+		},
+	}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// pathParams converts a Gin path to an OpenAPI one and lists its
+// parameters.
+func pathParams(ginPath string) (string, []map[string]any) {
+	params := []map[string]any{}
+	segments := strings.Split(ginPath, "/")
+	for i, seg := range segments {
+		if name, ok := strings.CutPrefix(seg, ":"); ok {
+			segments[i] = "{" + name + "}"
+		} else if name, ok := strings.CutPrefix(seg, "*"); ok {
+			segments[i] = "{" + name + "}"
+		} else {
+			continue
+		}
+		params = append(params, map[string]any{
+			"name":     strings.Trim(segments[i], "{}"),
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID names an operation after its route, e.g. get_users_id.
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, seg := range strings.Split(path, "/") {
+		seg = strings.TrimLeft(seg, ":*")
+		if seg != "" {
+			id += "_" + strings.NewReplacer("-", "_", ".", "_").Replace(seg)
+		}
+	}
+	return id
+}
+
+// Mount serves api's document at /openapi.json and Swagger UI at /docs/.
+// Call it once every route is registered: it fails if router has routes
+// that api does not document, or api documents routes router lacks. Routes
+// under the ignore prefixes, such as generated ones with their own
+// documents, are not checked.
+func Mount(router *gin.Engine, api API, ignore ...string) error {
+	if err := check(router.Routes(), api.Operations, ignore); err != nil {
+		return err
+	}
+
+	doc, err := Document(api)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("openapi: %w", err)
+	}
+
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", body)
+	})
+	router.GET("/docs/*filepath", serveUI)
+	return nil
+}
+
+func check(routes gin.RoutesInfo, ops []Operation, ignore []string) error {
+	documented := map[string]bool{}
+	for _, op := range ops {
+		documented[op.Method+" "+op.Path] = true
+	}
+
+	var undocumented []string
+	for _, r := range routes {
+		if slices.ContainsFunc(ignore, func(prefix string) bool { return strings.HasPrefix(r.Path, prefix) }) {
+			continue
+		}
+		key := r.Method + " " + r.Path
+		if !documented[key] {
+			undocumented = append(undocumented, key)
+		}
+		delete(documented, key)
+	}
+
+	var problems []string
+	if len(undocumented) > 0 {
+		problems = append(problems, "undocumented routes: "+strings.Join(undocumented, ", "))
+	}
+	if len(documented) > 0 {
+		missing := make([]string, 0, len(documented))
+		for key := range documented {
+			missing = append(missing, key)
+		}
+		slices.Sort(missing)
+		problems = append(problems, "documented routes not registered: "+strings.Join(missing, ", "))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("openapi: document does not match router: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Package openapitest checks a service's OpenAPI document against a copy
+// committed to the repository, so that API changes show up in review.
+package openapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+var update = flag.Bool("update", false, "rewrite the committed OpenAPI documents")
+
+// Check mounts api on router, which must have every documented route, and
+// compares the document served at /openapi.json with the file at path. Run
+// the test with -update to rewrite the file after an intended change.
+func Check(t *testing.T, router *gin.Engine, api openapi.API, path string) {
+	t.Helper()
+	if err := openapi.Mount(router, api); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d", rec.Code)
+	}
+	var got bytes.Buffer
+	if err := json.Indent(&got, rec.Body.Bytes(), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	got.WriteByte('\n')
+
+	if *update {
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("the OpenAPI document differs from %s; run the test with -update and review the diff", path)
+	}
+}
@@ -0,0 +1,151 @@
+package openapi
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	validatepb "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// rules adds the buf.validate rules of md's fields that OpenAPI can express
+// to schema, the schema of a body whose JSON names are md's field names.
+// Fields whose zero value breaks their rules are required. Message fields
+// are followed into their own schemas.
+func (s *schemas) rules(schema map[string]any, md protoreflect.MessageDescriptor) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		schema, _ = s.components[strings.TrimPrefix(ref, "#/components/schemas/")].(map[string]any)
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	if properties == nil {
+		return nil
+	}
+
+	var zero []validate.Violation
+	if err := validate.Validate(dynamicpb.NewMessage(md)); err != nil {
+		var verr *validate.Error
+		if !errors.As(err, &verr) {
+			return err
+		}
+		zero = verr.Violations
+	}
+
+	required, _ := schema["required"].([]string)
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := string(fd.Name())
+		property, ok := properties[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		breaks := slices.ContainsFunc(zero, func(v validate.Violation) bool { return v.Field == name })
+		if breaks && !slices.Contains(required, name) {
+			required = append(required, name)
+		}
+
+		rules, _ := proto.GetExtension(fd.Options(), validatepb.E_Field).(*validatepb.FieldRules)
+		if fd.Message() != nil && !fd.IsMap() {
+			if fd.IsList() {
+				constrainRepeated(property, rules.GetRepeated())
+				property, _ = property["items"].(map[string]any)
+			}
+			if property == nil {
+				continue
+			}
+			if err := s.rules(property, fd.Message()); err != nil {
+				return err
+			}
+			continue
+		}
+		if repeated := rules.GetRepeated(); repeated != nil && fd.IsList() {
+			constrainRepeated(property, repeated)
+			rules = repeated.GetItems()
+			property, _ = property["items"].(map[string]any)
+		}
+		if property != nil {
+			constrainValue(property, rules)
+		}
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return nil
+}
+
+func constrainRepeated(schema map[string]any, rules *validatepb.RepeatedRules) {
+	if rules == nil {
+		return
+	}
+	if rules.HasMinItems() {
+		schema["minItems"] = rules.GetMinItems()
+	}
+	if rules.HasMaxItems() {
+		schema["maxItems"] = rules.GetMaxItems()
+	}
+	if rules.GetUnique() {
+		schema["uniqueItems"] = true
+	}
+}
+
+// constrainValue adds a string or number field's rules to its schema.
+func constrainValue(schema map[string]any, rules *validatepb.FieldRules) {
+	if rules == nil {
+		return
+	}
+	if str := rules.GetString(); str != nil {
+		switch {
+		case str.GetEmail():
+			schema["format"] = "email"
+		case str.GetUuid():
+			schema["format"] = "uuid"
+		}
+		if str.HasLen() {
+			schema["minLength"], schema["maxLength"] = str.GetLen(), str.GetLen()
+		}
+		if str.HasMinLen() {
+			schema["minLength"] = str.GetMinLen()
+		}
+		if str.HasMaxLen() {
+			schema["maxLength"] = str.GetMaxLen()
+		}
+		if str.HasPattern() {
+			schema["pattern"] = str.GetPattern()
+		}
+		if len(str.GetIn()) > 0 {
+			schema["enum"] = str.GetIn()
+		}
+		return
+	}
+
+	// The numeric rules messages share their field names.
+	typed := typeField(rules)
+	if typed == nil || typed.Message() == nil {
+		return
+	}
+	rules.ProtoReflect().Get(typed).Message().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() != nil {
+			return true
+		}
+		switch fd.Name() {
+		case "gt":
+			schema["minimum"], schema["exclusiveMinimum"] = v.Interface(), true
+		case "gte":
+			schema["minimum"] = v.Interface()
+		case "lt":
+			schema["maximum"], schema["exclusiveMaximum"] = v.Interface(), true
+		case "lte":
+			schema["maximum"] = v.Interface()
+		}
+		return true
+	})
+}
+
+func typeField(rules *validatepb.FieldRules) protoreflect.FieldDescriptor {
+	m := rules.ProtoReflect()
+	return m.WhichOneof(m.Descriptor().Oneofs().ByName("type"))
+}
@@ -0,0 +1,290 @@
+package openapi
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// SchemaTyper is implemented by types whose JSON is not derived from their
+// own fields, such as wrappers with a MarshalJSON method. SchemaType returns
+// the type the JSON is shaped like.
+type SchemaTyper interface {
+	SchemaType() reflect.Type
+}
+
+var (
+	timeType         = reflect.TypeFor[time.Time]()
+	protoMessageType = reflect.TypeFor[proto.Message]()
+	schemaTyperType  = reflect.TypeFor[SchemaTyper]()
+)
+
+// schemas builds JSON schemas for Go types. Named structs and proto
+// messages become components, referenced wherever they are used.
+type schemas struct {
+	components map[string]any
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]any{}, names: map[reflect.Type]string{}}
+}
+
+// schema describes how encoding/json renders t. Proto messages are described
+// as protojson renders them with proto field names.
+func (s *schemas) schema(t reflect.Type) map[string]any {
+	if t.Implements(schemaTyperType) {
+		return s.schema(reflect.Zero(t).Interface().(SchemaTyper).SchemaType())
+	}
+	if t.Kind() == reflect.Pointer && t.Implements(protoMessageType) {
+		return s.message(reflect.New(t.Elem()).Interface().(proto.Message).ProtoReflect().Descriptor())
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem := s.schema(t.Elem())
+		if _, ok := elem["$ref"]; ok {
+			return elem
+		}
+		elem["nullable"] = true
+		return elem
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.ref(t)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return map[string]any{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]any{"type": "number", "format": "double"}
+	}
+	// Interfaces, e.g. gin.H values: anything.
+	return map[string]any{}
+}
+
+func (s *schemas) ref(t reflect.Type) map[string]any {
+	name, ok := s.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := s.components[name]; taken {
+			pkg := path.Base(t.PkgPath())
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		s.names[t] = name
+		// Reserve the name first, in case t refers to itself.
+		s.components[name] = map[string]any{}
+		s.components[name] = s.object(t)
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func (s *schemas) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	s.fields(t, properties, &required)
+
+	object := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+// fields adds t's JSON fields to properties, flattening embedded structs as
+// encoding/json does.
+func (s *schemas) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.fields(embedded, properties, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		schema := s.schema(f.Type)
+		if constrain(schema, f.Tag.Get("binding")) {
+			*required = append(*required, name)
+		}
+		properties[name] = schema
+	}
+}
+
+// queryParams lists the form-tagged fields of q, a struct bound with
+// ShouldBindQuery, as query parameters.
+func (s *schemas) queryParams(q any) ([]map[string]any, error) {
+	if q == nil {
+		return nil, nil
+	}
+	t := reflect.TypeOf(q)
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("query must be a struct, not %s", t)
+	}
+
+	params := []map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		schema := s.schema(ft)
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       "query",
+			"required": constrain(schema, f.Tag.Get("binding")),
+			"schema":   schema,
+		})
+	}
+	return params, nil
+}
+
+// constrain adds the validator rules in a binding tag that OpenAPI can
+// express to schema, and reports whether the field is required.
+func constrain(schema map[string]any, binding string) bool {
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email", "uuid":
+			schema["format"] = name
+		case "oneof":
+			schema["enum"] = strings.Fields(arg)
+		case "min", "gte", "max", "lte", "gt", "lt":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				continue
+			}
+			key := map[string]string{"min": "minimum", "gte": "minimum", "gt": "minimum", "max": "maximum", "lte": "maximum", "lt": "maximum"}[name]
+			if schema["type"] == "string" {
+				key = map[string]string{"minimum": "minLength", "maximum": "maxLength"}[key]
+			} else if schema["type"] == "array" {
+				key = map[string]string{"minimum": "minItems", "maximum": "maxItems"}[key]
+			}
+			schema[key] = n
+			if name == "gt" {
+				schema["exclusiveMinimum"] = true
+			} else if name == "lt" {
+				schema["exclusiveMaximum"] = true
+			}
+		}
+	}
+	return required
+}
+
+// message describes a proto message as protojson renders it with proto
+// field names. Well-known types are described by their JSON mapping.
+func (s *schemas) message(md protoreflect.MessageDescriptor) map[string]any {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case "google.protobuf.Duration", "google.protobuf.FieldMask":
+		return map[string]any{"type": "string"}
+	case "google.protobuf.Struct", "google.protobuf.Empty", "google.protobuf.Any":
+		return map[string]any{"type": "object"}
+	case "google.protobuf.Value":
+		return map[string]any{}
+	}
+
+	name := string(md.FullName())
+	if _, ok := s.components[name]; !ok {
+		s.components[name] = map[string]any{}
+		properties := map[string]any{}
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			properties[string(fd.Name())] = s.protoField(fd)
+		}
+		s.components[name] = map[string]any{"type": "object", "properties": properties}
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func (s *schemas) protoField(fd protoreflect.FieldDescriptor) map[string]any {
+	if fd.IsMap() {
+		return map[string]any{"type": "object", "additionalProperties": s.protoValue(fd.MapValue())}
+	}
+	if fd.IsList() {
+		return map[string]any{"type": "array", "items": s.protoValue(fd)}
+	}
+	return s.protoValue(fd)
+}
+
+func (s *schemas) protoValue(fd protoreflect.FieldDescriptor) map[string]any {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.StringKind:
+		return map[string]any{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]any{"type": "string", "format": "byte"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]any{"type": "integer", "format": "int64", "minimum": 0}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson renders 64-bit integers as strings.
+		return map[string]any{"type": "string", "format": "int64"}
+	case protoreflect.FloatKind:
+		return map[string]any{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return map[string]any{"type": "number", "format": "double"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return map[string]any{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return s.message(fd.Message())
+	}
+	return map[string]any{}
+}
@@ -0,0 +1,49 @@
+package openapi
+
+import (
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// initializer points the bundled Swagger UI at the service's document.
+const initializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "../openapi.json",
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [
+      SwaggerUIBundle.presets.apis,
+      SwaggerUIStandalonePreset
+    ],
+    plugins: [
+      SwaggerUIBundle.plugins.DownloadUrl
+    ],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// serveUI serves the embedded Swagger UI.
+func serveUI(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("filepath"), "/")
+	switch name {
+	case "":
+		name = "index.html"
+	case "swagger-initializer.js":
+		c.Data(http.StatusOK, "text/javascript; charset=utf-8", []byte(initializer))
+		return
+	}
+
+	body, err := fs.ReadFile(swaggerFiles.FS, name)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Data(http.StatusOK, mime.TypeByExtension(path.Ext(name)), body)
+}
@@ -0,0 +1,86 @@
+// Package outbox implements the transactional outbox pattern. Repositories
+// call Add inside the same database transaction as their write, so an event
+// exists if and only if the change was committed; a Relay then publishes
+// pending events and marks them sent.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema creates the outbox table. Each service runs it as part of its own
+// schema initialisation.
+const Schema = `
+	CREATE TABLE IF NOT EXISTS outbox (
+		seq BIGSERIAL PRIMARY KEY,
+		id VARCHAR(36) NOT NULL UNIQUE,
+		aggregate_type VARCHAR(50) NOT NULL,
+		aggregate_id VARCHAR(36) NOT NULL,
+		event_type VARCHAR(100) NOT NULL,
+		payload BYTEA NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		sent_at TIMESTAMP,
+		attempts INT NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT ''
+	);
+	ALTER TABLE outbox ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP;
+	ALTER TABLE outbox ADD COLUMN IF NOT EXISTS dead_at TIMESTAMP;
+	CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (seq) WHERE sent_at IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_outbox_pending_aggregate ON outbox (aggregate_type, aggregate_id, seq) WHERE sent_at IS NULL;
+`
+
+// Event is a domain event waiting in, or relayed from, the outbox.
+type Event struct {
+	ID            string
+	AggregateType string
+	AggregateID   string
+	Type          string
+	Payload       []byte
+	CreatedAt     time.Time
+}
+
+// Execer is satisfied by *sql.Tx (and *sql.DB, for writes that are a single
+// statement).
+type Execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Add records an event for aggregateType/aggregateID with payload encoded as
+// JSON. Call it with the transaction that performs the write.
+func Add(tx Execer, aggregateType, aggregateID, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO outbox (id, aggregate_type, aggregate_id, event_type, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.Exec(query, uuid.New().String(), aggregateType, aggregateID, eventType, data, time.Now())
+	return err
+}
+
+// Publisher delivers relayed events to the outside world. It must be safe to
+// deliver the same event more than once: the relay guarantees at-least-once.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// PublisherFunc adapts a function to Publisher.
+type PublisherFunc func(ctx context.Context, event Event) error
+
+func (f PublisherFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// LogPublisher logs every event. It is the default until a broker is
+// configured.
+var LogPublisher = PublisherFunc(func(ctx context.Context, event Event) error {
+	log.Printf("outbox: %s %s/%s (%s)", event.Type, event.AggregateType, event.AggregateID, event.ID)
+	return nil
+})
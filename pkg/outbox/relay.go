@@ -0,0 +1,191 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"log"
+	"time"
+)
+
+// RelayConfig controls how often and how much the relay publishes, and how
+// it retries events that fail to publish.
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// MaxAttempts is how many times an event is tried before it is parked.
+	MaxAttempts int
+	// BaseBackoff is the wait after an event's first failure, doubled
+	// after each further failure up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Relay publishes pending outbox events in seq order, the order in which
+// they were inserted.
+//
+// Only one relay per database works at a time: each batch runs under a
+// transaction-scoped advisory lock, so running a relay in every replica is
+// safe. Events of one aggregate are published strictly in order; if one
+// fails, it is retried with exponential backoff and the aggregate's later
+// events wait for it while other aggregates proceed. An event that fails
+// MaxAttempts times is parked (dead_at is set): the relay stops trying it,
+// and the aggregate's later events stay held back until an operator clears
+// dead_at. An event is marked sent only after Publish returns, so a crash
+// in between causes a redelivery, never a loss.
+type Relay struct {
+	db        *sql.DB
+	publisher Publisher
+	cfg       RelayConfig
+	lockKey   int64
+}
+
+func NewRelay(db *sql.DB, publisher Publisher, cfg RelayConfig) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 10 * time.Minute
+	}
+	cfg.MaxBackoff = max(cfg.MaxBackoff, cfg.BaseBackoff)
+
+	h := fnv.New64a()
+	h.Write([]byte("outbox-relay"))
+	return &Relay{db: db, publisher: publisher, cfg: cfg, lockKey: int64(h.Sum64())}
+}
+
+// Run relays events until ctx is cancelled. A fully published batch is
+// followed immediately by the next one, so a backlog drains without waiting.
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		n, err := r.RelayBatch(ctx)
+		if err != nil {
+			log.Printf("outbox relay: %v", err)
+		}
+		if err == nil && n == r.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.cfg.PollInterval):
+		}
+	}
+}
+
+// RelayBatch publishes up to one batch of pending events and returns how
+// many were published.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, r.lockKey).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		// Another relay is working.
+		return 0, nil
+	}
+
+	// Events waiting out a backoff, and parked events, are left out together
+	// with every later event of their aggregate, so they neither fill the
+	// batch nor let a later event overtake them.
+	now := time.Now()
+	rows, err := tx.QueryContext(ctx, `SELECT seq, id, aggregate_type, aggregate_id, event_type, payload, created_at, attempts
+		FROM outbox o
+		WHERE sent_at IS NULL AND dead_at IS NULL AND (next_attempt_at IS NULL OR next_attempt_at <= $2)
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox w
+				WHERE w.aggregate_type = o.aggregate_type AND w.aggregate_id = o.aggregate_id
+					AND w.seq < o.seq AND w.sent_at IS NULL
+					AND (w.dead_at IS NOT NULL OR w.next_attempt_at > $2)
+			)
+		ORDER BY seq LIMIT $1`, r.cfg.BatchSize, now)
+	if err != nil {
+		return 0, err
+	}
+	type pending struct {
+		seq      int64
+		attempts int
+		event    Event
+	}
+	var batch []pending
+	for rows.Next() {
+		var p pending
+		e := &p.event
+		if err := rows.Scan(&p.seq, &e.ID, &e.AggregateType, &e.AggregateID, &e.Type, &e.Payload, &e.CreatedAt, &p.attempts); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published := 0
+	blocked := make(map[string]bool)
+	for _, p := range batch {
+		key := p.event.AggregateType + "/" + p.event.AggregateID
+		if blocked[key] {
+			continue
+		}
+
+		if err := r.publisher.Publish(ctx, p.event); err != nil {
+			blocked[key] = true
+			if dbErr := r.fail(ctx, tx, p.seq, p.attempts+1, p.event, err); dbErr != nil {
+				return 0, dbErr
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE outbox SET sent_at = $1, attempts = attempts + 1, last_error = '' WHERE seq = $2`, time.Now(), p.seq); err != nil {
+			return 0, err
+		}
+		published++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return published, nil
+}
+
+// fail records a failed attempt to publish an event: it is parked once it
+// has been tried MaxAttempts times, and otherwise retried after a backoff.
+func (r *Relay) fail(ctx context.Context, tx *sql.Tx, seq int64, attempts int, event Event, publishErr error) error {
+	if attempts >= r.cfg.MaxAttempts {
+		log.Printf("outbox relay: parking event %s (%s) after %d attempts: %v", event.ID, event.Type, attempts, publishErr)
+		_, err := tx.ExecContext(ctx, `UPDATE outbox SET attempts = $1, last_error = $2, dead_at = $3 WHERE seq = $4`,
+			attempts, publishErr.Error(), time.Now(), seq)
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `UPDATE outbox SET attempts = $1, last_error = $2, next_attempt_at = $3 WHERE seq = $4`,
+		attempts, publishErr.Error(), time.Now().Add(r.backoff(attempts)), seq)
+	return err
+}
+
+// backoff is the wait before the next attempt at an event that has failed
+// attempts times.
+func (r *Relay) backoff(attempts int) time.Duration {
+	wait := r.cfg.BaseBackoff
+	for i := 1; i < attempts && wait < r.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, r.cfg.MaxBackoff)
+}
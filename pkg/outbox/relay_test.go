@@ -0,0 +1,30 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelayBackoff(t *testing.T) {
+	r := NewRelay(nil, nil, RelayConfig{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := r.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := r.backoff(1000); got != 10*time.Second {
+		t.Errorf("backoff(1000) = %v, want the 10s cap", got)
+	}
+}
+
+func TestNewRelayDefaults(t *testing.T) {
+	cfg := NewRelay(nil, nil, RelayConfig{}).cfg
+	if cfg.PollInterval != time.Second || cfg.BatchSize != 100 || cfg.MaxAttempts != 10 ||
+		cfg.BaseBackoff != time.Second || cfg.MaxBackoff != 10*time.Minute {
+		t.Errorf("defaults = %+v", cfg)
+	}
+	if cfg := NewRelay(nil, nil, RelayConfig{BaseBackoff: time.Minute, MaxBackoff: time.Second}).cfg; cfg.MaxBackoff != time.Minute {
+		t.Errorf("MaxBackoff below BaseBackoff = %v, want it raised to %v", cfg.MaxBackoff, time.Minute)
+	}
+}
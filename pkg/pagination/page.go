@@ -0,0 +1,98 @@
+package pagination
+
+import "errors"
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Options configures a Paginator for one list endpoint.
+type Options struct {
+	// SortFields whitelists the fields clients may sort by.
+	SortFields  []string
+	DefaultSort Sort
+	DefaultSize int
+	MaxSize     int
+}
+
+// Paginator turns page_size/page_token/order_by request fields into a Page
+// and builds next_page_token from the rows a repository returns.
+type Paginator struct {
+	codec *Codec
+	opts  Options
+}
+
+func NewPaginator(codec *Codec, opts Options) *Paginator {
+	if opts.DefaultSize == 0 {
+		opts.DefaultSize = DefaultPageSize
+	}
+	if opts.MaxSize == 0 {
+		opts.MaxSize = MaxPageSize
+	}
+	if opts.DefaultSort.Field == "" {
+		opts.DefaultSort = Sort{Field: FieldCreatedAt, Desc: true}
+	}
+	if len(opts.SortFields) == 0 {
+		opts.SortFields = []string{FieldCreatedAt}
+	}
+	return &Paginator{codec: codec, opts: opts}
+}
+
+// Page is a validated page request.
+type Page struct {
+	Sort Sort
+	Size int
+	// After is nil for the first page.
+	After *Cursor
+	// Filter is the digest of the list filter, recorded in page tokens.
+	Filter string
+}
+
+// Limit is the number of rows to fetch: one extra row tells whether a next
+// page exists without a separate count query.
+func (p Page) Limit() int {
+	return p.Size + 1
+}
+
+// Prepare validates a page request. filter holds the list's filter values
+// (see FilterDigest): a page token is only accepted with the sort and filter
+// it was issued for, since its position means nothing in another listing.
+func (p *Paginator) Prepare(orderBy string, pageSize int, pageToken string, filter any) (Page, error) {
+	sort, err := ParseSort(orderBy, p.opts.SortFields, p.opts.DefaultSort)
+	if err != nil {
+		return Page{}, err
+	}
+
+	if pageSize < 0 {
+		return Page{}, errors.New("page size must not be negative")
+	}
+	if pageSize == 0 {
+		pageSize = p.opts.DefaultSize
+	}
+	if pageSize > p.opts.MaxSize {
+		pageSize = p.opts.MaxSize
+	}
+
+	page := Page{Sort: sort, Size: pageSize, Filter: FilterDigest(filter)}
+	if pageToken != "" {
+		page.After, err = p.codec.Decode(pageToken, sort.String(), page.Filter)
+		if err != nil {
+			return Page{}, err
+		}
+	}
+	return page, nil
+}
+
+// Trim cuts rows (fetched with page.Limit()) down to the page size and
+// returns the token for the following page, or "" on the last page.
+func Trim[T any](p *Paginator, page Page, rows []T, cursorOf func(T) Cursor) ([]T, string) {
+	if len(rows) <= page.Size {
+		return rows, ""
+	}
+	rows = rows[:page.Size]
+	cursor := cursorOf(rows[len(rows)-1])
+	cursor.Sort = page.Sort.String()
+	cursor.Filter = page.Filter
+	return rows, p.codec.Encode(cursor)
+}
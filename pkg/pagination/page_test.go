@@ -0,0 +1,138 @@
+package pagination
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPrepareClampsPageSize(t *testing.T) {
+	p := NewPaginator(NewCodec("secret"), Options{})
+	custom := NewPaginator(NewCodec("secret"), Options{DefaultSize: 5, MaxSize: 10})
+
+	cases := []struct {
+		name      string
+		paginator *Paginator
+		size      int
+		want      int
+	}{
+		{"default", p, 0, DefaultPageSize},
+		{"within bounds", p, 7, 7},
+		{"at max", p, MaxPageSize, MaxPageSize},
+		{"over max", p, MaxPageSize + 1, MaxPageSize},
+		{"far over max", p, 1 << 30, MaxPageSize},
+		{"custom default", custom, 0, 5},
+		{"over custom max", custom, 11, 10},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			page, err := c.paginator.Prepare("", c.size, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Size != c.want {
+				t.Errorf("Size = %d, want %d", page.Size, c.want)
+			}
+			if page.Limit() != c.want+1 {
+				t.Errorf("Limit = %d, want %d", page.Limit(), c.want+1)
+			}
+		})
+	}
+
+	if _, err := p.Prepare("", -1, "", nil); err == nil {
+		t.Error("Prepare accepted a negative page size")
+	}
+}
+
+func TestPrepareDefaults(t *testing.T) {
+	p := NewPaginator(NewCodec("secret"), Options{})
+	page, err := p.Prepare("", 0, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Sort != (Sort{Field: FieldCreatedAt, Desc: true}) {
+		t.Errorf("Sort = %+v, want -created_at", page.Sort)
+	}
+	if page.After != nil {
+		t.Errorf("After = %+v on the first page", page.After)
+	}
+
+	if _, err := p.Prepare("name", 0, "", nil); err == nil {
+		t.Error("Prepare accepted a sort field outside the default whitelist")
+	}
+}
+
+type row struct {
+	id        string
+	createdAt time.Time
+}
+
+func rowCursor(r row) Cursor {
+	return Cursor{CreatedAt: r.createdAt, ID: r.id}
+}
+
+func rows(n int) []row {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]row, n)
+	for i := range out {
+		out[i] = row{id: string(rune('a' + i)), createdAt: start.Add(time.Duration(i) * time.Minute)}
+	}
+	return out
+}
+
+// TestTrimAndResume pages through with the token Trim returns and checks it
+// is only accepted back with the same sort and filter.
+func TestTrimAndResume(t *testing.T) {
+	type filter struct{ Status string }
+	p := NewPaginator(NewCodec("secret"), Options{SortFields: []string{FieldCreatedAt, "name"}})
+
+	page, err := p.Prepare("created_at", 2, "", filter{Status: "paid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, next := Trim(p, page, rows(page.Limit()), rowCursor)
+	if len(got) != 2 || next == "" {
+		t.Fatalf("got %d rows and token %q, want 2 rows and a token", len(got), next)
+	}
+
+	page, err = p.Prepare("created_at", 2, next, filter{Status: "paid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.After == nil || page.After.ID != got[1].id || !page.After.CreatedAt.Equal(got[1].createdAt) {
+		t.Errorf("After = %+v, want the last row of the first page", page.After)
+	}
+
+	if _, err := p.Prepare("-created_at", 2, next, filter{Status: "paid"}); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("other sort: err = %v, want ErrInvalidPageToken", err)
+	}
+	if _, err := p.Prepare("name", 2, next, filter{Status: "paid"}); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("other sort field: err = %v, want ErrInvalidPageToken", err)
+	}
+	if _, err := p.Prepare("created_at", 2, next, filter{Status: "cancelled"}); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("other filter: err = %v, want ErrInvalidPageToken", err)
+	}
+	if _, err := p.Prepare("created_at", 2, next, nil); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("no filter: err = %v, want ErrInvalidPageToken", err)
+	}
+
+	// The page size may change between pages.
+	if _, err := p.Prepare("created_at", 5, next, filter{Status: "paid"}); err != nil {
+		t.Errorf("other page size: %v", err)
+	}
+}
+
+func TestTrimLastPage(t *testing.T) {
+	p := NewPaginator(NewCodec("secret"), Options{})
+	page, err := p.Prepare("", 3, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{0, 2, 3} {
+		got, next := Trim(p, page, rows(n), rowCursor)
+		if len(got) != n || next != "" {
+			t.Errorf("%d rows: got %d rows and token %q, want %d rows and no token", n, len(got), next, n)
+		}
+	}
+}
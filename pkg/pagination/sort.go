@@ -0,0 +1,46 @@
+package pagination
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const FieldCreatedAt = "created_at"
+
+// Sort is a whitelisted sort field and direction.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// String returns the canonical spec, e.g. "-created_at".
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Direction returns the SQL ORDER BY direction and the comparison operator
+// that selects rows after a cursor in that direction.
+func (s Sort) Direction() (string, string) {
+	if s.Desc {
+		return "DESC", "<"
+	}
+	return "ASC", ">"
+}
+
+// ParseSort parses an order_by value of the form "field" or "-field". Only
+// fields in allowed are accepted; an empty value yields def.
+func ParseSort(orderBy string, allowed []string, def Sort) (Sort, error) {
+	orderBy = strings.TrimSpace(orderBy)
+	if orderBy == "" {
+		return def, nil
+	}
+	field, desc := strings.CutPrefix(orderBy, "-")
+	if !slices.Contains(allowed, field) {
+		return Sort{}, fmt.Errorf("unsupported sort field %q", field)
+	}
+	return Sort{Field: field, Desc: desc}, nil
+}
@@ -0,0 +1,60 @@
+package pagination
+
+import "testing"
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{FieldCreatedAt, "name"}
+	def := Sort{Field: FieldCreatedAt, Desc: true}
+
+	cases := []struct {
+		orderBy string
+		want    Sort
+		wantErr bool
+	}{
+		{"", def, false},
+		{"  ", def, false},
+		{"name", Sort{Field: "name"}, false},
+		{"-name", Sort{Field: "name", Desc: true}, false},
+		{" created_at ", Sort{Field: FieldCreatedAt}, false},
+		{"amount", Sort{}, true},
+		{"--name", Sort{}, true},
+		{"name desc", Sort{}, true},
+		{"-", Sort{}, true},
+	}
+	for _, c := range cases {
+		got, err := ParseSort(c.orderBy, allowed, def)
+		if (err != nil) != c.wantErr {
+			t.Errorf("ParseSort(%q) err = %v, want error %v", c.orderBy, err, c.wantErr)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("ParseSort(%q) = %+v, want %+v", c.orderBy, got, c.want)
+		}
+	}
+}
+
+func TestSortStringAndDirection(t *testing.T) {
+	cases := []struct {
+		sort      Sort
+		spec      string
+		direction string
+		cmp       string
+	}{
+		{Sort{Field: "name"}, "name", "ASC", ">"},
+		{Sort{Field: "name", Desc: true}, "-name", "DESC", "<"},
+	}
+	for _, c := range cases {
+		if got := c.sort.String(); got != c.spec {
+			t.Errorf("%+v.String() = %q, want %q", c.sort, got, c.spec)
+		}
+		direction, cmp := c.sort.Direction()
+		if direction != c.direction || cmp != c.cmp {
+			t.Errorf("%+v.Direction() = %s, %s, want %s, %s", c.sort, direction, cmp, c.direction, c.cmp)
+		}
+
+		parsed, err := ParseSort(c.spec, []string{c.sort.Field}, Sort{})
+		if err != nil || parsed != c.sort {
+			t.Errorf("ParseSort(%q) = %+v, %v, want %+v", c.spec, parsed, err, c.sort)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Package restproxy serves a service's gRPC API as REST/JSON, following the
+// google.api.http rules in its proto definitions. The generated handlers
+// call the service's own gRPC server, so REST and gRPC clients go through
+// the same handlers and get the same errors.
+package restproxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Prefix is the path every generated route starts with. The hand-written
+// routes live outside it.
+const Prefix = "/v1"
+
+// ProxiedKey is the metadata key set on every call the proxy forwards, so a
+// gRPC server can tell outside clients from the services calling it directly.
+const ProxiedKey = "x-rest-proxy"
+
+// Proxied reports whether the incoming gRPC call came through the proxy.
+func Proxied(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return len(md.Get(ProxiedKey)) > 0
+}
+
+// RegisterFunc registers a service's generated routes, e.g.
+// pb.RegisterUserServiceHandlerFromEndpoint.
+type RegisterFunc func(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) error
+
+// New returns a handler proxying the routes registered by register to the
+// gRPC server at grpcAddr. The connection is closed when ctx is done.
+func New(ctx context.Context, grpcAddr string, register ...RegisterFunc) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		// Field names as in the proto, which are the snake_case names the
+		// hand-written handlers use.
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		runtime.WithMetadata(func(context.Context, *http.Request) metadata.MD {
+			return metadata.Pairs(ProxiedKey, "true")
+		}),
+	)
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	for _, r := range register {
+		if err := r(ctx, mux, grpcAddr, opts); err != nil {
+			return nil, err
+		}
+	}
+	return mux, nil
+}
+
+// headerMatcher forwards the request ID as-is, so it reads the same whether
+// a call came over REST or gRPC, and other headers as grpc-gateway does by
+// default.
+func headerMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, "X-Request-ID") {
+		return "x-request-id", true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
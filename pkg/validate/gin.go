@@ -0,0 +1,45 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// BindJSON binds the request body into req as c.ShouldBindJSON does, then
+// validates msg, the proto request the body corresponds to, filled in from
+// req. Fields already set on msg, such as IDs taken from the path, are kept.
+//
+// req's JSON field names must be msg's proto field names; fields only one
+// of them has are ignored.
+func BindJSON(c *gin.Context, req any, msg proto.Message) error {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	fromBody := msg.ProtoReflect().New().Interface()
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, fromBody); err != nil {
+		return fmt.Errorf("validate: %T does not match %T: %w", req, msg, err)
+	}
+	proto.Merge(msg, fromBody)
+	return Validate(msg)
+}
+
+// ErrorBody is the JSON body for a request that failed BindJSON or
+// Validate: {"error": ...}, plus the violations if it broke its rules.
+func ErrorBody(err error) gin.H {
+	body := gin.H{"error": err.Error()}
+	var verr *Error
+	if errors.As(err, &verr) {
+		body["violations"] = verr.Violations
+	}
+	return body
+}
@@ -0,0 +1,63 @@
+package validate
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryServerInterceptor validates every request before its handler runs.
+// Invalid requests fail with INVALID_ARGUMENT and a google.rpc.BadRequest
+// detail with a field violation per broken rule.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if msg, ok := req.(proto.Message); ok {
+			if err := Validate(msg); err != nil {
+				return nil, Status(err).Err()
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Status converts an error from Validate to a gRPC status. Rules that
+// cannot be applied are a server bug, so they become INTERNAL.
+func Status(err error) *status.Status {
+	var verr *Error
+	if !errors.As(err, &verr) {
+		return status.New(codes.Internal, err.Error())
+	}
+
+	st := status.New(codes.InvalidArgument, verr.Error())
+	details := &errdetails.BadRequest{}
+	for _, v := range verr.Violations {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Message,
+			Reason:      v.Rule,
+		})
+	}
+	if withDetails, err := st.WithDetails(details); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+// FromStatus returns the violations in a status made by Status, e.g. one
+// received from another service, or nil.
+func FromStatus(st *status.Status) []Violation {
+	var violations []Violation
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, fv := range br.GetFieldViolations() {
+				violations = append(violations, Violation{Field: fv.GetField(), Rule: fv.GetReason(), Message: fv.GetDescription()})
+			}
+		}
+	}
+	return violations
+}
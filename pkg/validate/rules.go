@@ -0,0 +1,261 @@
+package validate
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	validatepb "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	// emailPattern is the HTML5 definition of an email address, which
+	// protovalidate follows.
+	emailPattern = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+	// patterns caches the compiled pattern rules.
+	patterns sync.Map
+)
+
+func (v *validator) string(path, s string, rules *validatepb.StringRules) error {
+	var err error
+	rules.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, rule protoreflect.Value) bool {
+		id := "string." + string(fd.Name())
+		switch fd.Name() {
+		case "const":
+			if s != rule.String() {
+				v.add(path, id, "value must equal `%s`", rule.String())
+			}
+		case "len":
+			if uint64(utf8.RuneCountInString(s)) != rule.Uint() {
+				v.add(path, id, "value length must be %d characters", rule.Uint())
+			}
+		case "min_len":
+			if uint64(utf8.RuneCountInString(s)) < rule.Uint() {
+				v.add(path, id, "value length must be at least %d characters", rule.Uint())
+			}
+		case "max_len":
+			if uint64(utf8.RuneCountInString(s)) > rule.Uint() {
+				v.add(path, id, "value length must be at most %d characters", rule.Uint())
+			}
+		case "pattern":
+			var re *regexp.Regexp
+			if re, err = pattern(rule.String()); err == nil && !re.MatchString(s) {
+				v.add(path, id, "value does not match regex pattern `%s`", rule.String())
+			}
+		case "prefix":
+			if !strings.HasPrefix(s, rule.String()) {
+				v.add(path, id, "value does not have prefix `%s`", rule.String())
+			}
+		case "suffix":
+			if !strings.HasSuffix(s, rule.String()) {
+				v.add(path, id, "value does not have suffix `%s`", rule.String())
+			}
+		case "contains":
+			if !strings.Contains(s, rule.String()) {
+				v.add(path, id, "value does not contain substring `%s`", rule.String())
+			}
+		case "not_contains":
+			if strings.Contains(s, rule.String()) {
+				v.add(path, id, "value contains substring `%s`", rule.String())
+			}
+		case "in", "not_in":
+			v.inList(path, id, fd.Name(), rule.List(), func(item protoreflect.Value) bool { return item.String() == s })
+		case "email":
+			v.wellKnown(path, "email", s, rule.Bool(), emailPattern, "a valid email address")
+		case "uuid":
+			v.wellKnown(path, "uuid", s, rule.Bool(), uuidPattern, "a valid UUID")
+		case "example":
+		default:
+			err = unsupported(path, id)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// wellKnown checks a well-known string format, reporting an empty value
+// under its own rule as protovalidate does.
+func (v *validator) wellKnown(path, rule, s string, enabled bool, re *regexp.Regexp, what string) {
+	switch {
+	case !enabled:
+	case s == "":
+		v.add(path, "string."+rule+"_empty", "value is empty, which is not %s", what)
+	case !re.MatchString(s):
+		v.add(path, "string."+rule, "value must be %s", what)
+	}
+}
+
+func pattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("validate: pattern %q: %w", expr, err)
+	}
+	patterns.Store(expr, re)
+	return re, nil
+}
+
+func (v *validator) enum(path string, ed protoreflect.EnumDescriptor, n protoreflect.EnumNumber, rules *validatepb.EnumRules) error {
+	var err error
+	rules.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, rule protoreflect.Value) bool {
+		id := "enum." + string(fd.Name())
+		switch fd.Name() {
+		case "const":
+			if n != protoreflect.EnumNumber(rule.Int()) {
+				v.add(path, id, "value must equal %d", rule.Int())
+			}
+		case "defined_only":
+			if rule.Bool() && ed.Values().ByNumber(n) == nil {
+				v.add(path, id, "value must be one of the defined enum values")
+			}
+		case "in", "not_in":
+			v.inList(path, id, fd.Name(), rule.List(), func(item protoreflect.Value) bool { return protoreflect.EnumNumber(item.Int()) == n })
+		case "example":
+		default:
+			err = unsupported(path, id)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// scalar checks a number or bool against its rules message, e.g. Int32Rules;
+// the numeric rules messages all share the same field names.
+func (v *validator) scalar(path, typ string, value protoreflect.Value, rules protoreflect.Message) error {
+	var err error
+	rules.Range(func(fd protoreflect.FieldDescriptor, rule protoreflect.Value) bool {
+		id := typ + "." + string(fd.Name())
+		switch fd.Name() {
+		case "const":
+			if compare(value, rule) != 0 {
+				v.add(path, id, "value must equal %v", rule)
+			}
+		case "gt":
+			if compare(value, rule) <= 0 {
+				v.add(path, id, "value must be greater than %v", rule)
+			}
+		case "gte":
+			if compare(value, rule) < 0 {
+				v.add(path, id, "value must be greater than or equal to %v", rule)
+			}
+		case "lt":
+			if compare(value, rule) >= 0 {
+				v.add(path, id, "value must be less than %v", rule)
+			}
+		case "lte":
+			if compare(value, rule) > 0 {
+				v.add(path, id, "value must be less than or equal to %v", rule)
+			}
+		case "in", "not_in":
+			v.inList(path, id, fd.Name(), rule.List(), func(item protoreflect.Value) bool { return compare(value, item) == 0 })
+		case "finite":
+			if f := value.Float(); rule.Bool() && (math.IsInf(f, 0) || math.IsNaN(f)) {
+				v.add(path, id, "value must be finite")
+			}
+		case "example":
+		default:
+			err = unsupported(path, id)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// compare orders two numbers of the same kind; bools are only equal or not.
+func compare(a, b protoreflect.Value) int {
+	switch a.Interface().(type) {
+	case int32, int64:
+		return cmp(a.Int(), b.Int())
+	case uint32, uint64:
+		return cmp(a.Uint(), b.Uint())
+	case float32, float64:
+		return cmp(a.Float(), b.Float())
+	}
+	if a.Bool() == b.Bool() {
+		return 0
+	}
+	return 1
+}
+
+func cmp[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (v *validator) repeated(path string, fd protoreflect.FieldDescriptor, list protoreflect.List, rules *validatepb.RepeatedRules) error {
+	var err error
+	rules.ProtoReflect().Range(func(rfd protoreflect.FieldDescriptor, rule protoreflect.Value) bool {
+		id := "repeated." + string(rfd.Name())
+		switch rfd.Name() {
+		case "min_items":
+			if uint64(list.Len()) < rule.Uint() {
+				v.add(path, id, "value must contain at least %d item(s)", rule.Uint())
+			}
+		case "max_items":
+			if uint64(list.Len()) > rule.Uint() {
+				v.add(path, id, "value must contain no more than %d item(s)", rule.Uint())
+			}
+		case "unique":
+			if !rule.Bool() {
+				break
+			}
+			if fd.Message() != nil {
+				err = unsupported(path, id+" on messages")
+				break
+			}
+			seen := make(map[any]bool, list.Len())
+			for i := 0; i < list.Len(); i++ {
+				item := list.Get(i).Interface()
+				if b, ok := item.([]byte); ok {
+					item = string(b)
+				}
+				if seen[item] {
+					v.add(path, id, "repeated value must contain unique items")
+					break
+				}
+				seen[item] = true
+			}
+		case "items":
+		default:
+			err = unsupported(path, id)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// inList applies an in or not_in rule.
+func (v *validator) inList(path, id string, rule protoreflect.Name, list protoreflect.List, equal func(protoreflect.Value) bool) {
+	in := false
+	for i := 0; i < list.Len() && !in; i++ {
+		in = equal(list.Get(i))
+	}
+	switch {
+	case rule == "in" && !in:
+		v.add(path, id, "value must be in list %s", formatList(list))
+	case rule == "not_in" && in:
+		v.add(path, id, "value must not be in list %s", formatList(list))
+	}
+}
+
+func formatList(list protoreflect.List) string {
+	items := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		items = append(items, fmt.Sprint(list.Get(i)))
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
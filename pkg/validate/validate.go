@@ -0,0 +1,235 @@
+// Package validate enforces the buf.validate (protovalidate) rules declared
+// on the request messages in proto/*.proto, so a rule is written once and
+// holds for gRPC, through UnaryServerInterceptor, for the generated /v1
+// routes, which call the gRPC server, and for the Gin handlers, through
+// BindJSON.
+//
+// The standard rules below are supported. A message that uses any other
+// rule, such as a CEL expression, fails with an error naming it rather than
+// passing unchecked.
+//
+//   - required, ignore
+//   - string: const, len, min_len, max_len, pattern, prefix, suffix,
+//     contains, not_contains, in, not_in, email, uuid
+//   - numbers: const, gt, gte, lt, lte, in, not_in, finite
+//   - bool: const
+//   - enum: const, defined_only, in, not_in
+//   - repeated: min_items, max_items, unique, items
+//   - oneof: required
+//
+// Nested messages are validated too, as protovalidate does.
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	validatepb "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Violation is a broken rule.
+type Violation struct {
+	// Field is the path to the field, e.g. "address.postal_code" or
+	// "items[1].quantity".
+	Field string `json:"field"`
+	// Rule is the protovalidate rule ID, e.g. "string.email".
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error is returned for a message that breaks its rules.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.Field+": "+v.Message)
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+// Validate checks msg against its rules. It returns a *Error listing every
+// violation, or another error if the rules themselves cannot be applied.
+func Validate(msg proto.Message) error {
+	v := &validator{}
+	if err := v.message("", msg.ProtoReflect()); err != nil {
+		return err
+	}
+	if len(v.violations) > 0 {
+		return &Error{Violations: v.violations}
+	}
+	return nil
+}
+
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) add(field, rule, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) message(path string, m protoreflect.Message) error {
+	md := m.Descriptor()
+	if rules, _ := proto.GetExtension(md.Options(), validatepb.E_Message).(*validatepb.MessageRules); len(rules.GetCel()) > 0 || len(rules.GetOneof()) > 0 {
+		return unsupported(string(md.FullName()), "message rules")
+	}
+
+	oneofs := md.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		od := oneofs.Get(i)
+		if od.IsSynthetic() {
+			continue
+		}
+		rules, _ := proto.GetExtension(od.Options(), validatepb.E_Oneof).(*validatepb.OneofRules)
+		if rules.GetRequired() && m.WhichOneof(od) == nil {
+			v.add(join(path, string(od.Name())), "required", "exactly one field is required in oneof")
+		}
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if err := v.field(m, fields.Get(i), path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *validator) field(m protoreflect.Message, fd protoreflect.FieldDescriptor, parent string) error {
+	path := join(parent, string(fd.Name()))
+	rules, _ := proto.GetExtension(fd.Options(), validatepb.E_Field).(*validatepb.FieldRules)
+	if rules.GetIgnore() == validatepb.Ignore_IGNORE_ALWAYS {
+		return nil
+	}
+	if len(rules.GetCel()) > 0 {
+		return unsupported(path, "cel")
+	}
+
+	// Has reports a proto3 scalar as unset when it holds its zero value.
+	if !m.Has(fd) {
+		if rules.GetRequired() {
+			v.add(path, "required", "value is required")
+			return nil
+		}
+		if fd.HasPresence() || rules.GetIgnore() == validatepb.Ignore_IGNORE_IF_ZERO_VALUE {
+			return nil
+		}
+	}
+
+	value := m.Get(fd)
+	switch {
+	case fd.IsMap():
+		if rules.GetType() != nil {
+			return unsupported(path, "map")
+		}
+		if fd.MapValue().Message() == nil {
+			return nil
+		}
+		var err error
+		value.Map().Range(func(key protoreflect.MapKey, val protoreflect.Value) bool {
+			err = v.message(fmt.Sprintf("%s[%v]", path, key.Interface()), val.Message())
+			return err == nil
+		})
+		return err
+	case fd.IsList():
+		return v.list(path, fd, value.List(), rules)
+	default:
+		return v.value(path, fd, value, rules)
+	}
+}
+
+func (v *validator) list(path string, fd protoreflect.FieldDescriptor, list protoreflect.List, rules *validatepb.FieldRules) error {
+	if rules.GetType() != nil && rules.GetRepeated() == nil {
+		return fmt.Errorf("validate: %s: %s rules on a repeated field", path, ruleType(rules))
+	}
+	repeated := rules.GetRepeated()
+	if repeated != nil {
+		if err := v.repeated(path, fd, list, repeated); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < list.Len(); i++ {
+		if err := v.value(fmt.Sprintf("%s[%d]", path, i), fd, list.Get(i), repeated.GetItems()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// value checks a singular value, or one element of a list, against rules.
+func (v *validator) value(path string, fd protoreflect.FieldDescriptor, value protoreflect.Value, rules *validatepb.FieldRules) error {
+	if fd.Message() != nil {
+		if rules.GetType() != nil {
+			return unsupported(path, ruleType(rules))
+		}
+		return v.message(path, value.Message())
+	}
+
+	typed := rules.GetType()
+	if typed == nil {
+		return nil
+	}
+	name := ruleType(rules)
+	if name != kindRules[fd.Kind()] {
+		return fmt.Errorf("validate: %s: %s rules on a %s field", path, name, fd.Kind())
+	}
+	switch r := typed.(type) {
+	case *validatepb.FieldRules_String_:
+		return v.string(path, value.String(), r.String_)
+	case *validatepb.FieldRules_Enum:
+		return v.enum(path, fd.Enum(), value.Enum(), r.Enum)
+	case *validatepb.FieldRules_Bytes, *validatepb.FieldRules_Any, *validatepb.FieldRules_Duration, *validatepb.FieldRules_Timestamp:
+		return unsupported(path, name)
+	default:
+		return v.scalar(path, name, value, rules.ProtoReflect().Get(typeField(rules)).Message())
+	}
+}
+
+// kindRules names the FieldRules type that applies to each field kind.
+var kindRules = map[protoreflect.Kind]string{
+	protoreflect.BoolKind:     "bool",
+	protoreflect.EnumKind:     "enum",
+	protoreflect.Int32Kind:    "int32",
+	protoreflect.Sint32Kind:   "sint32",
+	protoreflect.Uint32Kind:   "uint32",
+	protoreflect.Int64Kind:    "int64",
+	protoreflect.Sint64Kind:   "sint64",
+	protoreflect.Uint64Kind:   "uint64",
+	protoreflect.Sfixed32Kind: "sfixed32",
+	protoreflect.Fixed32Kind:  "fixed32",
+	protoreflect.FloatKind:    "float",
+	protoreflect.Sfixed64Kind: "sfixed64",
+	protoreflect.Fixed64Kind:  "fixed64",
+	protoreflect.DoubleKind:   "double",
+	protoreflect.StringKind:   "string",
+	protoreflect.BytesKind:    "bytes",
+}
+
+// typeField is the FieldRules field set in its type oneof, e.g. "string".
+func typeField(rules *validatepb.FieldRules) protoreflect.FieldDescriptor {
+	m := rules.ProtoReflect()
+	return m.WhichOneof(m.Descriptor().Oneofs().ByName("type"))
+}
+
+func ruleType(rules *validatepb.FieldRules) string {
+	if fd := typeField(rules); fd != nil {
+		return string(fd.Name())
+	}
+	return ""
+}
+
+func join(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func unsupported(path, rule string) error {
+	return fmt.Errorf("validate: %s: unsupported rule %q", path, rule)
+}
@@ -0,0 +1,401 @@
+package validate
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	validatepb "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// The tests build their messages at run time: a message Test with the
+// fields under test, an enum Color and a message Inner whose name must not
+// be empty.
+
+func newMessage(t *testing.T, msg *descriptorpb.DescriptorProto) *dynamicpb.Message {
+	t.Helper()
+	msg.Name = proto.String("Test")
+	for i, fd := range msg.Field {
+		fd.Number = proto.Int32(int32(i + 1))
+		if fd.GetProto3Optional() {
+			fd.OneofIndex = proto.Int32(int32(len(msg.OneofDecl)))
+			msg.OneofDecl = append(msg.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + fd.GetName())})
+		}
+	}
+
+	inner := &descriptorpb.DescriptorProto{
+		Name:  proto.String("Inner"),
+		Field: []*descriptorpb.FieldDescriptorProto{field("name", descriptorpb.FieldDescriptorProto_TYPE_STRING, stringRules(&validatepb.StringRules{MinLen: proto.Uint64(1)}))},
+	}
+	inner.Field[0].Number = proto.Int32(1)
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("validatetest/" + strings.ReplaceAll(t.Name(), "/", "_") + ".proto"),
+		Package:    proto.String("validatetest"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"buf/validate/validate.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Color"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("COLOR_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("COLOR_RED"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{inner, msg},
+	}
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dynamicpb.NewMessage(fd.Messages().ByName("Test"))
+}
+
+func field(name string, typ descriptorpb.FieldDescriptorProto_Type, rules *validatepb.FieldRules) *descriptorpb.FieldDescriptorProto {
+	fd := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Type:     typ.Enum(),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	switch typ {
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		fd.TypeName = proto.String(".validatetest.Color")
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		fd.TypeName = proto.String(".validatetest.Inner")
+	}
+	if rules != nil {
+		fd.Options = &descriptorpb.FieldOptions{}
+		proto.SetExtension(fd.Options, validatepb.E_Field, rules)
+	}
+	return fd
+}
+
+func repeated(fd *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	fd.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return fd
+}
+
+func optional(fd *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	fd.Proto3Optional = proto.Bool(true)
+	return fd
+}
+
+func stringRules(r *validatepb.StringRules) *validatepb.FieldRules {
+	return &validatepb.FieldRules{Type: &validatepb.FieldRules_String_{String_: r}}
+}
+
+func int64Rules(r *validatepb.Int64Rules) *validatepb.FieldRules {
+	return &validatepb.FieldRules{Type: &validatepb.FieldRules_Int64{Int64: r}}
+}
+
+func doubleRules(r *validatepb.DoubleRules) *validatepb.FieldRules {
+	return &validatepb.FieldRules{Type: &validatepb.FieldRules_Double{Double: r}}
+}
+
+func enumRules(r *validatepb.EnumRules) *validatepb.FieldRules {
+	return &validatepb.FieldRules{Type: &validatepb.FieldRules_Enum{Enum: r}}
+}
+
+func repeatedRules(r *validatepb.RepeatedRules) *validatepb.FieldRules {
+	return &validatepb.FieldRules{Type: &validatepb.FieldRules_Repeated{Repeated: r}}
+}
+
+// set sets a field of m. A []any fills a repeated field and a map[string]any
+// the fields of a message.
+func set(m protoreflect.Message, name string, value any) {
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+	switch v := value.(type) {
+	case nil:
+	case []any:
+		list := m.Mutable(fd).List()
+		for _, item := range v {
+			if fields, ok := item.(map[string]any); ok {
+				elem := list.NewElement()
+				for k, fv := range fields {
+					set(elem.Message(), k, fv)
+				}
+				list.Append(elem)
+				continue
+			}
+			list.Append(protoreflect.ValueOf(item))
+		}
+	case map[string]any:
+		inner := m.Mutable(fd).Message()
+		for k, fv := range v {
+			set(inner, k, fv)
+		}
+	default:
+		m.Set(fd, protoreflect.ValueOf(value))
+	}
+}
+
+// violations lists err's violations as "field rule".
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *Error
+	if !errors.As(err, &verr) {
+		t.Fatalf("got error %v, want violations", err)
+	}
+	got := make([]string, 0, len(verr.Violations))
+	for _, v := range verr.Violations {
+		got = append(got, v.Field+" "+v.Rule)
+	}
+	return got
+}
+
+const (
+	typeString  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+	typeInt64   = descriptorpb.FieldDescriptorProto_TYPE_INT64
+	typeUint32  = descriptorpb.FieldDescriptorProto_TYPE_UINT32
+	typeDouble  = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE
+	typeBool    = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+	typeEnum    = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+	typeMessage = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+)
+
+func TestFieldRules(t *testing.T) {
+	const uuid = "0b9c1e8a-5c1f-4a8e-9d7b-3f2a6c4e1d00"
+	cases := []struct {
+		name  string
+		field *descriptorpb.FieldDescriptorProto
+		value any
+		want  []string
+	}{
+		{"required/unset", field("f", typeString, &validatepb.FieldRules{Required: proto.Bool(true)}), nil, []string{"f required"}},
+		{"required/set", field("f", typeString, &validatepb.FieldRules{Required: proto.Bool(true)}), "x", nil},
+		{"required/optional zero", optional(field("f", typeInt64, &validatepb.FieldRules{Required: proto.Bool(true)})), int64(0), nil},
+		{"implicit zero is checked", field("f", typeString, stringRules(&validatepb.StringRules{MinLen: proto.Uint64(3)})), nil, []string{"f string.min_len"}},
+		{"optional unset is skipped", optional(field("f", typeInt64, int64Rules(&validatepb.Int64Rules{GreaterThan: &validatepb.Int64Rules_Gt{Gt: 0}}))), nil, nil},
+		{"optional zero is checked", optional(field("f", typeInt64, int64Rules(&validatepb.Int64Rules{GreaterThan: &validatepb.Int64Rules_Gt{Gt: 0}}))), int64(0), []string{"f int64.gt"}},
+		{"ignore if zero/zero", field("f", typeString, &validatepb.FieldRules{Ignore: validatepb.Ignore_IGNORE_IF_ZERO_VALUE.Enum(), Type: &validatepb.FieldRules_String_{String_: &validatepb.StringRules{MinLen: proto.Uint64(3)}}}), nil, nil},
+		{"ignore if zero/set", field("f", typeString, &validatepb.FieldRules{Ignore: validatepb.Ignore_IGNORE_IF_ZERO_VALUE.Enum(), Type: &validatepb.FieldRules_String_{String_: &validatepb.StringRules{MinLen: proto.Uint64(3)}}}), "ab", []string{"f string.min_len"}},
+		{"ignore always", field("f", typeString, &validatepb.FieldRules{Ignore: validatepb.Ignore_IGNORE_ALWAYS.Enum(), Required: proto.Bool(true)}), nil, nil},
+
+		{"string.const/ok", field("f", typeString, stringRules(&validatepb.StringRules{Const: proto.String("a")})), "a", nil},
+		{"string.const/broken", field("f", typeString, stringRules(&validatepb.StringRules{Const: proto.String("a")})), "b", []string{"f string.const"}},
+		{"string.len/counts characters", field("f", typeString, stringRules(&validatepb.StringRules{Len: proto.Uint64(3)})), "héé", nil},
+		{"string.len/broken", field("f", typeString, stringRules(&validatepb.StringRules{Len: proto.Uint64(3)})), "ab", []string{"f string.len"}},
+		{"string.min_len", field("f", typeString, stringRules(&validatepb.StringRules{MinLen: proto.Uint64(2)})), "a", []string{"f string.min_len"}},
+		{"string.max_len/counts characters", field("f", typeString, stringRules(&validatepb.StringRules{MaxLen: proto.Uint64(5)})), "héllo", nil},
+		{"string.max_len/broken", field("f", typeString, stringRules(&validatepb.StringRules{MaxLen: proto.Uint64(3)})), "abcd", []string{"f string.max_len"}},
+		{"string.pattern/ok", field("f", typeString, stringRules(&validatepb.StringRules{Pattern: proto.String("^[a-z]+$")})), "abc", nil},
+		{"string.pattern/broken", field("f", typeString, stringRules(&validatepb.StringRules{Pattern: proto.String("^[a-z]+$")})), "ABC", []string{"f string.pattern"}},
+		{"string.prefix", field("f", typeString, stringRules(&validatepb.StringRules{Prefix: proto.String("ab")})), "xab", []string{"f string.prefix"}},
+		{"string.suffix", field("f", typeString, stringRules(&validatepb.StringRules{Suffix: proto.String("yz")})), "yzx", []string{"f string.suffix"}},
+		{"string.contains", field("f", typeString, stringRules(&validatepb.StringRules{Contains: proto.String("mid")})), "nope", []string{"f string.contains"}},
+		{"string.not_contains", field("f", typeString, stringRules(&validatepb.StringRules{NotContains: proto.String("bad")})), "so bad", []string{"f string.not_contains"}},
+		{"string.in/ok", field("f", typeString, stringRules(&validatepb.StringRules{In: []string{"a", "b"}})), "b", nil},
+		{"string.in/broken", field("f", typeString, stringRules(&validatepb.StringRules{In: []string{"a", "b"}})), "c", []string{"f string.in"}},
+		{"string.not_in", field("f", typeString, stringRules(&validatepb.StringRules{NotIn: []string{"a"}})), "a", []string{"f string.not_in"}},
+		{"string.email/ok", field("f", typeString, stringRules(&validatepb.StringRules{WellKnown: &validatepb.StringRules_Email{Email: true}})), "a@example.com", nil},
+		{"string.email/broken", field("f", typeString, stringRules(&validatepb.StringRules{WellKnown: &validatepb.StringRules_Email{Email: true}})), "nope", []string{"f string.email"}},
+		{"string.email/empty", field("f", typeString, stringRules(&validatepb.StringRules{WellKnown: &validatepb.StringRules_Email{Email: true}})), nil, []string{"f string.email_empty"}},
+		{"string.uuid/ok", field("f", typeString, stringRules(&validatepb.StringRules{WellKnown: &validatepb.StringRules_Uuid{Uuid: true}})), uuid, nil},
+		{"string.uuid/broken", field("f", typeString, stringRules(&validatepb.StringRules{WellKnown: &validatepb.StringRules_Uuid{Uuid: true}})), "123", []string{"f string.uuid"}},
+		{"string.uuid/empty", field("f", typeString, stringRules(&validatepb.StringRules{WellKnown: &validatepb.StringRules_Uuid{Uuid: true}})), nil, []string{"f string.uuid_empty"}},
+
+		{"int64.const", field("f", typeInt64, int64Rules(&validatepb.Int64Rules{Const: proto.Int64(5)})), int64(4), []string{"f int64.const"}},
+		{"int64.gt/ok", field("f", typeInt64, int64Rules(&validatepb.Int64Rules{GreaterThan: &validatepb.Int64Rules_Gt{Gt: 0}})), int64(1), nil},
+		{"int64.gt/broken", field("f", typeInt64, int64Rules(&validatepb.Int64Rules{GreaterThan: &validatepb.Int64Rules_Gt{Gt: 0}})), int64(-1), []string{"f int64.gt"}},
+		{"int64.gte", field("f", typeInt64, int64Rules(&validatepb.Int64Rules{GreaterThan: &validatepb.Int64Rules_Gte{Gte: 1}})), nil, []string{"f int64.gte"}},
+		{"int64.lt", field("f", typeInt64, int64Rules(&validatepb.Int64Rules{LessThan: &validatepb.Int64Rules_Lt{Lt: 10}})), int64(10), []string{"f int64.lt"}},
+		{"int64.lte/ok", field("f", typeInt64, int64Rules(&validatepb.Int64Rules{LessThan: &validatepb.Int64Rules_Lte{Lte: 10}})), int64(10), nil},
+		{"int64.lte/broken", field("f", typeInt64, int64Rules(&validatepb.Int64Rules{LessThan: &validatepb.Int64Rules_Lte{Lte: 10}})), int64(11), []string{"f int64.lte"}},
+		{"int64.range", field("f", typeInt64, int64Rules(&validatepb.Int64Rules{GreaterThan: &validatepb.Int64Rules_Gte{Gte: 1}, LessThan: &validatepb.Int64Rules_Lte{Lte: 10}})), int64(11), []string{"f int64.lte"}},
+		{"int64.in", field("f", typeInt64, int64Rules(&validatepb.Int64Rules{In: []int64{1, 2}})), int64(3), []string{"f int64.in"}},
+		{"int64.not_in", field("f", typeInt64, int64Rules(&validatepb.Int64Rules{NotIn: []int64{3}})), int64(3), []string{"f int64.not_in"}},
+		{"uint32.lte", field("f", typeUint32, &validatepb.FieldRules{Type: &validatepb.FieldRules_Uint32{Uint32: &validatepb.UInt32Rules{LessThan: &validatepb.UInt32Rules_Lte{Lte: 5}}}}), uint32(6), []string{"f uint32.lte"}},
+		{"double.gt", field("f", typeDouble, doubleRules(&validatepb.DoubleRules{GreaterThan: &validatepb.DoubleRules_Gt{Gt: 0}})), nil, []string{"f double.gt"}},
+		{"double.finite/ok", field("f", typeDouble, doubleRules(&validatepb.DoubleRules{Finite: proto.Bool(true)})), 1.5, nil},
+		{"double.finite/inf", field("f", typeDouble, doubleRules(&validatepb.DoubleRules{Finite: proto.Bool(true)})), math.Inf(1), []string{"f double.finite"}},
+		{"double.finite/nan", field("f", typeDouble, doubleRules(&validatepb.DoubleRules{Finite: proto.Bool(true)})), math.NaN(), []string{"f double.finite"}},
+		{"bool.const", field("f", typeBool, &validatepb.FieldRules{Type: &validatepb.FieldRules_Bool{Bool: &validatepb.BoolRules{Const: proto.Bool(true)}}}), nil, []string{"f bool.const"}},
+
+		{"enum.const", field("f", typeEnum, enumRules(&validatepb.EnumRules{Const: proto.Int32(1)})), nil, []string{"f enum.const"}},
+		{"enum.defined_only/ok", field("f", typeEnum, enumRules(&validatepb.EnumRules{DefinedOnly: proto.Bool(true)})), protoreflect.EnumNumber(1), nil},
+		{"enum.defined_only/broken", field("f", typeEnum, enumRules(&validatepb.EnumRules{DefinedOnly: proto.Bool(true)})), protoreflect.EnumNumber(7), []string{"f enum.defined_only"}},
+		{"enum.in", field("f", typeEnum, enumRules(&validatepb.EnumRules{In: []int32{1}})), nil, []string{"f enum.in"}},
+		{"enum.not_in", field("f", typeEnum, enumRules(&validatepb.EnumRules{NotIn: []int32{1}})), protoreflect.EnumNumber(1), []string{"f enum.not_in"}},
+
+		{"repeated.min_items", repeated(field("f", typeString, repeatedRules(&validatepb.RepeatedRules{MinItems: proto.Uint64(1)}))), nil, []string{"f repeated.min_items"}},
+		{"repeated.max_items", repeated(field("f", typeString, repeatedRules(&validatepb.RepeatedRules{MaxItems: proto.Uint64(1)}))), []any{"a", "b"}, []string{"f repeated.max_items"}},
+		{"repeated.unique/ok", repeated(field("f", typeString, repeatedRules(&validatepb.RepeatedRules{Unique: proto.Bool(true)}))), []any{"a", "b"}, nil},
+		{"repeated.unique/broken", repeated(field("f", typeString, repeatedRules(&validatepb.RepeatedRules{Unique: proto.Bool(true)}))), []any{"a", "b", "a"}, []string{"f repeated.unique"}},
+		{"repeated.items", repeated(field("f", typeString, repeatedRules(&validatepb.RepeatedRules{Items: stringRules(&validatepb.StringRules{MaxLen: proto.Uint64(1)})}))), []any{"a", "bc"}, []string{"f[1] string.max_len"}},
+
+		{"nested message", field("f", typeMessage, nil), map[string]any{"name": ""}, []string{"f.name string.min_len"}},
+		{"nested message/ok", field("f", typeMessage, nil), map[string]any{"name": "x"}, nil},
+		{"nested message/unset", field("f", typeMessage, nil), nil, nil},
+		{"repeated messages", repeated(field("f", typeMessage, nil)), []any{map[string]any{"name": "x"}, map[string]any{"name": ""}}, []string{"f[1].name string.min_len"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := newMessage(t, &descriptorpb.DescriptorProto{Field: []*descriptorpb.FieldDescriptorProto{tc.field}})
+			set(msg, "f", tc.value)
+			if got := violations(t, Validate(msg)); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("violations = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestRulesThatCannotBeApplied checks that rules Validate does not support,
+// or that do not fit their field, fail loudly instead of passing.
+func TestRulesThatCannotBeApplied(t *testing.T) {
+	cases := []struct {
+		name  string
+		field *descriptorpb.FieldDescriptorProto
+		value any
+	}{
+		{"cel", field("f", typeString, &validatepb.FieldRules{Cel: []*validatepb.Rule{{Id: proto.String("f.cel"), Expression: proto.String("this != ''")}}}), "x"},
+		{"unsupported string rule", field("f", typeString, stringRules(&validatepb.StringRules{MinBytes: proto.Uint64(1)})), "x"},
+		{"bytes rules", field("f", descriptorpb.FieldDescriptorProto_TYPE_BYTES, &validatepb.FieldRules{Type: &validatepb.FieldRules_Bytes{Bytes: &validatepb.BytesRules{MinLen: proto.Uint64(1)}}}), []byte("x")},
+		{"bad pattern", field("f", typeString, stringRules(&validatepb.StringRules{Pattern: proto.String("(")})), "x"},
+		{"rules for another type", field("f", typeInt64, stringRules(&validatepb.StringRules{MinLen: proto.Uint64(1)})), int64(1)},
+		{"item rules without repeated", repeated(field("f", typeString, stringRules(&validatepb.StringRules{MinLen: proto.Uint64(1)}))), []any{"x"}},
+		{"unique messages", repeated(field("f", typeMessage, repeatedRules(&validatepb.RepeatedRules{Unique: proto.Bool(true)}))), []any{map[string]any{"name": "x"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := newMessage(t, &descriptorpb.DescriptorProto{Field: []*descriptorpb.FieldDescriptorProto{tc.field}})
+			set(msg, "f", tc.value)
+			err := Validate(msg)
+			var verr *Error
+			if err == nil || errors.As(err, &verr) {
+				t.Fatalf("Validate = %v, want an error about the rules", err)
+			}
+		})
+	}
+
+	t.Run("message cel", func(t *testing.T) {
+		opts := &descriptorpb.MessageOptions{}
+		proto.SetExtension(opts, validatepb.E_Message, &validatepb.MessageRules{Cel: []*validatepb.Rule{{Id: proto.String("m.cel"), Expression: proto.String("true")}}})
+		msg := newMessage(t, &descriptorpb.DescriptorProto{Options: opts})
+		if err := Validate(msg); err == nil || errors.As(err, new(*Error)) {
+			t.Fatalf("Validate = %v, want an error about the rules", err)
+		}
+	})
+}
+
+func TestOneofRequired(t *testing.T) {
+	opts := &descriptorpb.OneofOptions{}
+	proto.SetExtension(opts, validatepb.E_Oneof, &validatepb.OneofRules{Required: proto.Bool(true)})
+	build := func(t *testing.T) *dynamicpb.Message {
+		a := field("a", typeString, nil)
+		b := field("b", typeInt64, nil)
+		a.OneofIndex, b.OneofIndex = proto.Int32(0), proto.Int32(0)
+		return newMessage(t, &descriptorpb.DescriptorProto{
+			Field:     []*descriptorpb.FieldDescriptorProto{a, b},
+			OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("choice"), Options: opts}},
+		})
+	}
+
+	t.Run("unset", func(t *testing.T) {
+		if got, want := violations(t, Validate(build(t))), []string{"choice required"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("violations = %q, want %q", got, want)
+		}
+	})
+	t.Run("set", func(t *testing.T) {
+		msg := build(t)
+		set(msg, "b", int64(0))
+		if err := Validate(msg); err != nil {
+			t.Errorf("Validate = %v, want nil", err)
+		}
+	})
+}
+
+func TestEveryViolationIsReported(t *testing.T) {
+	msg := newMessage(t, &descriptorpb.DescriptorProto{Field: []*descriptorpb.FieldDescriptorProto{
+		field("name", typeString, &validatepb.FieldRules{Required: proto.Bool(true)}),
+		field("email", typeString, stringRules(&validatepb.StringRules{WellKnown: &validatepb.StringRules_Email{Email: true}})),
+	}})
+	set(msg, "email", "nope")
+
+	err := Validate(msg)
+	if got, want := violations(t, err), []string{"name required", "email string.email"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("violations = %q, want %q", got, want)
+	}
+	if got, want := err.Error(), "invalid request: name: value is required; email: value must be a valid email address"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestStatusRoundTrip(t *testing.T) {
+	msg := newMessage(t, &descriptorpb.DescriptorProto{Field: []*descriptorpb.FieldDescriptorProto{
+		field("f", typeString, stringRules(&validatepb.StringRules{MinLen: proto.Uint64(2)})),
+	}})
+	err := Validate(msg)
+	st := Status(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code = %v, want InvalidArgument", st.Code())
+	}
+	var verr *Error
+	errors.As(err, &verr)
+	if got := FromStatus(st); !reflect.DeepEqual(got, verr.Violations) {
+		t.Errorf("FromStatus = %v, want %v", got, verr.Violations)
+	}
+
+	if code := Status(errors.New("broken rules")).Code(); code != codes.Internal {
+		t.Errorf("code for a rules error = %v, want Internal", code)
+	}
+}
+
+func TestBindJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newTest := func(t *testing.T) *dynamicpb.Message {
+		return newMessage(t, &descriptorpb.DescriptorProto{Field: []*descriptorpb.FieldDescriptorProto{
+			field("id", typeString, &validatepb.FieldRules{Required: proto.Bool(true)}),
+			field("name", typeString, stringRules(&validatepb.StringRules{MinLen: proto.Uint64(2)})),
+		}})
+	}
+	type request struct {
+		Name string `json:"name"`
+	}
+	bind := func(t *testing.T, body string, msg *dynamicpb.Message) error {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		var req request
+		return BindJSON(c, &req, msg)
+	}
+
+	t.Run("keeps fields set from the path", func(t *testing.T) {
+		msg := newTest(t)
+		set(msg, "id", "42")
+		if err := bind(t, `{"name": "ok"}`, msg); err != nil {
+			t.Fatalf("BindJSON = %v, want nil", err)
+		}
+	})
+	t.Run("validates the body", func(t *testing.T) {
+		msg := newTest(t)
+		set(msg, "id", "42")
+		err := bind(t, `{"name": "x"}`, msg)
+		if got, want := violations(t, err), []string{"name string.min_len"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("violations = %q, want %q", got, want)
+		}
+		if body := ErrorBody(err); body["violations"] == nil {
+			t.Errorf("ErrorBody = %v, want violations", body)
+		}
+	})
+	t.Run("malformed JSON", func(t *testing.T) {
+		err := bind(t, `{`, newTest(t))
+		if err == nil || errors.As(err, new(*Error)) {
+			t.Fatalf("BindJSON = %v, want a decoding error", err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	payload := []byte(`{"id":"evt_1","type":"payment.settled"}`)
+	header := Sign("secret", payload, now)
+	other := Sign("old-secret", payload, now)
+
+	cases := []struct {
+		name    string
+		secret  string
+		payload []byte
+		header  string
+		now     time.Time
+		want    error
+	}{
+		{"valid", "secret", payload, header, now, nil},
+		{"within tolerance after", "secret", payload, header, now.Add(5 * time.Minute), nil},
+		{"within tolerance before", "secret", payload, header, now.Add(-5 * time.Minute), nil},
+		{"too old", "secret", payload, header, now.Add(5*time.Minute + time.Second), ErrStaleTimestamp},
+		{"from the future", "secret", payload, header, now.Add(-5*time.Minute - time.Second), ErrStaleTimestamp},
+		{"wrong secret", "other", payload, header, now, ErrInvalidSignature},
+		{"tampered body", "secret", []byte(`{"id":"evt_2","type":"payment.settled"}`), header, now, ErrInvalidSignature},
+		{"tampered timestamp", "secret", payload, strings.Replace(header, "t=1700000000", "t=1700000001", 1), now, ErrInvalidSignature},
+		{"rotated secret, either signature", "secret", payload, other + "," + strings.Split(header, ",")[1], now, nil},
+		{"rotated secret, old signature only", "secret", payload, other, now, ErrInvalidSignature},
+		{"empty header", "secret", payload, "", now, ErrInvalidSignature},
+		{"no signature", "secret", payload, "t=1700000000", now, ErrInvalidSignature},
+		{"no timestamp", "secret", payload, strings.Split(header, ",")[1], now, ErrInvalidSignature},
+		{"non-numeric timestamp", "secret", payload, "t=abc," + strings.Split(header, ",")[1], now, ErrInvalidSignature},
+		{"non-hex signature", "secret", payload, "t=1700000000,v1=zz", now, ErrInvalidSignature},
+	}
+
+	for _, tc := range cases {
+		err := Verify(tc.secret, tc.payload, tc.header, 5*time.Minute, tc.now)
+		if !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+			t.Errorf("%s: Verify = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestSignFormat(t *testing.T) {
+	header := Sign("secret", []byte("body"), time.Unix(1700000000, 0))
+	ts, sig, ok := strings.Cut(header, ",")
+	if !ok || ts != "t=1700000000" || !strings.HasPrefix(sig, "v1=") || len(sig) != len("v1=")+64 {
+		t.Errorf("Sign = %q, want t=1700000000,v1=<64 hex digits>", header)
+	}
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestPublicAddr(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.215.14":        true,
+		"8.8.8.8":              true,
+		"2606:4700::1111":      true,
+		"127.0.0.1":            false,
+		"127.1.2.3":            false,
+		"::1":                  false,
+		"169.254.169.254":      false,
+		"fe80::1":              false,
+		"10.0.0.1":             false,
+		"172.16.5.4":           false,
+		"192.168.1.1":          false,
+		"fd00::1":              false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::":                   false,
+		"224.0.0.1":            false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:169.254.1.1":   false,
+		"64:ff9b::a9fe:a9fe":   false,
+		"255.255.255.255":      false,
+		"198.18.0.1":           false,
+		"::ffff:93.184.215.14": true,
+	}
+	for addr, want := range cases {
+		if got := PublicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("PublicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	cases := map[string]bool{
+		"https://93.184.215.14/hook":          true,
+		"http://[2606:4700::1111]:8080/hook":  true,
+		"http://127.0.0.1/hook":               false,
+		"http://169.254.169.254/latest/meta":  false,
+		"http://[::1]:9000/hook":              false,
+		"http://10.1.2.3/hook":                false,
+		"http://localhost:8080/hook":          false,
+		"http://[::ffff:192.168.0.1]:80/hook": false,
+	}
+	for raw, ok := range cases {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = CheckURL(context.Background(), u)
+		if ok && err != nil {
+			t.Errorf("CheckURL(%s) = %v, want nil", raw, err)
+		}
+		if !ok && !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("CheckURL(%s) = %v, want ErrForbiddenAddress", raw, err)
+		}
+	}
+}
+
+func TestNewClientRefusesLoopback(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second).Post(server.URL, "application/json", nil)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("Post to %s: %v, want ErrForbiddenAddress", server.URL, err)
+	}
+	if called {
+		t.Error("the loopback server received the request")
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
+	"github.com/edwinjordan/golang_microservices/pkg/restproxy"
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/catalog/internal/config"
+	grpcHandler "github.com/edwinjordan/golang_microservices/services/catalog/internal/delivery/grpc"
+	httpHandler "github.com/edwinjordan/golang_microservices/services/catalog/internal/delivery/http"
+	"github.com/edwinjordan/golang_microservices/services/catalog/internal/domain"
+	"github.com/edwinjordan/golang_microservices/services/catalog/internal/repository"
+	"github.com/edwinjordan/golang_microservices/services/catalog/internal/usecase"
+	pb "github.com/edwinjordan/golang_microservices/services/catalog/pkg/pb"
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	// Load configuration
+	cfg := config.LoadConfig()
+
+	// Connect to database with retry
+	var db *sql.DB
+	var err error
+	for i := 0; i < 30; i++ {
+		db, err = sql.Open("postgres", cfg.GetDSN())
+		if err == nil {
+			err = db.Ping()
+			if err == nil {
+				break
+			}
+		}
+		log.Printf("Failed to connect to database, retrying in 2 seconds... (%d/30)", i+1)
+		time.Sleep(2 * time.Second)
+	}
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	log.Println("Connected to database successfully")
+
+	// Initialize database schema
+	initSchema(db)
+
+	// Initialize layers
+	productRepo := repository.NewPostgresProductRepository(db)
+	skuRepo := repository.NewPostgresSKURepository(db)
+	catalogUsecase := usecase.NewCatalogUsecase(productRepo, skuRepo, pagination.NewCodec(cfg.PageTokenSecret))
+
+	// Start gRPC server
+	go func() {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.GRPCPort))
+		if err != nil {
+			log.Fatalf("Failed to listen on gRPC port: %v", err)
+		}
+
+		grpcServer := grpc.NewServer(grpc.UnaryInterceptor(validate.UnaryServerInterceptor()))
+		catalogGRPCHandler := grpcHandler.NewCatalogGRPCHandler(catalogUsecase)
+		pb.RegisterCatalogServiceServer(grpcServer, catalogGRPCHandler)
+
+		log.Printf("gRPC server listening on port %s", cfg.GRPCPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Failed to serve gRPC: %v", err)
+		}
+	}()
+
+	// Start HTTP server
+	router := gin.Default()
+	registerRoutes(router, catalogUsecase)
+
+	// REST routes generated from the HTTP rules in proto/catalog.proto, proxied
+	// to the gRPC server
+	restProxy, err := restproxy.New(context.Background(), "localhost:"+cfg.GRPCPort, pb.RegisterCatalogServiceHandlerFromEndpoint)
+	if err != nil {
+		log.Fatalf("Failed to start REST proxy: %v", err)
+	}
+	router.Any(restproxy.Prefix+"/*path", gin.WrapH(restProxy))
+
+	if err := openapi.Mount(router, httpHandler.API(), restproxy.Prefix); err != nil {
+		log.Fatalf("Failed to serve OpenAPI document: %v", err)
+	}
+
+	log.Printf("HTTP server listening on port %s", cfg.HTTPPort)
+	if err := router.Run(fmt.Sprintf(":%s", cfg.HTTPPort)); err != nil {
+		log.Fatalf("Failed to start HTTP server: %v", err)
+	}
+}
+
+// registerRoutes registers the hand-written HTTP routes. main adds the
+// generated REST routes and the OpenAPI document.
+func registerRoutes(router *gin.Engine, catalogUsecase domain.CatalogUsecase) {
+	catalogHandler := httpHandler.NewCatalogHandler(catalogUsecase)
+
+	router.GET("/health", catalogHandler.Health)
+	router.POST("/products", catalogHandler.CreateProduct)
+	router.GET("/products", catalogHandler.ListProducts)
+	router.GET("/products/:id", catalogHandler.GetProduct)
+	router.PATCH("/products/:id", catalogHandler.UpdateProduct)
+	router.POST("/products/:id/skus", catalogHandler.CreateSKU)
+	router.GET("/skus/:code", catalogHandler.GetSKU)
+	router.PATCH("/skus/:code", catalogHandler.UpdateSKU)
+}
+
+func initSchema(db *sql.DB) {
+	schema := `
+	CREATE TABLE IF NOT EXISTS products (
+		id VARCHAR(36) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_class VARCHAR(32) NOT NULL DEFAULT 'standard';
+
+	CREATE INDEX IF NOT EXISTS idx_products_created_at ON products (created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_products_name ON products (name, id);
+
+	CREATE TABLE IF NOT EXISTS skus (
+		code VARCHAR(64) PRIMARY KEY,
+		product_id VARCHAR(36) NOT NULL REFERENCES products(id),
+		name VARCHAR(255) NOT NULL DEFAULT '',
+		price DECIMAL(10, 2) NOT NULL,
+		currency VARCHAR(3) NOT NULL,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_skus_product_id ON skus (product_id);
+	`
+	_, err := db.Exec(schema)
+	if err != nil {
+		log.Fatalf("Failed to initialize schema: %v", err)
+	}
+	log.Println("Database schema initialized")
+}
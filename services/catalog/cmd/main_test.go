@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/edwinjordan/golang_microservices/pkg/openapi/openapitest"
+	httpHandler "github.com/edwinjordan/golang_microservices/services/catalog/internal/delivery/http"
+	"github.com/gin-gonic/gin"
+)
+
+// TestOpenAPIDocument keeps openapi.json in step with the routes. The
+// handlers are never called, so they get no dependencies.
+func TestOpenAPIDocument(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	registerRoutes(router, nil)
+	openapitest.Check(t, router, httpHandler.API(), "../openapi.json")
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+type Config struct {
+	DBHost          string
+	DBPort          string
+	DBUser          string
+	DBPassword      string
+	DBName          string
+	HTTPPort        string
+	GRPCPort        string
+	PageTokenSecret string
+}
+
+func LoadConfig() *Config {
+	return &Config{
+		DBHost:          getEnv("CATALOG_DB_HOST", "localhost"),
+		DBPort:          getEnv("CATALOG_DB_PORT", "5432"),
+		DBUser:          getEnv("CATALOG_DB_USER", "catalogservice"),
+		DBPassword:      getEnv("CATALOG_DB_PASSWORD", "catalogpass123"),
+		DBName:          getEnv("CATALOG_DB_NAME", "catalog_db"),
+		HTTPPort:        getEnv("CATALOG_SERVICE_HTTP_PORT", "8084"),
+		GRPCPort:        getEnv("CATALOG_SERVICE_GRPC_PORT", "9094"),
+		PageTokenSecret: getEnv("CATALOG_PAGE_TOKEN_SECRET", "change-me-page-token-secret"),
+	}
+}
+
+func (c *Config) GetDSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,118 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edwinjordan/golang_microservices/services/catalog/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/catalog/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type CatalogGRPCHandler struct {
+	pb.UnimplementedCatalogServiceServer
+	catalogUsecase domain.CatalogUsecase
+}
+
+func NewCatalogGRPCHandler(catalogUsecase domain.CatalogUsecase) *CatalogGRPCHandler {
+	return &CatalogGRPCHandler{catalogUsecase: catalogUsecase}
+}
+
+func (h *CatalogGRPCHandler) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.Product, error) {
+	product, err := h.catalogUsecase.CreateProduct(req.Name, req.Description, req.TaxClass)
+	if err != nil {
+		return nil, err
+	}
+	return toPBProduct(product), nil
+}
+
+func (h *CatalogGRPCHandler) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.Product, error) {
+	product, err := h.catalogUsecase.GetProduct(req.Id)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return toPBProduct(product), nil
+}
+
+func (h *CatalogGRPCHandler) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	page, err := h.catalogUsecase.ListProducts(domain.ListProductsRequest{
+		ActiveOnly: req.ActiveOnly,
+		OrderBy:    req.OrderBy,
+		PageSize:   int(req.PageSize),
+		PageToken:  req.PageToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListProductsResponse{
+		Products:      make([]*pb.Product, 0, len(page.Products)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, product := range page.Products {
+		resp.Products = append(resp.Products, toPBProduct(product))
+	}
+	return resp, nil
+}
+
+func (h *CatalogGRPCHandler) CreateSku(ctx context.Context, req *pb.CreateSkuRequest) (*pb.Sku, error) {
+	sku, err := h.catalogUsecase.CreateSKU(req.ProductId, req.Code, req.Name, req.Price, req.Currency)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return toPBSku(sku), nil
+}
+
+func (h *CatalogGRPCHandler) GetSku(ctx context.Context, req *pb.GetSkuRequest) (*pb.Sku, error) {
+	sku, err := h.catalogUsecase.GetSKU(req.Code)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return toPBSku(sku), nil
+}
+
+func toPBProduct(product *domain.Product) *pb.Product {
+	resp := &pb.Product{
+		Id:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		TaxClass:    product.TaxClass,
+		Active:      product.Active,
+		CreatedAt:   timestamppb.New(product.CreatedAt),
+		UpdatedAt:   timestamppb.New(product.UpdatedAt),
+	}
+	for _, sku := range product.SKUs {
+		resp.Skus = append(resp.Skus, toPBSku(sku))
+	}
+	return resp
+}
+
+func toPBSku(sku *domain.SKU) *pb.Sku {
+	return &pb.Sku{
+		Code:        sku.Code,
+		ProductId:   sku.ProductID,
+		Name:        sku.Name,
+		Price:       sku.Price,
+		Currency:    sku.Currency,
+		Active:      sku.Active,
+		ProductName: sku.ProductName,
+		TaxClass:    sku.ProductTaxClass,
+		Sellable:    sku.Sellable(),
+		CreatedAt:   timestamppb.New(sku.CreatedAt),
+		UpdatedAt:   timestamppb.New(sku.UpdatedAt),
+	}
+}
+
+// catalogError maps lookup failures to NotFound and AlreadyExists so callers
+// can tell them apart from infrastructure errors.
+func catalogError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrProductNotFound), errors.Is(err, domain.ErrSKUNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrSKUExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/catalog/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/catalog/pkg/pb"
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogHandler struct {
+	catalogUsecase domain.CatalogUsecase
+}
+
+func NewCatalogHandler(catalogUsecase domain.CatalogUsecase) *CatalogHandler {
+	return &CatalogHandler{catalogUsecase: catalogUsecase}
+}
+
+// CreateProductRequest creates a product; tax_class defaults to "standard".
+type CreateProductRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	TaxClass    string `json:"tax_class"`
+}
+
+type UpdateProductRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	TaxClass    *string `json:"tax_class"`
+	Active      *bool   `json:"active"`
+}
+
+type CreateSKURequest struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Currency string  `json:"currency"`
+}
+
+type UpdateSKURequest struct {
+	Name   *string  `json:"name"`
+	Price  *float64 `json:"price"`
+	Active *bool    `json:"active"`
+}
+
+type ListProductsQuery struct {
+	ActiveOnly bool   `form:"active_only"`
+	OrderBy    string `form:"order_by"`
+	PageSize   int    `form:"page_size"`
+	PageToken  string `form:"page_token"`
+}
+
+type SKUResponse struct {
+	*domain.SKU
+	Sellable bool `json:"sellable"`
+}
+
+type ProductResponse struct {
+	*domain.Product
+	SKUs []SKUResponse `json:"skus,omitempty"`
+}
+
+type ListProductsResponse struct {
+	Products      []ProductResponse `json:"products"`
+	NextPageToken string            `json:"next_page_token,omitempty"`
+}
+
+func (h *CatalogHandler) CreateProduct(c *gin.Context) {
+	var req CreateProductRequest
+	if err := validate.BindJSON(c, &req, &pb.CreateProductRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	product, err := h.catalogUsecase.CreateProduct(req.Name, req.Description, req.TaxClass)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toProductResponse(product))
+}
+
+func (h *CatalogHandler) GetProduct(c *gin.Context) {
+	product, err := h.catalogUsecase.GetProduct(c.Param("id"))
+	if err != nil {
+		c.JSON(catalogStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toProductResponse(product))
+}
+
+func (h *CatalogHandler) ListProducts(c *gin.Context) {
+	var query ListProductsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.catalogUsecase.ListProducts(domain.ListProductsRequest{
+		ActiveOnly: query.ActiveOnly,
+		OrderBy:    query.OrderBy,
+		PageSize:   query.PageSize,
+		PageToken:  query.PageToken,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := ListProductsResponse{
+		Products:      make([]ProductResponse, 0, len(page.Products)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, product := range page.Products {
+		resp.Products = append(resp.Products, toProductResponse(product))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *CatalogHandler) UpdateProduct(c *gin.Context) {
+	var req UpdateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := h.catalogUsecase.UpdateProduct(c.Param("id"), domain.ProductUpdate{
+		Name:        req.Name,
+		Description: req.Description,
+		TaxClass:    req.TaxClass,
+		Active:      req.Active,
+	})
+	if err != nil {
+		c.JSON(catalogStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toProductResponse(product))
+}
+
+func (h *CatalogHandler) CreateSKU(c *gin.Context) {
+	var req CreateSKURequest
+	if err := validate.BindJSON(c, &req, &pb.CreateSkuRequest{ProductId: c.Param("id")}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	sku, err := h.catalogUsecase.CreateSKU(c.Param("id"), req.Code, req.Name, req.Price, req.Currency)
+	if err != nil {
+		c.JSON(catalogStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toSKUResponse(sku))
+}
+
+func (h *CatalogHandler) GetSKU(c *gin.Context) {
+	sku, err := h.catalogUsecase.GetSKU(c.Param("code"))
+	if err != nil {
+		c.JSON(catalogStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toSKUResponse(sku))
+}
+
+func (h *CatalogHandler) UpdateSKU(c *gin.Context) {
+	var req UpdateSKURequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sku, err := h.catalogUsecase.UpdateSKU(c.Param("code"), domain.SKUUpdate{
+		Name:   req.Name,
+		Price:  req.Price,
+		Active: req.Active,
+	})
+	if err != nil {
+		c.JSON(catalogStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toSKUResponse(sku))
+}
+
+func (h *CatalogHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"service": "catalog-service",
+	})
+}
+
+func toProductResponse(product *domain.Product) ProductResponse {
+	resp := ProductResponse{Product: product}
+	for _, sku := range product.SKUs {
+		resp.SKUs = append(resp.SKUs, toSKUResponse(sku))
+	}
+	return resp
+}
+
+func toSKUResponse(sku *domain.SKU) SKUResponse {
+	return SKUResponse{SKU: sku, Sellable: sku.Sellable()}
+}
+
+func catalogStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrProductNotFound), errors.Is(err, domain.ErrSKUNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrSKUExists):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	pb "github.com/edwinjordan/golang_microservices/services/catalog/pkg/pb"
+)
+
+// API documents the catalog service's HTTP routes. The service will not
+// start if a route is missing from it.
+func API() openapi.API {
+	return openapi.API{
+		Title:       "Catalog Service",
+		Version:     "1.0",
+		Description: "Products and their sellable SKUs.",
+		Operations: []openapi.Operation{
+			{Method: http.MethodGet, Path: "/health", Tag: "health", Summary: "Health check", Response: openapi.HealthResponse{}},
+
+			{Method: http.MethodPost, Path: "/products", Tag: "products", Summary: "Create a product", Body: CreateProductRequest{}, Rules: &pb.CreateProductRequest{}, Status: http.StatusCreated, Response: ProductResponse{}},
+			{Method: http.MethodGet, Path: "/products", Tag: "products", Summary: "List products", Query: ListProductsQuery{}, Response: ListProductsResponse{}},
+			{Method: http.MethodGet, Path: "/products/:id", Tag: "products", Summary: "Get a product and its SKUs", Response: ProductResponse{}},
+			{Method: http.MethodPatch, Path: "/products/:id", Tag: "products", Summary: "Update a product's present fields", Body: UpdateProductRequest{}, Response: ProductResponse{}},
+
+			{Method: http.MethodPost, Path: "/products/:id/skus", Tag: "skus", Summary: "Add a SKU to a product", Body: CreateSKURequest{}, Rules: &pb.CreateSkuRequest{}, Status: http.StatusCreated, Response: SKUResponse{}},
+			{Method: http.MethodGet, Path: "/skus/:code", Tag: "skus", Summary: "Get a SKU", Response: SKUResponse{}},
+			{Method: http.MethodPatch, Path: "/skus/:code", Tag: "skus", Summary: "Update a SKU's present fields", Body: UpdateSKURequest{}, Response: SKUResponse{}},
+		},
+	}
+}
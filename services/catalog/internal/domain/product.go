@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
+)
+
+// DefaultCurrency is used for SKUs created without a currency.
+const DefaultCurrency = "USD"
+
+// DefaultTaxClass is used for products created without a tax class. The
+// order service maps tax classes to rates.
+const DefaultTaxClass = "standard"
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrSKUNotFound     = errors.New("sku not found")
+	ErrSKUExists       = errors.New("sku code already exists")
+)
+
+type Product struct {
+	ID          string    `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	TaxClass    string    `json:"tax_class" db:"tax_class"`
+	Active      bool      `json:"active" db:"active"`
+	SKUs        []*SKU    `json:"skus,omitempty"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SKU is a sellable variant of a product, identified by a merchant-chosen
+// code, with its own price.
+type SKU struct {
+	Code      string    `json:"code" db:"code"`
+	ProductID string    `json:"product_id" db:"product_id"`
+	Name      string    `json:"name" db:"name"`
+	Price     float64   `json:"price" db:"price"`
+	Currency  string    `json:"currency" db:"currency"`
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// ProductName, ProductTaxClass and ProductActive are read from the
+	// owning product.
+	ProductName     string `json:"product_name"`
+	ProductTaxClass string `json:"tax_class"`
+	ProductActive   bool   `json:"-"`
+}
+
+// Sellable reports whether the SKU can be ordered: it and its product must
+// both be active.
+func (s *SKU) Sellable() bool {
+	return s.Active && s.ProductActive
+}
+
+// Sort fields accepted by ListProducts.
+const (
+	ProductSortCreatedAt = "created_at"
+	ProductSortName      = "name"
+)
+
+type ProductFilter struct {
+	ActiveOnly bool
+	Page       pagination.Page
+}
+
+type ListProductsRequest struct {
+	ActiveOnly bool
+	OrderBy    string
+	PageSize   int
+	PageToken  string
+}
+
+type ProductPage struct {
+	Products      []*Product `json:"products"`
+	NextPageToken string     `json:"next_page_token"`
+}
+
+// ProductUpdate holds the fields to change; nil fields are left alone.
+type ProductUpdate struct {
+	Name        *string
+	Description *string
+	TaxClass    *string
+	Active      *bool
+}
+
+// SKUUpdate holds the fields to change; nil fields are left alone.
+type SKUUpdate struct {
+	Name   *string
+	Price  *float64
+	Active *bool
+}
+
+type ProductRepository interface {
+	Create(product *Product) error
+	GetByID(id string) (*Product, error)
+	Update(product *Product) error
+	List(filter ProductFilter) ([]*Product, error)
+}
+
+type SKURepository interface {
+	Create(sku *SKU) error
+	GetByCode(code string) (*SKU, error)
+	Update(sku *SKU) error
+	ListByProduct(productID string) ([]*SKU, error)
+}
+
+type CatalogUsecase interface {
+	CreateProduct(name, description, taxClass string) (*Product, error)
+	// GetProduct returns the product with its SKUs.
+	GetProduct(id string) (*Product, error)
+	ListProducts(req ListProductsRequest) (*ProductPage, error)
+	UpdateProduct(id string, update ProductUpdate) (*Product, error)
+	CreateSKU(productID, code, name string, price float64, currency string) (*SKU, error)
+	GetSKU(code string) (*SKU, error)
+	UpdateSKU(code string, update SKUUpdate) (*SKU, error)
+}
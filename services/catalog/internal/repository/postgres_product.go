@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/catalog/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PostgresProductRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresProductRepository(db *sql.DB) domain.ProductRepository {
+	return &PostgresProductRepository{db: db}
+}
+
+func (r *PostgresProductRepository) Create(product *domain.Product) error {
+	product.ID = uuid.New().String()
+	product.Active = true
+	product.CreatedAt = time.Now()
+	product.UpdatedAt = product.CreatedAt
+
+	query := `INSERT INTO products (id, name, description, tax_class, active, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.Exec(query, product.ID, product.Name, product.Description, product.TaxClass, product.Active, product.CreatedAt, product.UpdatedAt)
+	return err
+}
+
+func (r *PostgresProductRepository) GetByID(id string) (*domain.Product, error) {
+	product := &domain.Product{}
+	query := `SELECT id, name, description, tax_class, active, created_at, updated_at FROM products WHERE id = $1`
+	err := r.db.QueryRow(query, id).Scan(&product.ID, &product.Name, &product.Description, &product.TaxClass, &product.Active, &product.CreatedAt, &product.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (r *PostgresProductRepository) Update(product *domain.Product) error {
+	product.UpdatedAt = time.Now()
+	query := `UPDATE products SET name = $1, description = $2, tax_class = $3, active = $4, updated_at = $5 WHERE id = $6`
+	_, err := r.db.Exec(query, product.Name, product.Description, product.TaxClass, product.Active, product.UpdatedAt, product.ID)
+	return err
+}
+
+// List returns products using keyset pagination on (sort column, id).
+func (r *PostgresProductRepository) List(filter domain.ProductFilter) ([]*domain.Product, error) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.ActiveOnly {
+		conds = append(conds, "active")
+	}
+
+	column := "created_at"
+	if filter.Page.Sort.Field == domain.ProductSortName {
+		column = "name"
+	}
+	direction, cmp := filter.Page.Sort.Direction()
+
+	if after := filter.Page.After; after != nil {
+		var value any = after.CreatedAt
+		if column == "name" {
+			value = after.Key
+		}
+		conds = append(conds, fmt.Sprintf("(%s, id) %s (%s, %s)", column, cmp, arg(value), arg(after.ID)))
+	}
+
+	query := `SELECT id, name, description, tax_class, active, created_at, updated_at FROM products`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", column, direction, direction, arg(filter.Page.Limit()))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*domain.Product
+	for rows.Next() {
+		product := &domain.Product{}
+		if err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.TaxClass, &product.Active, &product.CreatedAt, &product.UpdatedAt); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
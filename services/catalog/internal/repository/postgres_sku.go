@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/catalog/internal/domain"
+)
+
+type PostgresSKURepository struct {
+	db *sql.DB
+}
+
+func NewPostgresSKURepository(db *sql.DB) domain.SKURepository {
+	return &PostgresSKURepository{db: db}
+}
+
+const skuSelect = `SELECT s.code, s.product_id, s.name, s.price, s.currency, s.active, s.created_at, s.updated_at,
+	p.name, p.tax_class, p.active
+	FROM skus s JOIN products p ON p.id = s.product_id`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSKU(row rowScanner, sku *domain.SKU) error {
+	return row.Scan(&sku.Code, &sku.ProductID, &sku.Name, &sku.Price, &sku.Currency, &sku.Active, &sku.CreatedAt,
+		&sku.UpdatedAt, &sku.ProductName, &sku.ProductTaxClass, &sku.ProductActive)
+}
+
+func (r *PostgresSKURepository) Create(sku *domain.SKU) error {
+	sku.Active = true
+	sku.CreatedAt = time.Now()
+	sku.UpdatedAt = sku.CreatedAt
+
+	query := `INSERT INTO skus (code, product_id, name, price, currency, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (code) DO NOTHING`
+	res, err := r.db.Exec(query, sku.Code, sku.ProductID, sku.Name, sku.Price, sku.Currency, sku.Active, sku.CreatedAt, sku.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return domain.ErrSKUExists
+	}
+	return nil
+}
+
+func (r *PostgresSKURepository) GetByCode(code string) (*domain.SKU, error) {
+	sku := &domain.SKU{}
+	err := scanSKU(r.db.QueryRow(skuSelect+` WHERE s.code = $1`, code), sku)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrSKUNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sku, nil
+}
+
+func (r *PostgresSKURepository) Update(sku *domain.SKU) error {
+	sku.UpdatedAt = time.Now()
+	query := `UPDATE skus SET name = $1, price = $2, active = $3, updated_at = $4 WHERE code = $5`
+	_, err := r.db.Exec(query, sku.Name, sku.Price, sku.Active, sku.UpdatedAt, sku.Code)
+	return err
+}
+
+func (r *PostgresSKURepository) ListByProduct(productID string) ([]*domain.SKU, error) {
+	rows, err := r.db.Query(skuSelect+` WHERE s.product_id = $1 ORDER BY s.created_at, s.code`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var skus []*domain.SKU
+	for rows.Next() {
+		sku := &domain.SKU{}
+		if err := scanSKU(rows, sku); err != nil {
+			return nil, err
+		}
+		skus = append(skus, sku)
+	}
+	return skus, rows.Err()
+}
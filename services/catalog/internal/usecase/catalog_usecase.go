@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
+	"github.com/edwinjordan/golang_microservices/services/catalog/internal/domain"
+)
+
+type catalogUsecase struct {
+	productRepo domain.ProductRepository
+	skuRepo     domain.SKURepository
+	paginator   *pagination.Paginator
+}
+
+func NewCatalogUsecase(productRepo domain.ProductRepository, skuRepo domain.SKURepository, pageTokens *pagination.Codec) domain.CatalogUsecase {
+	return &catalogUsecase{
+		productRepo: productRepo,
+		skuRepo:     skuRepo,
+		paginator: pagination.NewPaginator(pageTokens, pagination.Options{
+			SortFields: []string{domain.ProductSortCreatedAt, domain.ProductSortName},
+		}),
+	}
+}
+
+func (u *catalogUsecase) CreateProduct(name, description, taxClass string) (*domain.Product, error) {
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	taxClass = strings.ToLower(strings.TrimSpace(taxClass))
+	if taxClass == "" {
+		taxClass = domain.DefaultTaxClass
+	}
+
+	product := &domain.Product{Name: name, Description: description, TaxClass: taxClass}
+	if err := u.productRepo.Create(product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (u *catalogUsecase) GetProduct(id string) (*domain.Product, error) {
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+
+	product, err := u.productRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	product.SKUs, err = u.skuRepo.ListByProduct(id)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (u *catalogUsecase) ListProducts(req domain.ListProductsRequest) (*domain.ProductPage, error) {
+	filter := domain.ProductFilter{ActiveOnly: req.ActiveOnly}
+	page, err := u.paginator.Prepare(req.OrderBy, req.PageSize, req.PageToken, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.Page = page
+	products, err := u.productRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	products, next := pagination.Trim(u.paginator, page, products, func(p *domain.Product) pagination.Cursor {
+		return pagination.Cursor{Key: p.Name, CreatedAt: p.CreatedAt, ID: p.ID}
+	})
+	return &domain.ProductPage{Products: products, NextPageToken: next}, nil
+}
+
+func (u *catalogUsecase) UpdateProduct(id string, update domain.ProductUpdate) (*domain.Product, error) {
+	product, err := u.productRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		if *update.Name == "" {
+			return nil, errors.New("name must not be empty")
+		}
+		product.Name = *update.Name
+	}
+	if update.Description != nil {
+		product.Description = *update.Description
+	}
+	if update.TaxClass != nil {
+		taxClass := strings.ToLower(strings.TrimSpace(*update.TaxClass))
+		if taxClass == "" {
+			return nil, errors.New("tax class must not be empty")
+		}
+		product.TaxClass = taxClass
+	}
+	if update.Active != nil {
+		product.Active = *update.Active
+	}
+
+	if err := u.productRepo.Update(product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (u *catalogUsecase) CreateSKU(productID, code, name string, price float64, currency string) (*domain.SKU, error) {
+	if productID == "" || code == "" {
+		return nil, errors.New("productID and code are required")
+	}
+	if price <= 0 {
+		return nil, errors.New("price must be positive")
+	}
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+
+	product, err := u.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	sku := &domain.SKU{
+		Code:            code,
+		ProductID:       product.ID,
+		Name:            name,
+		Price:           price,
+		Currency:        strings.ToUpper(currency),
+		ProductName:     product.Name,
+		ProductTaxClass: product.TaxClass,
+		ProductActive:   product.Active,
+	}
+	if err := u.skuRepo.Create(sku); err != nil {
+		return nil, err
+	}
+	return sku, nil
+}
+
+func (u *catalogUsecase) GetSKU(code string) (*domain.SKU, error) {
+	if code == "" {
+		return nil, errors.New("code is required")
+	}
+	return u.skuRepo.GetByCode(code)
+}
+
+func (u *catalogUsecase) UpdateSKU(code string, update domain.SKUUpdate) (*domain.SKU, error) {
+	sku, err := u.skuRepo.GetByCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		sku.Name = *update.Name
+	}
+	if update.Price != nil {
+		if *update.Price <= 0 {
+			return nil, errors.New("price must be positive")
+		}
+		sku.Price = *update.Price
+	}
+	if update.Active != nil {
+		sku.Active = *update.Active
+	}
+
+	if err := u.skuRepo.Update(sku); err != nil {
+		return nil, err
+	}
+	return sku, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/edwinjordan/golang_microservices/services/gateway/internal/config"
+	httpHandler "github.com/edwinjordan/golang_microservices/services/gateway/internal/delivery/http"
+	"github.com/edwinjordan/golang_microservices/services/gateway/internal/domain"
+	"github.com/edwinjordan/golang_microservices/services/gateway/internal/ratelimit"
+	"github.com/edwinjordan/golang_microservices/services/gateway/internal/upstream"
+	"github.com/edwinjordan/golang_microservices/services/gateway/internal/usecase"
+	orderpb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	paymentpb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
+	"github.com/edwinjordan/golang_microservices/services/user/pkg/auth"
+	userpb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	// Load configuration
+	cfg := config.LoadConfig()
+
+	// Connect to the services
+	userConn := dial("user", cfg.UserGRPCAddr, cfg)
+	defer userConn.Close()
+	orderConn := dial("order", cfg.OrderGRPCAddr, cfg)
+	defer orderConn.Close()
+	paymentConn := dial("payment", cfg.PaymentGRPCAddr, cfg)
+	defer paymentConn.Close()
+
+	userClient := userpb.NewUserServiceClient(userConn)
+	accountUsecase := usecase.NewAccountUsecase(
+		userClient,
+		orderpb.NewOrderServiceClient(orderConn),
+		paymentpb.NewPaymentServiceClient(paymentConn),
+	)
+	introspector := auth.NewIntrospector(userClient, cfg.AuthCacheTTL)
+	limiter := ratelimit.New(cfg.RateLimitRPS, cfg.RateLimitBurst)
+
+	// Start HTTP server
+	router := gin.Default()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+	router.Use(httpHandler.RequestID(), httpHandler.CORS(cfg.CORSAllowedOrigins))
+
+	registerRoutes(router, userClient, accountUsecase, introspector, limiter)
+
+	if err := openapi.Mount(router, httpHandler.API()); err != nil {
+		log.Fatalf("Failed to serve OpenAPI document: %v", err)
+	}
+
+	log.Printf("HTTP server listening on port %s", cfg.HTTPPort)
+	if err := router.Run(fmt.Sprintf(":%s", cfg.HTTPPort)); err != nil {
+		log.Fatalf("Failed to start HTTP server: %v", err)
+	}
+}
+
+// registerRoutes registers the hand-written HTTP routes. main adds the
+// OpenAPI document.
+func registerRoutes(router *gin.Engine, userClient userpb.UserServiceClient, accountUsecase domain.AccountUsecase, introspector *auth.Introspector, limiter *ratelimit.Limiter) {
+	authHandler := httpHandler.NewAuthHandler(userClient)
+	accountHandler := httpHandler.NewAccountHandler(accountUsecase)
+
+	router.GET("/health", accountHandler.Health)
+
+	public := router.Group("/", httpHandler.RateLimit(limiter))
+	public.POST("/users", authHandler.Register)
+	public.POST("/login", authHandler.Login)
+	public.POST("/token/refresh", authHandler.RefreshToken)
+
+	authorized := router.Group("/", httpHandler.RateLimit(limiter), httpHandler.RequireAuth(introspector), httpHandler.RateLimitUser(limiter))
+	authorized.POST("/logout", authHandler.Logout)
+	authorized.GET("/me", accountHandler.Me)
+	authorized.GET("/me/sessions", authHandler.ListSessions)
+	authorized.DELETE("/me/sessions/:session_id", authHandler.RevokeSession)
+	authorized.GET("/me/orders", accountHandler.MyOrders)
+	authorized.POST("/orders", accountHandler.CreateOrder)
+	authorized.GET("/orders/:id", accountHandler.GetOrder)
+	authorized.POST("/orders/:id/payments", accountHandler.PayOrder)
+	authorized.GET("/payments/:id", accountHandler.GetPayment)
+}
+
+func dial(service, addr string, cfg *config.Config) *grpc.ClientConn {
+	conn, err := upstream.Dial(addr, cfg.UpstreamTimeout)
+	if err != nil {
+		log.Fatalf("Failed to connect to %s service: %v", service, err)
+	}
+	return conn
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	HTTPPort string
+
+	UserGRPCAddr    string
+	OrderGRPCAddr   string
+	PaymentGRPCAddr string
+	// UpstreamTimeout bounds each gRPC call to a service.
+	UpstreamTimeout time.Duration
+
+	// AuthCacheTTL is how long a checked access token is trusted before the
+	// user service is asked again.
+	AuthCacheTTL time.Duration
+
+	// Each client IP, and each authenticated user, may make RateLimitRPS
+	// requests per second on average, in bursts of up to RateLimitBurst.
+	RateLimitRPS   float64
+	RateLimitBurst int
+
+	// TrustedProxies lists the proxies (IPs or CIDRs) whose X-Forwarded-For
+	// header is believed when finding a client's IP. With none, the IP is
+	// the connection's address.
+	TrustedProxies []string
+
+	// CORSAllowedOrigins lists the browser origins allowed to call the
+	// gateway; "*" allows any.
+	CORSAllowedOrigins []string
+}
+
+func LoadConfig() *Config {
+	return &Config{
+		HTTPPort: getEnv("GATEWAY_HTTP_PORT", "8080"),
+
+		UserGRPCAddr:    getEnv("USER_GRPC_ADDR", "localhost:9091"),
+		OrderGRPCAddr:   getEnv("ORDER_GRPC_ADDR", "localhost:9092"),
+		PaymentGRPCAddr: getEnv("PAYMENT_GRPC_ADDR", "localhost:9093"),
+		UpstreamTimeout: getEnvDuration("GATEWAY_UPSTREAM_TIMEOUT", 10*time.Second),
+
+		AuthCacheTTL: getEnvDuration("GATEWAY_AUTH_CACHE_TTL", 30*time.Second),
+
+		RateLimitRPS:   getEnvFloat("GATEWAY_RATE_LIMIT_RPS", 10),
+		RateLimitBurst: getEnvInt("GATEWAY_RATE_LIMIT_BURST", 20),
+		TrustedProxies: getEnvList("GATEWAY_TRUSTED_PROXIES", nil),
+
+		CORSAllowedOrigins: getEnvList("GATEWAY_CORS_ALLOWED_ORIGINS", []string{"*"}),
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList reads a comma-separated list.
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
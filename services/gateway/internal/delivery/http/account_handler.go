@@ -0,0 +1,167 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/services/gateway/internal/domain"
+	orderpb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	paymentpb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
+	userpb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler serves the authenticated user's profile, orders and
+// payments.
+type AccountHandler struct {
+	accountUsecase domain.AccountUsecase
+}
+
+func NewAccountHandler(accountUsecase domain.AccountUsecase) *AccountHandler {
+	return &AccountHandler{accountUsecase: accountUsecase}
+}
+
+type MyOrdersQuery struct {
+	Status    string `form:"status"`
+	Currency  string `form:"currency"`
+	OrderBy   string `form:"order_by"`
+	PageSize  int32  `form:"page_size"`
+	PageToken string `form:"page_token"`
+}
+
+type CreateOrderRequest struct {
+	SKU         string   `json:"sku"`
+	Quantity    int32    `json:"quantity"`
+	AddressID   string   `json:"address_id"`
+	CouponCodes []string `json:"coupon_codes"`
+}
+
+type PayOrderRequest struct {
+	Currency string `json:"currency"`
+}
+
+type OrderDetailsResponse struct {
+	Order    protoJSON[*orderpb.Order]       `json:"order"`
+	Payments []protoJSON[*paymentpb.Payment] `json:"payments"`
+}
+
+type MyOrdersResponse struct {
+	User          protoJSON[*userpb.GetUserResponse] `json:"user"`
+	Orders        []OrderDetailsResponse             `json:"orders"`
+	NextPageToken string                             `json:"next_page_token,omitempty"`
+}
+
+func (h *AccountHandler) Me(c *gin.Context) {
+	user, err := h.accountUsecase.Profile(c.Request.Context(), currentPrincipal(c).UserID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, asJSON(user))
+}
+
+// MyOrders returns a page of the user's orders with their payments, and the
+// user they belong to, in one response.
+func (h *AccountHandler) MyOrders(c *gin.Context) {
+	var query MyOrdersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.accountUsecase.MyOrders(c.Request.Context(), currentPrincipal(c).UserID, domain.OrderFilter{
+		Status:    query.Status,
+		Currency:  query.Currency,
+		OrderBy:   query.OrderBy,
+		PageSize:  query.PageSize,
+		PageToken: query.PageToken,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	orders := make([]OrderDetailsResponse, 0, len(page.Orders))
+	for _, o := range page.Orders {
+		orders = append(orders, toOrderDetailsResponse(o))
+	}
+	c.JSON(http.StatusOK, MyOrdersResponse{
+		User:          asJSON(page.User),
+		Orders:        orders,
+		NextPageToken: page.NextPageToken,
+	})
+}
+
+func (h *AccountHandler) CreateOrder(c *gin.Context) {
+	var req CreateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.accountUsecase.CreateOrder(c.Request.Context(), currentPrincipal(c).UserID, domain.CreateOrderInput{
+		SKU:         req.SKU,
+		Quantity:    req.Quantity,
+		AddressID:   req.AddressID,
+		CouponCodes: req.CouponCodes,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, asJSON(order))
+}
+
+func (h *AccountHandler) GetOrder(c *gin.Context) {
+	order, err := h.accountUsecase.GetOrder(c.Request.Context(), currentPrincipal(c).UserID, c.Param("id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toOrderDetailsResponse(order))
+}
+
+func (h *AccountHandler) PayOrder(c *gin.Context) {
+	var req PayOrderRequest
+	// The body is optional: without one the order's currency is used.
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	payment, err := h.accountUsecase.PayOrder(c.Request.Context(), currentPrincipal(c).UserID, c.Param("id"), req.Currency)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, asJSON(payment))
+}
+
+func (h *AccountHandler) GetPayment(c *gin.Context) {
+	payment, err := h.accountUsecase.GetPayment(c.Request.Context(), currentPrincipal(c).UserID, c.Param("id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, asJSON(payment))
+}
+
+func (h *AccountHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"service": "gateway",
+	})
+}
+
+func toOrderDetailsResponse(o *domain.OrderDetails) OrderDetailsResponse {
+	return OrderDetailsResponse{
+		Order:    asJSON(o.Order),
+		Payments: protoList(o.Payments),
+	}
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+
+	userpb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthHandler proxies registration, login and session management to the
+// user service.
+type AuthHandler struct {
+	userClient userpb.UserServiceClient
+}
+
+func NewAuthHandler(userClient userpb.UserServiceClient) *AuthHandler {
+	return &AuthHandler{userClient: userClient}
+}
+
+// RegisterRequest is checked against userpb.RegisterRequest: the user
+// service can create users without a password, but registering through the
+// gateway needs one.
+type RegisterRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	// Code is a TOTP or recovery code, required once two-factor is enabled.
+	Code string `json:"code"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ListSessionsResponse struct {
+	Sessions []protoJSON[*userpb.Session] `json:"sessions"`
+}
+
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := validate.BindJSON(c, &req, &userpb.RegisterRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	user, err := h.userClient.CreateUser(c.Request.Context(), &userpb.CreateUserRequest{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, asJSON(user))
+}
+
+// Login passes the client's user agent and IP on, so the session list shows
+// the client rather than the gateway.
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.userClient.Login(c.Request.Context(), &userpb.LoginRequest{
+		Email:     req.Email,
+		Password:  req.Password,
+		Code:      req.Code,
+		UserAgent: c.Request.UserAgent(),
+		IpAddress: c.ClientIP(),
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, asJSON(resp))
+}
+
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.userClient.RefreshToken(c.Request.Context(), &userpb.RefreshTokenRequest{RefreshToken: req.RefreshToken})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, asJSON(resp.Tokens))
+}
+
+// Logout revokes the session of the token the request was made with.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	principal := currentPrincipal(c)
+	_, err := h.userClient.RevokeSession(c.Request.Context(), &userpb.RevokeSessionRequest{
+		UserId:    principal.UserID,
+		SessionId: principal.SessionID,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	resp, err := h.userClient.ListSessions(c.Request.Context(), &userpb.ListSessionsRequest{UserId: currentPrincipal(c).UserID})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ListSessionsResponse{Sessions: protoList(resp.Sessions)})
+}
+
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	_, err := h.userClient.RevokeSession(c.Request.Context(), &userpb.RevokeSessionRequest{
+		UserId:    currentPrincipal(c).UserID,
+		SessionId: c.Param("session_id"),
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/edwinjordan/golang_microservices/services/gateway/internal/ratelimit"
+	"github.com/edwinjordan/golang_microservices/services/gateway/internal/upstream"
+	"github.com/edwinjordan/golang_microservices/services/user/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const principalKey = "principal"
+
+// maxRequestIDLength bounds client-supplied request IDs.
+const maxRequestIDLength = 128
+
+// RequestID gives every request an ID: the client's X-Request-ID if it sent
+// a usable one, otherwise a new UUID. The ID is echoed in the response and
+// forwarded to the services.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(upstream.RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength || strings.ContainsFunc(id, isControl) {
+			id = uuid.New().String()
+		}
+
+		c.Header(upstream.RequestIDHeader, id)
+		c.Request = c.Request.WithContext(upstream.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// CORS allows browsers on allowedOrigins to call the gateway and answers
+// preflight requests. "*" allows every origin.
+func CORS(allowedOrigins []string) gin.HandlerFunc {
+	allowAny := slices.Contains(allowedOrigins, "*")
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Origin")
+		if !allowAny && !slices.Contains(allowedOrigins, origin) {
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.Header("Access-Control-Expose-Headers", upstream.RequestIDHeader+", Retry-After")
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type, "+upstream.RequestIDHeader)
+			c.Header("Access-Control-Max-Age", "600")
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimit rejects client IPs that exceed the limiter's rate with 429. It
+// runs before RequireAuth, so requests with bad tokens are limited before
+// they reach the user service. The client IP is the connection's address
+// unless the router trusts the proxy it came through.
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit(c, limiter, "ip:"+c.ClientIP())
+	}
+}
+
+// RateLimitUser rejects users that exceed the limiter's rate with 429. It
+// must run after RequireAuth.
+func RateLimitUser(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit(c, limiter, "user:"+currentPrincipal(c).UserID)
+	}
+}
+
+func limit(c *gin.Context, limiter *ratelimit.Limiter, key string) {
+	if ok, retryAfter := limiter.Allow(key); !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		return
+	}
+	c.Next()
+}
+
+// RequireAuth rejects requests without a valid, unrevoked bearer token and
+// stores the resolved principal on the context.
+func RequireAuth(introspector *auth.Introspector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		principal, err := introspector.Check(c.Request.Context(), token)
+		if errors.Is(err, auth.ErrUnauthenticated) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to check access token (request %s): %v", upstream.RequestID(c.Request.Context()), err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "authentication unavailable"})
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Request = c.Request.WithContext(upstream.WithAccessToken(c.Request.Context(), token))
+		c.Next()
+	}
+}
+
+// currentPrincipal returns the authenticated principal, or nil on public
+// routes.
+func currentPrincipal(c *gin.Context) *auth.Principal {
+	principal, _ := c.Get(principalKey)
+	p, _ := principal.(*auth.Principal)
+	return p
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	orderpb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	paymentpb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
+	userpb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+)
+
+// API documents the gateway's public routes. The gateway will not start if a
+// route is missing from it.
+func API() openapi.API {
+	return openapi.API{
+		Title:       "API Gateway",
+		Version:     "1.0",
+		Description: "The public API. Routes marked with a lock need an access token from POST /login; all but /health are rate limited.",
+		Operations: []openapi.Operation{
+			{Method: http.MethodGet, Path: "/health", Tag: "health", Summary: "Health check", Response: openapi.HealthResponse{}},
+
+			{Method: http.MethodPost, Path: "/users", Tag: "auth", Summary: "Register", Body: RegisterRequest{}, Rules: &userpb.RegisterRequest{}, Status: http.StatusCreated, Response: (*userpb.CreateUserResponse)(nil)},
+			{Method: http.MethodPost, Path: "/login", Tag: "auth", Summary: "Log in and start a session", Body: LoginRequest{}, Rules: &userpb.LoginRequest{}, Response: (*userpb.LoginResponse)(nil)},
+			{Method: http.MethodPost, Path: "/token/refresh", Tag: "auth", Summary: "Exchange a refresh token for a new token pair", Body: RefreshRequest{}, Rules: &userpb.RefreshTokenRequest{}, Response: (*userpb.TokenPair)(nil)},
+			{Method: http.MethodPost, Path: "/logout", Tag: "auth", Summary: "Revoke the current session", Status: http.StatusNoContent, Auth: true},
+			{Method: http.MethodGet, Path: "/me/sessions", Tag: "auth", Summary: "List your sessions", Response: ListSessionsResponse{}, Auth: true},
+			{Method: http.MethodDelete, Path: "/me/sessions/:session_id", Tag: "auth", Summary: "Revoke one of your sessions", Status: http.StatusNoContent, Auth: true},
+
+			{Method: http.MethodGet, Path: "/me", Tag: "account", Summary: "Get your profile", Response: (*userpb.GetUserResponse)(nil), Auth: true},
+			{Method: http.MethodGet, Path: "/me/orders", Tag: "account", Summary: "List your orders with their payments", Query: MyOrdersQuery{}, Response: MyOrdersResponse{}, Auth: true},
+			{Method: http.MethodPost, Path: "/orders", Tag: "account", Summary: "Place an order", Body: CreateOrderRequest{}, Rules: &orderpb.CreateOrderRequest{}, Status: http.StatusCreated, Response: (*orderpb.CreateOrderResponse)(nil), Auth: true},
+			{Method: http.MethodGet, Path: "/orders/:id", Tag: "account", Summary: "Get one of your orders with its payments", Response: OrderDetailsResponse{}, Auth: true},
+			{Method: http.MethodPost, Path: "/orders/:id/payments", Tag: "account", Summary: "Pay one of your orders", Body: PayOrderRequest{}, BodyOptional: true, Status: http.StatusCreated, Response: (*paymentpb.ProcessPaymentResponse)(nil), Auth: true},
+			{Method: http.MethodGet, Path: "/payments/:id", Tag: "account", Summary: "Get one of your payments", Response: (*paymentpb.Payment)(nil), Auth: true},
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/gateway/internal/domain"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var jsonOptions = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+// protoJSON renders a service message as JSON with the proto field names,
+// which are the snake_case names the services' HTTP APIs use.
+type protoJSON[M proto.Message] struct {
+	Message M
+}
+
+func asJSON[M proto.Message](m M) protoJSON[M] {
+	return protoJSON[M]{Message: m}
+}
+
+func (m protoJSON[M]) MarshalJSON() ([]byte, error) {
+	return jsonOptions.Marshal(m.Message)
+}
+
+// SchemaType tells the OpenAPI document to describe the message itself.
+func (protoJSON[M]) SchemaType() reflect.Type {
+	return reflect.TypeFor[M]()
+}
+
+func protoList[M proto.Message](messages []M) []protoJSON[M] {
+	list := make([]protoJSON[M], 0, len(messages))
+	for _, m := range messages {
+		list = append(list, asJSON(m))
+	}
+	return list
+}
+
+// abortWithError responds with the HTTP equivalent of a service error,
+// passing on the field violations of a request the service rejected.
+func abortWithError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	s := status.Convert(err)
+	body := gin.H{"error": s.Message()}
+	if violations := validate.FromStatus(s); len(violations) > 0 {
+		body["violations"] = violations
+	}
+	c.AbortWithStatusJSON(httpStatus(s.Code()), body)
+}
+
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.FailedPrecondition, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded, codes.Canceled:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
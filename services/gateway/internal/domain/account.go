@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	orderpb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	paymentpb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
+	userpb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+)
+
+// ErrNotFound is returned for resources that do not exist or belong to
+// another user; the two are not told apart.
+var ErrNotFound = errors.New("not found")
+
+// OrderDetails is an order with its payments.
+type OrderDetails struct {
+	Order    *orderpb.Order
+	Payments []*paymentpb.Payment
+}
+
+// MyOrders is one page of a user's orders, joined with the user and each
+// order's payments.
+type MyOrders struct {
+	User          *userpb.GetUserResponse
+	Orders        []*OrderDetails
+	NextPageToken string
+}
+
+// OrderFilter narrows and pages MyOrders.
+type OrderFilter struct {
+	Status    string
+	Currency  string
+	OrderBy   string
+	PageSize  int32
+	PageToken string
+}
+
+// CreateOrderInput is an order as the user places it; the user is always
+// the caller.
+type CreateOrderInput struct {
+	SKU         string
+	Quantity    int32
+	AddressID   string
+	CouponCodes []string
+}
+
+// AccountUsecase serves the authenticated user's own data. Every method
+// takes the caller's user ID and only touches that user's resources.
+type AccountUsecase interface {
+	Profile(ctx context.Context, userID string) (*userpb.GetUserResponse, error)
+	MyOrders(ctx context.Context, userID string, filter OrderFilter) (*MyOrders, error)
+	GetOrder(ctx context.Context, userID, orderID string) (*OrderDetails, error)
+	CreateOrder(ctx context.Context, userID string, input CreateOrderInput) (*orderpb.CreateOrderResponse, error)
+	// PayOrder charges the order's amount; currency defaults to the
+	// order's.
+	PayOrder(ctx context.Context, userID, orderID, currency string) (*paymentpb.ProcessPaymentResponse, error)
+	GetPayment(ctx context.Context, userID, paymentID string) (*paymentpb.Payment, error)
+}
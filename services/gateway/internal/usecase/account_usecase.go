@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+
+	"github.com/edwinjordan/golang_microservices/services/gateway/internal/domain"
+	orderpb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	paymentpb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
+	userpb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxPaymentsPerOrder caps the payments listed with each order.
+const maxPaymentsPerOrder = 100
+
+type accountUsecase struct {
+	userClient    userpb.UserServiceClient
+	orderClient   orderpb.OrderServiceClient
+	paymentClient paymentpb.PaymentServiceClient
+}
+
+func NewAccountUsecase(userClient userpb.UserServiceClient, orderClient orderpb.OrderServiceClient, paymentClient paymentpb.PaymentServiceClient) domain.AccountUsecase {
+	return &accountUsecase{
+		userClient:    userClient,
+		orderClient:   orderClient,
+		paymentClient: paymentClient,
+	}
+}
+
+func (u *accountUsecase) Profile(ctx context.Context, userID string) (*userpb.GetUserResponse, error) {
+	return u.userClient.GetUser(ctx, &userpb.GetUserRequest{Id: userID})
+}
+
+// MyOrders fetches the user and a page of their orders, then each order's
+// payments in parallel.
+func (u *accountUsecase) MyOrders(ctx context.Context, userID string, filter domain.OrderFilter) (*domain.MyOrders, error) {
+	var user *userpb.GetUserResponse
+	var userErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		user, userErr = u.Profile(ctx, userID)
+	}()
+
+	page, err := u.orderClient.ListOrders(ctx, &orderpb.ListOrdersRequest{
+		UserId:    userID,
+		Status:    filter.Status,
+		Currency:  filter.Currency,
+		OrderBy:   filter.OrderBy,
+		PageSize:  filter.PageSize,
+		PageToken: filter.PageToken,
+	})
+	if err != nil {
+		wg.Wait()
+		return nil, err
+	}
+
+	orders := make([]*domain.OrderDetails, len(page.Orders))
+	errs := make([]error, len(page.Orders))
+	for i, order := range page.Orders {
+		orders[i] = &domain.OrderDetails{Order: order}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			orders[i].Payments, errs[i] = u.payments(ctx, order.Id)
+		}()
+	}
+	wg.Wait()
+
+	if userErr != nil {
+		return nil, userErr
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &domain.MyOrders{User: user, Orders: orders, NextPageToken: page.NextPageToken}, nil
+}
+
+func (u *accountUsecase) GetOrder(ctx context.Context, userID, orderID string) (*domain.OrderDetails, error) {
+	order, err := u.ownOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := u.payments(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.OrderDetails{Order: order, Payments: payments}, nil
+}
+
+func (u *accountUsecase) CreateOrder(ctx context.Context, userID string, input domain.CreateOrderInput) (*orderpb.CreateOrderResponse, error) {
+	return u.orderClient.CreateOrder(ctx, &orderpb.CreateOrderRequest{
+		UserId:      userID,
+		Sku:         input.SKU,
+		Quantity:    input.Quantity,
+		AddressId:   input.AddressID,
+		CouponCodes: input.CouponCodes,
+	})
+}
+
+// PayOrder charges the order's own amount, so a client cannot underpay.
+func (u *accountUsecase) PayOrder(ctx context.Context, userID, orderID, currency string) (*paymentpb.ProcessPaymentResponse, error) {
+	order, err := u.ownOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.paymentClient.ProcessPayment(ctx, &paymentpb.ProcessPaymentRequest{
+		OrderId:  order.Id,
+		Amount:   order.Amount,
+		Currency: currency,
+	})
+}
+
+// GetPayment finds the payment among its order's, which also proves the
+// order is the user's.
+func (u *accountUsecase) GetPayment(ctx context.Context, userID, paymentID string) (*paymentpb.Payment, error) {
+	payment, err := u.paymentClient.GetPayment(ctx, &paymentpb.GetPaymentRequest{Id: paymentID})
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	order, err := u.GetOrder(ctx, userID, payment.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range order.Payments {
+		if p.Id == paymentID {
+			return p, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+// ownOrder returns the order if it belongs to the user. Other users' orders
+// are reported as not found.
+func (u *accountUsecase) ownOrder(ctx context.Context, userID, orderID string) (*orderpb.Order, error) {
+	resp, err := u.orderClient.GetOrder(ctx, &orderpb.GetOrderRequest{Id: orderID})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if resp.UserId != userID {
+		return nil, domain.ErrNotFound
+	}
+
+	// GetOrder has no timestamps; ListOrders's Order is the fuller shape.
+	return &orderpb.Order{
+		Id:               resp.Id,
+		UserId:           resp.UserId,
+		Product:          resp.Product,
+		Amount:           resp.Amount,
+		Status:           resp.Status,
+		Sku:              resp.Sku,
+		Quantity:         resp.Quantity,
+		UnitPrice:        resp.UnitPrice,
+		ShippingAddress:  resp.ShippingAddress,
+		Subtotal:         resp.Subtotal,
+		DiscountAmount:   resp.DiscountAmount,
+		Discounts:        resp.Discounts,
+		TaxAmount:        resp.TaxAmount,
+		PricesIncludeTax: resp.PricesIncludeTax,
+		TaxLines:         resp.TaxLines,
+		Currency:         resp.Currency,
+	}, nil
+}
+
+func (u *accountUsecase) payments(ctx context.Context, orderID string) ([]*paymentpb.Payment, error) {
+	resp, err := u.paymentClient.ListPayments(ctx, &paymentpb.ListPaymentsRequest{
+		OrderId:  orderID,
+		PageSize: maxPaymentsPerOrder,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Payments, nil
+}
+
+// notFound turns a service's NOT_FOUND into ErrNotFound, so missing and
+// foreign resources look the same to the client.
+func notFound(err error) error {
+	if status.Code(err) == codes.NotFound {
+		return domain.ErrNotFound
+	}
+	return err
+}
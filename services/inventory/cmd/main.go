@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus"
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/edwinjordan/golang_microservices/pkg/restproxy"
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/config"
+	eventsHandler "github.com/edwinjordan/golang_microservices/services/inventory/internal/delivery/events"
+	grpcHandler "github.com/edwinjordan/golang_microservices/services/inventory/internal/delivery/grpc"
+	httpHandler "github.com/edwinjordan/golang_microservices/services/inventory/internal/delivery/http"
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/domain"
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/repository"
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/usecase"
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/worker"
+	pb "github.com/edwinjordan/golang_microservices/services/inventory/pkg/pb"
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	// Load configuration
+	cfg := config.LoadConfig()
+
+	// Connect to database with retry
+	var db *sql.DB
+	var err error
+	for i := 0; i < 30; i++ {
+		db, err = sql.Open("postgres", cfg.GetDSN())
+		if err == nil {
+			err = db.Ping()
+			if err == nil {
+				break
+			}
+		}
+		log.Printf("Failed to connect to database, retrying in 2 seconds... (%d/30)", i+1)
+		time.Sleep(2 * time.Second)
+	}
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	log.Println("Connected to database successfully")
+
+	// Initialize database schema
+	initSchema(db)
+
+	// Initialize layers
+	stockRepo := repository.NewPostgresStockRepository(db)
+	reservationRepo := repository.NewPostgresReservationRepository(db)
+	inventoryUsecase := usecase.NewInventoryUsecase(stockRepo, reservationRepo, cfg.ReservationTTL)
+
+	// Start reservation expiry
+	expirer := worker.NewReservationExpirer(inventoryUsecase, cfg.ExpiryPollInterval, cfg.ExpiryBatchSize)
+	go expirer.Run(context.Background())
+
+	// Connect to event bus
+	bus, err := eventbus.Open(context.Background(), eventbus.Config{
+		Driver: cfg.EventBusDriver,
+		URL:    cfg.EventBusURL,
+		Stream: cfg.EventBusStream,
+		Name:   eventsHandler.Group,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to event bus: %v", err)
+	}
+	defer bus.Close()
+
+	// Subscribe to order events
+	subscriber := eventsHandler.NewSubscriber(inventoryUsecase)
+	if err := subscriber.Register(context.Background(), bus, db); err != nil {
+		log.Fatalf("Failed to subscribe to events: %v", err)
+	}
+
+	// Start gRPC server
+	go func() {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.GRPCPort))
+		if err != nil {
+			log.Fatalf("Failed to listen on gRPC port: %v", err)
+		}
+
+		grpcServer := grpc.NewServer(grpc.UnaryInterceptor(validate.UnaryServerInterceptor()))
+		inventoryGRPCHandler := grpcHandler.NewInventoryGRPCHandler(inventoryUsecase)
+		pb.RegisterInventoryServiceServer(grpcServer, inventoryGRPCHandler)
+
+		log.Printf("gRPC server listening on port %s", cfg.GRPCPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Failed to serve gRPC: %v", err)
+		}
+	}()
+
+	// Start HTTP server
+	router := gin.Default()
+	registerRoutes(router, inventoryUsecase)
+
+	// REST routes generated from the HTTP rules in proto/inventory.proto, proxied
+	// to the gRPC server
+	restProxy, err := restproxy.New(context.Background(), "localhost:"+cfg.GRPCPort, pb.RegisterInventoryServiceHandlerFromEndpoint)
+	if err != nil {
+		log.Fatalf("Failed to start REST proxy: %v", err)
+	}
+	router.Any(restproxy.Prefix+"/*path", gin.WrapH(restProxy))
+
+	if err := openapi.Mount(router, httpHandler.API(), restproxy.Prefix); err != nil {
+		log.Fatalf("Failed to serve OpenAPI document: %v", err)
+	}
+
+	log.Printf("HTTP server listening on port %s", cfg.HTTPPort)
+	if err := router.Run(fmt.Sprintf(":%s", cfg.HTTPPort)); err != nil {
+		log.Fatalf("Failed to start HTTP server: %v", err)
+	}
+}
+
+// registerRoutes registers the hand-written HTTP routes. main adds the
+// generated REST routes and the OpenAPI document.
+func registerRoutes(router *gin.Engine, inventoryUsecase domain.InventoryUsecase) {
+	inventoryHandler := httpHandler.NewInventoryHandler(inventoryUsecase)
+
+	router.GET("/health", inventoryHandler.Health)
+	router.GET("/stock/:sku", inventoryHandler.GetStock)
+	router.PUT("/stock/:sku/warehouses/:warehouse", inventoryHandler.SetStock)
+	router.POST("/reservations", inventoryHandler.Reserve)
+	router.GET("/reservations/:order_id", inventoryHandler.GetReservation)
+	router.POST("/reservations/:order_id/commit", inventoryHandler.CommitReservation)
+	router.POST("/reservations/:order_id/release", inventoryHandler.ReleaseReservation)
+}
+
+func initSchema(db *sql.DB) {
+	_, err := db.Exec(repository.Schema + eventbus.InboxSchema)
+	if err != nil {
+		log.Fatalf("Failed to initialize schema: %v", err)
+	}
+	log.Println("Database schema initialized")
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	DBHost     string
+	DBPort     string
+	DBUser     string
+	DBPassword string
+	DBName     string
+	HTTPPort   string
+	GRPCPort   string
+
+	ReservationTTL     time.Duration
+	ExpiryPollInterval time.Duration
+	ExpiryBatchSize    int
+
+	EventBusDriver string
+	EventBusURL    string
+	EventBusStream string
+}
+
+func LoadConfig() *Config {
+	return &Config{
+		DBHost:     getEnv("INVENTORY_DB_HOST", "localhost"),
+		DBPort:     getEnv("INVENTORY_DB_PORT", "5432"),
+		DBUser:     getEnv("INVENTORY_DB_USER", "inventoryservice"),
+		DBPassword: getEnv("INVENTORY_DB_PASSWORD", "inventorypass123"),
+		DBName:     getEnv("INVENTORY_DB_NAME", "inventory_db"),
+		HTTPPort:   getEnv("INVENTORY_SERVICE_HTTP_PORT", "8085"),
+		GRPCPort:   getEnv("INVENTORY_SERVICE_GRPC_PORT", "9095"),
+
+		ReservationTTL:     getEnvDuration("INVENTORY_RESERVATION_TTL", 45*time.Minute),
+		ExpiryPollInterval: getEnvDuration("INVENTORY_EXPIRY_POLL_INTERVAL", time.Minute),
+		ExpiryBatchSize:    getEnvInt("INVENTORY_EXPIRY_BATCH_SIZE", 100),
+
+		EventBusDriver: getEnv("EVENT_BUS_DRIVER", "memory"),
+		EventBusURL:    getEnv("EVENT_BUS_URL", "nats://localhost:4222"),
+		EventBusStream: getEnv("EVENT_BUS_STREAM", "EVENTS"),
+	}
+}
+
+func (c *Config) GetDSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
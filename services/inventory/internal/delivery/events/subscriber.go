@@ -0,0 +1,73 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus"
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/domain"
+)
+
+// Group is the inventory service's consumer group.
+const Group = "inventory-service"
+
+// Order statuses, mirroring the order service, that settle a reservation.
+const (
+	orderStatusPaid      = "paid"
+	orderStatusCancelled = "cancelled"
+	orderStatusExpired   = "expired"
+)
+
+type Subscriber struct {
+	inventoryUsecase domain.InventoryUsecase
+}
+
+func NewSubscriber(inventoryUsecase domain.InventoryUsecase) *Subscriber {
+	return &Subscriber{inventoryUsecase: inventoryUsecase}
+}
+
+// Register subscribes the inventory service to the order events it reacts to.
+func (s *Subscriber) Register(ctx context.Context, bus eventbus.Bus, db *sql.DB) error {
+	return bus.Subscribe(ctx, Group, []string{eventbus.OrderUpdated}, eventbus.Dedupe(db, Group, s.Handle))
+}
+
+// Handle commits an order's reservation when the order is paid and releases
+// it when the order is cancelled or expires. Orders without a reservation
+// are ignored.
+func (s *Subscriber) Handle(ctx context.Context, env *eventspb.Envelope) error {
+	msg, err := eventbus.Decode(env)
+	if err != nil {
+		return err
+	}
+
+	event, ok := msg.(*eventspb.OrderUpdated)
+	if !ok {
+		return nil
+	}
+	orderID := event.GetOrder().GetId()
+
+	switch status := event.GetOrder().GetStatus(); status {
+	case orderStatusPaid:
+		_, err = s.inventoryUsecase.Commit(orderID)
+		if errors.Is(err, domain.ErrReservationClosed) {
+			// Retrying cannot bring the stock back; someone has to look.
+			log.Printf("Order %s was paid after its stock reservation lapsed", orderID)
+			return nil
+		}
+	case orderStatusCancelled, orderStatusExpired:
+		_, err = s.inventoryUsecase.Release(orderID, "order "+status)
+		if errors.Is(err, domain.ErrReservationCommitted) {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if errors.Is(err, domain.ErrReservationNotFound) {
+		return nil
+	}
+	return err
+}
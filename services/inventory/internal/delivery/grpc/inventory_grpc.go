@@ -0,0 +1,133 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/inventory/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type InventoryGRPCHandler struct {
+	pb.UnimplementedInventoryServiceServer
+	inventoryUsecase domain.InventoryUsecase
+}
+
+func NewInventoryGRPCHandler(inventoryUsecase domain.InventoryUsecase) *InventoryGRPCHandler {
+	return &InventoryGRPCHandler{inventoryUsecase: inventoryUsecase}
+}
+
+func (h *InventoryGRPCHandler) GetStock(ctx context.Context, req *pb.GetStockRequest) (*pb.Stock, error) {
+	stock, err := h.inventoryUsecase.GetStock(req.Sku)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.Stock{
+		Sku:       stock.SKU,
+		OnHand:    stock.OnHand,
+		Reserved:  stock.Reserved,
+		Available: stock.Available(),
+		Levels:    make([]*pb.StockLevel, 0, len(stock.Levels)),
+	}
+	for _, level := range stock.Levels {
+		resp.Levels = append(resp.Levels, toPBStockLevel(level))
+	}
+	return resp, nil
+}
+
+func (h *InventoryGRPCHandler) SetStock(ctx context.Context, req *pb.SetStockRequest) (*pb.StockLevel, error) {
+	level, err := h.inventoryUsecase.SetStock(req.Sku, req.Warehouse, req.GetOnHand())
+	if err != nil {
+		return nil, inventoryError(err)
+	}
+	return toPBStockLevel(level), nil
+}
+
+func (h *InventoryGRPCHandler) ReserveStock(ctx context.Context, req *pb.ReserveStockRequest) (*pb.Reservation, error) {
+	items := make([]*domain.ReservationItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, &domain.ReservationItem{SKU: item.Sku, Quantity: item.Quantity})
+	}
+
+	reservation, err := h.inventoryUsecase.Reserve(req.OrderId, items, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, inventoryError(err)
+	}
+	return toPBReservation(reservation), nil
+}
+
+func (h *InventoryGRPCHandler) CommitReservation(ctx context.Context, req *pb.CommitReservationRequest) (*pb.Reservation, error) {
+	reservation, err := h.inventoryUsecase.Commit(req.OrderId)
+	if err != nil {
+		return nil, inventoryError(err)
+	}
+	return toPBReservation(reservation), nil
+}
+
+func (h *InventoryGRPCHandler) ReleaseReservation(ctx context.Context, req *pb.ReleaseReservationRequest) (*pb.Reservation, error) {
+	reservation, err := h.inventoryUsecase.Release(req.OrderId, req.Reason)
+	if err != nil {
+		return nil, inventoryError(err)
+	}
+	return toPBReservation(reservation), nil
+}
+
+func (h *InventoryGRPCHandler) GetReservation(ctx context.Context, req *pb.GetReservationRequest) (*pb.Reservation, error) {
+	reservation, err := h.inventoryUsecase.GetReservation(req.OrderId)
+	if err != nil {
+		return nil, inventoryError(err)
+	}
+	return toPBReservation(reservation), nil
+}
+
+func toPBStockLevel(level *domain.StockLevel) *pb.StockLevel {
+	return &pb.StockLevel{
+		Sku:       level.SKU,
+		Warehouse: level.Warehouse,
+		OnHand:    level.OnHand,
+		Reserved:  level.Reserved,
+		Available: level.Available(),
+		UpdatedAt: timestamppb.New(level.UpdatedAt),
+	}
+}
+
+func toPBReservation(reservation *domain.Reservation) *pb.Reservation {
+	resp := &pb.Reservation{
+		Id:            reservation.ID,
+		OrderId:       reservation.OrderID,
+		Status:        reservation.Status,
+		Items:         make([]*pb.ReservationItem, 0, len(reservation.Items)),
+		ReleaseReason: reservation.ReleaseReason,
+		ExpiresAt:     timestamppb.New(reservation.ExpiresAt),
+		CreatedAt:     timestamppb.New(reservation.CreatedAt),
+		UpdatedAt:     timestamppb.New(reservation.UpdatedAt),
+	}
+	for _, item := range reservation.Items {
+		resp.Items = append(resp.Items, &pb.ReservationItem{
+			Sku:       item.SKU,
+			Quantity:  item.Quantity,
+			Warehouse: item.Warehouse,
+		})
+	}
+	return resp
+}
+
+// inventoryError maps domain failures to status codes callers can act on:
+// FailedPrecondition means retrying the same request will not help.
+func inventoryError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrReservationNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrInsufficientStock),
+		errors.Is(err, domain.ErrStockBelowReserved),
+		errors.Is(err, domain.ErrReservationClosed),
+		errors.Is(err, domain.ErrReservationCommitted):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
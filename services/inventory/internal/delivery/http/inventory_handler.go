@@ -0,0 +1,166 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/inventory/pkg/pb"
+	"github.com/gin-gonic/gin"
+)
+
+type InventoryHandler struct {
+	inventoryUsecase domain.InventoryUsecase
+}
+
+func NewInventoryHandler(inventoryUsecase domain.InventoryUsecase) *InventoryHandler {
+	return &InventoryHandler{inventoryUsecase: inventoryUsecase}
+}
+
+type SetStockRequest struct {
+	OnHand *int64 `json:"on_hand"`
+}
+
+type ReserveRequest struct {
+	OrderID    string                    `json:"order_id"`
+	Items      []*domain.ReservationItem `json:"items"`
+	TTLSeconds int64                     `json:"ttl_seconds"`
+}
+
+type ReleaseRequest struct {
+	Reason string `json:"reason"`
+}
+
+type StockLevelResponse struct {
+	*domain.StockLevel
+	Available int64 `json:"available"`
+}
+
+type StockResponse struct {
+	SKU       string               `json:"sku"`
+	OnHand    int64                `json:"on_hand"`
+	Reserved  int64                `json:"reserved"`
+	Available int64                `json:"available"`
+	Levels    []StockLevelResponse `json:"levels"`
+}
+
+func (h *InventoryHandler) GetStock(c *gin.Context) {
+	stock, err := h.inventoryUsecase.GetStock(c.Param("sku"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := StockResponse{
+		SKU:       stock.SKU,
+		OnHand:    stock.OnHand,
+		Reserved:  stock.Reserved,
+		Available: stock.Available(),
+		Levels:    make([]StockLevelResponse, 0, len(stock.Levels)),
+	}
+	for _, level := range stock.Levels {
+		resp.Levels = append(resp.Levels, StockLevelResponse{StockLevel: level, Available: level.Available()})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetStock is PUT /stock/:sku/warehouses/:warehouse: set the units on hand,
+// as after a stock count or a delivery.
+func (h *InventoryHandler) SetStock(c *gin.Context) {
+	var req SetStockRequest
+	if err := validate.BindJSON(c, &req, &pb.SetStockRequest{Sku: c.Param("sku"), Warehouse: c.Param("warehouse")}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	level, err := h.inventoryUsecase.SetStock(c.Param("sku"), c.Param("warehouse"), *req.OnHand)
+	if err != nil {
+		c.JSON(inventoryStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, StockLevelResponse{StockLevel: level, Available: level.Available()})
+}
+
+func (h *InventoryHandler) Reserve(c *gin.Context) {
+	var req ReserveRequest
+	if err := validate.BindJSON(c, &req, &pb.ReserveStockRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	reservation, err := h.inventoryUsecase.Reserve(req.OrderID, req.Items, time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		c.JSON(inventoryStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+func (h *InventoryHandler) GetReservation(c *gin.Context) {
+	reservation, err := h.inventoryUsecase.GetReservation(c.Param("order_id"))
+	if err != nil {
+		c.JSON(inventoryStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+func (h *InventoryHandler) CommitReservation(c *gin.Context) {
+	reservation, err := h.inventoryUsecase.Commit(c.Param("order_id"))
+	if err != nil {
+		c.JSON(inventoryStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+func (h *InventoryHandler) ReleaseReservation(c *gin.Context) {
+	var req ReleaseRequest
+	msg := &pb.ReleaseReservationRequest{OrderId: c.Param("order_id")}
+	var err error
+	if c.Request.ContentLength > 0 {
+		err = validate.BindJSON(c, &req, msg)
+	} else {
+		err = validate.Validate(msg)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	reservation, err := h.inventoryUsecase.Release(c.Param("order_id"), req.Reason)
+	if err != nil {
+		c.JSON(inventoryStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+func (h *InventoryHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"service": "inventory-service",
+	})
+}
+
+func inventoryStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrReservationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInsufficientStock),
+		errors.Is(err, domain.ErrStockBelowReserved),
+		errors.Is(err, domain.ErrReservationClosed),
+		errors.Is(err, domain.ErrReservationCommitted):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/inventory/pkg/pb"
+)
+
+// API documents the inventory service's HTTP routes. The service will not
+// start if a route is missing from it.
+func API() openapi.API {
+	return openapi.API{
+		Title:       "Inventory Service",
+		Version:     "1.0",
+		Description: "Stock levels per warehouse and order reservations.",
+		Operations: []openapi.Operation{
+			{Method: http.MethodGet, Path: "/health", Tag: "health", Summary: "Health check", Response: openapi.HealthResponse{}},
+
+			{Method: http.MethodGet, Path: "/stock/:sku", Tag: "stock", Summary: "Get a SKU's stock across warehouses", Response: StockResponse{}},
+			{Method: http.MethodPut, Path: "/stock/:sku/warehouses/:warehouse", Tag: "stock", Summary: "Set a SKU's on-hand stock in a warehouse", Body: SetStockRequest{}, Rules: &pb.SetStockRequest{}, Response: StockLevelResponse{}},
+
+			{Method: http.MethodPost, Path: "/reservations", Tag: "reservations", Summary: "Reserve stock for an order", Body: ReserveRequest{}, Rules: &pb.ReserveStockRequest{}, Status: http.StatusCreated, Response: domain.Reservation{}},
+			{Method: http.MethodGet, Path: "/reservations/:order_id", Tag: "reservations", Summary: "Get an order's reservation", Response: domain.Reservation{}},
+			{Method: http.MethodPost, Path: "/reservations/:order_id/commit", Tag: "reservations", Summary: "Commit a reservation, deducting its stock", Response: domain.Reservation{}},
+			{Method: http.MethodPost, Path: "/reservations/:order_id/release", Tag: "reservations", Summary: "Release a reservation's stock", Body: ReleaseRequest{}, BodyOptional: true, Rules: &pb.ReleaseReservationRequest{}, Response: domain.Reservation{}},
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Reservation statuses. An active reservation holds stock until it is
+// committed (sold), released, or expires.
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusCommitted = "committed"
+	ReservationStatusReleased  = "released"
+	ReservationStatusExpired   = "expired"
+)
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrStockBelowReserved is returned when setting on-hand stock below
+	// what is already reserved.
+	ErrStockBelowReserved   = errors.New("on-hand stock cannot be below reserved stock")
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationExists    = errors.New("order already has a reservation")
+	ErrReservationClosed    = errors.New("reservation was released or expired")
+	ErrReservationCommitted = errors.New("reservation is committed")
+)
+
+// StockLevel is the stock of one SKU in one warehouse. Reserved units are
+// still on hand but promised to an order.
+type StockLevel struct {
+	SKU       string    `json:"sku" db:"sku"`
+	Warehouse string    `json:"warehouse" db:"warehouse"`
+	OnHand    int64     `json:"on_hand" db:"on_hand"`
+	Reserved  int64     `json:"reserved" db:"reserved"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (l *StockLevel) Available() int64 {
+	return l.OnHand - l.Reserved
+}
+
+// Stock is a SKU's stock summed over its warehouses.
+type Stock struct {
+	SKU      string        `json:"sku"`
+	OnHand   int64         `json:"on_hand"`
+	Reserved int64         `json:"reserved"`
+	Levels   []*StockLevel `json:"levels"`
+}
+
+func (s *Stock) Available() int64 {
+	return s.OnHand - s.Reserved
+}
+
+// ReservationItem is one SKU of a reservation. Each item is held in a single
+// warehouse, chosen when it is reserved.
+type ReservationItem struct {
+	SKU       string `json:"sku"`
+	Warehouse string `json:"warehouse"`
+	Quantity  int64  `json:"quantity"`
+}
+
+// Reservation holds stock for one order.
+type Reservation struct {
+	ID            string             `json:"id"`
+	OrderID       string             `json:"order_id"`
+	Status        string             `json:"status"`
+	Items         []*ReservationItem `json:"items"`
+	ReleaseReason string             `json:"release_reason,omitempty"`
+	ExpiresAt     time.Time          `json:"expires_at"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+type StockRepository interface {
+	// Set sets the on-hand stock of a SKU in a warehouse, creating the level
+	// if needed.
+	Set(sku, warehouse string, onHand int64) (*StockLevel, error)
+	ListBySKU(sku string) ([]*StockLevel, error)
+}
+
+// ReservationRepository moves stock between available, reserved and sold.
+// Each method changes the reservation and the stock levels in one
+// transaction, and stock levels never go negative or below reserved stock,
+// however many callers race.
+type ReservationRepository interface {
+	// Create reserves every item, choosing for each the warehouse with the
+	// most stock available, or fails with ErrInsufficientStock and reserves
+	// nothing. It fails with ErrReservationExists if the order already has
+	// a reservation.
+	Create(reservation *Reservation) error
+	GetByOrderID(orderID string) (*Reservation, error)
+	Commit(orderID string) (*Reservation, error)
+	Release(orderID, reason string) (*Reservation, error)
+	// ExpireDue releases up to limit active reservations that expired before
+	// now. Rows locked by another caller are skipped.
+	ExpireDue(now time.Time, limit int) ([]*Reservation, error)
+}
+
+type InventoryUsecase interface {
+	GetStock(sku string) (*Stock, error)
+	SetStock(sku, warehouse string, onHand int64) (*StockLevel, error)
+	// Reserve reserves stock for an order. It is idempotent: an order that
+	// already has a reservation gets it back unchanged.
+	Reserve(orderID string, items []*ReservationItem, ttl time.Duration) (*Reservation, error)
+	Commit(orderID string) (*Reservation, error)
+	Release(orderID, reason string) (*Reservation, error)
+	GetReservation(orderID string) (*Reservation, error)
+	// ExpireDue releases up to limit expired reservations and returns how
+	// many it released.
+	ExpireDue(limit int) (int, error)
+}
@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostgresReservationRepository keeps stock consistent under concurrency
+// with conditional updates: a unit is only reserved by an UPDATE whose WHERE
+// clause re-checks availability on the locked row, and the stock_levels
+// CHECK constraints reject anything that would still overdraw it. Stock rows
+// are always locked in (sku, warehouse) order so concurrent transactions do
+// not deadlock.
+type PostgresReservationRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresReservationRepository(db *sql.DB) domain.ReservationRepository {
+	return &PostgresReservationRepository{db: db}
+}
+
+const reservationColumns = `id, order_id, status, release_reason, expires_at, created_at, updated_at`
+
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func scanReservation(row rowScanner, res *domain.Reservation) error {
+	return row.Scan(&res.ID, &res.OrderID, &res.Status, &res.ReleaseReason, &res.ExpiresAt, &res.CreatedAt, &res.UpdatedAt)
+}
+
+func (r *PostgresReservationRepository) Create(res *domain.Reservation) error {
+	res.ID = uuid.New().String()
+	res.Status = domain.ReservationStatusActive
+	res.CreatedAt = time.Now()
+	res.UpdatedAt = res.CreatedAt
+
+	return r.withTx(func(tx *sql.Tx) error {
+		query := `INSERT INTO reservations (` + reservationColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (order_id) DO NOTHING`
+		result, err := tx.Exec(query, res.ID, res.OrderID, res.Status, res.ReleaseReason, res.ExpiresAt, res.CreatedAt, res.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return domain.ErrReservationExists
+		}
+
+		sort.Slice(res.Items, func(i, j int) bool { return res.Items[i].SKU < res.Items[j].SKU })
+		for _, item := range res.Items {
+			if err := reserveItem(tx, item, res.CreatedAt); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO reservation_items (reservation_id, sku, warehouse, quantity) VALUES ($1, $2, $3, $4)`,
+				res.ID, item.SKU, item.Warehouse, item.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// reserveItem reserves the item in the warehouse with the most stock
+// available. If another transaction takes that stock first, the guarded
+// UPDATE matches nothing and the next candidate is tried.
+func reserveItem(tx *sql.Tx, item *domain.ReservationItem, now time.Time) error {
+	rows, err := tx.Query(`SELECT warehouse FROM stock_levels
+		WHERE sku = $1 AND on_hand - reserved >= $2
+		ORDER BY on_hand - reserved DESC, warehouse`, item.SKU, item.Quantity)
+	if err != nil {
+		return err
+	}
+	var warehouses []string
+	for rows.Next() {
+		var warehouse string
+		if err := rows.Scan(&warehouse); err != nil {
+			rows.Close()
+			return err
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, warehouse := range warehouses {
+		result, err := tx.Exec(`UPDATE stock_levels SET reserved = reserved + $3, updated_at = $4
+			WHERE sku = $1 AND warehouse = $2 AND on_hand - reserved >= $3`, item.SKU, warehouse, item.Quantity, now)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 1 {
+			item.Warehouse = warehouse
+			return nil
+		}
+	}
+	return domain.ErrInsufficientStock
+}
+
+func (r *PostgresReservationRepository) GetByOrderID(orderID string) (*domain.Reservation, error) {
+	res := &domain.Reservation{}
+	err := scanReservation(r.db.QueryRow(`SELECT `+reservationColumns+` FROM reservations WHERE order_id = $1`, orderID), res)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadItems(r.db, []*domain.Reservation{res}); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *PostgresReservationRepository) Commit(orderID string) (*domain.Reservation, error) {
+	var res *domain.Reservation
+	err := r.withTx(func(tx *sql.Tx) error {
+		var err error
+		res, err = lockReservation(tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		switch res.Status {
+		case domain.ReservationStatusCommitted:
+			return nil
+		case domain.ReservationStatusReleased, domain.ReservationStatusExpired:
+			return domain.ErrReservationClosed
+		}
+
+		res.Status = domain.ReservationStatusCommitted
+		res.UpdatedAt = time.Now()
+		for _, item := range res.Items {
+			_, err := tx.Exec(`UPDATE stock_levels SET on_hand = on_hand - $3, reserved = reserved - $3, updated_at = $4
+				WHERE sku = $1 AND warehouse = $2`, item.SKU, item.Warehouse, item.Quantity, res.UpdatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return updateStatus(tx, res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *PostgresReservationRepository) Release(orderID, reason string) (*domain.Reservation, error) {
+	var res *domain.Reservation
+	err := r.withTx(func(tx *sql.Tx) error {
+		var err error
+		res, err = lockReservation(tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		switch res.Status {
+		case domain.ReservationStatusCommitted:
+			return domain.ErrReservationCommitted
+		case domain.ReservationStatusReleased, domain.ReservationStatusExpired:
+			return nil
+		}
+
+		res.Status = domain.ReservationStatusReleased
+		res.ReleaseReason = reason
+		res.UpdatedAt = time.Now()
+		if err := releaseStock(tx, []*domain.Reservation{res}, res.UpdatedAt); err != nil {
+			return err
+		}
+		return updateStatus(tx, res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *PostgresReservationRepository) ExpireDue(now time.Time, limit int) ([]*domain.Reservation, error) {
+	var reservations []*domain.Reservation
+	err := r.withTx(func(tx *sql.Tx) error {
+		query := `SELECT ` + reservationColumns + ` FROM reservations
+			WHERE status = $1 AND expires_at <= $2
+			ORDER BY expires_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED`
+		rows, err := tx.Query(query, domain.ReservationStatusActive, now, limit)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			res := &domain.Reservation{}
+			if err := scanReservation(rows, res); err != nil {
+				rows.Close()
+				return err
+			}
+			reservations = append(reservations, res)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(reservations) == 0 {
+			return nil
+		}
+
+		if err := loadItems(tx, reservations); err != nil {
+			return err
+		}
+		updatedAt := time.Now()
+		if err := releaseStock(tx, reservations, updatedAt); err != nil {
+			return err
+		}
+		for _, res := range reservations {
+			res.Status = domain.ReservationStatusExpired
+			res.ReleaseReason = "reservation expired"
+			res.UpdatedAt = updatedAt
+			if err := updateStatus(tx, res); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// lockReservation loads an order's reservation and its items, locking the
+// reservation row until the transaction ends.
+func lockReservation(tx *sql.Tx, orderID string) (*domain.Reservation, error) {
+	res := &domain.Reservation{}
+	err := scanReservation(tx.QueryRow(`SELECT `+reservationColumns+` FROM reservations WHERE order_id = $1 FOR UPDATE`, orderID), res)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadItems(tx, []*domain.Reservation{res}); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func loadItems(q querier, reservations []*domain.Reservation) error {
+	byID := make(map[string]*domain.Reservation, len(reservations))
+	ids := make([]string, 0, len(reservations))
+	for _, res := range reservations {
+		byID[res.ID] = res
+		ids = append(ids, res.ID)
+	}
+
+	rows, err := q.Query(`SELECT reservation_id, sku, warehouse, quantity FROM reservation_items
+		WHERE reservation_id = ANY($1) ORDER BY sku, warehouse`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reservationID string
+		item := &domain.ReservationItem{}
+		if err := rows.Scan(&reservationID, &item.SKU, &item.Warehouse, &item.Quantity); err != nil {
+			return err
+		}
+		res := byID[reservationID]
+		res.Items = append(res.Items, item)
+	}
+	return rows.Err()
+}
+
+// releaseStock returns the reserved units of the given reservations, summed
+// per stock level and applied in lock order.
+func releaseStock(tx *sql.Tx, reservations []*domain.Reservation, now time.Time) error {
+	type level struct{ sku, warehouse string }
+	quantities := make(map[level]int64)
+	for _, res := range reservations {
+		for _, item := range res.Items {
+			quantities[level{item.SKU, item.Warehouse}] += item.Quantity
+		}
+	}
+
+	levels := make([]level, 0, len(quantities))
+	for l := range quantities {
+		levels = append(levels, l)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i].sku != levels[j].sku {
+			return levels[i].sku < levels[j].sku
+		}
+		return levels[i].warehouse < levels[j].warehouse
+	})
+
+	for _, l := range levels {
+		_, err := tx.Exec(`UPDATE stock_levels SET reserved = reserved - $3, updated_at = $4 WHERE sku = $1 AND warehouse = $2`,
+			l.sku, l.warehouse, quantities[l], now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func updateStatus(tx *sql.Tx, res *domain.Reservation) error {
+	_, err := tx.Exec(`UPDATE reservations SET status = $1, release_reason = $2, updated_at = $3 WHERE id = $4`,
+		res.Status, res.ReleaseReason, res.UpdatedAt, res.ID)
+	return err
+}
+
+// withTx runs fn in a transaction, so that a reservation and the stock it
+// holds change together.
+func (r *PostgresReservationRepository) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
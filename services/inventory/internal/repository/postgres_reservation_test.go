@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/domain"
+	"github.com/google/uuid"
+)
+
+// openTestDB connects to the Postgres database named by
+// INVENTORY_TEST_DATABASE_URL and creates the schema. The tests use their own
+// SKUs and order IDs, so the database may be shared.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("INVENTORY_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("INVENTORY_TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(Schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestConcurrentReservationsDoNotOversell(t *testing.T) {
+	db := openTestDB(t)
+	stockRepo := NewPostgresStockRepository(db)
+	reservationRepo := NewPostgresReservationRepository(db)
+
+	run := uuid.New().String()[:8]
+	skus := []string{"A-" + run, "B-" + run}
+	onHand := map[string]int64{"east": 5, "west": 3}
+	for _, sku := range skus {
+		for warehouse, n := range onHand {
+			if _, err := stockRepo.Set(sku, warehouse, n); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Every worker reserves one unit of both SKUs, listed in either order,
+	// then commits, releases or keeps the reservation.
+	const workers = 40
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			orderID := fmt.Sprintf("%s-%d", run, i)
+			items := []*domain.ReservationItem{{SKU: skus[0], Quantity: 1}, {SKU: skus[1], Quantity: 1}}
+			if i%2 == 1 {
+				items[0], items[1] = items[1], items[0]
+			}
+			err := reservationRepo.Create(&domain.Reservation{OrderID: orderID, Items: items, ExpiresAt: time.Now().Add(time.Hour)})
+			if err == nil {
+				switch i % 3 {
+				case 0:
+					_, err = reservationRepo.Commit(orderID)
+				case 1:
+					_, err = reservationRepo.Release(orderID, "test")
+				}
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	insufficient := 0
+	for i, err := range errs {
+		switch {
+		case errors.Is(err, domain.ErrInsufficientStock):
+			insufficient++
+		case err != nil:
+			t.Errorf("worker %d: %v", i, err)
+		}
+	}
+	if insufficient == 0 {
+		t.Errorf("no reservation ran out of stock; the test did not contend")
+	}
+
+	for _, sku := range skus {
+		held := holdsByStatus(t, db, sku)
+		levels, err := stockRepo.ListBySKU(sku)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(levels) != len(onHand) {
+			t.Fatalf("%s: got %d stock levels, want %d", sku, len(levels), len(onHand))
+		}
+
+		var sold int64
+		for _, level := range levels {
+			committed := held[domain.ReservationStatusCommitted][level.Warehouse]
+			active := held[domain.ReservationStatusActive][level.Warehouse]
+			sold += committed + active
+			if level.OnHand != onHand[level.Warehouse]-committed {
+				t.Errorf("%s/%s: on_hand = %d, want %d - %d committed", sku, level.Warehouse, level.OnHand, onHand[level.Warehouse], committed)
+			}
+			if level.Reserved != active {
+				t.Errorf("%s/%s: reserved = %d, want %d held by active reservations", sku, level.Warehouse, level.Reserved, active)
+			}
+		}
+		if sold > onHand["east"]+onHand["west"] {
+			t.Errorf("%s: %d units committed or reserved from %d on hand", sku, sold, onHand["east"]+onHand["west"])
+		}
+	}
+}
+
+// holdsByStatus sums the quantities of a SKU held by reservations, keyed by
+// reservation status and warehouse.
+func holdsByStatus(t *testing.T, db *sql.DB, sku string) map[string]map[string]int64 {
+	t.Helper()
+	rows, err := db.Query(`SELECT r.status, i.warehouse, SUM(i.quantity) FROM reservation_items i
+		JOIN reservations r ON r.id = i.reservation_id
+		WHERE i.sku = $1
+		GROUP BY r.status, i.warehouse`, sku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	held := make(map[string]map[string]int64)
+	for rows.Next() {
+		var status, warehouse string
+		var quantity int64
+		if err := rows.Scan(&status, &warehouse, &quantity); err != nil {
+			t.Fatal(err)
+		}
+		if held[status] == nil {
+			held[status] = make(map[string]int64)
+		}
+		held[status][warehouse] = quantity
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return held
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/domain"
+)
+
+type PostgresStockRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresStockRepository(db *sql.DB) domain.StockRepository {
+	return &PostgresStockRepository{db: db}
+}
+
+const stockColumns = `sku, warehouse, on_hand, reserved, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanStockLevel(row rowScanner, level *domain.StockLevel) error {
+	return row.Scan(&level.SKU, &level.Warehouse, &level.OnHand, &level.Reserved, &level.UpdatedAt)
+}
+
+// Set only overwrites on_hand while it stays at or above reserved, checked
+// in the same statement so a concurrent reservation cannot slip in between.
+func (r *PostgresStockRepository) Set(sku, warehouse string, onHand int64) (*domain.StockLevel, error) {
+	query := `INSERT INTO stock_levels (sku, warehouse, on_hand, reserved, updated_at) VALUES ($1, $2, $3, 0, $4)
+		ON CONFLICT (sku, warehouse) DO UPDATE SET on_hand = EXCLUDED.on_hand, updated_at = EXCLUDED.updated_at
+		WHERE stock_levels.reserved <= EXCLUDED.on_hand
+		RETURNING ` + stockColumns
+	level := &domain.StockLevel{}
+	err := scanStockLevel(r.db.QueryRow(query, sku, warehouse, onHand, time.Now()), level)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrStockBelowReserved
+	}
+	if err != nil {
+		return nil, err
+	}
+	return level, nil
+}
+
+func (r *PostgresStockRepository) ListBySKU(sku string) ([]*domain.StockLevel, error) {
+	rows, err := r.db.Query(`SELECT `+stockColumns+` FROM stock_levels WHERE sku = $1 ORDER BY warehouse`, sku)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var levels []*domain.StockLevel
+	for rows.Next() {
+		level := &domain.StockLevel{}
+		if err := scanStockLevel(rows, level); err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+	return levels, rows.Err()
+}
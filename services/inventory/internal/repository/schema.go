@@ -0,0 +1,36 @@
+package repository
+
+// Schema creates the tables the stock and reservation repositories use. It
+// is safe to run on every start.
+const Schema = `
+	CREATE TABLE IF NOT EXISTS stock_levels (
+		sku VARCHAR(64) NOT NULL,
+		warehouse VARCHAR(64) NOT NULL,
+		on_hand BIGINT NOT NULL,
+		reserved BIGINT NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (sku, warehouse),
+		CHECK (reserved >= 0),
+		CHECK (reserved <= on_hand)
+	);
+
+	CREATE TABLE IF NOT EXISTS reservations (
+		id VARCHAR(36) PRIMARY KEY,
+		order_id VARCHAR(36) NOT NULL UNIQUE,
+		status VARCHAR(20) NOT NULL,
+		release_reason VARCHAR(255) NOT NULL DEFAULT '',
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_reservations_status_expires_at ON reservations (status, expires_at);
+
+	CREATE TABLE IF NOT EXISTS reservation_items (
+		reservation_id VARCHAR(36) NOT NULL REFERENCES reservations (id) ON DELETE CASCADE,
+		sku VARCHAR(64) NOT NULL,
+		warehouse VARCHAR(64) NOT NULL,
+		quantity BIGINT NOT NULL CHECK (quantity > 0),
+		PRIMARY KEY (reservation_id, sku)
+	);
+	`
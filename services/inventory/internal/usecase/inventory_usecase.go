@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/domain"
+)
+
+type inventoryUsecase struct {
+	stockRepo       domain.StockRepository
+	reservationRepo domain.ReservationRepository
+	// defaultTTL applies to reservations made without a TTL. It should
+	// exceed the order service's payment window, so unpaid orders expire and
+	// release their stock before their reservations lapse.
+	defaultTTL time.Duration
+}
+
+func NewInventoryUsecase(stockRepo domain.StockRepository, reservationRepo domain.ReservationRepository, defaultTTL time.Duration) domain.InventoryUsecase {
+	return &inventoryUsecase{
+		stockRepo:       stockRepo,
+		reservationRepo: reservationRepo,
+		defaultTTL:      defaultTTL,
+	}
+}
+
+func (u *inventoryUsecase) GetStock(sku string) (*domain.Stock, error) {
+	if sku == "" {
+		return nil, errors.New("sku is required")
+	}
+
+	levels, err := u.stockRepo.ListBySKU(sku)
+	if err != nil {
+		return nil, err
+	}
+
+	stock := &domain.Stock{SKU: sku, Levels: levels}
+	for _, level := range levels {
+		stock.OnHand += level.OnHand
+		stock.Reserved += level.Reserved
+	}
+	if stock.Levels == nil {
+		stock.Levels = []*domain.StockLevel{}
+	}
+	return stock, nil
+}
+
+func (u *inventoryUsecase) SetStock(sku, warehouse string, onHand int64) (*domain.StockLevel, error) {
+	if sku == "" || warehouse == "" {
+		return nil, errors.New("sku and warehouse are required")
+	}
+	if onHand < 0 {
+		return nil, errors.New("on_hand must not be negative")
+	}
+	return u.stockRepo.Set(sku, warehouse, onHand)
+}
+
+func (u *inventoryUsecase) Reserve(orderID string, items []*domain.ReservationItem, ttl time.Duration) (*domain.Reservation, error) {
+	if orderID == "" || len(items) == 0 {
+		return nil, errors.New("orderID and items are required")
+	}
+	if ttl <= 0 {
+		ttl = u.defaultTTL
+	}
+
+	// Merge repeated SKUs so each is reserved, and later released, once.
+	var merged []*domain.ReservationItem
+	bySKU := make(map[string]*domain.ReservationItem)
+	for _, item := range items {
+		if item.SKU == "" || item.Quantity <= 0 {
+			return nil, errors.New("each item needs a sku and a positive quantity")
+		}
+		if existing, ok := bySKU[item.SKU]; ok {
+			existing.Quantity += item.Quantity
+			continue
+		}
+		m := &domain.ReservationItem{SKU: item.SKU, Quantity: item.Quantity}
+		bySKU[item.SKU] = m
+		merged = append(merged, m)
+	}
+
+	reservation := &domain.Reservation{
+		OrderID:   orderID,
+		Items:     merged,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	err := u.reservationRepo.Create(reservation)
+	if errors.Is(err, domain.ErrReservationExists) {
+		return u.reservationRepo.GetByOrderID(orderID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+func (u *inventoryUsecase) Commit(orderID string) (*domain.Reservation, error) {
+	if orderID == "" {
+		return nil, errors.New("orderID is required")
+	}
+	return u.reservationRepo.Commit(orderID)
+}
+
+func (u *inventoryUsecase) Release(orderID, reason string) (*domain.Reservation, error) {
+	if orderID == "" {
+		return nil, errors.New("orderID is required")
+	}
+	return u.reservationRepo.Release(orderID, reason)
+}
+
+func (u *inventoryUsecase) GetReservation(orderID string) (*domain.Reservation, error) {
+	if orderID == "" {
+		return nil, errors.New("orderID is required")
+	}
+	return u.reservationRepo.GetByOrderID(orderID)
+}
+
+func (u *inventoryUsecase) ExpireDue(limit int) (int, error) {
+	reservations, err := u.reservationRepo.ExpireDue(time.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+	return len(reservations), nil
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/inventory/internal/domain"
+)
+
+// ReservationExpirer returns the stock of reservations past their TTL.
+// Every replica may run it: reservations are claimed with FOR UPDATE SKIP
+// LOCKED.
+type ReservationExpirer struct {
+	inventoryUsecase domain.InventoryUsecase
+	pollInterval     time.Duration
+	batchSize        int
+}
+
+func NewReservationExpirer(inventoryUsecase domain.InventoryUsecase, pollInterval time.Duration, batchSize int) *ReservationExpirer {
+	return &ReservationExpirer{
+		inventoryUsecase: inventoryUsecase,
+		pollInterval:     pollInterval,
+		batchSize:        batchSize,
+	}
+}
+
+// Run expires due reservations until ctx is cancelled.
+func (e *ReservationExpirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		e.expireDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expireDue works through full batches so a backlog clears in one tick.
+func (e *ReservationExpirer) expireDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := e.inventoryUsecase.ExpireDue(e.batchSize)
+		if err != nil {
+			log.Printf("Failed to expire reservations: %v", err)
+			return
+		}
+		if n > 0 {
+			log.Printf("Expired %d stock reservations", n)
+		}
+		if n < e.batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus"
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/edwinjordan/golang_microservices/pkg/restproxy"
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/config"
+	eventsHandler "github.com/edwinjordan/golang_microservices/services/notification/internal/delivery/events"
+	grpcHandler "github.com/edwinjordan/golang_microservices/services/notification/internal/delivery/grpc"
+	httpHandler "github.com/edwinjordan/golang_microservices/services/notification/internal/delivery/http"
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/domain"
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/repository"
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/templates"
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/transport"
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/usecase"
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/worker"
+	pb "github.com/edwinjordan/golang_microservices/services/notification/pkg/pb"
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	// Load configuration
+	cfg := config.LoadConfig()
+
+	// Connect to database with retry
+	var db *sql.DB
+	var err error
+	for i := 0; i < 30; i++ {
+		db, err = sql.Open("postgres", cfg.GetDSN())
+		if err == nil {
+			err = db.Ping()
+			if err == nil {
+				break
+			}
+		}
+		log.Printf("Failed to connect to database, retrying in 2 seconds... (%d/30)", i+1)
+		time.Sleep(2 * time.Second)
+	}
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	log.Println("Connected to database successfully")
+
+	// Initialize database schema
+	initSchema(db)
+
+	// Initialize layers
+	renderer, err := templates.New(cfg.DefaultLocale)
+	if err != nil {
+		log.Fatalf("Failed to load templates: %v", err)
+	}
+	notificationRepo := repository.NewPostgresNotificationRepository(db)
+	notificationUsecase := usecase.NewNotificationUsecase(notificationRepo, renderer, cfg.UserGRPCAddr)
+
+	// Start notification dispatch
+	dispatcher := worker.NewNotificationDispatcher(notificationRepo, newTransports(cfg), worker.DispatcherConfig{
+		PollInterval: cfg.DispatchPollInterval,
+		BatchSize:    cfg.DispatchBatchSize,
+		Timeout:      cfg.DispatchTimeout,
+		MaxAttempts:  cfg.DispatchMaxAttempts,
+		BaseBackoff:  cfg.DispatchBaseBackoff,
+		MaxBackoff:   cfg.DispatchMaxBackoff,
+	})
+	go dispatcher.Run(context.Background())
+
+	// Connect to event bus
+	bus, err := eventbus.Open(context.Background(), eventbus.Config{
+		Driver: cfg.EventBusDriver,
+		URL:    cfg.EventBusURL,
+		Stream: cfg.EventBusStream,
+		Name:   eventsHandler.Group,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to event bus: %v", err)
+	}
+	defer bus.Close()
+
+	// Subscribe to user, order and payment events
+	subscriber := eventsHandler.NewSubscriber(notificationUsecase)
+	if err := subscriber.Register(context.Background(), bus, db); err != nil {
+		log.Fatalf("Failed to subscribe to events: %v", err)
+	}
+
+	// Start gRPC server
+	go func() {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.GRPCPort))
+		if err != nil {
+			log.Fatalf("Failed to listen on gRPC port: %v", err)
+		}
+
+		grpcServer := grpc.NewServer(grpc.UnaryInterceptor(validate.UnaryServerInterceptor()))
+		notificationGRPCHandler := grpcHandler.NewNotificationGRPCHandler(notificationUsecase)
+		pb.RegisterNotificationServiceServer(grpcServer, notificationGRPCHandler)
+
+		log.Printf("gRPC server listening on port %s", cfg.GRPCPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Failed to serve gRPC: %v", err)
+		}
+	}()
+
+	// Start HTTP server
+	router := gin.Default()
+	registerRoutes(router, notificationUsecase)
+
+	// REST routes generated from the HTTP rules in proto/notification.proto, proxied
+	// to the gRPC server
+	restProxy, err := restproxy.New(context.Background(), "localhost:"+cfg.GRPCPort, pb.RegisterNotificationServiceHandlerFromEndpoint)
+	if err != nil {
+		log.Fatalf("Failed to start REST proxy: %v", err)
+	}
+	router.Any(restproxy.Prefix+"/*path", gin.WrapH(restProxy))
+
+	if err := openapi.Mount(router, httpHandler.API(), restproxy.Prefix); err != nil {
+		log.Fatalf("Failed to serve OpenAPI document: %v", err)
+	}
+
+	log.Printf("HTTP server listening on port %s", cfg.HTTPPort)
+	if err := router.Run(fmt.Sprintf(":%s", cfg.HTTPPort)); err != nil {
+		log.Fatalf("Failed to start HTTP server: %v", err)
+	}
+}
+
+// registerRoutes registers the hand-written HTTP routes. main adds the
+// generated REST routes and the OpenAPI document.
+func registerRoutes(router *gin.Engine, notificationUsecase domain.NotificationUsecase) {
+	notificationHandler := httpHandler.NewNotificationHandler(notificationUsecase)
+
+	router.GET("/health", notificationHandler.Health)
+	router.POST("/notifications", notificationHandler.SendNotification)
+	router.GET("/notifications", notificationHandler.ListNotifications)
+	router.GET("/notifications/:id", notificationHandler.GetNotification)
+}
+
+// newTransports returns the transport for each channel.
+func newTransports(cfg *config.Config) map[string]domain.Transport {
+	transports := make(map[string]domain.Transport)
+	// Channels writing to the file sink share one transport, which
+	// serializes writes.
+	fileSink := transport.NewFileTransport(cfg.FileSink)
+
+	switch cfg.EmailTransport {
+	case "smtp":
+		log.Printf("Sending email through SMTP server %s", cfg.SMTPAddr)
+		transports[domain.ChannelEmail] = transport.NewSMTPTransport(transport.SMTPConfig{
+			Addr:     cfg.SMTPAddr,
+			From:     cfg.SMTPFrom,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		})
+	case "file":
+		log.Printf("Writing email to %s", cfg.FileSink)
+		transports[domain.ChannelEmail] = fileSink
+	default:
+		log.Fatalf("Unknown email transport %q", cfg.EmailTransport)
+	}
+
+	switch cfg.SMSTransport {
+	case "file":
+		log.Printf("Writing SMS to %s", cfg.FileSink)
+		transports[domain.ChannelSMS] = fileSink
+	default:
+		log.Fatalf("Unknown SMS transport %q", cfg.SMSTransport)
+	}
+	return transports
+}
+
+func initSchema(db *sql.DB) {
+	schema := `
+	CREATE TABLE IF NOT EXISTS notifications (
+		id VARCHAR(36) PRIMARY KEY,
+		idempotency_key VARCHAR(255) NOT NULL,
+		user_id VARCHAR(36) NOT NULL,
+		channel VARCHAR(20) NOT NULL,
+		template VARCHAR(64) NOT NULL,
+		locale VARCHAR(20) NOT NULL,
+		recipient VARCHAR(255) NOT NULL DEFAULT '',
+		subject TEXT NOT NULL DEFAULT '',
+		body TEXT NOT NULL DEFAULT '',
+		html_body TEXT NOT NULL DEFAULT '',
+		status VARCHAR(20) NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		attempts INT NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMP NOT NULL,
+		sent_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		UNIQUE (idempotency_key, channel)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_notifications_status_next_attempt_at ON notifications (status, next_attempt_at);
+	CREATE INDEX IF NOT EXISTS idx_notifications_user_id_created_at ON notifications (user_id, created_at DESC);
+	`
+	_, err := db.Exec(schema + eventbus.InboxSchema)
+	if err != nil {
+		log.Fatalf("Failed to initialize schema: %v", err)
+	}
+	log.Println("Database schema initialized")
+}
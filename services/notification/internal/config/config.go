@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	DBHost       string
+	DBPort       string
+	DBUser       string
+	DBPassword   string
+	DBName       string
+	HTTPPort     string
+	GRPCPort     string
+	UserGRPCAddr string
+
+	DefaultLocale string
+
+	// EmailTransport is "smtp" or "file"; SMSTransport is "file". The file
+	// transport appends each notification to FileSink as a JSON line.
+	EmailTransport string
+	SMSTransport   string
+	SMTPAddr       string
+	SMTPFrom       string
+	SMTPUsername   string
+	SMTPPassword   string
+	FileSink       string
+
+	DispatchPollInterval time.Duration
+	DispatchBatchSize    int
+	DispatchTimeout      time.Duration
+	DispatchMaxAttempts  int
+	DispatchBaseBackoff  time.Duration
+	DispatchMaxBackoff   time.Duration
+
+	EventBusDriver string
+	EventBusURL    string
+	EventBusStream string
+}
+
+func LoadConfig() *Config {
+	return &Config{
+		DBHost:       getEnv("NOTIFICATION_DB_HOST", "localhost"),
+		DBPort:       getEnv("NOTIFICATION_DB_PORT", "5432"),
+		DBUser:       getEnv("NOTIFICATION_DB_USER", "notificationservice"),
+		DBPassword:   getEnv("NOTIFICATION_DB_PASSWORD", "notificationpass123"),
+		DBName:       getEnv("NOTIFICATION_DB_NAME", "notifications_db"),
+		HTTPPort:     getEnv("NOTIFICATION_SERVICE_HTTP_PORT", "8086"),
+		GRPCPort:     getEnv("NOTIFICATION_SERVICE_GRPC_PORT", "9096"),
+		UserGRPCAddr: getEnv("USER_GRPC_ADDR", "localhost:9091"),
+
+		DefaultLocale: getEnv("NOTIFICATION_DEFAULT_LOCALE", "en"),
+
+		EmailTransport: getEnv("NOTIFICATION_EMAIL_TRANSPORT", "smtp"),
+		SMSTransport:   getEnv("NOTIFICATION_SMS_TRANSPORT", "file"),
+		SMTPAddr:       getEnv("NOTIFICATION_SMTP_ADDR", "localhost:1025"),
+		SMTPFrom:       getEnv("NOTIFICATION_SMTP_FROM", "no-reply@example.com"),
+		SMTPUsername:   getEnv("NOTIFICATION_SMTP_USERNAME", ""),
+		SMTPPassword:   getEnv("NOTIFICATION_SMTP_PASSWORD", ""),
+		FileSink:       getEnv("NOTIFICATION_FILE_SINK", "notifications.jsonl"),
+
+		DispatchPollInterval: getEnvDuration("NOTIFICATION_DISPATCH_POLL_INTERVAL", 5*time.Second),
+		DispatchBatchSize:    getEnvInt("NOTIFICATION_DISPATCH_BATCH_SIZE", 20),
+		DispatchTimeout:      getEnvDuration("NOTIFICATION_DISPATCH_TIMEOUT", 10*time.Second),
+		DispatchMaxAttempts:  getEnvInt("NOTIFICATION_DISPATCH_MAX_ATTEMPTS", 6),
+		DispatchBaseBackoff:  getEnvDuration("NOTIFICATION_DISPATCH_BASE_BACKOFF", 30*time.Second),
+		DispatchMaxBackoff:   getEnvDuration("NOTIFICATION_DISPATCH_MAX_BACKOFF", 30*time.Minute),
+
+		EventBusDriver: getEnv("EVENT_BUS_DRIVER", "memory"),
+		EventBusURL:    getEnv("EVENT_BUS_URL", "nats://localhost:4222"),
+		EventBusStream: getEnv("EVENT_BUS_STREAM", "EVENTS"),
+	}
+}
+
+func (c *Config) GetDSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
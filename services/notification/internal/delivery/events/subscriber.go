@@ -0,0 +1,160 @@
+// Package events turns user, order and payment events into notifications.
+package events
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus"
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/domain"
+)
+
+// Group is the notification service's consumer group.
+const Group = "notification-service"
+
+// Statuses, mirroring the order and payment services, that notify the user.
+const (
+	orderStatusCancelled  = "cancelled"
+	orderStatusExpired    = "expired"
+	paymentStatusCaptured = "captured"
+	paymentStatusDeclined = "declined"
+	paymentStatusFailed   = "failed"
+)
+
+type Subscriber struct {
+	notificationUsecase domain.NotificationUsecase
+}
+
+func NewSubscriber(notificationUsecase domain.NotificationUsecase) *Subscriber {
+	return &Subscriber{notificationUsecase: notificationUsecase}
+}
+
+// Register subscribes the notification service to the events it notifies
+// users about.
+func (s *Subscriber) Register(ctx context.Context, bus eventbus.Bus, db *sql.DB) error {
+	types := []string{
+		eventbus.UserCreated,
+		eventbus.OrderCreated, eventbus.OrderUpdated, eventbus.OrderExpired,
+		eventbus.PaymentCreated, eventbus.PaymentUpdated, eventbus.PaymentRefunded,
+	}
+	return bus.Subscribe(ctx, Group, types, eventbus.Dedupe(db, Group, s.Handle))
+}
+
+// Handle notifies the user an event concerns. Idempotency keys name the
+// occurrence rather than the event, so an order cancelled twice, or a
+// payment reported captured by several events, is notified once.
+func (s *Subscriber) Handle(ctx context.Context, env *eventspb.Envelope) error {
+	msg, err := eventbus.Decode(env)
+	if err != nil {
+		return err
+	}
+
+	req, ok := notifyRequest(msg)
+	if !ok {
+		return nil
+	}
+
+	_, err = s.notificationUsecase.Notify(ctx, req)
+	if errors.Is(err, domain.ErrUserNotFound) || errors.Is(err, domain.ErrInvalidNotification) {
+		// Retrying will not help; drop it rather than block the stream.
+		log.Printf("Dropping %s notification %s: %v", req.Template, req.IdempotencyKey, err)
+		return nil
+	}
+	return err
+}
+
+// notifyRequest maps an event to the notification it triggers, if any.
+func notifyRequest(msg any) (domain.NotifyRequest, bool) {
+	switch event := msg.(type) {
+	case *eventspb.UserCreated:
+		user := event.GetUser()
+		return domain.NotifyRequest{
+			IdempotencyKey: domain.TemplateWelcome + ":" + user.GetId(),
+			UserID:         user.GetId(),
+			Template:       domain.TemplateWelcome,
+			Data:           map[string]any{"Name": user.GetName()},
+		}, true
+	case *eventspb.OrderCreated:
+		return orderRequest(domain.TemplateOrderPlaced, event.GetOrder()), true
+	case *eventspb.OrderUpdated:
+		if event.GetOrder().GetStatus() != orderStatusCancelled {
+			return domain.NotifyRequest{}, false
+		}
+		return orderRequest(domain.TemplateOrderCancelled, event.GetOrder()), true
+	case *eventspb.OrderExpired:
+		return orderRequest(domain.TemplateOrderCancelled, event.GetOrder()), true
+	case *eventspb.PaymentCreated:
+		return paymentRequest(event.GetPayment())
+	case *eventspb.PaymentUpdated:
+		return paymentRequest(event.GetPayment())
+	case *eventspb.PaymentRefunded:
+		payment, refund := event.GetPayment(), event.GetRefund()
+		return domain.NotifyRequest{
+			IdempotencyKey: domain.TemplatePaymentRefunded + ":" + refund.GetId(),
+			UserID:         payment.GetUserId(),
+			Template:       domain.TemplatePaymentRefunded,
+			Data: map[string]any{
+				"OrderID":   payment.GetOrderId(),
+				"PaymentID": payment.GetId(),
+				"Amount":    refund.GetAmount(),
+				"Currency":  payment.GetCurrency(),
+				"Reason":    refund.GetReason(),
+			},
+		}, true
+	}
+	return domain.NotifyRequest{}, false
+}
+
+// orderRequest notifies about an order. Cancelled and expired orders share
+// a key, so an order is reported ended once.
+func orderRequest(template string, order *eventspb.Order) domain.NotifyRequest {
+	status := order.GetStatus()
+	if template == domain.TemplateOrderCancelled && status != orderStatusExpired {
+		status = orderStatusCancelled
+	}
+	return domain.NotifyRequest{
+		IdempotencyKey: template + ":" + order.GetId(),
+		UserID:         order.GetUserId(),
+		Template:       template,
+		Data: map[string]any{
+			"OrderID":  order.GetId(),
+			"Product":  order.GetProduct(),
+			"Quantity": order.GetQuantity(),
+			"Amount":   order.GetAmount(),
+			"Currency": order.GetCurrency(),
+			"Status":   status,
+		},
+	}
+}
+
+// paymentRequest notifies about a payment that was captured or failed.
+func paymentRequest(payment *eventspb.Payment) (domain.NotifyRequest, bool) {
+	var template string
+	amount := payment.GetAmount()
+	switch payment.GetStatus() {
+	case paymentStatusCaptured:
+		template = domain.TemplatePaymentReceived
+		if captured := payment.GetCapturedAmount(); captured > 0 {
+			amount = captured
+		}
+	case paymentStatusDeclined, paymentStatusFailed:
+		template = domain.TemplatePaymentFailed
+	default:
+		return domain.NotifyRequest{}, false
+	}
+	return domain.NotifyRequest{
+		IdempotencyKey: template + ":" + payment.GetId(),
+		UserID:         payment.GetUserId(),
+		Template:       template,
+		Data: map[string]any{
+			"OrderID":   payment.GetOrderId(),
+			"PaymentID": payment.GetId(),
+			"Amount":    amount,
+			"Currency":  payment.GetCurrency(),
+			"Reason":    payment.GetFailureReason(),
+		},
+	}, true
+}
@@ -0,0 +1,98 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/notification/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type NotificationGRPCHandler struct {
+	pb.UnimplementedNotificationServiceServer
+	notificationUsecase domain.NotificationUsecase
+}
+
+func NewNotificationGRPCHandler(notificationUsecase domain.NotificationUsecase) *NotificationGRPCHandler {
+	return &NotificationGRPCHandler{notificationUsecase: notificationUsecase}
+}
+
+func (h *NotificationGRPCHandler) SendNotification(ctx context.Context, req *pb.SendNotificationRequest) (*pb.SendNotificationResponse, error) {
+	data := make(map[string]any, len(req.Data))
+	for k, v := range req.Data {
+		data[k] = v
+	}
+
+	notifications, err := h.notificationUsecase.Notify(ctx, domain.NotifyRequest{
+		IdempotencyKey: req.IdempotencyKey,
+		UserID:         req.UserId,
+		Template:       req.Template,
+		Data:           data,
+	})
+	if err != nil {
+		return nil, notificationError(err)
+	}
+	return &pb.SendNotificationResponse{Notifications: toPBNotifications(notifications)}, nil
+}
+
+func (h *NotificationGRPCHandler) GetNotification(ctx context.Context, req *pb.GetNotificationRequest) (*pb.Notification, error) {
+	n, err := h.notificationUsecase.GetNotification(req.Id)
+	if err != nil {
+		return nil, notificationError(err)
+	}
+	return toPBNotification(n), nil
+}
+
+func (h *NotificationGRPCHandler) ListNotifications(ctx context.Context, req *pb.ListNotificationsRequest) (*pb.ListNotificationsResponse, error) {
+	notifications, err := h.notificationUsecase.ListNotifications(req.UserId, int(req.Limit))
+	if err != nil {
+		return nil, notificationError(err)
+	}
+	return &pb.ListNotificationsResponse{Notifications: toPBNotifications(notifications)}, nil
+}
+
+func toPBNotifications(notifications []*domain.Notification) []*pb.Notification {
+	resp := make([]*pb.Notification, 0, len(notifications))
+	for _, n := range notifications {
+		resp = append(resp, toPBNotification(n))
+	}
+	return resp
+}
+
+func toPBNotification(n *domain.Notification) *pb.Notification {
+	resp := &pb.Notification{
+		Id:             n.ID,
+		IdempotencyKey: n.IdempotencyKey,
+		UserId:         n.UserID,
+		Channel:        n.Channel,
+		Template:       n.Template,
+		Locale:         n.Locale,
+		Recipient:      n.Recipient,
+		Subject:        n.Subject,
+		Body:           n.Body,
+		HtmlBody:       n.HTMLBody,
+		Status:         n.Status,
+		Reason:         n.Reason,
+		Attempts:       int32(n.Attempts),
+		NextAttemptAt:  timestamppb.New(n.NextAttemptAt),
+		CreatedAt:      timestamppb.New(n.CreatedAt),
+		UpdatedAt:      timestamppb.New(n.UpdatedAt),
+	}
+	if n.SentAt != nil {
+		resp.SentAt = timestamppb.New(*n.SentAt)
+	}
+	return resp
+}
+
+func notificationError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrNotificationNotFound), errors.Is(err, domain.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrUnknownTemplate), errors.Is(err, domain.ErrInvalidNotification):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
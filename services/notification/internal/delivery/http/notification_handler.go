@@ -0,0 +1,119 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/notification/pkg/pb"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationUsecase domain.NotificationUsecase
+}
+
+func NewNotificationHandler(notificationUsecase domain.NotificationUsecase) *NotificationHandler {
+	return &NotificationHandler{notificationUsecase: notificationUsecase}
+}
+
+type SendNotificationRequest struct {
+	UserID   string         `json:"user_id"`
+	Template string         `json:"template"`
+	Data     map[string]any `json:"data"`
+	// IdempotencyKey is optional; sending again with the same key returns
+	// the notifications already queued.
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+type ListNotificationsQuery struct {
+	UserID string `form:"user_id"`
+	Limit  int    `form:"limit"`
+}
+
+type NotificationsResponse struct {
+	Notifications []*domain.Notification `json:"notifications"`
+}
+
+// SendNotification queues a templated notification on every channel the
+// user's preferences allow. The notifications are sent asynchronously.
+func (h *NotificationHandler) SendNotification(c *gin.Context) {
+	var req SendNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// data takes any JSON here but only strings over gRPC, and has no rules,
+	// so it is left out of the message validated.
+	if err := validate.Validate(&pb.SendNotificationRequest{
+		UserId:         req.UserID,
+		Template:       req.Template,
+		IdempotencyKey: req.IdempotencyKey,
+	}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	notifications, err := h.notificationUsecase.Notify(c.Request.Context(), domain.NotifyRequest{
+		IdempotencyKey: req.IdempotencyKey,
+		UserID:         req.UserID,
+		Template:       req.Template,
+		Data:           req.Data,
+	})
+	if err != nil {
+		c.JSON(notificationStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, NotificationsResponse{Notifications: notifications})
+}
+
+func (h *NotificationHandler) GetNotification(c *gin.Context) {
+	n, err := h.notificationUsecase.GetNotification(c.Param("id"))
+	if err != nil {
+		c.JSON(notificationStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, n)
+}
+
+// ListNotifications is GET /notifications?user_id=&limit=: the user's
+// notifications, newest first.
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	var query ListNotificationsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	notifications, err := h.notificationUsecase.ListNotifications(query.UserID, query.Limit)
+	if err != nil {
+		c.JSON(notificationStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if notifications == nil {
+		notifications = []*domain.Notification{}
+	}
+
+	c.JSON(http.StatusOK, NotificationsResponse{Notifications: notifications})
+}
+
+func notificationStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrNotificationNotFound), errors.Is(err, domain.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrUnknownTemplate), errors.Is(err, domain.ErrInvalidNotification):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *NotificationHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"service": "notification-service",
+	})
+}
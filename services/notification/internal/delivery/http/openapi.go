@@ -0,0 +1,26 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/notification/pkg/pb"
+)
+
+// API documents the notification service's HTTP routes. The service will
+// not start if a route is missing from it.
+func API() openapi.API {
+	return openapi.API{
+		Title:       "Notification Service",
+		Version:     "1.0",
+		Description: "Templated email and SMS notifications.",
+		Operations: []openapi.Operation{
+			{Method: http.MethodGet, Path: "/health", Tag: "health", Summary: "Health check", Response: openapi.HealthResponse{}},
+
+			{Method: http.MethodPost, Path: "/notifications", Tag: "notifications", Summary: "Queue a notification on the user's allowed channels", Body: SendNotificationRequest{}, Rules: &pb.SendNotificationRequest{}, Status: http.StatusAccepted, Response: NotificationsResponse{}},
+			{Method: http.MethodGet, Path: "/notifications", Tag: "notifications", Summary: "List a user's notifications, newest first", Query: ListNotificationsQuery{}, Response: NotificationsResponse{}},
+			{Method: http.MethodGet, Path: "/notifications/:id", Tag: "notifications", Summary: "Get a notification", Response: domain.Notification{}},
+		},
+	}
+}
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Channels a notification can be sent on.
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Notification statuses. A pending notification is sent by the dispatcher
+// and retried with exponential backoff; it becomes sent, or failed once its
+// attempts are used up. Notifications the user opted out of, or has no
+// address for, are recorded as skipped and never sent.
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Templates. Each belongs to a topic, which decides which preference
+// governs it.
+const (
+	TemplateWelcome         = "welcome"
+	TemplateOrderPlaced     = "order_placed"
+	TemplateOrderCancelled  = "order_cancelled"
+	TemplatePaymentReceived = "payment_received"
+	TemplatePaymentFailed   = "payment_failed"
+	TemplatePaymentRefunded = "payment_refunded"
+)
+
+// Topics. Account notifications go out on every enabled channel; order and
+// payment notifications can also be turned off per topic.
+const (
+	TopicAccount  = "account"
+	TopicOrders   = "orders"
+	TopicPayments = "payments"
+)
+
+// TemplateTopics maps every known template to its topic.
+var TemplateTopics = map[string]string{
+	TemplateWelcome:         TopicAccount,
+	TemplateOrderPlaced:     TopicOrders,
+	TemplateOrderCancelled:  TopicOrders,
+	TemplatePaymentReceived: TopicPayments,
+	TemplatePaymentFailed:   TopicPayments,
+	TemplatePaymentRefunded: TopicPayments,
+}
+
+var (
+	ErrNotificationNotFound  = errors.New("notification not found")
+	ErrDuplicateNotification = errors.New("notification already exists")
+	ErrUnknownTemplate       = errors.New("unknown template")
+	ErrInvalidNotification   = errors.New("invalid notification")
+	ErrUserNotFound          = errors.New("user not found")
+)
+
+// Notification is one message to one user on one channel. It is rendered
+// when it is queued, so retries send exactly what was first rendered.
+// IdempotencyKey and Channel are unique together: notifying twice with the
+// same key sends once.
+type Notification struct {
+	ID             string `json:"id"`
+	IdempotencyKey string `json:"idempotency_key"`
+	UserID         string `json:"user_id"`
+	Channel        string `json:"channel"`
+	Template       string `json:"template"`
+	// Locale is the locale the notification was rendered in, which may be
+	// a fallback of the user's.
+	Locale    string `json:"locale"`
+	Recipient string `json:"recipient"`
+	Subject   string `json:"subject,omitempty"`
+	Body      string `json:"body"`
+	HTMLBody  string `json:"html_body,omitempty"`
+	Status    string `json:"status"`
+	// Reason says why a notification was skipped or failed; for pending
+	// ones it holds the last attempt's error.
+	Reason        string     `json:"reason,omitempty"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// NotifyRequest asks for a template to be sent to a user on every channel
+// their preferences allow. Data fills the template; Name (the user's name)
+// is added unless given.
+type NotifyRequest struct {
+	IdempotencyKey string
+	UserID         string
+	Template       string
+	Data           map[string]any
+}
+
+// Rendered is a template rendered for one channel. Subject and HTML are
+// empty for SMS.
+type Rendered struct {
+	Locale  string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Renderer renders notification templates.
+type Renderer interface {
+	// Render renders template for channel in locale. A locale without its
+	// own translation falls back to its language and then to the default
+	// locale. Unknown templates fail with ErrUnknownTemplate.
+	Render(template, channel, locale string, data map[string]any) (*Rendered, error)
+}
+
+// Transport delivers notifications on one channel.
+type Transport interface {
+	Send(ctx context.Context, n *Notification) error
+}
+
+type NotificationRepository interface {
+	// Create stores a new notification. It fails with
+	// ErrDuplicateNotification if one with the same idempotency key and
+	// channel exists.
+	Create(n *Notification) error
+	GetByID(id string) (*Notification, error)
+	GetByKey(idempotencyKey, channel string) (*Notification, error)
+	// ListByUser returns the user's notifications, newest first.
+	ListByUser(userID string, limit int) ([]*Notification, error)
+	// ClaimDue leases up to limit pending notifications due by now, pushing
+	// their next attempt back by lease so other dispatchers skip them.
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]*Notification, error)
+	// RecordAttempt saves the outcome of a send attempt.
+	RecordAttempt(n *Notification) error
+}
+
+type NotificationUsecase interface {
+	// Notify renders and queues the request's notifications, one per
+	// channel. Channels the user's preferences rule out are recorded as
+	// skipped.
+	Notify(ctx context.Context, req NotifyRequest) ([]*Notification, error)
+	GetNotification(id string) (*Notification, error)
+	ListNotifications(userID string, limit int) ([]*Notification, error)
+}
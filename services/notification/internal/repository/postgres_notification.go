@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PostgresNotificationRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresNotificationRepository(db *sql.DB) domain.NotificationRepository {
+	return &PostgresNotificationRepository{db: db}
+}
+
+const notificationColumns = `id, idempotency_key, user_id, channel, template, locale, recipient, subject, body, html_body,
+	status, reason, attempts, next_attempt_at, sent_at, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanNotification(row rowScanner) (*domain.Notification, error) {
+	n := &domain.Notification{}
+	err := row.Scan(&n.ID, &n.IdempotencyKey, &n.UserID, &n.Channel, &n.Template, &n.Locale, &n.Recipient, &n.Subject,
+		&n.Body, &n.HTMLBody, &n.Status, &n.Reason, &n.Attempts, &n.NextAttemptAt, &n.SentAt, &n.CreatedAt, &n.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (r *PostgresNotificationRepository) Create(n *domain.Notification) error {
+	n.ID = uuid.New().String()
+	n.CreatedAt = time.Now()
+	n.UpdatedAt = n.CreatedAt
+	if n.NextAttemptAt.IsZero() {
+		n.NextAttemptAt = n.CreatedAt
+	}
+
+	query := `INSERT INTO notifications (` + notificationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		ON CONFLICT (idempotency_key, channel) DO NOTHING`
+	res, err := r.db.Exec(query, n.ID, n.IdempotencyKey, n.UserID, n.Channel, n.Template, n.Locale, n.Recipient,
+		n.Subject, n.Body, n.HTMLBody, n.Status, n.Reason, n.Attempts, n.NextAttemptAt, n.SentAt, n.CreatedAt, n.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return domain.ErrDuplicateNotification
+	}
+	return nil
+}
+
+func (r *PostgresNotificationRepository) GetByID(id string) (*domain.Notification, error) {
+	n, err := scanNotification(r.db.QueryRow(`SELECT `+notificationColumns+` FROM notifications WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotificationNotFound
+	}
+	return n, err
+}
+
+func (r *PostgresNotificationRepository) GetByKey(idempotencyKey, channel string) (*domain.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE idempotency_key = $1 AND channel = $2`
+	n, err := scanNotification(r.db.QueryRow(query, idempotencyKey, channel))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotificationNotFound
+	}
+	return n, err
+}
+
+func (r *PostgresNotificationRepository) ListByUser(userID string, limit int) ([]*domain.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC LIMIT $2`
+	return r.query(query, userID, limit)
+}
+
+func (r *PostgresNotificationRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*domain.Notification, error) {
+	query := `UPDATE notifications SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM notifications
+			WHERE status = $3 AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + notificationColumns
+	return r.query(query, now, now.Add(lease), domain.StatusPending, limit)
+}
+
+func (r *PostgresNotificationRepository) RecordAttempt(n *domain.Notification) error {
+	n.UpdatedAt = time.Now()
+	query := `UPDATE notifications SET status = $1, reason = $2, attempts = $3, next_attempt_at = $4, sent_at = $5,
+		updated_at = $6 WHERE id = $7`
+	_, err := r.db.Exec(query, n.Status, n.Reason, n.Attempts, n.NextAttemptAt, n.SentAt, n.UpdatedAt, n.ID)
+	return err
+}
+
+func (r *PostgresNotificationRepository) query(query string, args ...any) ([]*domain.Notification, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*domain.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
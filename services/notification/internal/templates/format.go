@@ -0,0 +1,85 @@
+package templates
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+)
+
+// numberFormat holds a language's separators.
+type numberFormat struct {
+	decimal   string
+	thousands string
+}
+
+var numberFormats = map[string]numberFormat{
+	"en": {decimal: ".", thousands: ","},
+	"id": {decimal: ",", thousands: "."},
+}
+
+// funcsFor returns the template functions for locale, formatting numbers the
+// way its language does and falling back to English.
+func funcsFor(locale string) texttemplate.FuncMap {
+	language, _, _ := strings.Cut(locale, "-")
+	format, ok := numberFormats[language]
+	if !ok {
+		format = numberFormats["en"]
+	}
+	return texttemplate.FuncMap{
+		"money": func(amount any, currency string) (string, error) {
+			return format.money(amount, currency)
+		},
+	}
+}
+
+// money formats amount with two decimals and grouped thousands, prefixed
+// with the currency code: "USD 1,234.50" in English, "IDR 1.234,50" in
+// Indonesian.
+func (f numberFormat) money(amount any, currency string) (string, error) {
+	value, err := toFloat(amount)
+	if err != nil {
+		return "", err
+	}
+
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	whole, fraction, _ := strings.Cut(strconv.FormatFloat(math.Round(value*100)/100, 'f', 2, 64), ".")
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteString(f.thousands)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	formatted := sign + grouped.String() + f.decimal + fraction
+	if currency == "" {
+		return formatted, nil
+	}
+	return currency + " " + formatted, nil
+}
+
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("money: unsupported amount %T", v)
+	}
+}
@@ -0,0 +1,171 @@
+// Package templates renders notification templates. Templates live in
+// files/<locale>/: <name>.txt is a text/template defining "subject", "text"
+// (the plain-text email) and "sms", and <name>.html is an html/template
+// defining "content", which files/layout.html wraps into the HTML email.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/domain"
+)
+
+//go:embed files
+var files embed.FS
+
+type localeTemplates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+// Renderer renders the embedded templates.
+type Renderer struct {
+	defaultLocale string
+	locales       map[string]*localeTemplates
+}
+
+// New parses the embedded templates. defaultLocale must have every
+// template, since every other locale falls back to it.
+func New(defaultLocale string) (domain.Renderer, error) {
+	layout, err := htmltemplate.ParseFS(files, "files/layout.html")
+	if err != nil {
+		return nil, fmt.Errorf("parse layout: %w", err)
+	}
+
+	entries, err := fs.ReadDir(files, "files")
+	if err != nil {
+		return nil, err
+	}
+	r := &Renderer{defaultLocale: defaultLocale, locales: make(map[string]*localeTemplates)}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		templates, err := parseLocale(entry.Name(), layout)
+		if err != nil {
+			return nil, err
+		}
+		r.locales[entry.Name()] = templates
+	}
+
+	defaults, ok := r.locales[defaultLocale]
+	if !ok {
+		return nil, fmt.Errorf("no templates for default locale %q", defaultLocale)
+	}
+	for name := range domain.TemplateTopics {
+		if defaults.text[name] == nil || defaults.html[name] == nil {
+			return nil, fmt.Errorf("default locale %q has no %s template", defaultLocale, name)
+		}
+	}
+	return r, nil
+}
+
+func parseLocale(locale string, layout *htmltemplate.Template) (*localeTemplates, error) {
+	dir := path.Join("files", locale)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := funcsFor(locale)
+	templates := &localeTemplates{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+	for _, entry := range entries {
+		file := path.Join(dir, entry.Name())
+		name, ext, _ := strings.Cut(entry.Name(), ".")
+		switch ext {
+		case "txt":
+			t, err := texttemplate.New(entry.Name()).Option("missingkey=error").Funcs(funcs).ParseFS(files, file)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", file, err)
+			}
+			templates.text[name] = t
+		case "html":
+			t, err := layout.Clone()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := t.Option("missingkey=error").Funcs(htmltemplate.FuncMap(funcs)).ParseFS(files, file); err != nil {
+				return nil, fmt.Errorf("parse %s: %w", file, err)
+			}
+			templates.html[name] = t
+		}
+	}
+	return templates, nil
+}
+
+func (r *Renderer) Render(template, channel, locale string, data map[string]any) (*domain.Rendered, error) {
+	if _, ok := domain.TemplateTopics[template]; !ok {
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownTemplate, template)
+	}
+
+	locale = r.resolve(template, locale)
+	templates := r.locales[locale]
+	text := templates.text[template]
+	rendered := &domain.Rendered{Locale: locale}
+
+	var err error
+	switch channel {
+	case domain.ChannelSMS:
+		rendered.Text, err = executeText(text, "sms", data)
+	case domain.ChannelEmail:
+		if rendered.Subject, err = executeText(text, "subject", data); err != nil {
+			break
+		}
+		if rendered.Text, err = executeText(text, "text", data); err != nil {
+			break
+		}
+		rendered.HTML, err = r.executeHTML(templates, template, rendered.Subject, data)
+	default:
+		return nil, fmt.Errorf("%w: unknown channel %q", domain.ErrInvalidNotification, channel)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: render %s/%s: %v", domain.ErrInvalidNotification, locale, template, err)
+	}
+	return rendered, nil
+}
+
+// resolve picks the most specific locale that has the template: the locale
+// itself ("id-ID"), its language ("id"), then the default.
+func (r *Renderer) resolve(template, locale string) string {
+	language, _, _ := strings.Cut(locale, "-")
+	for _, candidate := range []string{locale, language} {
+		if t, ok := r.locales[candidate]; ok && t.text[template] != nil && t.html[template] != nil {
+			return candidate
+		}
+	}
+	return r.defaultLocale
+}
+
+func executeText(t *texttemplate.Template, name string, data map[string]any) (string, error) {
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// executeHTML renders the layout around the template's content, with the
+// subject available to the layout as .Subject.
+func (r *Renderer) executeHTML(templates *localeTemplates, template, subject string, data map[string]any) (string, error) {
+	page := make(map[string]any, len(data)+1)
+	for k, v := range data {
+		page[k] = v
+	}
+	page["Subject"] = subject
+
+	var buf bytes.Buffer
+	if err := templates.html[template].ExecuteTemplate(&buf, "layout.html", page); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/domain"
+)
+
+// FileTransport appends each notification to a file as a JSON line. It
+// stands in for providers the service has no integration with, such as SMS,
+// and is handy in development.
+type FileTransport struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileTransport(path string) domain.Transport {
+	return &FileTransport{path: path}
+}
+
+type fileRecord struct {
+	ID        string    `json:"id"`
+	Channel   string    `json:"channel"`
+	Template  string    `json:"template"`
+	Locale    string    `json:"locale"`
+	Recipient string    `json:"recipient"`
+	Subject   string    `json:"subject,omitempty"`
+	Body      string    `json:"body"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+func (t *FileTransport) Send(ctx context.Context, n *domain.Notification) error {
+	line, err := json.Marshal(fileRecord{
+		ID:        n.ID,
+		Channel:   n.Channel,
+		Template:  n.Template,
+		Locale:    n.Locale,
+		Recipient: n.Recipient,
+		Subject:   n.Subject,
+		Body:      n.Body,
+		SentAt:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,102 @@
+// Package transport delivers rendered notifications.
+package transport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/domain"
+)
+
+// SMTPConfig addresses an SMTP server. Username may be empty for servers
+// that accept unauthenticated mail, such as a local MailHog.
+type SMTPConfig struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+// SMTPTransport sends email through an SMTP server.
+type SMTPTransport struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPTransport(cfg SMTPConfig) domain.Transport {
+	return &SMTPTransport{cfg: cfg}
+}
+
+func (t *SMTPTransport) Send(ctx context.Context, n *domain.Notification) error {
+	msg, err := buildMessage(t.cfg.From, n)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if t.cfg.Username != "" {
+		host, _, err := net.SplitHostPort(t.cfg.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", t.cfg.Username, t.cfg.Password, host)
+	}
+
+	// net/smtp takes no context, so the send runs on its own and is
+	// abandoned if ctx ends first.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(t.cfg.Addr, auth, t.cfg.From, []string{n.Recipient}, msg)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// buildMessage builds a multipart/alternative message carrying the plain-text
+// and HTML bodies.
+func buildMessage(from string, n *domain.Notification) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", n.Body},
+		{"text/html; charset=utf-8", n.HTMLBody},
+	} {
+		if part.content == "" {
+			continue
+		}
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", n.Recipient)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@notification-service>\r\n", n.ID)
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
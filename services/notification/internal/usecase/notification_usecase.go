@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/domain"
+	userpb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+type notificationUsecase struct {
+	repo           domain.NotificationRepository
+	renderer       domain.Renderer
+	userGRPCClient userpb.UserServiceClient
+}
+
+func NewNotificationUsecase(repo domain.NotificationRepository, renderer domain.Renderer, userGRPCAddr string) domain.NotificationUsecase {
+	// Connect to user service
+	conn, err := grpc.NewClient(userGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("Failed to connect to user service: %v", err)
+	}
+
+	return &notificationUsecase{
+		repo:           repo,
+		renderer:       renderer,
+		userGRPCClient: userpb.NewUserServiceClient(conn),
+	}
+}
+
+func (u *notificationUsecase) Notify(ctx context.Context, req domain.NotifyRequest) ([]*domain.Notification, error) {
+	topic, ok := domain.TemplateTopics[req.Template]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownTemplate, req.Template)
+	}
+	if req.UserID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", domain.ErrInvalidNotification)
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = uuid.New().String()
+	}
+
+	user, err := u.userGRPCClient.GetUser(ctx, &userpb.GetUserRequest{Id: req.UserID})
+	if err != nil {
+		return nil, userError(req.UserID, err)
+	}
+	preferences, err := u.userGRPCClient.GetNotificationPreferences(ctx, &userpb.GetNotificationPreferencesRequest{UserId: req.UserID})
+	if err != nil {
+		return nil, userError(req.UserID, err)
+	}
+
+	data := make(map[string]any, len(req.Data)+1)
+	for k, v := range req.Data {
+		data[k] = v
+	}
+	if _, ok := data["Name"]; !ok {
+		data["Name"] = user.Name
+	}
+
+	channels := []struct {
+		channel   string
+		enabled   bool
+		recipient string
+	}{
+		{domain.ChannelEmail, preferences.EmailEnabled, user.Email},
+		{domain.ChannelSMS, preferences.SmsEnabled, preferences.Phone},
+	}
+
+	notifications := make([]*domain.Notification, 0, len(channels))
+	for _, c := range channels {
+		n := &domain.Notification{
+			IdempotencyKey: req.IdempotencyKey,
+			UserID:         req.UserID,
+			Channel:        c.channel,
+			Template:       req.Template,
+			Locale:         preferences.Locale,
+			Recipient:      c.recipient,
+			Status:         domain.StatusPending,
+		}
+
+		if reason := skipReason(c.channel, c.enabled, c.recipient, topic, preferences); reason != "" {
+			n.Status = domain.StatusSkipped
+			n.Reason = reason
+		} else {
+			rendered, err := u.renderer.Render(req.Template, c.channel, preferences.Locale, data)
+			if err != nil {
+				return nil, err
+			}
+			n.Locale = rendered.Locale
+			n.Subject = rendered.Subject
+			n.Body = rendered.Text
+			n.HTMLBody = rendered.HTML
+		}
+
+		if err := u.repo.Create(n); errors.Is(err, domain.ErrDuplicateNotification) {
+			// Already queued under this key: report what was queued then.
+			if n, err = u.repo.GetByKey(req.IdempotencyKey, c.channel); err != nil {
+				return nil, err
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, nil
+}
+
+// skipReason says why the user should not get a notification on channel, or
+// returns "" if they should.
+func skipReason(channel string, enabled bool, recipient, topic string, preferences *userpb.NotificationPreferences) string {
+	switch {
+	case !enabled:
+		return channel + " notifications disabled"
+	case topic == domain.TopicOrders && !preferences.OrderUpdates:
+		return "order updates disabled"
+	case topic == domain.TopicPayments && !preferences.PaymentUpdates:
+		return "payment updates disabled"
+	case recipient == "":
+		return "no " + channel + " address"
+	}
+	return ""
+}
+
+func userError(userID string, err error) error {
+	if status.Code(err) == codes.NotFound {
+		return fmt.Errorf("%w: %s", domain.ErrUserNotFound, userID)
+	}
+	return fmt.Errorf("look up user %s: %w", userID, err)
+}
+
+func (u *notificationUsecase) GetNotification(id string) (*domain.Notification, error) {
+	return u.repo.GetByID(id)
+}
+
+func (u *notificationUsecase) ListNotifications(userID string, limit int) ([]*domain.Notification, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", domain.ErrInvalidNotification)
+	}
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	return u.repo.ListByUser(userID, min(limit, maxListLimit))
+}
@@ -0,0 +1,114 @@
+// Package worker contains the notification service's background workers.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/notification/internal/domain"
+)
+
+// DispatcherConfig controls send polling and retries.
+type DispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	MaxAttempts  int
+	// Retries wait BaseBackoff * 2^(attempt-1), capped at MaxBackoff, with
+	// up to 20% jitter.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// NotificationDispatcher sends queued notifications through the transport
+// for their channel. Several instances may run at once: notifications are
+// leased when claimed.
+type NotificationDispatcher struct {
+	cfg        DispatcherConfig
+	repo       domain.NotificationRepository
+	transports map[string]domain.Transport
+}
+
+// NewNotificationDispatcher returns a dispatcher sending each channel's
+// notifications through transports[channel]. Notifications on a channel
+// without a transport fail.
+func NewNotificationDispatcher(repo domain.NotificationRepository, transports map[string]domain.Transport, cfg DispatcherConfig) *NotificationDispatcher {
+	return &NotificationDispatcher{cfg: cfg, repo: repo, transports: transports}
+}
+
+// Run polls for due notifications until ctx is cancelled.
+func (d *NotificationDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatchDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *NotificationDispatcher) dispatchDue(ctx context.Context) {
+	// The lease must outlast one send per claimed notification.
+	lease := d.cfg.Timeout*time.Duration(d.cfg.BatchSize) + time.Minute
+	notifications, err := d.repo.ClaimDue(time.Now(), lease, d.cfg.BatchSize)
+	if err != nil {
+		log.Printf("Failed to claim notifications: %v", err)
+		return
+	}
+
+	for _, n := range notifications {
+		if ctx.Err() != nil {
+			return
+		}
+		d.attempt(ctx, n)
+	}
+}
+
+func (d *NotificationDispatcher) attempt(ctx context.Context, n *domain.Notification) {
+	transport, ok := d.transports[n.Channel]
+
+	var err error
+	if ok {
+		sendCtx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+		err = transport.Send(sendCtx, n)
+		cancel()
+	}
+
+	n.Attempts++
+	switch {
+	case !ok:
+		n.Status = domain.StatusFailed
+		n.Reason = fmt.Sprintf("no transport for channel %q", n.Channel)
+	case err == nil:
+		now := time.Now()
+		n.Status = domain.StatusSent
+		n.Reason = ""
+		n.SentAt = &now
+	case n.Attempts >= d.cfg.MaxAttempts:
+		n.Status = domain.StatusFailed
+		n.Reason = err.Error()
+	default:
+		n.Reason = err.Error()
+		n.NextAttemptAt = time.Now().Add(d.backoff(n.Attempts))
+	}
+
+	if err := d.repo.RecordAttempt(n); err != nil {
+		log.Printf("Failed to record attempt for notification %s: %v", n.ID, err)
+	}
+}
+
+func (d *NotificationDispatcher) backoff(attempts int) time.Duration {
+	wait := d.cfg.BaseBackoff
+	for i := 1; i < attempts && wait < d.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, d.cfg.MaxBackoff)
+	return wait + time.Duration(rand.Int63n(int64(wait)/5+1))
+}
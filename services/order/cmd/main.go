@@ -1,21 +1,35 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net"
 	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus"
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
+	"github.com/edwinjordan/golang_microservices/pkg/restproxy"
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
 	"github.com/edwinjordan/golang_microservices/services/order/internal/config"
+	eventsHandler "github.com/edwinjordan/golang_microservices/services/order/internal/delivery/events"
 	grpcHandler "github.com/edwinjordan/golang_microservices/services/order/internal/delivery/grpc"
 	httpHandler "github.com/edwinjordan/golang_microservices/services/order/internal/delivery/http"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
 	"github.com/edwinjordan/golang_microservices/services/order/internal/repository"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/tax"
 	"github.com/edwinjordan/golang_microservices/services/order/internal/usecase"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/worker"
 	pb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	"github.com/edwinjordan/golang_microservices/services/user/pkg/auth"
+	userpb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
@@ -48,7 +62,69 @@ func main() {
 
 	// Initialize layers
 	orderRepo := repository.NewPostgresOrderRepository(db)
-	orderUsecase := usecase.NewOrderUsecase(orderRepo, cfg.UserGRPCAddr)
+	subscriptionRepo := repository.NewPostgresWebhookSubscriptionRepository(db)
+	deliveryRepo := repository.NewPostgresWebhookDeliveryRepository(db)
+	webhookUsecase := usecase.NewWebhookUsecase(subscriptionRepo, deliveryRepo, cfg.WebhookAllowPrivateURLs)
+	promotionRepo := repository.NewPostgresPromotionRepository(db)
+	promotionUsecase := usecase.NewPromotionUsecase(promotionRepo)
+	taxCalculator := newTaxCalculator(cfg)
+	orderUsecase := usecase.NewOrderUsecase(orderRepo, promotionRepo, taxCalculator, webhookUsecase, cfg.UserGRPCAddr, cfg.CatalogGRPCAddr, cfg.InventoryGRPCAddr, pagination.NewCodec(cfg.PageTokenSecret))
+	sagaRepo := repository.NewPostgresSagaRepository(db)
+	sagaUsecase := usecase.NewSagaUsecase(sagaRepo, orderUsecase, cfg.PaymentGRPCAddr, cfg.InventoryGRPCAddr, usecase.SagaConfig{
+		StepTimeout: cfg.SagaStepTimeout,
+		MaxAttempts: cfg.SagaMaxAttempts,
+		BaseBackoff: cfg.SagaBaseBackoff,
+		MaxBackoff:  cfg.SagaMaxBackoff,
+	})
+	shipmentRepo := repository.NewPostgresShipmentRepository(db)
+	fulfillmentUsecase := usecase.NewFulfillmentUsecase(orderRepo, shipmentRepo, webhookUsecase)
+
+	// Start webhook delivery worker
+	dispatcher := worker.NewWebhookDispatcher(subscriptionRepo, deliveryRepo, worker.DispatcherConfig{
+		PollInterval:     cfg.WebhookPollInterval,
+		BatchSize:        cfg.WebhookBatchSize,
+		Timeout:          cfg.WebhookTimeout,
+		MaxAttempts:      cfg.WebhookMaxAttempts,
+		BaseBackoff:      cfg.WebhookBaseBackoff,
+		MaxBackoff:       cfg.WebhookMaxBackoff,
+		AllowPrivateURLs: cfg.WebhookAllowPrivateURLs,
+	})
+	go dispatcher.Run(context.Background())
+
+	// Start unpaid order expiry
+	expirer := worker.NewOrderExpirer(orderUsecase, cfg.PaymentWindow, cfg.ExpiryPollInterval, cfg.ExpiryBatchSize)
+	go expirer.Run(context.Background())
+
+	// Start saga retry worker
+	sagaRunner := worker.NewSagaRunner(sagaUsecase, cfg.SagaPollInterval, cfg.SagaBatchSize)
+	go sagaRunner.Run(context.Background())
+
+	// Connect to event bus
+	bus, err := eventbus.Open(context.Background(), eventbus.Config{
+		Driver: cfg.EventBusDriver,
+		URL:    cfg.EventBusURL,
+		Stream: cfg.EventBusStream,
+		Name:   eventsHandler.Source,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to event bus: %v", err)
+	}
+	defer bus.Close()
+
+	// Subscribe to payment events
+	subscriber := eventsHandler.NewSubscriber(orderUsecase, webhookUsecase)
+	if err := subscriber.Register(context.Background(), bus, db); err != nil {
+		log.Fatalf("Failed to subscribe to events: %v", err)
+	}
+
+	// Start outbox relay
+	publisher := eventbus.OutboxPublisher(bus, eventsHandler.Source, eventsHandler.Translate)
+	relay := outbox.NewRelay(db, publisher, outbox.RelayConfig{
+		PollInterval: cfg.OutboxPollInterval,
+		BatchSize:    cfg.OutboxBatchSize,
+		MaxAttempts:  cfg.OutboxMaxAttempts,
+	})
+	go relay.Run(context.Background())
 
 	// Start gRPC server
 	go func() {
@@ -57,8 +133,8 @@ func main() {
 			log.Fatalf("Failed to listen on gRPC port: %v", err)
 		}
 
-		grpcServer := grpc.NewServer()
-		orderGRPCHandler := grpcHandler.NewOrderGRPCHandler(orderUsecase)
+		grpcServer := grpc.NewServer(grpc.UnaryInterceptor(validate.UnaryServerInterceptor()))
+		orderGRPCHandler := grpcHandler.NewOrderGRPCHandler(orderUsecase, webhookUsecase, fulfillmentUsecase)
 		pb.RegisterOrderServiceServer(grpcServer, orderGRPCHandler)
 
 		log.Printf("gRPC server listening on port %s", cfg.GRPCPort)
@@ -69,16 +145,86 @@ func main() {
 
 	// Start HTTP server
 	router := gin.Default()
+	// Admin routes check the caller's access token with the user service
+	userConn, err := grpc.NewClient(cfg.UserGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to connect to user service: %v", err)
+	}
+	defer userConn.Close()
+	introspector := auth.NewIntrospector(userpb.NewUserServiceClient(userConn), cfg.AuthCacheTTL)
+	registerRoutes(router, introspector, orderUsecase, webhookUsecase, sagaUsecase, fulfillmentUsecase, promotionUsecase)
+
+	// REST routes generated from the HTTP rules in proto/order.proto, proxied
+	// to the gRPC server
+	restProxy, err := restproxy.New(context.Background(), "localhost:"+cfg.GRPCPort, pb.RegisterOrderServiceHandlerFromEndpoint)
+	if err != nil {
+		log.Fatalf("Failed to start REST proxy: %v", err)
+	}
+	router.Any(restproxy.Prefix+"/*path", gin.WrapH(restProxy))
+
+	if err := openapi.Mount(router, httpHandler.API(), restproxy.Prefix); err != nil {
+		log.Fatalf("Failed to serve OpenAPI document: %v", err)
+	}
+
+	log.Printf("HTTP server listening on port %s", cfg.HTTPPort)
+	if err := router.Run(fmt.Sprintf(":%s", cfg.HTTPPort)); err != nil {
+		log.Fatalf("Failed to start HTTP server: %v", err)
+	}
+}
+
+// registerRoutes registers the hand-written HTTP routes. main adds the
+// generated REST routes and the OpenAPI document.
+func registerRoutes(router *gin.Engine, introspector *auth.Introspector, orderUsecase domain.OrderUsecase, webhookUsecase domain.WebhookUsecase, sagaUsecase domain.SagaUsecase, fulfillmentUsecase domain.FulfillmentUsecase, promotionUsecase domain.PromotionUsecase) {
 	orderHandler := httpHandler.NewOrderHandler(orderUsecase)
+	webhookHandler := httpHandler.NewWebhookHandler(webhookUsecase)
+	sagaHandler := httpHandler.NewSagaHandler(sagaUsecase)
+	fulfillmentHandler := httpHandler.NewFulfillmentHandler(fulfillmentUsecase)
+	promotionHandler := httpHandler.NewPromotionHandler(promotionUsecase)
 
 	router.GET("/health", orderHandler.Health)
 	router.POST("/orders", orderHandler.CreateOrder)
+	router.GET("/orders", orderHandler.ListOrders)
 	router.GET("/orders/:id", orderHandler.GetOrder)
+	router.POST("/orders/:id/cancel", orderHandler.CancelOrder)
+	router.GET("/orders/:id/fulfillment", fulfillmentHandler.GetFulfillment)
+	router.POST("/orders/:id/shipments", fulfillmentHandler.CreateShipment)
+	router.GET("/orders/:id/shipments/:shipment_id", fulfillmentHandler.GetShipment)
+	router.POST("/orders/:id/shipments/:shipment_id/events", fulfillmentHandler.AddShipmentEvent)
+	router.GET("/promotions", promotionHandler.ListPromotions)
+	router.GET("/promotions/:code", promotionHandler.GetPromotion)
+	router.POST("/checkout", sagaHandler.Checkout)
+	router.GET("/sagas", sagaHandler.ListSagas)
+	router.GET("/sagas/:id", sagaHandler.GetSaga)
 
-	log.Printf("HTTP server listening on port %s", cfg.HTTPPort)
-	if err := router.Run(fmt.Sprintf(":%s", cfg.HTTPPort)); err != nil {
-		log.Fatalf("Failed to start HTTP server: %v", err)
+	admin := router.Group("/", httpHandler.RequireAuth(introspector), httpHandler.RequireRole(auth.RoleAdmin))
+	admin.POST("/promotions", promotionHandler.CreatePromotion)
+	admin.PATCH("/promotions/:code", promotionHandler.UpdatePromotion)
+	admin.POST("/webhooks/subscriptions", webhookHandler.CreateSubscription)
+	admin.GET("/webhooks/subscriptions", webhookHandler.ListSubscriptions)
+	admin.DELETE("/webhooks/subscriptions/:id", webhookHandler.DeleteSubscription)
+	admin.GET("/webhooks/deliveries/dead", webhookHandler.ListDeadLetters)
+	admin.GET("/webhooks/deliveries/:id/attempts", webhookHandler.ListAttempts)
+	admin.POST("/webhooks/deliveries/:id/redrive", webhookHandler.RedriveDelivery)
+}
+
+// newTaxCalculator loads the rate table named by ORDER_TAX_TABLE. Without
+// one no tax is charged.
+func newTaxCalculator(cfg *config.Config) domain.TaxCalculator {
+	if cfg.TaxTable == "" {
+		log.Println("No tax table configured, orders will not be taxed")
+		calculator, err := tax.NewTable(tax.TableConfig{Pricing: domain.PricingTaxExclusive})
+		if err != nil {
+			log.Fatalf("Failed to create tax table: %v", err)
+		}
+		return calculator
 	}
+
+	calculator, err := tax.LoadTable(cfg.TaxTable)
+	if err != nil {
+		log.Fatalf("Failed to load tax table: %v", err)
+	}
+	log.Printf("Using tax table %s", cfg.TaxTable)
+	return calculator
 }
 
 func initSchema(db *sql.DB) {
@@ -87,13 +233,186 @@ func initSchema(db *sql.DB) {
 		id VARCHAR(36) PRIMARY KEY,
 		user_id VARCHAR(36) NOT NULL,
 		product VARCHAR(255) NOT NULL,
-		amount DECIMAL(10, 2) NOT NULL,
+		amount NUMERIC(19, 4) NOT NULL,
 		status VARCHAR(50) NOT NULL,
 		created_at TIMESTAMP NOT NULL,
 		updated_at TIMESTAMP NOT NULL
 	);
+
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS sku VARCHAR(64) NOT NULL DEFAULT '';
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS quantity INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS unit_price NUMERIC(19, 4) NOT NULL DEFAULT 0;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_address JSONB;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS subtotal NUMERIC(19, 4);
+	UPDATE orders SET subtotal = amount WHERE subtotal IS NULL;
+	ALTER TABLE orders ALTER COLUMN subtotal SET NOT NULL;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(19, 4) NOT NULL DEFAULT 0;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(19, 4) NOT NULL DEFAULT 0;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS prices_include_tax BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_lines JSONB NOT NULL DEFAULT '[]';
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+	CREATE INDEX IF NOT EXISTS idx_orders_user_id_created_at ON orders (user_id, created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_orders_status_created_at ON orders (status, created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders (created_at, id);
+	DROP INDEX IF EXISTS idx_orders_amount;
+	CREATE INDEX IF NOT EXISTS idx_orders_currency_amount ON orders (currency, amount, id);
+	-- Money was DECIMAL(10, 2), which overflows for currencies such as IDR
+	-- and drops the third decimal of KWD.
+	ALTER TABLE orders
+		ALTER COLUMN amount TYPE NUMERIC(19, 4),
+		ALTER COLUMN unit_price TYPE NUMERIC(19, 4),
+		ALTER COLUMN subtotal TYPE NUMERIC(19, 4),
+		ALTER COLUMN discount_amount TYPE NUMERIC(19, 4),
+		ALTER COLUMN tax_amount TYPE NUMERIC(19, 4);
+
+	CREATE TABLE IF NOT EXISTS order_payments (
+		payment_id VARCHAR(36) PRIMARY KEY,
+		order_id VARCHAR(36) NOT NULL,
+		currency VARCHAR(3) NOT NULL,
+		captured_amount NUMERIC(19, 4) NOT NULL DEFAULT 0,
+		refunded_amount NUMERIC(19, 4) NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_order_payments_order_id ON order_payments (order_id);
+
+	CREATE TABLE IF NOT EXISTS promotions (
+		id VARCHAR(36) PRIMARY KEY,
+		code VARCHAR(64) NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		type VARCHAR(20) NOT NULL,
+		value NUMERIC(19, 4) NOT NULL,
+		currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+		min_order_amount NUMERIC(19, 4) NOT NULL DEFAULT 0,
+		max_redemptions INT NOT NULL DEFAULT 0,
+		per_user_limit INT NOT NULL DEFAULT 0,
+		redemptions INT NOT NULL DEFAULT 0 CHECK (redemptions >= 0),
+		starts_at TIMESTAMP,
+		ends_at TIMESTAMP,
+		stackable BOOLEAN NOT NULL DEFAULT FALSE,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	ALTER TABLE promotions ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+	CREATE INDEX IF NOT EXISTS idx_promotions_created_at ON promotions (created_at, id);
+	ALTER TABLE promotions
+		ALTER COLUMN value TYPE NUMERIC(19, 4),
+		ALTER COLUMN min_order_amount TYPE NUMERIC(19, 4);
+
+	CREATE TABLE IF NOT EXISTS promotion_redemptions (
+		id VARCHAR(36) PRIMARY KEY,
+		promotion_id VARCHAR(36) NOT NULL REFERENCES promotions (id),
+		user_id VARCHAR(36) NOT NULL,
+		order_id VARCHAR(36) NOT NULL REFERENCES orders (id),
+		created_at TIMESTAMP NOT NULL,
+		released_at TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_user ON promotion_redemptions (promotion_id, user_id) WHERE released_at IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_order_id ON promotion_redemptions (order_id);
+
+	CREATE TABLE IF NOT EXISTS order_discounts (
+		order_id VARCHAR(36) NOT NULL REFERENCES orders (id),
+		promotion_id VARCHAR(36) NOT NULL REFERENCES promotions (id),
+		code VARCHAR(64) NOT NULL,
+		type VARCHAR(20) NOT NULL,
+		value NUMERIC(19, 4) NOT NULL,
+		amount NUMERIC(19, 4) NOT NULL,
+		PRIMARY KEY (order_id, promotion_id)
+	);
+	ALTER TABLE order_discounts
+		ALTER COLUMN value TYPE NUMERIC(19, 4),
+		ALTER COLUMN amount TYPE NUMERIC(19, 4);
+
+	CREATE TABLE IF NOT EXISTS shipments (
+		id VARCHAR(36) PRIMARY KEY,
+		order_id VARCHAR(36) NOT NULL REFERENCES orders (id),
+		carrier VARCHAR(64) NOT NULL,
+		tracking_number VARCHAR(128) NOT NULL,
+		quantity INTEGER NOT NULL CHECK (quantity > 0),
+		status VARCHAR(20) NOT NULL,
+		shipped_at TIMESTAMP,
+		delivered_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_shipments_order_id ON shipments (order_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS shipment_events (
+		id VARCHAR(36) PRIMARY KEY,
+		shipment_id VARCHAR(36) NOT NULL REFERENCES shipments (id) ON DELETE CASCADE,
+		status VARCHAR(20) NOT NULL,
+		location VARCHAR(255) NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		occurred_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_shipment_events_shipment_id ON shipment_events (shipment_id, occurred_at);
+
+	CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+		id VARCHAR(36) PRIMARY KEY,
+		url TEXT NOT NULL,
+		event_types TEXT[] NOT NULL,
+		secret VARCHAR(100) NOT NULL,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id VARCHAR(36) PRIMARY KEY,
+		subscription_id VARCHAR(36) NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+		event_id VARCHAR(36) NOT NULL,
+		event_type VARCHAR(100) NOT NULL,
+		payload BYTEA NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMP NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		last_status_code INT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		delivered_at TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+
+	CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+		id VARCHAR(36) PRIMARY KEY,
+		delivery_id VARCHAR(36) NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+		status_code INT NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		duration_ms BIGINT NOT NULL,
+		attempted_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts (delivery_id, attempted_at);
+
+	CREATE TABLE IF NOT EXISTS sagas (
+		id VARCHAR(36) PRIMARY KEY,
+		type VARCHAR(50) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		step VARCHAR(50) NOT NULL,
+		data JSONB NOT NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		next_attempt_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_sagas_due ON sagas (status, next_attempt_at);
+	CREATE INDEX IF NOT EXISTS idx_sagas_created_at ON sagas (created_at, id);
+
+	CREATE TABLE IF NOT EXISTS saga_steps (
+		seq BIGSERIAL PRIMARY KEY,
+		id VARCHAR(36) NOT NULL UNIQUE,
+		saga_id VARCHAR(36) NOT NULL REFERENCES sagas (id) ON DELETE CASCADE,
+		step VARCHAR(50) NOT NULL,
+		action VARCHAR(20) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_saga_steps_saga_id ON saga_steps (saga_id, seq);
 	`
-	_, err := db.Exec(schema)
+	_, err := db.Exec(schema + outbox.Schema + eventbus.InboxSchema)
 	if err != nil {
 		log.Fatalf("Failed to initialize schema: %v", err)
 	}
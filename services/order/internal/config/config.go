@@ -3,29 +3,103 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
-	DBHost        string
-	DBPort        string
-	DBUser        string
-	DBPassword    string
-	DBName        string
-	HTTPPort      string
-	GRPCPort      string
-	UserGRPCAddr  string
+	DBHost            string
+	DBPort            string
+	DBUser            string
+	DBPassword        string
+	DBName            string
+	HTTPPort          string
+	GRPCPort          string
+	UserGRPCAddr      string
+	PaymentGRPCAddr   string
+	CatalogGRPCAddr   string
+	InventoryGRPCAddr string
+	PageTokenSecret   string
+	TaxTable          string
+
+	WebhookPollInterval time.Duration
+	WebhookBatchSize    int
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+	WebhookBaseBackoff  time.Duration
+	WebhookMaxBackoff   time.Duration
+	// WebhookAllowPrivateURLs lets subscriptions point at loopback,
+	// link-local and private addresses. Only for local development.
+	WebhookAllowPrivateURLs bool
+
+	// AuthCacheTTL is how long a checked access token is trusted before the
+	// user service is asked again.
+	AuthCacheTTL time.Duration
+
+	PaymentWindow      time.Duration
+	ExpiryPollInterval time.Duration
+	ExpiryBatchSize    int
+
+	SagaPollInterval time.Duration
+	SagaBatchSize    int
+	SagaStepTimeout  time.Duration
+	SagaMaxAttempts  int
+	SagaBaseBackoff  time.Duration
+	SagaMaxBackoff   time.Duration
+
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+	OutboxMaxAttempts  int
+
+	EventBusDriver string
+	EventBusURL    string
+	EventBusStream string
 }
 
 func LoadConfig() *Config {
 	return &Config{
-		DBHost:       getEnv("ORDER_DB_HOST", "localhost"),
-		DBPort:       getEnv("ORDER_DB_PORT", "5432"),
-		DBUser:       getEnv("ORDER_DB_USER", "orderservice"),
-		DBPassword:   getEnv("ORDER_DB_PASSWORD", "orderpass123"),
-		DBName:       getEnv("ORDER_DB_NAME", "orders_db"),
-		HTTPPort:     getEnv("ORDER_SERVICE_HTTP_PORT", "8082"),
-		GRPCPort:     getEnv("ORDER_SERVICE_GRPC_PORT", "9092"),
-		UserGRPCAddr: getEnv("USER_GRPC_ADDR", "localhost:9091"),
+		DBHost:            getEnv("ORDER_DB_HOST", "localhost"),
+		DBPort:            getEnv("ORDER_DB_PORT", "5432"),
+		DBUser:            getEnv("ORDER_DB_USER", "orderservice"),
+		DBPassword:        getEnv("ORDER_DB_PASSWORD", "orderpass123"),
+		DBName:            getEnv("ORDER_DB_NAME", "orders_db"),
+		HTTPPort:          getEnv("ORDER_SERVICE_HTTP_PORT", "8082"),
+		GRPCPort:          getEnv("ORDER_SERVICE_GRPC_PORT", "9092"),
+		UserGRPCAddr:      getEnv("USER_GRPC_ADDR", "localhost:9091"),
+		PaymentGRPCAddr:   getEnv("PAYMENT_GRPC_ADDR", "localhost:9093"),
+		CatalogGRPCAddr:   getEnv("CATALOG_GRPC_ADDR", "localhost:9094"),
+		InventoryGRPCAddr: getEnv("INVENTORY_GRPC_ADDR", "localhost:9095"),
+		PageTokenSecret:   getEnv("ORDER_PAGE_TOKEN_SECRET", "change-me-page-token-secret"),
+		TaxTable:          getEnv("ORDER_TAX_TABLE", ""),
+
+		WebhookPollInterval:     getEnvDuration("ORDER_WEBHOOK_POLL_INTERVAL", 5*time.Second),
+		WebhookBatchSize:        getEnvInt("ORDER_WEBHOOK_BATCH_SIZE", 20),
+		WebhookTimeout:          getEnvDuration("ORDER_WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxAttempts:      getEnvInt("ORDER_WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookBaseBackoff:      getEnvDuration("ORDER_WEBHOOK_BASE_BACKOFF", 30*time.Second),
+		WebhookMaxBackoff:       getEnvDuration("ORDER_WEBHOOK_MAX_BACKOFF", time.Hour),
+		WebhookAllowPrivateURLs: getEnvBool("ORDER_WEBHOOK_ALLOW_PRIVATE_URLS", false),
+
+		AuthCacheTTL: getEnvDuration("ORDER_AUTH_CACHE_TTL", 30*time.Second),
+
+		PaymentWindow:      getEnvDuration("ORDER_PAYMENT_WINDOW", 30*time.Minute),
+		ExpiryPollInterval: getEnvDuration("ORDER_EXPIRY_POLL_INTERVAL", time.Minute),
+		ExpiryBatchSize:    getEnvInt("ORDER_EXPIRY_BATCH_SIZE", 100),
+
+		SagaPollInterval: getEnvDuration("ORDER_SAGA_POLL_INTERVAL", 5*time.Second),
+		SagaBatchSize:    getEnvInt("ORDER_SAGA_BATCH_SIZE", 20),
+		SagaStepTimeout:  getEnvDuration("ORDER_SAGA_STEP_TIMEOUT", 10*time.Second),
+		SagaMaxAttempts:  getEnvInt("ORDER_SAGA_MAX_ATTEMPTS", 5),
+		SagaBaseBackoff:  getEnvDuration("ORDER_SAGA_BASE_BACKOFF", 5*time.Second),
+		SagaMaxBackoff:   getEnvDuration("ORDER_SAGA_MAX_BACKOFF", 5*time.Minute),
+
+		OutboxPollInterval: getEnvDuration("ORDER_OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxBatchSize:    getEnvInt("ORDER_OUTBOX_BATCH_SIZE", 100),
+		OutboxMaxAttempts:  getEnvInt("ORDER_OUTBOX_MAX_ATTEMPTS", 10),
+
+		EventBusDriver: getEnv("EVENT_BUS_DRIVER", "memory"),
+		EventBusURL:    getEnv("EVENT_BUS_URL", "nats://localhost:4222"),
+		EventBusStream: getEnv("EVENT_BUS_STREAM", "EVENTS"),
 	}
 }
 
@@ -40,3 +114,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
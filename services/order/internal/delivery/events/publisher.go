@@ -0,0 +1,56 @@
+// Package events connects the order service to the event bus: it translates
+// outbox events into bus payloads and reacts to payment events.
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Source names the order service on published envelopes.
+const Source = "order-service"
+
+// Translate converts an order outbox event into its bus payload.
+func Translate(event outbox.Event) (proto.Message, error) {
+	var order domain.Order
+	switch event.Type {
+	case domain.EventOrderCreated:
+		if err := json.Unmarshal(event.Payload, &order); err != nil {
+			return nil, err
+		}
+		return &eventspb.OrderCreated{Order: toPBOrder(&order)}, nil
+	case domain.EventOrderUpdated:
+		if err := json.Unmarshal(event.Payload, &order); err != nil {
+			return nil, err
+		}
+		return &eventspb.OrderUpdated{Order: toPBOrder(&order)}, nil
+	case domain.EventOrderExpired:
+		if err := json.Unmarshal(event.Payload, &order); err != nil {
+			return nil, err
+		}
+		return &eventspb.OrderExpired{Order: toPBOrder(&order)}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func toPBOrder(order *domain.Order) *eventspb.Order {
+	return &eventspb.Order{
+		Id:        order.ID,
+		UserId:    order.UserID,
+		Product:   order.Product,
+		Sku:       order.SKU,
+		Quantity:  int32(order.Quantity),
+		UnitPrice: order.UnitPrice,
+		Amount:    order.Amount,
+		Currency:  order.Currency,
+		Status:    order.Status,
+		CreatedAt: timestamppb.New(order.CreatedAt),
+		UpdatedAt: timestamppb.New(order.UpdatedAt),
+	}
+}
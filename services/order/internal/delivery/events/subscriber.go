@@ -0,0 +1,106 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus"
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Group is the order service's consumer group.
+const Group = "order-service"
+
+// paymentStatusCaptured mirrors the payment service's status of that name.
+const paymentStatusCaptured = "captured"
+
+type Subscriber struct {
+	orderUsecase   domain.OrderUsecase
+	webhookUsecase domain.WebhookUsecase
+}
+
+func NewSubscriber(orderUsecase domain.OrderUsecase, webhookUsecase domain.WebhookUsecase) *Subscriber {
+	return &Subscriber{
+		orderUsecase:   orderUsecase,
+		webhookUsecase: webhookUsecase,
+	}
+}
+
+// Register subscribes the order service to the payment events it reacts to.
+func (s *Subscriber) Register(ctx context.Context, bus eventbus.Bus, db *sql.DB) error {
+	types := []string{eventbus.PaymentUpdated, eventbus.PaymentRefunded}
+	return bus.Subscribe(ctx, Group, types, eventbus.Dedupe(db, Group, s.Handle))
+}
+
+// Handle records the captured and refunded amounts of each payment, marks
+// orders paid when their payment is fully captured, and moves them to
+// partially refunded or refunded from the refunds across all their
+// payments, forwarding refunds to webhook subscribers. Closed orders keep
+// their status.
+func (s *Subscriber) Handle(ctx context.Context, env *eventspb.Envelope) error {
+	msg, err := eventbus.Decode(env)
+	if err != nil {
+		return err
+	}
+
+	switch event := msg.(type) {
+	case *eventspb.PaymentUpdated:
+		payment := event.GetPayment()
+		if payment.GetStatus() != paymentStatusCaptured {
+			return nil
+		}
+		if _, err := s.orderUsecase.RecordPayment(payment.GetOrderId(), orderPayment(payment)); err != nil && !stale(err) {
+			return err
+		}
+		// The payment service only authorizes the order total, so a full
+		// capture pays the order. A partial one leaves it pending, and it
+		// is refunded when the order expires.
+		if payment.GetCapturedAmount() < payment.GetAmount() {
+			log.Printf("Payment %s captured %.2f of %.2f %s; order %s stays pending",
+				payment.GetId(), payment.GetCapturedAmount(), payment.GetAmount(), payment.GetCurrency(), payment.GetOrderId())
+			return nil
+		}
+		// A capture that raced with cancellation or expiry is refunded by
+		// the payment service; the order stays closed. A capture seen after
+		// a refund is stale.
+		_, err := s.orderUsecase.UpdateOrderStatus(payment.GetOrderId(), domain.OrderStatusPaid)
+		if stale(err) {
+			return nil
+		}
+		return err
+	case *eventspb.PaymentRefunded:
+		payment := event.GetPayment()
+		_, err := s.orderUsecase.RecordPayment(payment.GetOrderId(), orderPayment(payment))
+		if err != nil && !stale(err) {
+			return err
+		}
+
+		data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(event)
+		if err != nil {
+			return err
+		}
+		return s.webhookUsecase.Publish(domain.EventPaymentRefunded, json.RawMessage(data))
+	}
+	return nil
+}
+
+func orderPayment(payment *eventspb.Payment) domain.OrderPayment {
+	return domain.OrderPayment{
+		PaymentID:      payment.GetId(),
+		Currency:       payment.GetCurrency(),
+		CapturedAmount: payment.GetCapturedAmount(),
+		RefundedAmount: payment.GetRefundedAmount(),
+	}
+}
+
+// stale reports whether an order refused a status because it has already
+// moved past it. ErrOrderConflict is not stale: the event is redelivered and
+// applied to the order as it now is.
+func stale(err error) bool {
+	return errors.Is(err, domain.ErrOrderClosed) || errors.Is(err, domain.ErrInvalidOrderTransition)
+}
@@ -0,0 +1,112 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *OrderGRPCHandler) CreateShipment(ctx context.Context, req *pb.CreateShipmentRequest) (*pb.Shipment, error) {
+	shipment, err := h.fulfillmentUsecase.CreateShipment(req.OrderId, domain.CreateShipmentRequest{
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+		Quantity:       int(req.Quantity),
+	})
+	if err != nil {
+		return nil, fulfillmentError(err)
+	}
+	return toPBShipment(shipment), nil
+}
+
+func (h *OrderGRPCHandler) GetShipment(ctx context.Context, req *pb.GetShipmentRequest) (*pb.Shipment, error) {
+	shipment, err := h.fulfillmentUsecase.GetShipment(req.OrderId, req.Id)
+	if err != nil {
+		return nil, fulfillmentError(err)
+	}
+	return toPBShipment(shipment), nil
+}
+
+func (h *OrderGRPCHandler) GetFulfillment(ctx context.Context, req *pb.GetFulfillmentRequest) (*pb.Fulfillment, error) {
+	fulfillment, err := h.fulfillmentUsecase.GetFulfillment(req.OrderId)
+	if err != nil {
+		return nil, fulfillmentError(err)
+	}
+
+	resp := &pb.Fulfillment{
+		OrderId:           fulfillment.OrderID,
+		Status:            fulfillment.Status,
+		Quantity:          int32(fulfillment.Quantity),
+		ShippedQuantity:   int32(fulfillment.ShippedQuantity),
+		DeliveredQuantity: int32(fulfillment.DeliveredQuantity),
+		Shipments:         make([]*pb.Shipment, 0, len(fulfillment.Shipments)),
+	}
+	for _, shipment := range fulfillment.Shipments {
+		resp.Shipments = append(resp.Shipments, toPBShipment(shipment))
+	}
+	return resp, nil
+}
+
+func (h *OrderGRPCHandler) UpdateShipmentStatus(ctx context.Context, req *pb.UpdateShipmentStatusRequest) (*pb.Shipment, error) {
+	event := domain.ShipmentEvent{
+		Status:      req.Status,
+		Location:    req.Location,
+		Description: req.Description,
+	}
+	if req.OccurredAt != nil {
+		event.OccurredAt = req.OccurredAt.AsTime()
+	}
+
+	shipment, err := h.fulfillmentUsecase.UpdateShipmentStatus(req.OrderId, req.Id, event)
+	if err != nil {
+		return nil, fulfillmentError(err)
+	}
+	return toPBShipment(shipment), nil
+}
+
+func toPBShipment(shipment *domain.Shipment) *pb.Shipment {
+	resp := &pb.Shipment{
+		Id:             shipment.ID,
+		OrderId:        shipment.OrderID,
+		Carrier:        shipment.Carrier,
+		TrackingNumber: shipment.TrackingNumber,
+		Quantity:       int32(shipment.Quantity),
+		Status:         shipment.Status,
+		CreatedAt:      timestamppb.New(shipment.CreatedAt),
+		UpdatedAt:      timestamppb.New(shipment.UpdatedAt),
+		Events:         make([]*pb.ShipmentEvent, 0, len(shipment.Events)),
+	}
+	if shipment.ShippedAt != nil {
+		resp.ShippedAt = timestamppb.New(*shipment.ShippedAt)
+	}
+	if shipment.DeliveredAt != nil {
+		resp.DeliveredAt = timestamppb.New(*shipment.DeliveredAt)
+	}
+	for _, event := range shipment.Events {
+		resp.Events = append(resp.Events, &pb.ShipmentEvent{
+			Id:          event.ID,
+			Status:      event.Status,
+			Location:    event.Location,
+			Description: event.Description,
+			OccurredAt:  timestamppb.New(event.OccurredAt),
+		})
+	}
+	return resp
+}
+
+func fulfillmentError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound), errors.Is(err, domain.ErrShipmentNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrOrderNotShippable), errors.Is(err, domain.ErrNoShippingAddress),
+		errors.Is(err, domain.ErrShipmentExceedsOrder), errors.Is(err, domain.ErrInvalidShipmentTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrInvalidShipmentStatus):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
@@ -2,48 +2,231 @@ package grpc
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 
 	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
 	pb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type OrderGRPCHandler struct {
 	pb.UnimplementedOrderServiceServer
-	orderUsecase domain.OrderUsecase
+	orderUsecase       domain.OrderUsecase
+	webhookUsecase     domain.WebhookUsecase
+	fulfillmentUsecase domain.FulfillmentUsecase
 }
 
-func NewOrderGRPCHandler(orderUsecase domain.OrderUsecase) *OrderGRPCHandler {
+func NewOrderGRPCHandler(orderUsecase domain.OrderUsecase, webhookUsecase domain.WebhookUsecase, fulfillmentUsecase domain.FulfillmentUsecase) *OrderGRPCHandler {
 	return &OrderGRPCHandler{
-		orderUsecase: orderUsecase,
+		orderUsecase:       orderUsecase,
+		webhookUsecase:     webhookUsecase,
+		fulfillmentUsecase: fulfillmentUsecase,
 	}
 }
 
 func (h *OrderGRPCHandler) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
 	order, err := h.orderUsecase.GetOrder(req.Id)
 	if err != nil {
-		return nil, err
+		return nil, orderError(err)
 	}
 
 	return &pb.GetOrderResponse{
-		Id:      order.ID,
-		UserId:  order.UserID,
-		Product: order.Product,
-		Amount:  order.Amount,
-		Status:  order.Status,
+		Id:               order.ID,
+		UserId:           order.UserID,
+		Product:          order.Product,
+		Sku:              order.SKU,
+		Quantity:         int32(order.Quantity),
+		UnitPrice:        order.UnitPrice,
+		Subtotal:         order.Subtotal,
+		DiscountAmount:   order.DiscountAmount,
+		Discounts:        toPBDiscounts(order.Discounts),
+		TaxAmount:        order.TaxAmount,
+		PricesIncludeTax: order.PricesIncludeTax,
+		TaxLines:         toPBTaxLines(order.TaxLines),
+		Amount:           order.Amount,
+		Currency:         order.Currency,
+		Status:           order.Status,
+		ShippingAddress:  toPBShippingAddress(order.ShippingAddress),
 	}, nil
 }
 
 func (h *OrderGRPCHandler) CreateOrder(ctx context.Context, req *pb.CreateOrderRequest) (*pb.CreateOrderResponse, error) {
-	order, err := h.orderUsecase.CreateOrder(req.UserId, req.Product, req.Amount)
+	quantity := int(req.Quantity)
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	order, err := h.orderUsecase.CreateOrder(domain.CreateOrderRequest{
+		UserID:      req.UserId,
+		SKU:         req.Sku,
+		Quantity:    quantity,
+		AddressID:   req.AddressId,
+		CouponCodes: req.CouponCodes,
+	})
 	if err != nil {
-		return nil, err
+		return nil, orderError(err)
 	}
 
 	return &pb.CreateOrderResponse{
-		Id:      order.ID,
-		UserId:  order.UserID,
-		Product: order.Product,
-		Amount:  order.Amount,
-		Status:  order.Status,
+		Id:               order.ID,
+		UserId:           order.UserID,
+		Product:          order.Product,
+		Sku:              order.SKU,
+		Quantity:         int32(order.Quantity),
+		UnitPrice:        order.UnitPrice,
+		Subtotal:         order.Subtotal,
+		DiscountAmount:   order.DiscountAmount,
+		Discounts:        toPBDiscounts(order.Discounts),
+		TaxAmount:        order.TaxAmount,
+		PricesIncludeTax: order.PricesIncludeTax,
+		TaxLines:         toPBTaxLines(order.TaxLines),
+		Amount:           order.Amount,
+		Currency:         order.Currency,
+		Status:           order.Status,
+		ShippingAddress:  toPBShippingAddress(order.ShippingAddress),
 	}, nil
 }
+
+func (h *OrderGRPCHandler) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	listReq := domain.ListOrdersRequest{
+		UserID:    req.UserId,
+		Status:    req.Status,
+		Currency:  req.Currency,
+		MinAmount: req.MinAmount,
+		MaxAmount: req.MaxAmount,
+		OrderBy:   req.OrderBy,
+		PageSize:  int(req.PageSize),
+		PageToken: req.PageToken,
+	}
+	if req.CreatedAfter != nil {
+		t := req.CreatedAfter.AsTime()
+		listReq.CreatedAfter = &t
+	}
+	if req.CreatedBefore != nil {
+		t := req.CreatedBefore.AsTime()
+		listReq.CreatedBefore = &t
+	}
+
+	page, err := h.orderUsecase.ListOrders(listReq)
+	if err != nil {
+		return nil, orderError(err)
+	}
+
+	resp := &pb.ListOrdersResponse{
+		Orders:        make([]*pb.Order, 0, len(page.Orders)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, order := range page.Orders {
+		resp.Orders = append(resp.Orders, toPBOrder(order))
+	}
+	return resp, nil
+}
+
+func (h *OrderGRPCHandler) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrderStatusRequest) (*pb.Order, error) {
+	order, err := h.orderUsecase.UpdateOrderStatus(req.Id, req.Status)
+	if err != nil {
+		return nil, orderError(err)
+	}
+	return toPBOrder(order), nil
+}
+
+func (h *OrderGRPCHandler) PublishEvent(ctx context.Context, req *pb.PublishEventRequest) (*pb.PublishEventResponse, error) {
+	if !json.Valid([]byte(req.Data)) {
+		return nil, status.Error(codes.InvalidArgument, "data must be valid JSON")
+	}
+	if err := h.webhookUsecase.Publish(req.Type, json.RawMessage(req.Data)); err != nil {
+		return nil, orderError(err)
+	}
+	return &pb.PublishEventResponse{}, nil
+}
+
+func toPBOrder(order *domain.Order) *pb.Order {
+	return &pb.Order{
+		Id:               order.ID,
+		UserId:           order.UserID,
+		Product:          order.Product,
+		Sku:              order.SKU,
+		Quantity:         int32(order.Quantity),
+		UnitPrice:        order.UnitPrice,
+		Subtotal:         order.Subtotal,
+		DiscountAmount:   order.DiscountAmount,
+		Discounts:        toPBDiscounts(order.Discounts),
+		TaxAmount:        order.TaxAmount,
+		PricesIncludeTax: order.PricesIncludeTax,
+		TaxLines:         toPBTaxLines(order.TaxLines),
+		Amount:           order.Amount,
+		Currency:         order.Currency,
+		Status:           order.Status,
+		CreatedAt:        timestamppb.New(order.CreatedAt),
+		UpdatedAt:        timestamppb.New(order.UpdatedAt),
+		ShippingAddress:  toPBShippingAddress(order.ShippingAddress),
+	}
+}
+
+func toPBDiscounts(discounts []domain.AppliedDiscount) []*pb.AppliedDiscount {
+	out := make([]*pb.AppliedDiscount, 0, len(discounts))
+	for _, d := range discounts {
+		out = append(out, &pb.AppliedDiscount{
+			PromotionId: d.PromotionID,
+			Code:        d.Code,
+			Type:        d.Type,
+			Value:       d.Value,
+			Amount:      d.Amount,
+		})
+	}
+	return out
+}
+
+func toPBTaxLines(lines []domain.TaxLine) []*pb.TaxLine {
+	out := make([]*pb.TaxLine, 0, len(lines))
+	for _, line := range lines {
+		out = append(out, &pb.TaxLine{
+			Sku:       line.SKU,
+			TaxClass:  line.TaxClass,
+			Rate:      line.Rate,
+			NetAmount: line.NetAmount,
+			TaxAmount: line.TaxAmount,
+		})
+	}
+	return out
+}
+
+func toPBShippingAddress(address *domain.ShippingAddress) *pb.ShippingAddress {
+	if address == nil {
+		return nil
+	}
+	return &pb.ShippingAddress{
+		AddressId:     address.AddressID,
+		RecipientName: address.RecipientName,
+		Line1:         address.Line1,
+		Line2:         address.Line2,
+		City:          address.City,
+		Region:        address.Region,
+		PostalCode:    address.PostalCode,
+		Country:       address.Country,
+		Phone:         address.Phone,
+	}
+}
+
+func orderError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrAmountNeedsCurrency):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrUnknownSKU), errors.Is(err, domain.ErrSKUNotSellable), errors.Is(err, domain.ErrUnknownAddress),
+		errors.Is(err, domain.ErrUnknownCoupon), errors.Is(err, domain.ErrCouponNotActive),
+		errors.Is(err, domain.ErrCouponMinimumNotMet), errors.Is(err, domain.ErrCouponNotStackable),
+		errors.Is(err, domain.ErrCouponCurrency), errors.Is(err, domain.ErrInvalidOrderStatus):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrOutOfStock), errors.Is(err, domain.ErrCouponExhausted), errors.Is(err, domain.ErrCouponUserLimit),
+		errors.Is(err, domain.ErrOrderNotCancellable), errors.Is(err, domain.ErrOrderClosed), errors.Is(err, domain.ErrInvalidOrderTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrOrderConflict):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return err
+}
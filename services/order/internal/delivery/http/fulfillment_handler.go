@@ -0,0 +1,121 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	"github.com/gin-gonic/gin"
+)
+
+type FulfillmentHandler struct {
+	fulfillmentUsecase domain.FulfillmentUsecase
+}
+
+func NewFulfillmentHandler(fulfillmentUsecase domain.FulfillmentUsecase) *FulfillmentHandler {
+	return &FulfillmentHandler{
+		fulfillmentUsecase: fulfillmentUsecase,
+	}
+}
+
+// CreateShipmentRequest ships part of an order; quantity defaults to
+// everything not yet shipped.
+type CreateShipmentRequest struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+	Quantity       int    `json:"quantity"`
+}
+
+// ShipmentEventRequest is a tracking update; occurred_at defaults to now.
+type ShipmentEventRequest struct {
+	Status      string     `json:"status"`
+	Location    string     `json:"location"`
+	Description string     `json:"description"`
+	OccurredAt  *time.Time `json:"occurred_at"`
+}
+
+// GetFulfillment is GET /orders/:id/fulfillment: the order's shipments and
+// how much of it has shipped and been delivered.
+func (h *FulfillmentHandler) GetFulfillment(c *gin.Context) {
+	fulfillment, err := h.fulfillmentUsecase.GetFulfillment(c.Param("id"))
+	if err != nil {
+		c.JSON(fulfillmentStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, fulfillment)
+}
+
+func (h *FulfillmentHandler) CreateShipment(c *gin.Context) {
+	var req CreateShipmentRequest
+	if err := validate.BindJSON(c, &req, &pb.CreateShipmentRequest{OrderId: c.Param("id")}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	shipment, err := h.fulfillmentUsecase.CreateShipment(c.Param("id"), domain.CreateShipmentRequest{
+		Carrier:        req.Carrier,
+		TrackingNumber: req.TrackingNumber,
+		Quantity:       req.Quantity,
+	})
+	if err != nil {
+		c.JSON(fulfillmentStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, shipment)
+}
+
+func (h *FulfillmentHandler) GetShipment(c *gin.Context) {
+	shipment, err := h.fulfillmentUsecase.GetShipment(c.Param("id"), c.Param("shipment_id"))
+	if err != nil {
+		c.JSON(fulfillmentStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+// AddShipmentEvent is POST /orders/:id/shipments/:shipment_id/events: record
+// a carrier tracking update and move the shipment to its status.
+func (h *FulfillmentHandler) AddShipmentEvent(c *gin.Context) {
+	var req ShipmentEventRequest
+	if err := validate.BindJSON(c, &req, &pb.UpdateShipmentStatusRequest{OrderId: c.Param("id"), Id: c.Param("shipment_id")}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	event := domain.ShipmentEvent{
+		Status:      req.Status,
+		Location:    req.Location,
+		Description: req.Description,
+	}
+	if req.OccurredAt != nil {
+		event.OccurredAt = *req.OccurredAt
+	}
+
+	shipment, err := h.fulfillmentUsecase.UpdateShipmentStatus(c.Param("id"), c.Param("shipment_id"), event)
+	if err != nil {
+		c.JSON(fulfillmentStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+func fulfillmentStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound), errors.Is(err, domain.ErrShipmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrOrderNotShippable), errors.Is(err, domain.ErrShipmentExceedsOrder),
+		errors.Is(err, domain.ErrInvalidShipmentTransition):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrNoShippingAddress):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+)
+
+// API documents the order service's HTTP routes. The service will not start
+// if a route is missing from it.
+func API() openapi.API {
+	return openapi.API{
+		Title:       "Order Service",
+		Version:     "1.0",
+		Description: "Orders, fulfillment, promotions, checkout sagas and outbound webhooks.",
+		Operations: []openapi.Operation{
+			{Method: http.MethodGet, Path: "/health", Tag: "health", Summary: "Health check", Response: openapi.HealthResponse{}},
+
+			{Method: http.MethodPost, Path: "/orders", Tag: "orders", Summary: "Place an order", Body: CreateOrderRequest{}, Rules: &pb.CreateOrderRequest{}, Status: http.StatusCreated, Response: OrderResponse{}},
+			{Method: http.MethodGet, Path: "/orders", Tag: "orders", Summary: "List orders", Query: ListOrdersQuery{}, Response: ListOrdersResponse{}},
+			{Method: http.MethodGet, Path: "/orders/:id", Tag: "orders", Summary: "Get an order", Response: OrderResponse{}},
+			{Method: http.MethodPost, Path: "/orders/:id/cancel", Tag: "orders", Summary: "Cancel an order", Response: OrderResponse{}},
+
+			{Method: http.MethodGet, Path: "/orders/:id/fulfillment", Tag: "fulfillment", Summary: "Get an order's shipments and fulfillment status", Response: domain.Fulfillment{}},
+			{Method: http.MethodPost, Path: "/orders/:id/shipments", Tag: "fulfillment", Summary: "Ship part of an order", Body: CreateShipmentRequest{}, Rules: &pb.CreateShipmentRequest{}, Status: http.StatusCreated, Response: domain.Shipment{}},
+			{Method: http.MethodGet, Path: "/orders/:id/shipments/:shipment_id", Tag: "fulfillment", Summary: "Get a shipment", Response: domain.Shipment{}},
+			{Method: http.MethodPost, Path: "/orders/:id/shipments/:shipment_id/events", Tag: "fulfillment", Summary: "Record a tracking update", Body: ShipmentEventRequest{}, Rules: &pb.UpdateShipmentStatusRequest{}, Response: domain.Shipment{}},
+
+			{Method: http.MethodPost, Path: "/promotions", Tag: "promotions", Summary: "Create a promotion (admins only)", Body: CreatePromotionRequest{}, Rules: &pb.CreatePromotionRequest{}, Status: http.StatusCreated, Response: domain.Promotion{}, Auth: true},
+			{Method: http.MethodGet, Path: "/promotions", Tag: "promotions", Summary: "List promotions, newest first", Query: ListPromotionsQuery{}, Response: ListPromotionsResponse{}},
+			{Method: http.MethodGet, Path: "/promotions/:code", Tag: "promotions", Summary: "Get a promotion", Response: domain.Promotion{}},
+			{Method: http.MethodPatch, Path: "/promotions/:code", Tag: "promotions", Summary: "Update a promotion's present fields (admins only)", Body: UpdatePromotionRequest{}, Response: domain.Promotion{}, Auth: true},
+
+			// 201 when the saga completes at once, 422 when it fails.
+			{Method: http.MethodPost, Path: "/checkout", Tag: "checkout", Summary: "Start a checkout saga", Body: CheckoutRequest{}, Rules: &pb.CreateOrderRequest{}, Status: http.StatusAccepted, Response: SagaResponse{}},
+			{Method: http.MethodGet, Path: "/sagas", Tag: "checkout", Summary: "List sagas, most recent first", Query: ListSagasQuery{}, Response: ListSagasResponse{}},
+			{Method: http.MethodGet, Path: "/sagas/:id", Tag: "checkout", Summary: "Get a saga and its steps", Response: SagaResponse{}},
+
+			{Method: http.MethodPost, Path: "/webhooks/subscriptions", Tag: "webhooks", Summary: "Subscribe to events (admins only)", Body: CreateSubscriptionRequest{}, Rules: &pb.CreateWebhookSubscriptionRequest{}, Status: http.StatusCreated, Response: SubscriptionResponse{}, Auth: true},
+			{Method: http.MethodGet, Path: "/webhooks/subscriptions", Tag: "webhooks", Summary: "List subscriptions (admins only)", Response: ListSubscriptionsResponse{}, Auth: true},
+			{Method: http.MethodDelete, Path: "/webhooks/subscriptions/:id", Tag: "webhooks", Summary: "Delete a subscription (admins only)", Status: http.StatusNoContent, Auth: true},
+			{Method: http.MethodGet, Path: "/webhooks/deliveries/dead", Tag: "webhooks", Summary: "List deliveries that ran out of attempts (admins only)", Query: ListDeliveriesQuery{}, Response: ListDeliveriesResponse{}, Auth: true},
+			{Method: http.MethodGet, Path: "/webhooks/deliveries/:id/attempts", Tag: "webhooks", Summary: "List a delivery's attempts (admins only)", Response: ListAttemptsResponse{}, Auth: true},
+			{Method: http.MethodPost, Path: "/webhooks/deliveries/:id/redrive", Tag: "webhooks", Summary: "Retry a dead delivery (admins only)", Status: http.StatusAccepted, Auth: true},
+		},
+	}
+}
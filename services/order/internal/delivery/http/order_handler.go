@@ -1,9 +1,13 @@
 package http
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
 	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,39 +21,106 @@ func NewOrderHandler(orderUsecase domain.OrderUsecase) *OrderHandler {
 	}
 }
 
+// CreateOrderRequest names what to buy; the price comes from the catalog.
+// Quantity defaults to 1 and the address to the user's default address.
 type CreateOrderRequest struct {
-	UserID  string  `json:"user_id" binding:"required"`
-	Product string  `json:"product" binding:"required"`
-	Amount  float64 `json:"amount" binding:"required"`
+	UserID      string   `json:"user_id"`
+	SKU         string   `json:"sku"`
+	Quantity    int      `json:"quantity"`
+	AddressID   string   `json:"address_id"`
+	CouponCodes []string `json:"coupon_codes"`
 }
 
 type OrderResponse struct {
-	ID      string  `json:"id"`
-	UserID  string  `json:"user_id"`
-	Product string  `json:"product"`
-	Amount  float64 `json:"amount"`
-	Status  string  `json:"status"`
+	ID               string                   `json:"id"`
+	UserID           string                   `json:"user_id"`
+	Product          string                   `json:"product"`
+	SKU              string                   `json:"sku"`
+	Quantity         int                      `json:"quantity"`
+	UnitPrice        float64                  `json:"unit_price"`
+	Subtotal         float64                  `json:"subtotal"`
+	DiscountAmount   float64                  `json:"discount_amount"`
+	Discounts        []domain.AppliedDiscount `json:"discounts,omitempty"`
+	TaxAmount        float64                  `json:"tax_amount"`
+	PricesIncludeTax bool                     `json:"prices_include_tax"`
+	TaxLines         []domain.TaxLine         `json:"tax_lines,omitempty"`
+	Amount           float64                  `json:"amount"`
+	Currency         string                   `json:"currency"`
+	Status           string                   `json:"status"`
+	CreatedAt        time.Time                `json:"created_at"`
+	ShippingAddress  *domain.ShippingAddress  `json:"shipping_address,omitempty"`
+}
+
+type ListOrdersQuery struct {
+	UserID        string     `form:"user_id"`
+	Status        string     `form:"status"`
+	Currency      string     `form:"currency"`
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinAmount     *float64   `form:"min_amount"`
+	MaxAmount     *float64   `form:"max_amount"`
+	OrderBy       string     `form:"order_by"`
+	PageSize      int        `form:"page_size"`
+	PageToken     string     `form:"page_token"`
+}
+
+type ListOrdersResponse struct {
+	Orders        []OrderResponse `json:"orders"`
+	NextPageToken string          `json:"next_page_token,omitempty"`
 }
 
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var req CreateOrderRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := validate.BindJSON(c, &req, &pb.CreateOrderRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
 		return
 	}
 
-	order, err := h.orderUsecase.CreateOrder(req.UserID, req.Product, req.Amount)
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	order, err := h.orderUsecase.CreateOrder(domain.CreateOrderRequest{
+		UserID:      req.UserID,
+		SKU:         req.SKU,
+		Quantity:    req.Quantity,
+		AddressID:   req.AddressID,
+		CouponCodes: req.CouponCodes,
+	})
+	if errors.Is(err, domain.ErrUnknownSKU) || errors.Is(err, domain.ErrSKUNotSellable) || errors.Is(err, domain.ErrUnknownAddress) ||
+		errors.Is(err, domain.ErrUnknownCoupon) || errors.Is(err, domain.ErrCouponNotActive) ||
+		errors.Is(err, domain.ErrCouponMinimumNotMet) || errors.Is(err, domain.ErrCouponNotStackable) ||
+		errors.Is(err, domain.ErrCouponCurrency) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, domain.ErrOutOfStock) || errors.Is(err, domain.ErrCouponExhausted) || errors.Is(err, domain.ErrCouponUserLimit) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, OrderResponse{
-		ID:      order.ID,
-		UserID:  order.UserID,
-		Product: order.Product,
-		Amount:  order.Amount,
-		Status:  order.Status,
+		ID:               order.ID,
+		UserID:           order.UserID,
+		Product:          order.Product,
+		SKU:              order.SKU,
+		Quantity:         order.Quantity,
+		UnitPrice:        order.UnitPrice,
+		Subtotal:         order.Subtotal,
+		DiscountAmount:   order.DiscountAmount,
+		Discounts:        order.Discounts,
+		TaxAmount:        order.TaxAmount,
+		PricesIncludeTax: order.PricesIncludeTax,
+		TaxLines:         order.TaxLines,
+		Amount:           order.Amount,
+		Currency:         order.Currency,
+		Status:           order.Status,
+		CreatedAt:        order.CreatedAt,
+		ShippingAddress:  order.ShippingAddress,
 	})
 }
 
@@ -63,14 +134,111 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, OrderResponse{
-		ID:      order.ID,
-		UserID:  order.UserID,
-		Product: order.Product,
-		Amount:  order.Amount,
-		Status:  order.Status,
+		ID:               order.ID,
+		UserID:           order.UserID,
+		Product:          order.Product,
+		SKU:              order.SKU,
+		Quantity:         order.Quantity,
+		UnitPrice:        order.UnitPrice,
+		Subtotal:         order.Subtotal,
+		DiscountAmount:   order.DiscountAmount,
+		Discounts:        order.Discounts,
+		TaxAmount:        order.TaxAmount,
+		PricesIncludeTax: order.PricesIncludeTax,
+		TaxLines:         order.TaxLines,
+		Amount:           order.Amount,
+		Currency:         order.Currency,
+		Status:           order.Status,
+		CreatedAt:        order.CreatedAt,
+		ShippingAddress:  order.ShippingAddress,
+	})
+}
+
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	order, err := h.orderUsecase.CancelOrder(c.Param("id"))
+	if errors.Is(err, domain.ErrOrderNotCancellable) || errors.Is(err, domain.ErrOrderClosed) || errors.Is(err, domain.ErrOrderConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, OrderResponse{
+		ID:               order.ID,
+		UserID:           order.UserID,
+		Product:          order.Product,
+		SKU:              order.SKU,
+		Quantity:         order.Quantity,
+		UnitPrice:        order.UnitPrice,
+		Subtotal:         order.Subtotal,
+		DiscountAmount:   order.DiscountAmount,
+		Discounts:        order.Discounts,
+		TaxAmount:        order.TaxAmount,
+		PricesIncludeTax: order.PricesIncludeTax,
+		TaxLines:         order.TaxLines,
+		Amount:           order.Amount,
+		Currency:         order.Currency,
+		Status:           order.Status,
+		CreatedAt:        order.CreatedAt,
+		ShippingAddress:  order.ShippingAddress,
 	})
 }
 
+func (h *OrderHandler) ListOrders(c *gin.Context) {
+	var query ListOrdersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.orderUsecase.ListOrders(domain.ListOrdersRequest{
+		UserID:        query.UserID,
+		Status:        query.Status,
+		Currency:      query.Currency,
+		CreatedAfter:  query.CreatedAfter,
+		CreatedBefore: query.CreatedBefore,
+		MinAmount:     query.MinAmount,
+		MaxAmount:     query.MaxAmount,
+		OrderBy:       query.OrderBy,
+		PageSize:      query.PageSize,
+		PageToken:     query.PageToken,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := ListOrdersResponse{
+		Orders:        make([]OrderResponse, 0, len(page.Orders)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, order := range page.Orders {
+		resp.Orders = append(resp.Orders, OrderResponse{
+			ID:               order.ID,
+			UserID:           order.UserID,
+			Product:          order.Product,
+			SKU:              order.SKU,
+			Quantity:         order.Quantity,
+			UnitPrice:        order.UnitPrice,
+			Subtotal:         order.Subtotal,
+			DiscountAmount:   order.DiscountAmount,
+			Discounts:        order.Discounts,
+			TaxAmount:        order.TaxAmount,
+			PricesIncludeTax: order.PricesIncludeTax,
+			TaxLines:         order.TaxLines,
+			Amount:           order.Amount,
+			Currency:         order.Currency,
+			Status:           order.Status,
+			CreatedAt:        order.CreatedAt,
+			ShippingAddress:  order.ShippingAddress,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 func (h *OrderHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "ok",
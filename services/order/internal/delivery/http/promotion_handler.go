@@ -0,0 +1,158 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	"github.com/gin-gonic/gin"
+)
+
+type PromotionHandler struct {
+	promotionUsecase domain.PromotionUsecase
+}
+
+func NewPromotionHandler(promotionUsecase domain.PromotionUsecase) *PromotionHandler {
+	return &PromotionHandler{
+		promotionUsecase: promotionUsecase,
+	}
+}
+
+// CreatePromotionRequest defines a coupon for orders in currency (default
+// USD). Zero max_redemptions or per_user_limit means unlimited.
+type CreatePromotionRequest struct {
+	Code           string     `json:"code"`
+	Description    string     `json:"description"`
+	Type           string     `json:"type"`
+	Value          float64    `json:"value"`
+	Currency       string     `json:"currency"`
+	MinOrderAmount float64    `json:"min_order_amount"`
+	MaxRedemptions int        `json:"max_redemptions"`
+	PerUserLimit   int        `json:"per_user_limit"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	Stackable      bool       `json:"stackable"`
+}
+
+// UpdatePromotionRequest changes the fields that are present.
+type UpdatePromotionRequest struct {
+	Description    *string    `json:"description"`
+	MinOrderAmount *float64   `json:"min_order_amount"`
+	MaxRedemptions *int       `json:"max_redemptions"`
+	PerUserLimit   *int       `json:"per_user_limit"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	Stackable      *bool      `json:"stackable"`
+	Active         *bool      `json:"active"`
+}
+
+type ListPromotionsQuery struct {
+	ActiveOnly bool `form:"active_only"`
+	Limit      int  `form:"limit"`
+}
+
+type ListPromotionsResponse struct {
+	Promotions []*domain.Promotion `json:"promotions"`
+}
+
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var req CreatePromotionRequest
+	if err := validate.BindJSON(c, &req, &pb.CreatePromotionRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	promotion, err := h.promotionUsecase.CreatePromotion(domain.CreatePromotionRequest{
+		Code:           req.Code,
+		Description:    req.Description,
+		Type:           req.Type,
+		Value:          req.Value,
+		Currency:       req.Currency,
+		MinOrderAmount: req.MinOrderAmount,
+		MaxRedemptions: req.MaxRedemptions,
+		PerUserLimit:   req.PerUserLimit,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		Stackable:      req.Stackable,
+	})
+	if err != nil {
+		c.JSON(promotionStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+// ListPromotions is GET /promotions, newest first; ?active_only=true hides
+// deactivated promotions.
+func (h *PromotionHandler) ListPromotions(c *gin.Context) {
+	var query ListPromotionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promotions, err := h.promotionUsecase.ListPromotions(query.ActiveOnly, query.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if promotions == nil {
+		promotions = []*domain.Promotion{}
+	}
+	c.JSON(http.StatusOK, ListPromotionsResponse{Promotions: promotions})
+}
+
+func (h *PromotionHandler) GetPromotion(c *gin.Context) {
+	promotion, err := h.promotionUsecase.GetPromotion(c.Param("code"))
+	if err != nil {
+		c.JSON(promotionStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// UpdatePromotion is PATCH /promotions/:code. Setting active to false
+// retires a coupon without touching orders that already used it.
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	var req UpdatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promotion, err := h.promotionUsecase.UpdatePromotion(c.Param("code"), domain.PromotionUpdate{
+		Description:    req.Description,
+		MinOrderAmount: req.MinOrderAmount,
+		MaxRedemptions: req.MaxRedemptions,
+		PerUserLimit:   req.PerUserLimit,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		Stackable:      req.Stackable,
+		Active:         req.Active,
+	})
+	if err != nil {
+		c.JSON(promotionStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+func promotionStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrPromotionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrPromotionExists):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidPromotion):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
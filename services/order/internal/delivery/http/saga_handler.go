@@ -0,0 +1,110 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	"github.com/gin-gonic/gin"
+)
+
+type SagaHandler struct {
+	sagaUsecase domain.SagaUsecase
+}
+
+func NewSagaHandler(sagaUsecase domain.SagaUsecase) *SagaHandler {
+	return &SagaHandler{
+		sagaUsecase: sagaUsecase,
+	}
+}
+
+// CheckoutRequest names what to buy; quantity defaults to 1 and the
+// address to the user's default address.
+type CheckoutRequest struct {
+	UserID      string   `json:"user_id"`
+	SKU         string   `json:"sku"`
+	Quantity    int      `json:"quantity"`
+	AddressID   string   `json:"address_id"`
+	CouponCodes []string `json:"coupon_codes"`
+}
+
+type SagaResponse struct {
+	Saga  *domain.Saga          `json:"saga"`
+	Steps []*domain.SagaStepLog `json:"steps,omitempty"`
+}
+
+type ListSagasQuery struct {
+	Status string `form:"status"`
+	Limit  int    `form:"limit"`
+}
+
+type ListSagasResponse struct {
+	Sagas []*domain.Saga `json:"sagas"`
+}
+
+// Checkout is POST /checkout: create an order and pay for it. A completed
+// checkout returns 201; one still retrying a step returns 202, and one that
+// was rolled back returns 422. Poll GET /sagas/:id for the outcome.
+func (h *SagaHandler) Checkout(c *gin.Context) {
+	var req CheckoutRequest
+	if err := validate.BindJSON(c, &req, &pb.CreateOrderRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	saga, err := h.sagaUsecase.StartCheckout(req.UserID, req.SKU, req.Quantity, req.AddressID, req.CouponCodes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	code := http.StatusAccepted
+	switch saga.Status {
+	case domain.SagaStatusCompleted:
+		code = http.StatusCreated
+	case domain.SagaStatusCompensated, domain.SagaStatusFailed:
+		code = http.StatusUnprocessableEntity
+	}
+	c.JSON(code, SagaResponse{Saga: saga})
+}
+
+// GetSaga is GET /sagas/:id: the saga and every step it has run.
+func (h *SagaHandler) GetSaga(c *gin.Context) {
+	saga, steps, err := h.sagaUsecase.GetSaga(c.Param("id"))
+	if errors.Is(err, domain.ErrSagaNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SagaResponse{Saga: saga, Steps: steps})
+}
+
+// ListSagas is GET /sagas, optionally filtered by status, most recent first.
+func (h *SagaHandler) ListSagas(c *gin.Context) {
+	var query ListSagasQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sagas, err := h.sagaUsecase.ListSagas(query.Status, query.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if sagas == nil {
+		sagas = []*domain.Saga{}
+	}
+	c.JSON(http.StatusOK, ListSagasResponse{Sagas: sagas})
+}
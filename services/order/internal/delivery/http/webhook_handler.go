@@ -0,0 +1,157 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookUsecase domain.WebhookUsecase
+}
+
+func NewWebhookHandler(webhookUsecase domain.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUsecase: webhookUsecase,
+	}
+}
+
+type CreateSubscriptionRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+}
+
+type SubscriptionResponse struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Secret is only returned when the subscription is created.
+	Secret string `json:"secret,omitempty"`
+}
+
+type ListSubscriptionsResponse struct {
+	Subscriptions []SubscriptionResponse `json:"subscriptions"`
+}
+
+type ListDeliveriesQuery struct {
+	Limit int `form:"limit"`
+}
+
+type ListDeliveriesResponse struct {
+	Deliveries []*domain.WebhookDelivery `json:"deliveries"`
+}
+
+type ListAttemptsResponse struct {
+	Attempts []*domain.DeliveryAttempt `json:"attempts"`
+}
+
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req CreateSubscriptionRequest
+	if err := validate.BindJSON(c, &req, &pb.CreateWebhookSubscriptionRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	sub, err := h.webhookUsecase.CreateSubscription(req.URL, req.EventTypes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := toSubscriptionResponse(sub)
+	resp.Secret = sub.Secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subs, err := h.webhookUsecase.ListSubscriptions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := ListSubscriptionsResponse{Subscriptions: make([]SubscriptionResponse, 0, len(subs))}
+	for _, sub := range subs {
+		resp.Subscriptions = append(resp.Subscriptions, toSubscriptionResponse(sub))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	err := h.webhookUsecase.DeleteSubscription(c.Param("id"))
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeadLetters is GET /webhooks/deliveries/dead: deliveries that ran out
+// of attempts, most recent first.
+func (h *WebhookHandler) ListDeadLetters(c *gin.Context) {
+	var query ListDeliveriesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := h.webhookUsecase.ListDeadLetters(query.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []*domain.WebhookDelivery{}
+	}
+	c.JSON(http.StatusOK, ListDeliveriesResponse{Deliveries: deliveries})
+}
+
+func (h *WebhookHandler) ListAttempts(c *gin.Context) {
+	attempts, err := h.webhookUsecase.ListAttempts(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if attempts == nil {
+		attempts = []*domain.DeliveryAttempt{}
+	}
+	c.JSON(http.StatusOK, ListAttemptsResponse{Attempts: attempts})
+}
+
+func (h *WebhookHandler) RedriveDelivery(c *gin.Context) {
+	err := h.webhookUsecase.RedriveDelivery(c.Param("id"))
+	if errors.Is(err, domain.ErrDeliveryNotDeadLettered) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func toSubscriptionResponse(sub *domain.WebhookSubscription) SubscriptionResponse {
+	return SubscriptionResponse{
+		ID:         sub.ID,
+		URL:        sub.URL,
+		EventTypes: sub.EventTypes,
+		Active:     sub.Active,
+		CreatedAt:  sub.CreatedAt,
+	}
+}
@@ -1,24 +1,248 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/fx"
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
+)
+
+// DefaultCurrency is the currency of orders placed before orders recorded
+// one, and of promotions created without one.
+const DefaultCurrency = "USD"
+
+// Order is one line of a catalog SKU. Product and UnitPrice are copied from
+// the catalog, and ShippingAddress from the user's address book, when the
+// order is created, so later changes there do not alter it. Amount is what
+// the user pays: Subtotal less the coupon discounts, plus TaxAmount unless
+// PricesIncludeTax. Every amount is in Currency, the SKU's currency.
 type Order struct {
-	ID        string    `json:"id" db:"id"`
-	UserID    string    `json:"user_id" db:"user_id"`
-	Product   string    `json:"product" db:"product"`
-	Amount    float64   `json:"amount" db:"amount"`
-	Status    string    `json:"status" db:"status"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        string  `json:"id" db:"id"`
+	UserID    string  `json:"user_id" db:"user_id"`
+	Product   string  `json:"product" db:"product"`
+	SKU       string  `json:"sku" db:"sku"`
+	Quantity  int     `json:"quantity" db:"quantity"`
+	UnitPrice float64 `json:"unit_price" db:"unit_price"`
+	Subtotal  float64 `json:"subtotal" db:"subtotal"`
+	// DiscountAmount is the sum of Discounts.
+	DiscountAmount float64           `json:"discount_amount" db:"discount_amount"`
+	Discounts      []AppliedDiscount `json:"discounts,omitempty" db:"-"`
+	// TaxAmount is the sum of TaxLines, computed on the discounted price.
+	TaxAmount        float64   `json:"tax_amount" db:"tax_amount"`
+	PricesIncludeTax bool      `json:"prices_include_tax" db:"prices_include_tax"`
+	TaxLines         []TaxLine `json:"tax_lines,omitempty" db:"tax_lines"`
+	Amount           float64   `json:"amount" db:"amount"`
+	Currency         string    `json:"currency" db:"currency"`
+	Status           string    `json:"status" db:"status"`
+	// ShippingAddress is nil when the user had no address to ship to.
+	ShippingAddress *ShippingAddress `json:"shipping_address,omitempty" db:"shipping_address"`
+	CreatedAt       time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// ShippingAddress is the snapshot of a user address taken for an order.
+// AddressID points back at the address book entry it was copied from.
+type ShippingAddress struct {
+	AddressID     string `json:"address_id"`
+	RecipientName string `json:"recipient_name"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2,omitempty"`
+	City          string `json:"city"`
+	Region        string `json:"region,omitempty"`
+	PostalCode    string `json:"postal_code"`
+	Country       string `json:"country"`
+	Phone         string `json:"phone,omitempty"`
+}
+
+const (
+	OrderStatusPending           = "pending"
+	OrderStatusPaid              = "paid"
+	OrderStatusPartiallyRefunded = "partially_refunded"
+	OrderStatusRefunded          = "refunded"
+	OrderStatusCancelled         = "cancelled"
+	// OrderStatusExpired is set on pending orders that were not paid within
+	// the payment window.
+	OrderStatusExpired = "expired"
+)
+
+// orderTransitions lists the statuses UpdateOrderStatus may move each status
+// to. Orders only move forward; cancelled and expired orders are closed, and
+// refunded orders are final.
+var orderTransitions = map[string][]string{
+	OrderStatusPending:           {OrderStatusPaid},
+	OrderStatusPaid:              {OrderStatusPartiallyRefunded, OrderStatusRefunded},
+	OrderStatusPartiallyRefunded: {OrderStatusRefunded},
+	OrderStatusRefunded:          {},
+}
+
+// SetStatus moves the order to status if orderTransitions allows it.
+// Repeating the current status is allowed and changes nothing.
+func (o *Order) SetStatus(status string) error {
+	if _, ok := orderTransitions[status]; !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidOrderStatus, status)
+	}
+	if o.Status == OrderStatusCancelled || o.Status == OrderStatusExpired {
+		return ErrOrderClosed
+	}
+	if status != o.Status && !slices.Contains(orderTransitions[o.Status], status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidOrderTransition, o.Status, status)
+	}
+	o.Status = status
+	return nil
+}
+
+// OrderPayment is what one payment for an order has captured and refunded,
+// as last reported by the payment service, in the payment's currency.
+type OrderPayment struct {
+	PaymentID      string  `json:"payment_id" db:"payment_id"`
+	Currency       string  `json:"currency" db:"currency"`
+	CapturedAmount float64 `json:"captured_amount" db:"captured_amount"`
+	RefundedAmount float64 `json:"refunded_amount" db:"refunded_amount"`
+}
+
+// RefundStatus is the status the refunds across an order's payments put it
+// in: refunded once every payment that captured anything is refunded in
+// full, partially refunded once any of them is refunded, and "" before any
+// refund. Each payment is compared in its own currency.
+func RefundStatus(payments []OrderPayment) string {
+	captured, refunded, full := 0, 0, 0
+	for _, p := range payments {
+		capturedMinor := fx.ToMinor(p.CapturedAmount, p.Currency)
+		if capturedMinor <= 0 {
+			continue
+		}
+		captured++
+		refundedMinor := fx.ToMinor(p.RefundedAmount, p.Currency)
+		if refundedMinor > 0 {
+			refunded++
+		}
+		if refundedMinor >= capturedMinor {
+			full++
+		}
+	}
+	switch {
+	case refunded == 0:
+		return ""
+	case full == captured:
+		return OrderStatusRefunded
+	default:
+		return OrderStatusPartiallyRefunded
+	}
+}
+
+// Domain events written to the outbox by OrderRepository. order.created and
+// order.expired share their names with the client webhook events of the same
+// meaning.
+const (
+	AggregateOrder    = "order"
+	EventOrderUpdated = "order.updated"
+)
+
+var (
+	ErrOrderNotFound          = errors.New("order not found")
+	ErrAmountNeedsCurrency    = errors.New("sorting or filtering orders by amount needs a currency")
+	ErrInvalidOrderStatus     = errors.New("invalid order status")
+	ErrInvalidOrderTransition = errors.New("invalid order status transition")
+	ErrInvalidUser            = errors.New("invalid user")
+	ErrOrderNotCancellable    = errors.New("only pending orders can be cancelled")
+	ErrOrderClosed            = errors.New("order is cancelled or expired")
+	ErrOrderConflict          = errors.New("order was changed by a concurrent request")
+	ErrOrderExists            = errors.New("an order with this id already exists")
+	ErrUnknownSKU             = errors.New("unknown sku")
+	ErrSKUNotSellable         = errors.New("sku is not available for sale")
+	ErrOutOfStock             = errors.New("not enough stock")
+	ErrUnknownAddress         = errors.New("unknown shipping address")
+)
+
+// Sort fields accepted by ListOrders. A leading "-" on the request value
+// selects descending order.
+const (
+	OrderSortCreatedAt = "created_at"
+	OrderSortAmount    = "amount"
+)
+
+type OrderFilter struct {
+	UserID        string
+	Status        string
+	Currency      string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	MinAmount     *float64
+	MaxAmount     *float64
+	Page          pagination.Page
+}
+
+// CreateOrderRequest names what to buy. The order ships to AddressID, or to
+// the user's default address when it is empty, and CouponCodes are redeemed
+// against it.
+type CreateOrderRequest struct {
+	// OrderID, if set, is the ID to create the order under. A request whose
+	// order already exists returns that order instead of creating another,
+	// so callers that retry can choose the ID before the first attempt.
+	OrderID     string
+	UserID      string
+	SKU         string
+	Quantity    int
+	AddressID   string
+	CouponCodes []string
+}
+
+type ListOrdersRequest struct {
+	UserID        string
+	Status        string
+	Currency      string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	MinAmount     *float64
+	MaxAmount     *float64
+	OrderBy       string
+	PageSize      int
+	PageToken     string
+}
+
+type OrderPage struct {
+	Orders        []*Order `json:"orders"`
+	NextPageToken string   `json:"next_page_token"`
 }
 
 type OrderRepository interface {
+	// Create stores the order and redeems its discounts in one transaction.
+	// It fails with ErrCouponExhausted or ErrCouponUserLimit when other
+	// orders took the last redemptions first, and with ErrOrderExists when
+	// an order with its ID was stored first.
 	Create(order *Order) error
 	GetByID(id string) (*Order, error)
-	Update(order *Order) error
+	// Update stores the order's status if it is still from. Cancelling the
+	// order gives back its coupon redemptions. It fails with ErrOrderClosed
+	// if the order was cancelled or expired in the meantime, such as by
+	// ExpirePending, and with ErrOrderConflict if it moved to another status.
+	Update(order *Order, from string) error
+	// SavePayment records what one of the order's payments has captured and
+	// refunded, keeping the larger amounts if an older report arrives late,
+	// and returns all the payments recorded for the order.
+	SavePayment(orderID string, payment OrderPayment) ([]OrderPayment, error)
+	List(filter OrderFilter) ([]*Order, error)
+	// ExpirePending marks up to limit orders still pending since before as
+	// expired, gives back their coupon redemptions and returns them. Rows
+	// locked by another caller are skipped.
+	ExpirePending(before time.Time, limit int) ([]*Order, error)
 }
 
 type OrderUsecase interface {
-	CreateOrder(userID, product string, amount float64) (*Order, error)
+	// CreateOrder prices the order from the catalog and applies its coupons:
+	// the client never sends a price.
+	CreateOrder(req CreateOrderRequest) (*Order, error)
 	GetOrder(id string) (*Order, error)
+	ListOrders(req ListOrdersRequest) (*OrderPage, error)
+	UpdateOrderStatus(id, status string) (*Order, error)
+	// RecordPayment records a payment's captured and refunded amounts. Once
+	// anything is refunded, the order's status follows RefundStatus.
+	RecordPayment(orderID string, payment OrderPayment) (*Order, error)
+	CancelOrder(id string) (*Order, error)
+	// ExpireUnpaid expires up to limit orders created before the given time
+	// that are still pending.
+	ExpireUnpaid(before time.Time, limit int) ([]*Order, error)
 }
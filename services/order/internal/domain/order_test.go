@@ -0,0 +1,35 @@
+package domain
+
+import "testing"
+
+func TestRefundStatus(t *testing.T) {
+	cases := []struct {
+		name     string
+		payments []OrderPayment
+		want     string
+	}{
+		{"no payments", nil, ""},
+		{"captured, nothing refunded", []OrderPayment{{Currency: "USD", CapturedAmount: 50}}, ""},
+		{"one payment partly refunded", []OrderPayment{{Currency: "USD", CapturedAmount: 50, RefundedAmount: 20}}, OrderStatusPartiallyRefunded},
+		{"one payment fully refunded", []OrderPayment{{Currency: "USD", CapturedAmount: 50, RefundedAmount: 50}}, OrderStatusRefunded},
+		{"one of two payments fully refunded", []OrderPayment{
+			{PaymentID: "a", Currency: "USD", CapturedAmount: 30, RefundedAmount: 30},
+			{PaymentID: "b", Currency: "EUR", CapturedAmount: 20},
+		}, OrderStatusPartiallyRefunded},
+		{"both payments fully refunded", []OrderPayment{
+			{PaymentID: "a", Currency: "USD", CapturedAmount: 30, RefundedAmount: 30},
+			{PaymentID: "b", Currency: "JPY", CapturedAmount: 2000, RefundedAmount: 2000},
+		}, OrderStatusRefunded},
+		{"uncaptured payment is ignored", []OrderPayment{
+			{PaymentID: "a", Currency: "USD", CapturedAmount: 30, RefundedAmount: 30},
+			{PaymentID: "b", Currency: "USD"},
+		}, OrderStatusRefunded},
+		{"refund within the minor unit", []OrderPayment{{Currency: "KWD", CapturedAmount: 1.235, RefundedAmount: 1.234}}, OrderStatusPartiallyRefunded},
+	}
+
+	for _, tc := range cases {
+		if got := RefundStatus(tc.payments); got != tc.want {
+			t.Errorf("%s: RefundStatus = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/fx"
+)
+
+// Promotion types. A percentage promotion takes Value percent off the order
+// subtotal; a fixed one takes Value off it.
+const (
+	PromotionTypePercentage = "percentage"
+	PromotionTypeFixed      = "fixed"
+)
+
+var (
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrPromotionExists   = errors.New("promotion code already exists")
+	ErrInvalidPromotion  = errors.New("invalid promotion")
+
+	// Coupon errors reject the order they were applied to.
+	ErrUnknownCoupon       = errors.New("unknown coupon code")
+	ErrCouponNotActive     = errors.New("coupon is not active")
+	ErrCouponMinimumNotMet = errors.New("order total is below the coupon minimum")
+	ErrCouponNotStackable  = errors.New("coupon cannot be combined with other coupons")
+	ErrCouponCurrency      = errors.New("coupon does not apply to orders in this currency")
+	ErrCouponExhausted     = errors.New("coupon has no redemptions left")
+	ErrCouponUserLimit     = errors.New("coupon redemption limit reached for this user")
+)
+
+// Promotion is a discount redeemed with a coupon code. It applies to orders
+// in Currency only, which Value (for fixed discounts) and MinOrderAmount are
+// in. Zero MaxRedemptions or PerUserLimit means unlimited; nil StartsAt or
+// EndsAt leaves that side of the validity window open.
+type Promotion struct {
+	ID             string     `json:"id"`
+	Code           string     `json:"code"`
+	Description    string     `json:"description"`
+	Type           string     `json:"type"`
+	Value          float64    `json:"value"`
+	Currency       string     `json:"currency"`
+	MinOrderAmount float64    `json:"min_order_amount"`
+	MaxRedemptions int        `json:"max_redemptions"`
+	PerUserLimit   int        `json:"per_user_limit"`
+	Redemptions    int        `json:"redemptions"`
+	StartsAt       *time.Time `json:"starts_at,omitempty"`
+	EndsAt         *time.Time `json:"ends_at,omitempty"`
+	// Stackable promotions can be combined with other stackable ones; a
+	// promotion that is not stackable must be the order's only coupon.
+	Stackable bool      `json:"stackable"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Redeemable reports whether the promotion is active, inside its validity
+// window at now and not used up. The per-user limit is checked when the
+// coupon is redeemed.
+func (p *Promotion) Redeemable(now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	return p.MaxRedemptions == 0 || p.Redemptions < p.MaxRedemptions
+}
+
+// Validate checks the promotion's settings.
+func (p *Promotion) Validate() error {
+	switch p.Type {
+	case PromotionTypePercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return fmt.Errorf("%w: percentage must be in (0, 100]", ErrInvalidPromotion)
+		}
+	case PromotionTypeFixed:
+		if p.Value <= 0 {
+			return fmt.Errorf("%w: fixed discount must be positive", ErrInvalidPromotion)
+		}
+	default:
+		return fmt.Errorf("%w: type must be %s or %s", ErrInvalidPromotion, PromotionTypePercentage, PromotionTypeFixed)
+	}
+	if p.MinOrderAmount < 0 || p.MaxRedemptions < 0 || p.PerUserLimit < 0 {
+		return fmt.Errorf("%w: minimum and limits must not be negative", ErrInvalidPromotion)
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.StartsAt.Before(*p.EndsAt) {
+		return fmt.Errorf("%w: starts_at must be before ends_at", ErrInvalidPromotion)
+	}
+	return nil
+}
+
+// AppliedDiscount is a promotion as applied to one order. Code, Type and
+// Value are copied so later promotion changes do not alter the order.
+type AppliedDiscount struct {
+	PromotionID string  `json:"promotion_id"`
+	Code        string  `json:"code"`
+	Type        string  `json:"type"`
+	Value       float64 `json:"value"`
+	Amount      float64 `json:"amount"`
+}
+
+// ApplyPromotions works out the discounts of promotions on subtotal, in
+// currency. Every discount is computed on the subtotal, in the order given,
+// and the total never exceeds it.
+func ApplyPromotions(subtotal float64, currency string, promotions []*Promotion, now time.Time) ([]AppliedDiscount, error) {
+	if len(promotions) > 1 {
+		for _, p := range promotions {
+			if !p.Stackable {
+				return nil, fmt.Errorf("%w: %s", ErrCouponNotStackable, p.Code)
+			}
+		}
+	}
+
+	discounts := make([]AppliedDiscount, 0, len(promotions))
+	remaining := subtotal
+	for _, p := range promotions {
+		if !p.Redeemable(now) {
+			if p.MaxRedemptions > 0 && p.Redemptions >= p.MaxRedemptions {
+				return nil, fmt.Errorf("%w: %s", ErrCouponExhausted, p.Code)
+			}
+			return nil, fmt.Errorf("%w: %s", ErrCouponNotActive, p.Code)
+		}
+		if p.Currency != currency {
+			return nil, fmt.Errorf("%w: %s is for %s orders", ErrCouponCurrency, p.Code, p.Currency)
+		}
+		if subtotal < p.MinOrderAmount {
+			return nil, fmt.Errorf("%w: %s requires %.2f", ErrCouponMinimumNotMet, p.Code, p.MinOrderAmount)
+		}
+
+		amount := p.Value
+		if p.Type == PromotionTypePercentage {
+			amount = fx.Round(subtotal*p.Value/100, currency)
+		}
+		amount = math.Min(amount, remaining)
+		remaining = fx.Round(remaining-amount, currency)
+
+		discounts = append(discounts, AppliedDiscount{
+			PromotionID: p.ID,
+			Code:        p.Code,
+			Type:        p.Type,
+			Value:       p.Value,
+			Amount:      amount,
+		})
+	}
+	return discounts, nil
+}
+
+type CreatePromotionRequest struct {
+	Code           string
+	Description    string
+	Type           string
+	Value          float64
+	Currency       string
+	MinOrderAmount float64
+	MaxRedemptions int
+	PerUserLimit   int
+	StartsAt       *time.Time
+	EndsAt         *time.Time
+	Stackable      bool
+}
+
+// PromotionUpdate changes the fields that are set. Type, Value and Currency
+// are fixed once a promotion exists, since orders already refer to it.
+type PromotionUpdate struct {
+	Description    *string
+	MinOrderAmount *float64
+	MaxRedemptions *int
+	PerUserLimit   *int
+	StartsAt       *time.Time
+	EndsAt         *time.Time
+	Stackable      *bool
+	Active         *bool
+}
+
+type PromotionRepository interface {
+	Create(promotion *Promotion) error
+	GetByCode(code string) (*Promotion, error)
+	List(activeOnly bool, limit int) ([]*Promotion, error)
+	Update(promotion *Promotion) error
+}
+
+type PromotionUsecase interface {
+	CreatePromotion(req CreatePromotionRequest) (*Promotion, error)
+	GetPromotion(code string) (*Promotion, error)
+	ListPromotions(activeOnly bool, limit int) ([]*Promotion, error)
+	UpdatePromotion(code string, update PromotionUpdate) (*Promotion, error)
+}
@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Saga statuses. A saga runs its steps forward; when a step is rejected, or
+// keeps failing, it compensates the completed steps in reverse order.
+const (
+	SagaStatusRunning      = "running"
+	SagaStatusCompleted    = "completed"
+	SagaStatusCompensating = "compensating"
+	SagaStatusCompensated  = "compensated"
+	// SagaStatusFailed means a compensation could not be run and the saga
+	// needs manual attention.
+	SagaStatusFailed = "failed"
+)
+
+// Checkout saga steps, in execution order.
+const (
+	SagaTypeCheckout = "checkout"
+
+	SagaStepCreateOrder      = "create_order"
+	SagaStepReserveStock     = "reserve_stock"
+	SagaStepAuthorizePayment = "authorize_payment"
+	SagaStepCapturePayment   = "capture_payment"
+	SagaStepConfirmOrder     = "confirm_order"
+)
+
+// Saga step log actions and outcomes.
+const (
+	SagaActionExecute    = "execute"
+	SagaActionCompensate = "compensate"
+
+	SagaStepSucceeded = "succeeded"
+	SagaStepFailed    = "failed"
+	SagaStepRejected  = "rejected"
+)
+
+var (
+	ErrSagaNotFound = errors.New("saga not found")
+	// ErrSagaStepRejected marks a step failure that retrying cannot fix,
+	// such as a declined payment. The saga compensates immediately.
+	ErrSagaStepRejected = errors.New("saga step rejected")
+)
+
+// CheckoutData is the state a checkout saga carries between steps. Amount
+// is the order's price after coupon discounts, known once the order is
+// created.
+type CheckoutData struct {
+	UserID      string   `json:"user_id"`
+	SKU         string   `json:"sku"`
+	Quantity    int      `json:"quantity"`
+	AddressID   string   `json:"address_id,omitempty"`
+	CouponCodes []string `json:"coupon_codes,omitempty"`
+	Amount      float64  `json:"amount,omitempty"`
+	OrderID     string   `json:"order_id,omitempty"`
+	PaymentID   string   `json:"payment_id,omitempty"`
+}
+
+type Saga struct {
+	ID     string       `json:"id"`
+	Type   string       `json:"type"`
+	Status string       `json:"status"`
+	Step   string       `json:"step"`
+	Data   CheckoutData `json:"data"`
+	// Attempts counts failures of the current step.
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Done reports whether the saga has reached a final status.
+func (s *Saga) Done() bool {
+	switch s.Status {
+	case SagaStatusCompleted, SagaStatusCompensated, SagaStatusFailed:
+		return true
+	}
+	return false
+}
+
+// SagaStepLog records one execution or compensation of a step.
+type SagaStepLog struct {
+	ID        string    `json:"id"`
+	SagaID    string    `json:"saga_id"`
+	Step      string    `json:"step"`
+	Action    string    `json:"action"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SagaRepository interface {
+	// Create inserts the saga leased until NextAttemptAt, so workers leave
+	// it alone while the caller runs it.
+	Create(saga *Saga) error
+	GetByID(id string) (*Saga, error)
+	// Save stores the saga's new state together with the step log entry.
+	Save(saga *Saga, entry *SagaStepLog) error
+	// ClaimDue leases up to limit running or compensating sagas whose next
+	// attempt is due, pushing it out by lease.
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]*Saga, error)
+	ListByStatus(status string, limit int) ([]*Saga, error)
+	ListSteps(sagaID string) ([]*SagaStepLog, error)
+}
+
+type SagaUsecase interface {
+	// StartCheckout creates an order and pays for it. It runs the saga
+	// until it finishes or a step has to be retried later; the returned saga
+	// shows which. An empty addressID ships to the user's default address.
+	StartCheckout(userID, sku string, quantity int, addressID string, couponCodes []string) (*Saga, error)
+	// RunDue resumes up to limit sagas whose retry is due and returns how
+	// many it ran.
+	RunDue(limit int) (int, error)
+	GetSaga(id string) (*Saga, []*SagaStepLog, error)
+	ListSagas(status string, limit int) ([]*Saga, error)
+}
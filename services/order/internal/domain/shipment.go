@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Shipment statuses. A shipment starts label_created and follows the
+// carrier's tracking updates; delivered, returned and cancelled are final.
+// Cancelled and returned shipments no longer count towards the order's
+// fulfilled quantity, so the units can be shipped again.
+const (
+	ShipmentStatusLabelCreated   = "label_created"
+	ShipmentStatusInTransit      = "in_transit"
+	ShipmentStatusOutForDelivery = "out_for_delivery"
+	ShipmentStatusDelivered      = "delivered"
+	ShipmentStatusException      = "exception"
+	ShipmentStatusReturned       = "returned"
+	ShipmentStatusCancelled      = "cancelled"
+)
+
+// shipmentTransitions lists the statuses each non-final status may move to.
+// Repeating the current status records a tracking update without a change.
+var shipmentTransitions = map[string][]string{
+	ShipmentStatusLabelCreated:   {ShipmentStatusInTransit, ShipmentStatusException, ShipmentStatusCancelled},
+	ShipmentStatusInTransit:      {ShipmentStatusOutForDelivery, ShipmentStatusDelivered, ShipmentStatusException, ShipmentStatusReturned},
+	ShipmentStatusOutForDelivery: {ShipmentStatusInTransit, ShipmentStatusDelivered, ShipmentStatusException, ShipmentStatusReturned},
+	ShipmentStatusException:      {ShipmentStatusInTransit, ShipmentStatusOutForDelivery, ShipmentStatusDelivered, ShipmentStatusReturned},
+}
+
+// Order fulfillment statuses, derived from the order's shipments.
+const (
+	FulfillmentStatusUnfulfilled        = "unfulfilled"
+	FulfillmentStatusPartiallyFulfilled = "partially_fulfilled"
+	FulfillmentStatusFulfilled          = "fulfilled"
+	FulfillmentStatusDelivered          = "delivered"
+)
+
+var (
+	ErrShipmentNotFound          = errors.New("shipment not found")
+	ErrOrderNotShippable         = errors.New("only paid orders can be shipped")
+	ErrNoShippingAddress         = errors.New("order has no shipping address")
+	ErrShipmentExceedsOrder      = errors.New("shipment quantity exceeds the unshipped quantity of the order")
+	ErrInvalidShipmentStatus     = errors.New("invalid shipment status")
+	ErrInvalidShipmentTransition = errors.New("invalid shipment status transition")
+)
+
+type Shipment struct {
+	ID             string `json:"id"`
+	OrderID        string `json:"order_id"`
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+	Quantity       int    `json:"quantity"`
+	Status         string `json:"status"`
+	// ShippedAt is when the carrier first reported the shipment in transit.
+	ShippedAt   *time.Time      `json:"shipped_at,omitempty"`
+	DeliveredAt *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Events      []ShipmentEvent `json:"events"`
+}
+
+// ShipmentEvent is one entry of a shipment's tracking history.
+type ShipmentEvent struct {
+	ID          string    `json:"id"`
+	Status      string    `json:"status"`
+	Location    string    `json:"location,omitempty"`
+	Description string    `json:"description,omitempty"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// Counted reports whether the shipment's units count as fulfilled.
+func (s *Shipment) Counted() bool {
+	return s.Status != ShipmentStatusCancelled && s.Status != ShipmentStatusReturned
+}
+
+// Apply moves the shipment to the event's status and stamps the shipped and
+// delivered times.
+func (s *Shipment) Apply(event ShipmentEvent) error {
+	if !validShipmentStatus(event.Status) {
+		return fmt.Errorf("%w: %s", ErrInvalidShipmentStatus, event.Status)
+	}
+
+	next, ok := shipmentTransitions[s.Status]
+	if !ok {
+		return fmt.Errorf("%w: shipment is %s", ErrInvalidShipmentTransition, s.Status)
+	}
+	allowed := event.Status == s.Status
+	for _, status := range next {
+		allowed = allowed || status == event.Status
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidShipmentTransition, s.Status, event.Status)
+	}
+
+	s.Status = event.Status
+	if s.ShippedAt == nil && event.Status != ShipmentStatusLabelCreated && event.Status != ShipmentStatusCancelled {
+		at := event.OccurredAt
+		s.ShippedAt = &at
+	}
+	if event.Status == ShipmentStatusDelivered {
+		at := event.OccurredAt
+		s.DeliveredAt = &at
+	}
+	return nil
+}
+
+func validShipmentStatus(status string) bool {
+	switch status {
+	case ShipmentStatusLabelCreated, ShipmentStatusInTransit, ShipmentStatusOutForDelivery, ShipmentStatusDelivered,
+		ShipmentStatusException, ShipmentStatusReturned, ShipmentStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Fulfillment summarizes how much of an order has been shipped and
+// delivered.
+type Fulfillment struct {
+	OrderID           string      `json:"order_id"`
+	Status            string      `json:"status"`
+	Quantity          int         `json:"quantity"`
+	ShippedQuantity   int         `json:"shipped_quantity"`
+	DeliveredQuantity int         `json:"delivered_quantity"`
+	Shipments         []*Shipment `json:"shipments"`
+}
+
+// NewFulfillment derives the fulfillment of order from its shipments.
+func NewFulfillment(order *Order, shipments []*Shipment) *Fulfillment {
+	f := &Fulfillment{OrderID: order.ID, Quantity: order.Quantity, Shipments: shipments}
+	for _, shipment := range shipments {
+		if shipment.Counted() {
+			f.ShippedQuantity += shipment.Quantity
+		}
+		if shipment.Status == ShipmentStatusDelivered {
+			f.DeliveredQuantity += shipment.Quantity
+		}
+	}
+
+	switch {
+	case f.DeliveredQuantity >= f.Quantity:
+		f.Status = FulfillmentStatusDelivered
+	case f.ShippedQuantity >= f.Quantity:
+		f.Status = FulfillmentStatusFulfilled
+	case f.ShippedQuantity > 0:
+		f.Status = FulfillmentStatusPartiallyFulfilled
+	default:
+		f.Status = FulfillmentStatusUnfulfilled
+	}
+	return f
+}
+
+type CreateShipmentRequest struct {
+	Carrier        string
+	TrackingNumber string
+	// Quantity defaults to the order's unshipped quantity.
+	Quantity int
+}
+
+type ShipmentRepository interface {
+	// Create stores the shipment with its label_created event. It locks the
+	// order and fails with ErrShipmentExceedsOrder when the order's counted
+	// shipments would exceed its quantity; a zero Quantity takes all that is
+	// left.
+	Create(shipment *Shipment) error
+	GetByID(orderID, id string) (*Shipment, error)
+	ListByOrder(orderID string) ([]*Shipment, error)
+	// AddEvent applies event to the shipment under a row lock and records it.
+	AddEvent(orderID, id string, event ShipmentEvent) (*Shipment, error)
+}
+
+type FulfillmentUsecase interface {
+	CreateShipment(orderID string, req CreateShipmentRequest) (*Shipment, error)
+	GetShipment(orderID, id string) (*Shipment, error)
+	GetFulfillment(orderID string) (*Fulfillment, error)
+	// UpdateShipmentStatus records a tracking update; a zero OccurredAt means
+	// now.
+	UpdateShipmentStatus(orderID, id string, event ShipmentEvent) (*Shipment, error)
+}
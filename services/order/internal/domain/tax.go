@@ -0,0 +1,49 @@
+package domain
+
+// Pricing modes. Under exclusive pricing catalog prices exclude tax and the
+// tax is added on top; under inclusive pricing they already contain it.
+const (
+	PricingTaxExclusive = "exclusive"
+	PricingTaxInclusive = "inclusive"
+)
+
+// TaxRequest asks for the tax on an order shipped to Country and Region.
+// Tax is charged where the goods are delivered. Amounts are in Currency.
+type TaxRequest struct {
+	Country  string
+	Region   string
+	Currency string
+	Lines    []TaxableLine
+}
+
+// TaxableLine is one order line. Amount is its price after discounts, as the
+// catalog states it: with tax included under inclusive pricing.
+type TaxableLine struct {
+	SKU      string
+	TaxClass string
+	Amount   float64
+}
+
+// TaxLine is the tax charged on one order line. NetAmount excludes tax, so
+// NetAmount + TaxAmount is what the line costs the customer.
+type TaxLine struct {
+	SKU       string  `json:"sku"`
+	TaxClass  string  `json:"tax_class"`
+	Rate      float64 `json:"rate"`
+	NetAmount float64 `json:"net_amount"`
+	TaxAmount float64 `json:"tax_amount"`
+}
+
+// TaxResult is the tax on a whole order. Total is what the customer pays.
+type TaxResult struct {
+	PricesIncludeTax bool
+	Lines            []TaxLine
+	TaxAmount        float64
+	Total            float64
+}
+
+// TaxCalculator works out the tax on an order. Tax is rounded to the
+// currency's minor unit per line, half away from zero.
+type TaxCalculator interface {
+	Calculate(req TaxRequest) (*TaxResult, error)
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Event types clients can subscribe to.
+const (
+	EventOrderCreated    = "order.created"
+	EventOrderPaid       = "order.paid"
+	EventOrderExpired    = "order.expired"
+	EventPaymentRefunded = "payment.refunded"
+	// EventShipmentUpdated is sent when a shipment is created and on every
+	// tracking update.
+	EventShipmentUpdated = "shipment.updated"
+)
+
+// WebhookEventTypes lists every event type a subscription may select.
+var WebhookEventTypes = []string{EventOrderCreated, EventOrderPaid, EventOrderExpired, EventPaymentRefunded, EventShipmentUpdated}
+
+// Delivery statuses. A pending delivery is retried with exponential backoff
+// until it succeeds or runs out of attempts, at which point it is dead and
+// shows up in the dead-letter list.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusDead      = "dead"
+)
+
+var (
+	ErrInvalidWebhookURL       = errors.New("webhook url must be an absolute http or https url")
+	ErrUnknownEventType        = errors.New("unknown event type")
+	ErrSubscriptionNotFound    = errors.New("webhook subscription not found")
+	ErrDeliveryNotDeadLettered = errors.New("delivery is not dead-lettered")
+)
+
+type WebhookSubscription struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"-"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookEvent is the envelope every delivery sends as its body.
+type WebhookEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type WebhookDelivery struct {
+	ID             string     `json:"id"`
+	SubscriptionID string     `json:"subscription_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"-"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// DeliveryAttempt records one HTTP request made for a delivery.
+type DeliveryAttempt struct {
+	ID          string        `json:"id"`
+	DeliveryID  string        `json:"delivery_id"`
+	StatusCode  int           `json:"status_code,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Duration    time.Duration `json:"duration"`
+	AttemptedAt time.Time     `json:"attempted_at"`
+}
+
+type WebhookSubscriptionRepository interface {
+	Create(sub *WebhookSubscription) error
+	GetByID(id string) (*WebhookSubscription, error)
+	List() ([]*WebhookSubscription, error)
+	ListByEventType(eventType string) ([]*WebhookSubscription, error)
+	Delete(id string) error
+}
+
+type WebhookDeliveryRepository interface {
+	// CreateBatch enqueues one delivery per subscription for an event.
+	CreateBatch(deliveries []*WebhookDelivery) error
+	GetByID(id string) (*WebhookDelivery, error)
+	// ClaimDue leases up to limit pending deliveries whose next attempt is
+	// due, pushing their next attempt out by lease so that concurrent
+	// workers do not pick them up again.
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]*WebhookDelivery, error)
+	// RecordAttempt stores the attempt and the delivery's new state.
+	RecordAttempt(delivery *WebhookDelivery, attempt *DeliveryAttempt) error
+	ListAttempts(deliveryID string) ([]*DeliveryAttempt, error)
+	ListByStatus(status string, limit int) ([]*WebhookDelivery, error)
+	// Requeue makes a dead delivery pending again with a fresh attempt budget.
+	Requeue(id string) error
+}
+
+type WebhookUsecase interface {
+	// CreateSubscription registers url for eventTypes and returns the
+	// subscription together with its signing secret, which is shown once.
+	CreateSubscription(url string, eventTypes []string) (*WebhookSubscription, error)
+	ListSubscriptions() ([]*WebhookSubscription, error)
+	DeleteSubscription(id string) error
+	// Publish enqueues a delivery of the event to every subscriber.
+	Publish(eventType string, data any) error
+	ListDeadLetters(limit int) ([]*WebhookDelivery, error)
+	ListAttempts(deliveryID string) ([]*DeliveryAttempt, error)
+	RedriveDelivery(id string) error
+}
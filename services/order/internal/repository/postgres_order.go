@@ -2,10 +2,19 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
 	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type PostgresOrderRepository struct {
@@ -16,30 +25,396 @@ func NewPostgresOrderRepository(db *sql.DB) domain.OrderRepository {
 	return &PostgresOrderRepository{db: db}
 }
 
+const orderColumns = `id, user_id, product, sku, quantity, unit_price, subtotal, discount_amount, tax_amount, prices_include_tax,
+	tax_lines, amount, currency, status, shipping_address, created_at, updated_at`
+
+func scanOrder(row rowScanner, order *domain.Order) error {
+	var taxLines, address []byte
+	err := row.Scan(&order.ID, &order.UserID, &order.Product, &order.SKU, &order.Quantity, &order.UnitPrice, &order.Subtotal,
+		&order.DiscountAmount, &order.TaxAmount, &order.PricesIncludeTax, &taxLines, &order.Amount, &order.Currency, &order.Status, &address,
+		&order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(taxLines, &order.TaxLines); err != nil {
+		return err
+	}
+	if address == nil {
+		return nil
+	}
+	order.ShippingAddress = &domain.ShippingAddress{}
+	return json.Unmarshal(address, order.ShippingAddress)
+}
+
+// Create keeps an ID chosen by the caller and generates one otherwise.
+// Coupon redemptions are taken with guarded updates on the promotion rows,
+// locked in ID order, so concurrent orders cannot overspend a limit.
 func (r *PostgresOrderRepository) Create(order *domain.Order) error {
-	order.ID = uuid.New().String()
+	if order.ID == "" {
+		order.ID = uuid.New().String()
+	}
 	order.CreatedAt = time.Now()
 	order.UpdatedAt = time.Now()
-	order.Status = "pending"
+	order.Status = domain.OrderStatusPending
 
-	query := `INSERT INTO orders (id, user_id, product, amount, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.db.Exec(query, order.ID, order.UserID, order.Product, order.Amount, order.Status, order.CreatedAt, order.UpdatedAt)
-	return err
+	// A nil address is stored as NULL.
+	var address any
+	if order.ShippingAddress != nil {
+		raw, err := json.Marshal(order.ShippingAddress)
+		if err != nil {
+			return err
+		}
+		address = string(raw)
+	}
+	taxLines, err := json.Marshal(order.TaxLines)
+	if err != nil {
+		return err
+	}
+
+	return r.withTx(func(tx *sql.Tx) error {
+		query := `INSERT INTO orders (` + orderColumns + `)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+			ON CONFLICT (id) DO NOTHING`
+		res, err := tx.Exec(query, order.ID, order.UserID, order.Product, order.SKU, order.Quantity, order.UnitPrice, order.Subtotal,
+			order.DiscountAmount, order.TaxAmount, order.PricesIncludeTax, string(taxLines), order.Amount, order.Currency, order.Status, address,
+			order.CreatedAt, order.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return domain.ErrOrderExists
+		}
+
+		discounts := append([]domain.AppliedDiscount(nil), order.Discounts...)
+		sort.Slice(discounts, func(i, j int) bool { return discounts[i].PromotionID < discounts[j].PromotionID })
+		for _, discount := range discounts {
+			if err := redeem(tx, order, discount); err != nil {
+				return err
+			}
+		}
+		return outbox.Add(tx, domain.AggregateOrder, order.ID, domain.EventOrderCreated, order)
+	})
 }
 
 func (r *PostgresOrderRepository) GetByID(id string) (*domain.Order, error) {
 	order := &domain.Order{}
-	query := `SELECT id, user_id, product, amount, status, created_at, updated_at FROM orders WHERE id = $1`
-	err := r.db.QueryRow(query, id).Scan(&order.ID, &order.UserID, &order.Product, &order.Amount, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE id = $1`
+	err := scanOrder(r.db.QueryRow(query, id), order)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrOrderNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+
+	if err := r.loadDiscounts([]*domain.Order{order}); err != nil {
+		return nil, err
+	}
 	return order, nil
 }
 
-func (r *PostgresOrderRepository) Update(order *domain.Order) error {
+func (r *PostgresOrderRepository) Update(order *domain.Order, from string) error {
 	order.UpdatedAt = time.Now()
-	query := `UPDATE orders SET status = $1, updated_at = $2 WHERE id = $3`
-	_, err := r.db.Exec(query, order.Status, order.UpdatedAt, order.ID)
+	return r.withTx(func(tx *sql.Tx) error {
+		query := `UPDATE orders SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4`
+		res, err := tx.Exec(query, order.Status, order.UpdatedAt, order.ID, from)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return updateConflict(tx, order.ID)
+		}
+		if order.Status == domain.OrderStatusCancelled {
+			if err := releaseRedemptions(tx, order.ID); err != nil {
+				return err
+			}
+		}
+		return outbox.Add(tx, domain.AggregateOrder, order.ID, domain.EventOrderUpdated, order)
+	})
+}
+
+// updateConflict tells why an update matched no row: the order is gone,
+// closed, or moved to another status since it was read.
+func updateConflict(tx *sql.Tx, id string) error {
+	var status string
+	err := tx.QueryRow(`SELECT status FROM orders WHERE id = $1`, id).Scan(&status)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return domain.ErrOrderNotFound
+	case err != nil:
+		return err
+	case status == domain.OrderStatusCancelled || status == domain.OrderStatusExpired:
+		return domain.ErrOrderClosed
+	default:
+		return domain.ErrOrderConflict
+	}
+}
+
+func (r *PostgresOrderRepository) SavePayment(orderID string, payment domain.OrderPayment) ([]domain.OrderPayment, error) {
+	var payments []domain.OrderPayment
+	err := r.withTx(func(tx *sql.Tx) error {
+		query := `INSERT INTO order_payments (payment_id, order_id, currency, captured_amount, refunded_amount, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (payment_id) DO UPDATE SET
+				captured_amount = GREATEST(order_payments.captured_amount, EXCLUDED.captured_amount),
+				refunded_amount = GREATEST(order_payments.refunded_amount, EXCLUDED.refunded_amount),
+				updated_at = EXCLUDED.updated_at`
+		if _, err := tx.Exec(query, payment.PaymentID, orderID, payment.Currency, payment.CapturedAmount, payment.RefundedAmount, time.Now()); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(`SELECT payment_id, currency, captured_amount, refunded_amount
+			FROM order_payments WHERE order_id = $1 ORDER BY payment_id`, orderID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var p domain.OrderPayment
+			if err := rows.Scan(&p.PaymentID, &p.Currency, &p.CapturedAmount, &p.RefundedAmount); err != nil {
+				return err
+			}
+			payments = append(payments, p)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *PostgresOrderRepository) ExpirePending(before time.Time, limit int) ([]*domain.Order, error) {
+	var orders []*domain.Order
+	err := r.withTx(func(tx *sql.Tx) error {
+		query := `UPDATE orders SET status = $1, updated_at = $2
+			WHERE id IN (
+				SELECT id FROM orders
+				WHERE status = $3 AND created_at < $4
+				ORDER BY created_at
+				LIMIT $5
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + orderColumns
+		rows, err := tx.Query(query, domain.OrderStatusExpired, time.Now(), domain.OrderStatusPending, before, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			order := &domain.Order{}
+			if err := scanOrder(rows, order); err != nil {
+				return err
+			}
+			orders = append(orders, order)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			if err := releaseRedemptions(tx, order.ID); err != nil {
+				return err
+			}
+			if err := outbox.Add(tx, domain.AggregateOrder, order.ID, domain.EventOrderUpdated, order); err != nil {
+				return err
+			}
+			if err := outbox.Add(tx, domain.AggregateOrder, order.ID, domain.EventOrderExpired, order); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// withTx runs fn in a transaction, so that a write and its outbox event are
+// committed together.
+func (r *PostgresOrderRepository) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// List returns orders matching the filter using keyset pagination on
+// (sort column, id), which stays stable while new orders are inserted.
+func (r *PostgresOrderRepository) List(filter domain.OrderFilter) ([]*domain.Order, error) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.UserID != "" {
+		conds = append(conds, "user_id = "+arg(filter.UserID))
+	}
+	if filter.Status != "" {
+		conds = append(conds, "status = "+arg(filter.Status))
+	}
+	if filter.Currency != "" {
+		conds = append(conds, "currency = "+arg(filter.Currency))
+	}
+	if filter.CreatedAfter != nil {
+		conds = append(conds, "created_at >= "+arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		conds = append(conds, "created_at < "+arg(*filter.CreatedBefore))
+	}
+	if filter.MinAmount != nil {
+		conds = append(conds, "amount >= "+arg(*filter.MinAmount))
+	}
+	if filter.MaxAmount != nil {
+		conds = append(conds, "amount <= "+arg(*filter.MaxAmount))
+	}
+
+	column := "created_at"
+	if filter.Page.Sort.Field == domain.OrderSortAmount {
+		column = "amount"
+	}
+	direction, cmp := filter.Page.Sort.Direction()
+
+	if after := filter.Page.After; after != nil {
+		var value any = after.CreatedAt
+		if column == "amount" {
+			amount, err := strconv.ParseFloat(after.Key, 64)
+			if err != nil {
+				return nil, pagination.ErrInvalidPageToken
+			}
+			value = amount
+		}
+		conds = append(conds, fmt.Sprintf("(%s, id) %s (%s, %s)", column, cmp, arg(value), arg(after.ID)))
+	}
+
+	query := `SELECT ` + orderColumns + ` FROM orders`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", column, direction, direction, arg(filter.Page.Limit()))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*domain.Order
+	for rows.Next() {
+		order := &domain.Order{}
+		if err := scanOrder(rows, order); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadDiscounts(orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// redeem takes one redemption of discount's promotion for order. The
+// guarded update re-checks the promotion under its row lock, which also
+// serializes the per-user count.
+func redeem(tx *sql.Tx, order *domain.Order, discount domain.AppliedDiscount) error {
+	now := time.Now()
+
+	var perUserLimit int
+	query := `UPDATE promotions SET redemptions = redemptions + 1, updated_at = $2
+		WHERE id = $1 AND active
+			AND (max_redemptions = 0 OR redemptions < max_redemptions)
+			AND (starts_at IS NULL OR starts_at <= $2)
+			AND (ends_at IS NULL OR ends_at > $2)
+		RETURNING per_user_limit`
+	err := tx.QueryRow(query, discount.PromotionID, now).Scan(&perUserLimit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", domain.ErrCouponExhausted, discount.Code)
+	}
+	if err != nil {
+		return err
+	}
+
+	if perUserLimit > 0 {
+		var used int
+		query = `SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2 AND released_at IS NULL`
+		if err := tx.QueryRow(query, discount.PromotionID, order.UserID).Scan(&used); err != nil {
+			return err
+		}
+		if used >= perUserLimit {
+			return fmt.Errorf("%w: %s", domain.ErrCouponUserLimit, discount.Code)
+		}
+	}
+
+	query = `INSERT INTO promotion_redemptions (id, promotion_id, user_id, order_id, created_at) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.Exec(query, uuid.New().String(), discount.PromotionID, order.UserID, order.ID, now); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO order_discounts (order_id, promotion_id, code, type, value, amount) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.Exec(query, order.ID, discount.PromotionID, discount.Code, discount.Type, discount.Value, discount.Amount)
 	return err
 }
+
+// releaseRedemptions gives back the coupon redemptions of a cancelled or
+// expired order. The discounts stay recorded on the order.
+func releaseRedemptions(tx *sql.Tx, orderID string) error {
+	query := `WITH released AS (
+			UPDATE promotion_redemptions SET released_at = $2
+			WHERE order_id = $1 AND released_at IS NULL
+			RETURNING promotion_id
+		)
+		UPDATE promotions p SET redemptions = p.redemptions - 1, updated_at = $2
+		FROM released r WHERE p.id = r.promotion_id`
+	_, err := tx.Exec(query, orderID, time.Now())
+	return err
+}
+
+// loadDiscounts fills in the applied discounts of orders.
+func (r *PostgresOrderRepository) loadDiscounts(orders []*domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*domain.Order, len(orders))
+	ids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		byID[order.ID] = order
+		ids = append(ids, order.ID)
+	}
+
+	query := `SELECT order_id, promotion_id, code, type, value, amount FROM order_discounts
+		WHERE order_id = ANY($1) ORDER BY order_id, code`
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID string
+		var d domain.AppliedDiscount
+		if err := rows.Scan(&orderID, &d.PromotionID, &d.Code, &d.Type, &d.Value, &d.Amount); err != nil {
+			return err
+		}
+		byID[orderID].Discounts = append(byID[orderID].Discounts, d)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PostgresPromotionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPromotionRepository(db *sql.DB) domain.PromotionRepository {
+	return &PostgresPromotionRepository{db: db}
+}
+
+const promotionColumns = `id, code, description, type, value, currency, min_order_amount, max_redemptions, per_user_limit,
+	redemptions, starts_at, ends_at, stackable, active, created_at, updated_at`
+
+func scanPromotion(row rowScanner) (*domain.Promotion, error) {
+	p := &domain.Promotion{}
+	err := row.Scan(&p.ID, &p.Code, &p.Description, &p.Type, &p.Value, &p.Currency, &p.MinOrderAmount, &p.MaxRedemptions,
+		&p.PerUserLimit, &p.Redemptions, &p.StartsAt, &p.EndsAt, &p.Stackable, &p.Active, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PostgresPromotionRepository) Create(p *domain.Promotion) error {
+	p.ID = uuid.New().String()
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+
+	query := `INSERT INTO promotions (` + promotionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (code) DO NOTHING`
+	res, err := r.db.Exec(query, p.ID, p.Code, p.Description, p.Type, p.Value, p.Currency, p.MinOrderAmount, p.MaxRedemptions,
+		p.PerUserLimit, p.Redemptions, p.StartsAt, p.EndsAt, p.Stackable, p.Active, p.CreatedAt, p.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrPromotionExists
+	}
+	return nil
+}
+
+func (r *PostgresPromotionRepository) GetByCode(code string) (*domain.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE code = $1`
+	p, err := scanPromotion(r.db.QueryRow(query, code))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrPromotionNotFound
+	}
+	return p, err
+}
+
+func (r *PostgresPromotionRepository) List(activeOnly bool, limit int) ([]*domain.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE (NOT $1 OR active) ORDER BY created_at DESC, id LIMIT $2`
+	rows, err := r.db.Query(query, activeOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []*domain.Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, rows.Err()
+}
+
+// Update writes the editable settings. The redemption count is left alone:
+// only orders change it.
+func (r *PostgresPromotionRepository) Update(p *domain.Promotion) error {
+	p.UpdatedAt = time.Now()
+
+	query := `UPDATE promotions SET description = $1, min_order_amount = $2, max_redemptions = $3, per_user_limit = $4,
+		starts_at = $5, ends_at = $6, stackable = $7, active = $8, updated_at = $9
+		WHERE id = $10
+		RETURNING redemptions`
+	err := r.db.QueryRow(query, p.Description, p.MinOrderAmount, p.MaxRedemptions, p.PerUserLimit, p.StartsAt, p.EndsAt,
+		p.Stackable, p.Active, p.UpdatedAt, p.ID).Scan(&p.Redemptions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrPromotionNotFound
+	}
+	return err
+}
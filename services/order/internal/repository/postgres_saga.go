@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PostgresSagaRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresSagaRepository(db *sql.DB) domain.SagaRepository {
+	return &PostgresSagaRepository{db: db}
+}
+
+const sagaColumns = `id, type, status, step, data, attempts, last_error, next_attempt_at, created_at, updated_at`
+
+func scanSaga(row rowScanner, s *domain.Saga) error {
+	var data []byte
+	err := row.Scan(&s.ID, &s.Type, &s.Status, &s.Step, &data, &s.Attempts, &s.LastError, &s.NextAttemptAt,
+		&s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &s.Data)
+}
+
+func (r *PostgresSagaRepository) Create(s *domain.Saga) error {
+	data, err := json.Marshal(s.Data)
+	if err != nil {
+		return err
+	}
+
+	s.ID = uuid.New().String()
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+
+	query := `INSERT INTO sagas (` + sagaColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err = r.db.Exec(query, s.ID, s.Type, s.Status, s.Step, data, s.Attempts, s.LastError, s.NextAttemptAt,
+		s.CreatedAt, s.UpdatedAt)
+	return err
+}
+
+func (r *PostgresSagaRepository) GetByID(id string) (*domain.Saga, error) {
+	s := &domain.Saga{}
+	err := scanSaga(r.db.QueryRow(`SELECT `+sagaColumns+` FROM sagas WHERE id = $1`, id), s)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrSagaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *PostgresSagaRepository) Save(s *domain.Saga, entry *domain.SagaStepLog) error {
+	data, err := json.Marshal(s.Data)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	s.UpdatedAt = time.Now()
+	query := `UPDATE sagas SET status = $1, step = $2, data = $3, attempts = $4, last_error = $5,
+		next_attempt_at = $6, updated_at = $7 WHERE id = $8`
+	_, err = tx.Exec(query, s.Status, s.Step, data, s.Attempts, s.LastError, s.NextAttemptAt, s.UpdatedAt, s.ID)
+	if err != nil {
+		return err
+	}
+
+	if entry != nil {
+		entry.ID = uuid.New().String()
+		entry.SagaID = s.ID
+		entry.CreatedAt = s.UpdatedAt
+		query = `INSERT INTO saga_steps (id, saga_id, step, action, status, error, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+		_, err = tx.Exec(query, entry.ID, entry.SagaID, entry.Step, entry.Action, entry.Status, entry.Error,
+			entry.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresSagaRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*domain.Saga, error) {
+	query := `UPDATE sagas SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM sagas
+			WHERE status IN ($3, $4) AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + sagaColumns
+	return r.query(query, now, now.Add(lease), domain.SagaStatusRunning, domain.SagaStatusCompensating, limit)
+}
+
+func (r *PostgresSagaRepository) ListByStatus(status string, limit int) ([]*domain.Saga, error) {
+	if status == "" {
+		return r.query(`SELECT `+sagaColumns+` FROM sagas ORDER BY created_at DESC, id LIMIT $1`, limit)
+	}
+	return r.query(`SELECT `+sagaColumns+` FROM sagas WHERE status = $1 ORDER BY created_at DESC, id LIMIT $2`, status, limit)
+}
+
+func (r *PostgresSagaRepository) ListSteps(sagaID string) ([]*domain.SagaStepLog, error) {
+	query := `SELECT id, saga_id, step, action, status, error, created_at
+		FROM saga_steps WHERE saga_id = $1 ORDER BY seq`
+	rows, err := r.db.Query(query, sagaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var steps []*domain.SagaStepLog
+	for rows.Next() {
+		step := &domain.SagaStepLog{}
+		err := rows.Scan(&step.ID, &step.SagaID, &step.Step, &step.Action, &step.Status, &step.Error, &step.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
+
+func (r *PostgresSagaRepository) query(query string, args ...any) ([]*domain.Saga, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sagas []*domain.Saga
+	for rows.Next() {
+		s := &domain.Saga{}
+		if err := scanSaga(rows, s); err != nil {
+			return nil, err
+		}
+		sagas = append(sagas, s)
+	}
+	return sagas, rows.Err()
+}
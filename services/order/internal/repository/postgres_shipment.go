@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PostgresShipmentRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresShipmentRepository(db *sql.DB) domain.ShipmentRepository {
+	return &PostgresShipmentRepository{db: db}
+}
+
+const shipmentColumns = `id, order_id, carrier, tracking_number, quantity, status, shipped_at, delivered_at, created_at, updated_at`
+
+func scanShipment(row rowScanner) (*domain.Shipment, error) {
+	s := &domain.Shipment{}
+	err := row.Scan(&s.ID, &s.OrderID, &s.Carrier, &s.TrackingNumber, &s.Quantity, &s.Status, &s.ShippedAt,
+		&s.DeliveredAt, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *PostgresShipmentRepository) Create(shipment *domain.Shipment) error {
+	shipment.ID = uuid.New().String()
+	shipment.Status = domain.ShipmentStatusLabelCreated
+	shipment.CreatedAt = time.Now()
+	shipment.UpdatedAt = shipment.CreatedAt
+
+	return r.withTx(func(tx *sql.Tx) error {
+		// Lock the order so concurrent shipments cannot both take the same
+		// units.
+		var ordered int
+		err := tx.QueryRow(`SELECT quantity FROM orders WHERE id = $1 FOR UPDATE`, shipment.OrderID).Scan(&ordered)
+		if err != nil {
+			return err
+		}
+
+		var shipped int
+		query := `SELECT COALESCE(SUM(quantity), 0) FROM shipments WHERE order_id = $1 AND status <> ALL($2)`
+		uncounted := pq.Array([]string{domain.ShipmentStatusCancelled, domain.ShipmentStatusReturned})
+		if err := tx.QueryRow(query, shipment.OrderID, uncounted).Scan(&shipped); err != nil {
+			return err
+		}
+
+		remaining := ordered - shipped
+		if shipment.Quantity == 0 {
+			shipment.Quantity = remaining
+		}
+		if shipment.Quantity <= 0 || shipment.Quantity > remaining {
+			return domain.ErrShipmentExceedsOrder
+		}
+
+		query = `INSERT INTO shipments (` + shipmentColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+		_, err = tx.Exec(query, shipment.ID, shipment.OrderID, shipment.Carrier, shipment.TrackingNumber, shipment.Quantity,
+			shipment.Status, shipment.ShippedAt, shipment.DeliveredAt, shipment.CreatedAt, shipment.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		event := domain.ShipmentEvent{Status: shipment.Status, OccurredAt: shipment.CreatedAt}
+		if err := insertShipmentEvent(tx, shipment.ID, &event); err != nil {
+			return err
+		}
+		shipment.Events = []domain.ShipmentEvent{event}
+		return nil
+	})
+}
+
+func (r *PostgresShipmentRepository) GetByID(orderID, id string) (*domain.Shipment, error) {
+	query := `SELECT ` + shipmentColumns + ` FROM shipments WHERE id = $1 AND order_id = $2`
+	shipment, err := scanShipment(r.db.QueryRow(query, id, orderID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrShipmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadEvents([]*domain.Shipment{shipment}); err != nil {
+		return nil, err
+	}
+	return shipment, nil
+}
+
+func (r *PostgresShipmentRepository) ListByOrder(orderID string) ([]*domain.Shipment, error) {
+	query := `SELECT ` + shipmentColumns + ` FROM shipments WHERE order_id = $1 ORDER BY created_at, id`
+	rows, err := r.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shipments []*domain.Shipment
+	for rows.Next() {
+		shipment, err := scanShipment(rows)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadEvents(shipments); err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
+
+func (r *PostgresShipmentRepository) AddEvent(orderID, id string, event domain.ShipmentEvent) (*domain.Shipment, error) {
+	var shipment *domain.Shipment
+	err := r.withTx(func(tx *sql.Tx) error {
+		var err error
+		query := `SELECT ` + shipmentColumns + ` FROM shipments WHERE id = $1 AND order_id = $2 FOR UPDATE`
+		shipment, err = scanShipment(tx.QueryRow(query, id, orderID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrShipmentNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := shipment.Apply(event); err != nil {
+			return err
+		}
+		shipment.UpdatedAt = time.Now()
+
+		query = `UPDATE shipments SET status = $1, shipped_at = $2, delivered_at = $3, updated_at = $4 WHERE id = $5`
+		if _, err := tx.Exec(query, shipment.Status, shipment.ShippedAt, shipment.DeliveredAt, shipment.UpdatedAt, shipment.ID); err != nil {
+			return err
+		}
+		return insertShipmentEvent(tx, shipment.ID, &event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadEvents([]*domain.Shipment{shipment}); err != nil {
+		return nil, err
+	}
+	return shipment, nil
+}
+
+func insertShipmentEvent(tx *sql.Tx, shipmentID string, event *domain.ShipmentEvent) error {
+	event.ID = uuid.New().String()
+	query := `INSERT INTO shipment_events (id, shipment_id, status, location, description, occurred_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := tx.Exec(query, event.ID, shipmentID, event.Status, event.Location, event.Description, event.OccurredAt, time.Now())
+	return err
+}
+
+// loadEvents fills in the tracking history of shipments, oldest first.
+func (r *PostgresShipmentRepository) loadEvents(shipments []*domain.Shipment) error {
+	if len(shipments) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*domain.Shipment, len(shipments))
+	ids := make([]string, 0, len(shipments))
+	for _, shipment := range shipments {
+		shipment.Events = []domain.ShipmentEvent{}
+		byID[shipment.ID] = shipment
+		ids = append(ids, shipment.ID)
+	}
+
+	query := `SELECT shipment_id, id, status, location, description, occurred_at FROM shipment_events
+		WHERE shipment_id = ANY($1) ORDER BY occurred_at, created_at, id`
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shipmentID string
+		var event domain.ShipmentEvent
+		if err := rows.Scan(&shipmentID, &event.ID, &event.Status, &event.Location, &event.Description, &event.OccurredAt); err != nil {
+			return err
+		}
+		shipment := byID[shipmentID]
+		shipment.Events = append(shipment.Events, event)
+	}
+	return rows.Err()
+}
+
+func (r *PostgresShipmentRepository) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
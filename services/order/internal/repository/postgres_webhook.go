@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PostgresWebhookSubscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresWebhookSubscriptionRepository(db *sql.DB) domain.WebhookSubscriptionRepository {
+	return &PostgresWebhookSubscriptionRepository{db: db}
+}
+
+const subscriptionColumns = `id, url, event_types, secret, active, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row rowScanner, sub *domain.WebhookSubscription) error {
+	return row.Scan(&sub.ID, &sub.URL, pq.Array(&sub.EventTypes), &sub.Secret, &sub.Active, &sub.CreatedAt)
+}
+
+func (r *PostgresWebhookSubscriptionRepository) Create(sub *domain.WebhookSubscription) error {
+	sub.ID = uuid.New().String()
+	sub.Active = true
+	sub.CreatedAt = time.Now()
+
+	query := `INSERT INTO webhook_subscriptions (` + subscriptionColumns + `) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.db.Exec(query, sub.ID, sub.URL, pq.Array(sub.EventTypes), sub.Secret, sub.Active, sub.CreatedAt)
+	return err
+}
+
+func (r *PostgresWebhookSubscriptionRepository) GetByID(id string) (*domain.WebhookSubscription, error) {
+	sub := &domain.WebhookSubscription{}
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+	err := scanSubscription(r.db.QueryRow(query, id), sub)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (r *PostgresWebhookSubscriptionRepository) List() ([]*domain.WebhookSubscription, error) {
+	return r.query(`SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions ORDER BY created_at, id`)
+}
+
+func (r *PostgresWebhookSubscriptionRepository) ListByEventType(eventType string) ([]*domain.WebhookSubscription, error) {
+	return r.query(`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE active AND $1 = ANY(event_types)`, eventType)
+}
+
+func (r *PostgresWebhookSubscriptionRepository) Delete(id string) error {
+	res, err := r.db.Exec(`DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return domain.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func (r *PostgresWebhookSubscriptionRepository) query(query string, args ...any) ([]*domain.WebhookSubscription, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*domain.WebhookSubscription
+	for rows.Next() {
+		sub := &domain.WebhookSubscription{}
+		if err := scanSubscription(rows, sub); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+type PostgresWebhookDeliveryRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresWebhookDeliveryRepository(db *sql.DB) domain.WebhookDeliveryRepository {
+	return &PostgresWebhookDeliveryRepository{db: db}
+}
+
+const deliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, last_status_code, created_at, updated_at, delivered_at`
+
+func scanDelivery(row rowScanner, d *domain.WebhookDelivery) error {
+	return row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastError, &d.LastStatusCode, &d.CreatedAt, &d.UpdatedAt, &d.DeliveredAt)
+}
+
+func (r *PostgresWebhookDeliveryRepository) CreateBatch(deliveries []*domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `INSERT INTO webhook_deliveries (` + deliveryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+	for _, d := range deliveries {
+		d.ID = uuid.New().String()
+		d.Status = domain.DeliveryStatusPending
+		d.NextAttemptAt = now
+		d.CreatedAt = now
+		d.UpdatedAt = now
+		_, err := tx.Exec(query, d.ID, d.SubscriptionID, d.EventID, d.EventType, d.Payload, d.Status, d.Attempts,
+			d.NextAttemptAt, d.LastError, d.LastStatusCode, d.CreatedAt, d.UpdatedAt, d.DeliveredAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresWebhookDeliveryRepository) GetByID(id string) (*domain.WebhookDelivery, error) {
+	d := &domain.WebhookDelivery{}
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+	if err := scanDelivery(r.db.QueryRow(query, id), d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *PostgresWebhookDeliveryRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $3 AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns
+	return r.query(query, now, now.Add(lease), domain.DeliveryStatusPending, limit)
+}
+
+func (r *PostgresWebhookDeliveryRepository) RecordAttempt(d *domain.WebhookDelivery, attempt *domain.DeliveryAttempt) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	attempt.ID = uuid.New().String()
+	attempt.DeliveryID = d.ID
+	query := `INSERT INTO webhook_delivery_attempts (id, delivery_id, status_code, error, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.Exec(query, attempt.ID, attempt.DeliveryID, attempt.StatusCode, attempt.Error,
+		attempt.Duration.Milliseconds(), attempt.AttemptedAt)
+	if err != nil {
+		return err
+	}
+
+	d.UpdatedAt = time.Now()
+	query = `UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4,
+		last_status_code = $5, updated_at = $6, delivered_at = $7 WHERE id = $8`
+	_, err = tx.Exec(query, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.LastStatusCode, d.UpdatedAt,
+		d.DeliveredAt, d.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresWebhookDeliveryRepository) ListAttempts(deliveryID string) ([]*domain.DeliveryAttempt, error) {
+	query := `SELECT id, delivery_id, status_code, error, duration_ms, attempted_at
+		FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY attempted_at, id`
+	rows, err := r.db.Query(query, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*domain.DeliveryAttempt
+	for rows.Next() {
+		a := &domain.DeliveryAttempt{}
+		var durationMS int64
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.StatusCode, &a.Error, &durationMS, &a.AttemptedAt); err != nil {
+			return nil, err
+		}
+		a.Duration = time.Duration(durationMS) * time.Millisecond
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+func (r *PostgresWebhookDeliveryRepository) ListByStatus(status string, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE status = $1 ORDER BY updated_at DESC, id LIMIT $2`
+	return r.query(query, status, limit)
+}
+
+func (r *PostgresWebhookDeliveryRepository) Requeue(id string) error {
+	query := `UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = $2, updated_at = $2
+		WHERE id = $3 AND status = $4`
+	res, err := r.db.Exec(query, domain.DeliveryStatusPending, time.Now(), id, domain.DeliveryStatusDead)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return domain.ErrDeliveryNotDeadLettered
+	}
+	return nil
+}
+
+func (r *PostgresWebhookDeliveryRepository) query(query string, args ...any) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		d := &domain.WebhookDelivery{}
+		if err := scanDelivery(rows, d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
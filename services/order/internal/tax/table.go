@@ -0,0 +1,135 @@
+// Package tax contains TaxCalculator implementations.
+package tax
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/edwinjordan/golang_microservices/pkg/fx"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+)
+
+// Rule is one row of a rate table. An empty Region applies to the whole
+// country and an empty TaxClass to every class without a rule of its own.
+// Rate is a fraction: 0.0725 for 7.25%.
+type Rule struct {
+	Country  string  `json:"country"`
+	Region   string  `json:"region,omitempty"`
+	TaxClass string  `json:"tax_class,omitempty"`
+	Rate     float64 `json:"rate"`
+}
+
+// TableConfig is the rate table file: the pricing mode and the rates.
+type TableConfig struct {
+	Pricing string `json:"pricing"`
+	Rates   []Rule `json:"rates"`
+}
+
+type ruleKey struct {
+	country, region, taxClass string
+}
+
+// Table looks rates up by destination and tax class. The most specific rule
+// wins: region and class, then country and class, then region, then
+// country. A class rule outranks a region's general rate, so a
+// country-wide exemption such as US digital goods holds in every state
+// unless the state has a rule for that class. A destination without any
+// rule is not taxed.
+type Table struct {
+	inclusive bool
+	rates     map[ruleKey]float64
+}
+
+// LoadTable reads a TableConfig from a JSON file.
+func LoadTable(path string) (domain.TaxCalculator, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tax table: %w", err)
+	}
+
+	var cfg TableConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("parse tax table %s: %w", path, err)
+	}
+	return NewTable(cfg)
+}
+
+func NewTable(cfg TableConfig) (domain.TaxCalculator, error) {
+	t := &Table{rates: make(map[ruleKey]float64, len(cfg.Rates))}
+	switch cfg.Pricing {
+	case domain.PricingTaxExclusive, "":
+	case domain.PricingTaxInclusive:
+		t.inclusive = true
+	default:
+		return nil, fmt.Errorf("tax table: pricing must be %s or %s", domain.PricingTaxExclusive, domain.PricingTaxInclusive)
+	}
+
+	for i, rule := range cfg.Rates {
+		key := newKey(rule.Country, rule.Region, rule.TaxClass)
+		if len(key.country) != 2 {
+			return nil, fmt.Errorf("tax table: rate %d: country must be an ISO 3166-1 alpha-2 code", i)
+		}
+		if rule.Rate < 0 || rule.Rate >= 1 {
+			return nil, fmt.Errorf("tax table: rate %d: rate must be a fraction in [0, 1)", i)
+		}
+		if _, ok := t.rates[key]; ok {
+			return nil, fmt.Errorf("tax table: rate %d duplicates an earlier rule", i)
+		}
+		t.rates[key] = rule.Rate
+	}
+	return t, nil
+}
+
+func newKey(country, region, taxClass string) ruleKey {
+	return ruleKey{
+		country:  strings.ToUpper(strings.TrimSpace(country)),
+		region:   strings.ToUpper(strings.TrimSpace(region)),
+		taxClass: strings.ToLower(strings.TrimSpace(taxClass)),
+	}
+}
+
+func (t *Table) rate(country, region, taxClass string) float64 {
+	key := newKey(country, region, taxClass)
+	candidates := []ruleKey{
+		key,
+		{key.country, "", key.taxClass},
+		{key.country, key.region, ""},
+		{key.country, "", ""},
+	}
+	for _, candidate := range candidates {
+		if rate, ok := t.rates[candidate]; ok {
+			return rate
+		}
+	}
+	return 0
+}
+
+func (t *Table) Calculate(req domain.TaxRequest) (*domain.TaxResult, error) {
+	result := &domain.TaxResult{
+		PricesIncludeTax: t.inclusive,
+		Lines:            make([]domain.TaxLine, 0, len(req.Lines)),
+	}
+	for _, line := range req.Lines {
+		if line.Amount < 0 {
+			return nil, fmt.Errorf("tax: line %s has a negative amount", line.SKU)
+		}
+
+		rate := t.rate(req.Country, req.Region, line.TaxClass)
+		amount := fx.Round(line.Amount, req.Currency)
+		taxLine := domain.TaxLine{SKU: line.SKU, TaxClass: line.TaxClass, Rate: rate}
+		if t.inclusive {
+			taxLine.TaxAmount = fx.Round(amount*rate/(1+rate), req.Currency)
+			taxLine.NetAmount = fx.Round(amount-taxLine.TaxAmount, req.Currency)
+		} else {
+			taxLine.NetAmount = amount
+			taxLine.TaxAmount = fx.Round(amount*rate, req.Currency)
+		}
+
+		result.Lines = append(result.Lines, taxLine)
+		result.TaxAmount = fx.Round(result.TaxAmount+taxLine.TaxAmount, req.Currency)
+		result.Total = fx.Round(result.Total+taxLine.NetAmount+taxLine.TaxAmount, req.Currency)
+	}
+	return result, nil
+}
@@ -0,0 +1,174 @@
+package tax
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var testRates = []Rule{
+	{Country: "US", Region: "CA", Rate: 0.0725},
+	{Country: "US", Region: "CA", TaxClass: "food", Rate: 0},
+	{Country: "US", Region: "NY", Rate: 0.04},
+	{Country: "US", Region: "NY", TaxClass: "digital", Rate: 0.04},
+	{Country: "US", TaxClass: "digital", Rate: 0},
+	{Country: "DE", Rate: 0.19},
+	{Country: "DE", TaxClass: "food", Rate: 0.07},
+	{Country: "JP", Rate: 0.10},
+}
+
+// TestTableCalculate compares Calculate's results with the golden files in
+// testdata. Run with -update to rewrite them after an intended change.
+func TestTableCalculate(t *testing.T) {
+	cases := []struct {
+		name    string
+		pricing string
+		req     domain.TaxRequest
+	}{
+		{"exclusive_single_line", domain.PricingTaxExclusive, domain.TaxRequest{
+			Country: "US", Region: "CA", Currency: "USD",
+			Lines: []domain.TaxableLine{{SKU: "TSHIRT-M", TaxClass: "standard", Amount: 19.99}},
+		}},
+		{"exclusive_half_cent", domain.PricingTaxExclusive, domain.TaxRequest{
+			Country: "DE", Currency: "EUR",
+			Lines: []domain.TaxableLine{
+				{SKU: "PEN", Amount: 1.005},
+				{SKU: "APPLE", TaxClass: "food", Amount: 0.5},
+			},
+		}},
+		{"exclusive_multi_line", domain.PricingTaxExclusive, domain.TaxRequest{
+			Country: "US", Region: "CA", Currency: "USD",
+			Lines: []domain.TaxableLine{
+				{SKU: "A", Amount: 0.10},
+				{SKU: "B", Amount: 0.20},
+				{SKU: "C", Amount: 0.30},
+				{SKU: "BREAD", TaxClass: "food", Amount: 3.49},
+			},
+		}},
+		{"exclusive_digital_goods_ca", domain.PricingTaxExclusive, domain.TaxRequest{
+			Country: "US", Region: "CA", Currency: "USD",
+			Lines: []domain.TaxableLine{
+				{SKU: "EBOOK", TaxClass: "digital", Amount: 9.99},
+				{SKU: "LAMP", TaxClass: "standard", Amount: 40},
+			},
+		}},
+		{"exclusive_untaxed_destination", domain.PricingTaxExclusive, domain.TaxRequest{
+			Country: "SG", Currency: "SGD",
+			Lines: []domain.TaxableLine{{SKU: "BOOK", Amount: 12.5}},
+		}},
+		{"inclusive_single_line", domain.PricingTaxInclusive, domain.TaxRequest{
+			Country: "DE", Currency: "EUR",
+			Lines: []domain.TaxableLine{{SKU: "HEADPHONES", Amount: 119}},
+		}},
+		{"inclusive_half_cent", domain.PricingTaxInclusive, domain.TaxRequest{
+			Country: "DE", Currency: "EUR",
+			Lines: []domain.TaxableLine{
+				{SKU: "PEN", Amount: 1.005},
+				{SKU: "APPLE", TaxClass: "food", Amount: 0.015},
+			},
+		}},
+		{"inclusive_multi_line", domain.PricingTaxInclusive, domain.TaxRequest{
+			Country: "DE", Currency: "EUR",
+			Lines: []domain.TaxableLine{
+				{SKU: "A", Amount: 0.10},
+				{SKU: "B", Amount: 0.20},
+				{SKU: "C", Amount: 0.30},
+				{SKU: "MILK", TaxClass: "food", Amount: 1.19},
+			},
+		}},
+		{"inclusive_zero_decimal_currency", domain.PricingTaxInclusive, domain.TaxRequest{
+			Country: "JP", Currency: "JPY",
+			Lines: []domain.TaxableLine{
+				{SKU: "TEA", Amount: 1080},
+				{SKU: "CUP", Amount: 555},
+			},
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calculator, err := NewTable(TableConfig{Pricing: tc.pricing, Rates: testRates})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := calculator.Calculate(tc.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tc.name, append(got, '\n'))
+		})
+	}
+}
+
+// TestTableRatePrecedence checks which rule applies when several match.
+func TestTableRatePrecedence(t *testing.T) {
+	calculator, err := NewTable(TableConfig{Rates: testRates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := calculator.(*Table)
+
+	cases := []struct {
+		country, region, taxClass string
+		want                      float64
+	}{
+		{"US", "CA", "food", 0},       // region and class
+		{"US", "CA", "digital", 0},    // country and class beats region
+		{"US", "NY", "digital", 0.04}, // region and class beats country and class
+		{"US", "CA", "standard", 0.0725},
+		{"US", "TX", "digital", 0},
+		{"US", "TX", "standard", 0},
+		{"DE", "", "food", 0.07},
+		{"DE", "BY", "standard", 0.19},
+		{"SG", "", "standard", 0},
+		{"us", " ca ", "DIGITAL", 0},
+	}
+	for _, c := range cases {
+		if got := table.rate(c.country, c.region, c.taxClass); got != c.want {
+			t.Errorf("rate(%q, %q, %q) = %v, want %v", c.country, c.region, c.taxClass, got, c.want)
+		}
+	}
+}
+
+func TestTableCalculateRejectsNegativeAmounts(t *testing.T) {
+	calculator, err := NewTable(TableConfig{Rates: testRates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = calculator.Calculate(domain.TaxRequest{
+		Country: "DE", Currency: "EUR",
+		Lines: []domain.TaxableLine{{SKU: "REFUND", Amount: -1}},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a negative line amount")
+	}
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
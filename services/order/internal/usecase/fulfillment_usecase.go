@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+)
+
+type fulfillmentUsecase struct {
+	orderRepo    domain.OrderRepository
+	shipmentRepo domain.ShipmentRepository
+	webhooks     domain.WebhookUsecase
+}
+
+func NewFulfillmentUsecase(orderRepo domain.OrderRepository, shipmentRepo domain.ShipmentRepository, webhooks domain.WebhookUsecase) domain.FulfillmentUsecase {
+	return &fulfillmentUsecase{
+		orderRepo:    orderRepo,
+		shipmentRepo: shipmentRepo,
+		webhooks:     webhooks,
+	}
+}
+
+// CreateShipment ships some or all of a paid order to its shipping address.
+func (u *fulfillmentUsecase) CreateShipment(orderID string, req domain.CreateShipmentRequest) (*domain.Shipment, error) {
+	carrier := strings.TrimSpace(req.Carrier)
+	trackingNumber := strings.TrimSpace(req.TrackingNumber)
+	if carrier == "" || trackingNumber == "" {
+		return nil, errors.New("carrier and tracking number are required")
+	}
+	if req.Quantity < 0 {
+		return nil, errors.New("quantity must not be negative")
+	}
+
+	order, err := u.getOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != domain.OrderStatusPaid && order.Status != domain.OrderStatusPartiallyRefunded {
+		return nil, domain.ErrOrderNotShippable
+	}
+	if order.ShippingAddress == nil {
+		return nil, domain.ErrNoShippingAddress
+	}
+
+	shipment := &domain.Shipment{
+		OrderID:        order.ID,
+		Carrier:        carrier,
+		TrackingNumber: trackingNumber,
+		Quantity:       req.Quantity,
+	}
+	if err := u.shipmentRepo.Create(shipment); err != nil {
+		return nil, err
+	}
+
+	u.publish(shipment)
+	return shipment, nil
+}
+
+func (u *fulfillmentUsecase) GetShipment(orderID, id string) (*domain.Shipment, error) {
+	if orderID == "" || id == "" {
+		return nil, errors.New("order id and shipment id are required")
+	}
+
+	return u.shipmentRepo.GetByID(orderID, id)
+}
+
+func (u *fulfillmentUsecase) GetFulfillment(orderID string) (*domain.Fulfillment, error) {
+	order, err := u.getOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	shipments, err := u.shipmentRepo.ListByOrder(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if shipments == nil {
+		shipments = []*domain.Shipment{}
+	}
+	return domain.NewFulfillment(order, shipments), nil
+}
+
+func (u *fulfillmentUsecase) UpdateShipmentStatus(orderID, id string, event domain.ShipmentEvent) (*domain.Shipment, error) {
+	if orderID == "" || id == "" {
+		return nil, errors.New("order id and shipment id are required")
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	event.Location = strings.TrimSpace(event.Location)
+	event.Description = strings.TrimSpace(event.Description)
+
+	shipment, err := u.shipmentRepo.AddEvent(orderID, id, event)
+	if err != nil {
+		return nil, err
+	}
+
+	u.publish(shipment)
+	return shipment, nil
+}
+
+func (u *fulfillmentUsecase) getOrder(id string) (*domain.Order, error) {
+	if id == "" {
+		return nil, errors.New("order id is required")
+	}
+
+	return u.orderRepo.GetByID(id)
+}
+
+// publish enqueues a shipment.updated webhook. The shipment change is
+// already committed, so a failure is only logged.
+func (u *fulfillmentUsecase) publish(shipment *domain.Shipment) {
+	if err := u.webhooks.Publish(domain.EventShipmentUpdated, shipment); err != nil {
+		log.Printf("Failed to publish %s for shipment %s: %v", domain.EventShipmentUpdated, shipment.ID, err)
+	}
+}
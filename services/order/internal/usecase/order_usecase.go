@@ -3,20 +3,37 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/fx"
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
+	catalogpb "github.com/edwinjordan/golang_microservices/services/catalog/pkg/pb"
+	inventorypb "github.com/edwinjordan/golang_microservices/services/inventory/pkg/pb"
 	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
 	userpb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 type orderUsecase struct {
-	orderRepo      domain.OrderRepository
-	userGRPCClient userpb.UserServiceClient
+	orderRepo         domain.OrderRepository
+	promotionRepo     domain.PromotionRepository
+	taxes             domain.TaxCalculator
+	userGRPCClient    userpb.UserServiceClient
+	catalogGRPCClient catalogpb.CatalogServiceClient
+	inventoryClient   inventorypb.InventoryServiceClient
+	paginator         *pagination.Paginator
+	webhooks          domain.WebhookUsecase
 }
 
-func NewOrderUsecase(orderRepo domain.OrderRepository, userGRPCAddr string) domain.OrderUsecase {
+func NewOrderUsecase(orderRepo domain.OrderRepository, promotionRepo domain.PromotionRepository, taxes domain.TaxCalculator, webhooks domain.WebhookUsecase, userGRPCAddr, catalogGRPCAddr, inventoryGRPCAddr string, pageTokens *pagination.Codec) domain.OrderUsecase {
 	// Connect to user service
 	conn, err := grpc.NewClient(userGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -25,39 +42,270 @@ func NewOrderUsecase(orderRepo domain.OrderRepository, userGRPCAddr string) doma
 
 	userClient := userpb.NewUserServiceClient(conn)
 
+	// Connect to catalog service
+	catalogConn, err := grpc.NewClient(catalogGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("Failed to connect to catalog service: %v", err)
+	}
+
+	// Connect to inventory service
+	inventoryConn, err := grpc.NewClient(inventoryGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("Failed to connect to inventory service: %v", err)
+	}
+
 	return &orderUsecase{
-		orderRepo:      orderRepo,
-		userGRPCClient: userClient,
+		orderRepo:         orderRepo,
+		promotionRepo:     promotionRepo,
+		taxes:             taxes,
+		userGRPCClient:    userClient,
+		catalogGRPCClient: catalogpb.NewCatalogServiceClient(catalogConn),
+		inventoryClient:   inventorypb.NewInventoryServiceClient(inventoryConn),
+		paginator: pagination.NewPaginator(pageTokens, pagination.Options{
+			SortFields: []string{domain.OrderSortCreatedAt, domain.OrderSortAmount},
+		}),
+		webhooks: webhooks,
 	}
 }
 
-func (u *orderUsecase) CreateOrder(userID, product string, amount float64) (*domain.Order, error) {
-	if userID == "" || product == "" || amount <= 0 {
-		return nil, errors.New("userID, product, and amount are required")
+func (u *orderUsecase) CreateOrder(req domain.CreateOrderRequest) (*domain.Order, error) {
+	if req.UserID == "" || req.SKU == "" || req.Quantity <= 0 {
+		return nil, errors.New("userID, sku, and a positive quantity are required")
+	}
+
+	if req.OrderID != "" {
+		order, err := u.orderRepo.GetByID(req.OrderID)
+		if err == nil {
+			return createdBefore(order, req)
+		}
+		if !errors.Is(err, domain.ErrOrderNotFound) {
+			return nil, err
+		}
 	}
 
 	// Validate user exists via gRPC
 	if u.userGRPCClient != nil {
-		resp, err := u.userGRPCClient.ValidateUser(context.Background(), &userpb.ValidateUserRequest{UserId: userID})
+		resp, err := u.userGRPCClient.ValidateUser(context.Background(), &userpb.ValidateUserRequest{UserId: req.UserID})
 		if err != nil || !resp.Valid {
-			return nil, errors.New("invalid user")
+			return nil, domain.ErrInvalidUser
 		}
 	}
 
+	item, err := u.resolveSKU(req.SKU)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := u.resolveAddress(req.UserID, req.AddressID)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions, err := u.resolveCoupons(req.CouponCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := item.Currency
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	subtotal := fx.Round(item.Price*float64(req.Quantity), currency)
+	discounts, err := domain.ApplyPromotions(subtotal, currency, promotions, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var discountAmount float64
+	for _, d := range discounts {
+		discountAmount += d.Amount
+	}
+	discountAmount = fx.Round(discountAmount, currency)
+
+	tax, err := u.calculateTax(item, subtotal-discountAmount, currency, address)
+	if err != nil {
+		return nil, err
+	}
+
+	// The order ID is chosen up front so the stock can be reserved under it
+	// before the order exists.
+	id := req.OrderID
+	if id == "" {
+		id = uuid.New().String()
+	}
 	order := &domain.Order{
-		UserID:  userID,
-		Product: product,
-		Amount:  amount,
+		ID:               id,
+		UserID:           req.UserID,
+		Product:          item.ProductName,
+		SKU:              item.Code,
+		Quantity:         req.Quantity,
+		UnitPrice:        item.Price,
+		Subtotal:         subtotal,
+		DiscountAmount:   discountAmount,
+		Discounts:        discounts,
+		TaxAmount:        tax.TaxAmount,
+		PricesIncludeTax: tax.PricesIncludeTax,
+		TaxLines:         tax.Lines,
+		Amount:           tax.Total,
+		Currency:         currency,
+		ShippingAddress:  address,
 	}
 
-	err := u.orderRepo.Create(order)
+	if err := u.reserveStock(order); err != nil {
+		return nil, err
+	}
+
+	err = u.orderRepo.Create(order)
+	if errors.Is(err, domain.ErrOrderExists) && req.OrderID != "" {
+		// A concurrent request created the order first. Reserving again
+		// under its ID found its reservation, so there is nothing to undo.
+		existing, err := u.orderRepo.GetByID(order.ID)
+		if err != nil {
+			return nil, err
+		}
+		return createdBefore(existing, req)
+	}
 	if err != nil {
+		u.releaseStock(order.ID, "order not created")
 		return nil, err
 	}
 
+	u.publish(domain.EventOrderCreated, order)
 	return order, nil
 }
 
+// createdBefore returns order, found under req.OrderID, if an earlier
+// attempt at req created it.
+func createdBefore(order *domain.Order, req domain.CreateOrderRequest) (*domain.Order, error) {
+	if order.UserID != req.UserID {
+		return nil, domain.ErrOrderExists
+	}
+	return order, nil
+}
+
+// resolveSKU looks the SKU up in the catalog, which owns names and prices.
+func (u *orderUsecase) resolveSKU(code string) (*catalogpb.Sku, error) {
+	item, err := u.catalogGRPCClient.GetSku(context.Background(), &catalogpb.GetSkuRequest{Code: code})
+	if status.Code(err) == codes.NotFound {
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnknownSKU, code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve sku %s: %w", code, err)
+	}
+	if !item.Sellable {
+		return nil, fmt.Errorf("%w: %s", domain.ErrSKUNotSellable, code)
+	}
+	return item, nil
+}
+
+// calculateTax works out the tax on the discounted order line. Tax is
+// charged by shipping destination; an order without a shipping address has
+// none to charge by and is not taxed.
+func (u *orderUsecase) calculateTax(item *catalogpb.Sku, amount float64, currency string, address *domain.ShippingAddress) (*domain.TaxResult, error) {
+	req := domain.TaxRequest{
+		Currency: currency,
+		Lines:    []domain.TaxableLine{{SKU: item.Code, TaxClass: item.TaxClass, Amount: amount}},
+	}
+	if address != nil {
+		req.Country = address.Country
+		req.Region = address.Region
+	}
+
+	result, err := u.taxes.Calculate(req)
+	if err != nil {
+		return nil, fmt.Errorf("calculate tax: %w", err)
+	}
+	return result, nil
+}
+
+// resolveCoupons looks up the promotions behind codes. Codes are
+// case-insensitive and a code given twice counts once; whether the
+// promotions can actually be redeemed is checked when they are applied, and
+// again when the order is stored.
+func (u *orderUsecase) resolveCoupons(codes []string) ([]*domain.Promotion, error) {
+	seen := make(map[string]bool, len(codes))
+	var promotions []*domain.Promotion
+	for _, code := range codes {
+		code = normalizeCouponCode(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		p, err := u.promotionRepo.GetByCode(code)
+		if errors.Is(err, domain.ErrPromotionNotFound) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrUnknownCoupon, code)
+		}
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, nil
+}
+
+// resolveAddress snapshots the shipping address from the user's address
+// book. Without an addressID the user's default address is used, and a user
+// with no addresses gets an order without one.
+func (u *orderUsecase) resolveAddress(userID, addressID string) (*domain.ShippingAddress, error) {
+	var address *userpb.Address
+	var err error
+	if addressID != "" {
+		address, err = u.userGRPCClient.GetAddress(context.Background(), &userpb.GetAddressRequest{UserId: userID, Id: addressID})
+		if status.Code(err) == codes.NotFound {
+			return nil, fmt.Errorf("%w: %s", domain.ErrUnknownAddress, addressID)
+		}
+	} else {
+		address, err = u.userGRPCClient.GetDefaultAddress(context.Background(), &userpb.GetDefaultAddressRequest{UserId: userID})
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve address: %w", err)
+	}
+
+	return &domain.ShippingAddress{
+		AddressID:     address.Id,
+		RecipientName: address.RecipientName,
+		Line1:         address.Line1,
+		Line2:         address.Line2,
+		City:          address.City,
+		Region:        address.Region,
+		PostalCode:    address.PostalCode,
+		Country:       address.Country,
+		Phone:         address.Phone,
+	}, nil
+}
+
+// reserveStock holds the order's stock in the inventory service. Paying for
+// the order commits the reservation and cancelling or expiring it releases
+// it; the inventory service does both when it sees the order.updated event.
+func (u *orderUsecase) reserveStock(order *domain.Order) error {
+	_, err := u.inventoryClient.ReserveStock(context.Background(), &inventorypb.ReserveStockRequest{
+		OrderId: order.ID,
+		Items:   []*inventorypb.ReservationItem{{Sku: order.SKU, Quantity: int64(order.Quantity)}},
+	})
+	if status.Code(err) == codes.FailedPrecondition {
+		return fmt.Errorf("%w: %s", domain.ErrOutOfStock, order.SKU)
+	}
+	if err != nil {
+		return fmt.Errorf("reserve stock: %w", err)
+	}
+	return nil
+}
+
+// releaseStock is best effort: a reservation left behind lapses after its
+// TTL.
+func (u *orderUsecase) releaseStock(orderID, reason string) {
+	_, err := u.inventoryClient.ReleaseReservation(context.Background(), &inventorypb.ReleaseReservationRequest{
+		OrderId: orderID,
+		Reason:  reason,
+	})
+	if err != nil {
+		log.Printf("Failed to release stock reserved for order %s: %v", orderID, err)
+	}
+}
+
 func (u *orderUsecase) GetOrder(id string) (*domain.Order, error) {
 	if id == "" {
 		return nil, errors.New("id is required")
@@ -70,3 +318,132 @@ func (u *orderUsecase) GetOrder(id string) (*domain.Order, error) {
 
 	return order, nil
 }
+
+// UpdateOrderStatus is called by other services when something downstream
+// changes the state of an order, such as a refund. Orders only move forward;
+// cancelled and expired orders are closed and the payment service releases
+// their payments instead.
+func (u *orderUsecase) UpdateOrderStatus(id, status string) (*domain.Order, error) {
+	order, err := u.GetOrder(id)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := order.Status
+	if err := order.SetStatus(status); err != nil {
+		return nil, err
+	}
+	if order.Status == previous {
+		return order, nil
+	}
+	if err := u.orderRepo.Update(order, previous); err != nil {
+		return nil, err
+	}
+
+	if status == domain.OrderStatusPaid {
+		u.publish(domain.EventOrderPaid, order)
+	}
+	return order, nil
+}
+
+// CancelOrder cancels an order that has not been paid. The payment service
+// voids any authorization it holds for the order when it sees the update.
+func (u *orderUsecase) CancelOrder(id string) (*domain.Order, error) {
+	order, err := u.GetOrder(id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != domain.OrderStatusPending {
+		return nil, domain.ErrOrderNotCancellable
+	}
+
+	order.Status = domain.OrderStatusCancelled
+	if err := u.orderRepo.Update(order, domain.OrderStatusPending); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// RecordPayment is called for each capture and refund the payment service
+// reports. An order paid with several payments is only refunded once all of
+// them are, so its status comes from every payment recorded for it rather
+// than from the one being reported.
+func (u *orderUsecase) RecordPayment(orderID string, payment domain.OrderPayment) (*domain.Order, error) {
+	payments, err := u.orderRepo.SavePayment(orderID, payment)
+	if err != nil {
+		return nil, err
+	}
+	status := domain.RefundStatus(payments)
+	if status == "" {
+		return u.GetOrder(orderID)
+	}
+	return u.UpdateOrderStatus(orderID, status)
+}
+
+// ExpireUnpaid is run periodically by the expiry worker. Each expired order
+// gets an order.expired event, which makes the payment service release its
+// payments, and an order.expired client webhook.
+func (u *orderUsecase) ExpireUnpaid(before time.Time, limit int) ([]*domain.Order, error) {
+	orders, err := u.orderRepo.ExpirePending(before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, order := range orders {
+		u.publish(domain.EventOrderExpired, order)
+	}
+	return orders, nil
+}
+
+// publish enqueues a webhook event. The order change is already committed,
+// so a failure is only logged.
+func (u *orderUsecase) publish(eventType string, order *domain.Order) {
+	if err := u.webhooks.Publish(eventType, order); err != nil {
+		log.Printf("Failed to publish %s for order %s: %v", eventType, order.ID, err)
+	}
+}
+
+func (u *orderUsecase) ListOrders(req domain.ListOrdersRequest) (*domain.OrderPage, error) {
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MinAmount > *req.MaxAmount {
+		return nil, errors.New("min amount must not exceed max amount")
+	}
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && req.CreatedAfter.After(*req.CreatedBefore) {
+		return nil, errors.New("created_after must be before created_before")
+	}
+
+	filter := domain.OrderFilter{
+		UserID:        req.UserID,
+		Status:        req.Status,
+		Currency:      strings.ToUpper(req.Currency),
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		MinAmount:     req.MinAmount,
+		MaxAmount:     req.MaxAmount,
+	}
+	page, err := u.paginator.Prepare(req.OrderBy, req.PageSize, req.PageToken, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Amounts in different currencies do not compare, so ordering or
+	// bounding by amount only makes sense within one currency.
+	byAmount := page.Sort.Field == domain.OrderSortAmount || filter.MinAmount != nil || filter.MaxAmount != nil
+	if byAmount && filter.Currency == "" {
+		return nil, domain.ErrAmountNeedsCurrency
+	}
+
+	filter.Page = page
+	orders, err := u.orderRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, next := pagination.Trim(u.paginator, page, orders, func(o *domain.Order) pagination.Cursor {
+		return pagination.Cursor{
+			Key:       strconv.FormatFloat(o.Amount, 'f', -1, 64),
+			CreatedAt: o.CreatedAt,
+			ID:        o.ID,
+		}
+	})
+	return &domain.OrderPage{Orders: orders, NextPageToken: next}, nil
+}
@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+)
+
+// listedOrders records the filter List is called with.
+type listedOrders struct {
+	domain.OrderRepository
+	filter *domain.OrderFilter
+}
+
+func (r *listedOrders) List(filter domain.OrderFilter) ([]*domain.Order, error) {
+	r.filter = &filter
+	return nil, nil
+}
+
+func TestListOrdersAmountNeedsCurrency(t *testing.T) {
+	amount := 10.0
+	cases := []struct {
+		name    string
+		req     domain.ListOrdersRequest
+		wantErr bool
+	}{
+		{"no amount", domain.ListOrdersRequest{}, false},
+		{"sort by amount", domain.ListOrdersRequest{OrderBy: "-amount"}, true},
+		{"min amount", domain.ListOrdersRequest{MinAmount: &amount}, true},
+		{"max amount", domain.ListOrdersRequest{MaxAmount: &amount}, true},
+		{"sort by amount in a currency", domain.ListOrdersRequest{OrderBy: "amount", Currency: "eur"}, false},
+		{"amount range in a currency", domain.ListOrdersRequest{MinAmount: &amount, MaxAmount: &amount, Currency: "EUR"}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := &listedOrders{}
+			u := NewOrderUsecase(repo, nil, nil, nil, "", "", "", pagination.NewCodec("secret"))
+
+			_, err := u.ListOrders(c.req)
+			if c.wantErr {
+				if !errors.Is(err, domain.ErrAmountNeedsCurrency) {
+					t.Fatalf("err = %v, want ErrAmountNeedsCurrency", err)
+				}
+				if repo.filter != nil {
+					t.Error("orders were listed anyway")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.req.Currency != "" && repo.filter.Currency != "EUR" {
+				t.Errorf("Currency = %q, want EUR", repo.filter.Currency)
+			}
+		})
+	}
+}
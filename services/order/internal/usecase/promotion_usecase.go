@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/edwinjordan/golang_microservices/pkg/fx"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+)
+
+type promotionUsecase struct {
+	promotionRepo domain.PromotionRepository
+}
+
+func NewPromotionUsecase(promotionRepo domain.PromotionRepository) domain.PromotionUsecase {
+	return &promotionUsecase{promotionRepo: promotionRepo}
+}
+
+func (u *promotionUsecase) CreatePromotion(req domain.CreatePromotionRequest) (*domain.Promotion, error) {
+	code := normalizeCouponCode(req.Code)
+	if code == "" {
+		return nil, fmt.Errorf("%w: code is required", domain.ErrInvalidPromotion)
+	}
+	if req.Currency == "" {
+		req.Currency = domain.DefaultCurrency
+	}
+	currency, err := fx.NormalizeCurrency(req.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPromotion, err)
+	}
+
+	p := &domain.Promotion{
+		Code:           code,
+		Description:    strings.TrimSpace(req.Description),
+		Type:           req.Type,
+		Value:          req.Value,
+		Currency:       currency,
+		MinOrderAmount: req.MinOrderAmount,
+		MaxRedemptions: req.MaxRedemptions,
+		PerUserLimit:   req.PerUserLimit,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		Stackable:      req.Stackable,
+		Active:         true,
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := u.promotionRepo.Create(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (u *promotionUsecase) GetPromotion(code string) (*domain.Promotion, error) {
+	return u.promotionRepo.GetByCode(normalizeCouponCode(code))
+}
+
+func (u *promotionUsecase) ListPromotions(activeOnly bool, limit int) ([]*domain.Promotion, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	return u.promotionRepo.List(activeOnly, limit)
+}
+
+// UpdatePromotion changes a promotion's settings. Lowering MaxRedemptions
+// below the redemptions already made simply stops further ones.
+func (u *promotionUsecase) UpdatePromotion(code string, update domain.PromotionUpdate) (*domain.Promotion, error) {
+	p, err := u.GetPromotion(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Description != nil {
+		p.Description = strings.TrimSpace(*update.Description)
+	}
+	if update.MinOrderAmount != nil {
+		p.MinOrderAmount = *update.MinOrderAmount
+	}
+	if update.MaxRedemptions != nil {
+		p.MaxRedemptions = *update.MaxRedemptions
+	}
+	if update.PerUserLimit != nil {
+		p.PerUserLimit = *update.PerUserLimit
+	}
+	if update.StartsAt != nil {
+		p.StartsAt = update.StartsAt
+	}
+	if update.EndsAt != nil {
+		p.EndsAt = update.EndsAt
+	}
+	if update.Stackable != nil {
+		p.Stackable = *update.Stackable
+	}
+	if update.Active != nil {
+		p.Active = *update.Active
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := u.promotionRepo.Update(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// normalizeCouponCode makes codes case-insensitive.
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,425 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	inventorypb "github.com/edwinjordan/golang_microservices/services/inventory/pkg/pb"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	paymentpb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// Payment statuses reported by the payment service.
+const (
+	paymentStatusAuthorized = "authorized"
+	paymentStatusCaptured   = "captured"
+)
+
+// Reservation statuses reported by the inventory service that still hold
+// stock for the order.
+const (
+	reservationStatusActive    = "active"
+	reservationStatusCommitted = "committed"
+)
+
+// SagaConfig controls step timeouts and retries.
+type SagaConfig struct {
+	StepTimeout time.Duration
+	// MaxAttempts bounds the failures of one step. A step that keeps
+	// failing is then compensated, or, past the point of no return, the
+	// saga is marked failed.
+	MaxAttempts int
+	// Retries wait BaseBackoff * 2^(attempt-1), capped at MaxBackoff, with
+	// up to 20% jitter.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// sagaStep is one step of the checkout saga. compensate undoes execute and
+// may be nil when there is nothing to undo; both must be idempotent, since a
+// crash between a step and saving its outcome runs it again.
+type sagaStep struct {
+	name       string
+	execute    func(ctx context.Context, data *domain.CheckoutData) error
+	compensate func(ctx context.Context, data *domain.CheckoutData) error
+	// retryOnly steps come after money has moved: they are retried but a
+	// failure never triggers compensation.
+	retryOnly bool
+}
+
+type sagaUsecase struct {
+	sagaRepo          domain.SagaRepository
+	orderUsecase      domain.OrderUsecase
+	paymentGRPCClient paymentpb.PaymentServiceClient
+	inventoryClient   inventorypb.InventoryServiceClient
+	cfg               SagaConfig
+	steps             []sagaStep
+}
+
+func NewSagaUsecase(sagaRepo domain.SagaRepository, orderUsecase domain.OrderUsecase, paymentGRPCAddr, inventoryGRPCAddr string, cfg SagaConfig) domain.SagaUsecase {
+	// Connect to payment service
+	conn, err := grpc.NewClient(paymentGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("Failed to connect to payment service: %v", err)
+	}
+
+	// Connect to inventory service
+	inventoryConn, err := grpc.NewClient(inventoryGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("Failed to connect to inventory service: %v", err)
+	}
+
+	u := &sagaUsecase{
+		sagaRepo:          sagaRepo,
+		orderUsecase:      orderUsecase,
+		paymentGRPCClient: paymentpb.NewPaymentServiceClient(conn),
+		inventoryClient:   inventorypb.NewInventoryServiceClient(inventoryConn),
+		cfg:               cfg,
+	}
+	u.steps = []sagaStep{
+		{name: domain.SagaStepCreateOrder, execute: u.createOrder, compensate: u.cancelOrder},
+		{name: domain.SagaStepReserveStock, execute: u.reserveStock, compensate: u.releaseStock},
+		{name: domain.SagaStepAuthorizePayment, execute: u.authorizePayment, compensate: u.voidPayment},
+		{name: domain.SagaStepCapturePayment, execute: u.capturePayment},
+		{name: domain.SagaStepConfirmOrder, execute: u.confirmOrder, retryOnly: true},
+	}
+	return u
+}
+
+func (u *sagaUsecase) StartCheckout(userID, sku string, quantity int, addressID string, couponCodes []string) (*domain.Saga, error) {
+	if userID == "" || sku == "" || quantity <= 0 {
+		return nil, errors.New("userID, sku, and a positive quantity are required")
+	}
+
+	saga := &domain.Saga{
+		Type:   domain.SagaTypeCheckout,
+		Status: domain.SagaStatusRunning,
+		Step:   u.steps[0].name,
+		// The order ID is chosen and saved before the order is created, so
+		// a retry of createOrder finds the order instead of creating another.
+		Data: domain.CheckoutData{
+			OrderID:     uuid.New().String(),
+			UserID:      userID,
+			SKU:         sku,
+			Quantity:    quantity,
+			AddressID:   addressID,
+			CouponCodes: couponCodes,
+		},
+		NextAttemptAt: time.Now().Add(u.lease()),
+	}
+	if err := u.sagaRepo.Create(saga); err != nil {
+		return nil, err
+	}
+
+	if err := u.advance(saga); err != nil {
+		return nil, err
+	}
+	return saga, nil
+}
+
+func (u *sagaUsecase) RunDue(limit int) (int, error) {
+	sagas, err := u.sagaRepo.ClaimDue(time.Now(), u.lease(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, saga := range sagas {
+		if err := u.advance(saga); err != nil {
+			log.Printf("Failed to advance saga %s: %v", saga.ID, err)
+		}
+	}
+	return len(sagas), nil
+}
+
+func (u *sagaUsecase) GetSaga(id string) (*domain.Saga, []*domain.SagaStepLog, error) {
+	saga, err := u.sagaRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	steps, err := u.sagaRepo.ListSteps(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return saga, steps, nil
+}
+
+func (u *sagaUsecase) ListSagas(status string, limit int) ([]*domain.Saga, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	return u.sagaRepo.ListByStatus(status, limit)
+}
+
+// lease is how long a saga being run stays hidden from other workers: long
+// enough for every step to time out once.
+func (u *sagaUsecase) lease() time.Duration {
+	return u.cfg.StepTimeout*time.Duration(len(u.steps)) + time.Minute
+}
+
+// advance runs steps until the saga is done or a step must wait for a retry.
+func (u *sagaUsecase) advance(saga *domain.Saga) error {
+	for !saga.Done() {
+		waiting, err := u.runStep(saga)
+		if err != nil || waiting {
+			return err
+		}
+	}
+	return nil
+}
+
+// runStep executes or compensates the current step and saves the outcome.
+// It reports whether the saga now waits for a retry.
+func (u *sagaUsecase) runStep(saga *domain.Saga) (bool, error) {
+	i := u.stepIndex(saga.Step)
+	if i < 0 {
+		return false, fmt.Errorf("saga %s is at unknown step %q", saga.ID, saga.Step)
+	}
+	step := u.steps[i]
+	entry := &domain.SagaStepLog{Step: step.name, Action: domain.SagaActionExecute}
+
+	ctx, cancel := context.WithTimeout(context.Background(), u.cfg.StepTimeout)
+	defer cancel()
+
+	var err error
+	if saga.Status == domain.SagaStatusRunning {
+		err = step.execute(ctx, &saga.Data)
+	} else {
+		entry.Action = domain.SagaActionCompensate
+		if step.compensate != nil {
+			err = step.compensate(ctx, &saga.Data)
+		}
+	}
+
+	waiting := false
+	saga.NextAttemptAt = time.Now().Add(u.lease())
+	switch {
+	case err == nil:
+		entry.Status = domain.SagaStepSucceeded
+		saga.Attempts = 0
+		if saga.Status == domain.SagaStatusRunning {
+			u.forward(saga, i)
+		} else {
+			u.backward(saga, i)
+		}
+	case saga.Status == domain.SagaStatusRunning && !step.retryOnly &&
+		(errors.Is(err, domain.ErrSagaStepRejected) || saga.Attempts+1 >= u.cfg.MaxAttempts):
+		// The failed step did not complete, so compensation starts with the
+		// step before it.
+		entry.Status = domain.SagaStepRejected
+		entry.Error = err.Error()
+		saga.LastError = err.Error()
+		saga.Attempts = 0
+		saga.Status = domain.SagaStatusCompensating
+		u.backward(saga, i)
+	case saga.Attempts+1 >= u.cfg.MaxAttempts:
+		entry.Status = domain.SagaStepFailed
+		entry.Error = err.Error()
+		saga.LastError = err.Error()
+		saga.Attempts++
+		saga.Status = domain.SagaStatusFailed
+	default:
+		entry.Status = domain.SagaStepFailed
+		entry.Error = err.Error()
+		saga.LastError = err.Error()
+		saga.Attempts++
+		saga.NextAttemptAt = time.Now().Add(u.backoff(saga.Attempts))
+		waiting = true
+	}
+
+	return waiting, u.sagaRepo.Save(saga, entry)
+}
+
+func (u *sagaUsecase) stepIndex(name string) int {
+	for i, step := range u.steps {
+		if step.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// forward moves a running saga past step i.
+func (u *sagaUsecase) forward(saga *domain.Saga, i int) {
+	if i+1 < len(u.steps) {
+		saga.Step = u.steps[i+1].name
+		return
+	}
+	saga.Status = domain.SagaStatusCompleted
+	saga.LastError = ""
+}
+
+// backward moves a compensating saga to the step before i.
+func (u *sagaUsecase) backward(saga *domain.Saga, i int) {
+	if i > 0 {
+		saga.Step = u.steps[i-1].name
+		return
+	}
+	saga.Status = domain.SagaStatusCompensated
+}
+
+func (u *sagaUsecase) backoff(attempts int) time.Duration {
+	wait := u.cfg.BaseBackoff
+	for i := 1; i < attempts && wait < u.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, u.cfg.MaxBackoff)
+	return wait + time.Duration(rand.Int63n(int64(wait)/5+1))
+}
+
+func (u *sagaUsecase) createOrder(ctx context.Context, data *domain.CheckoutData) error {
+	order, err := u.orderUsecase.CreateOrder(domain.CreateOrderRequest{
+		OrderID:     data.OrderID,
+		UserID:      data.UserID,
+		SKU:         data.SKU,
+		Quantity:    data.Quantity,
+		AddressID:   data.AddressID,
+		CouponCodes: data.CouponCodes,
+	})
+	if errors.Is(err, domain.ErrInvalidUser) || errors.Is(err, domain.ErrUnknownSKU) ||
+		errors.Is(err, domain.ErrSKUNotSellable) || errors.Is(err, domain.ErrOutOfStock) ||
+		errors.Is(err, domain.ErrUnknownAddress) || errors.Is(err, domain.ErrOrderExists) || isCouponError(err) {
+		return fmt.Errorf("%w: %v", domain.ErrSagaStepRejected, err)
+	}
+	if err != nil {
+		return err
+	}
+	data.Amount = order.Amount
+	return nil
+}
+
+// isCouponError reports whether err rejected the order's coupons. Retrying
+// would not change the outcome.
+func isCouponError(err error) bool {
+	return errors.Is(err, domain.ErrUnknownCoupon) || errors.Is(err, domain.ErrCouponNotActive) ||
+		errors.Is(err, domain.ErrCouponMinimumNotMet) || errors.Is(err, domain.ErrCouponNotStackable) ||
+		errors.Is(err, domain.ErrCouponCurrency) ||
+		errors.Is(err, domain.ErrCouponExhausted) || errors.Is(err, domain.ErrCouponUserLimit)
+}
+
+// cancelOrder also makes the payment service void any authorization it still
+// holds for the order, including one whose outcome the saga never learned.
+func (u *sagaUsecase) cancelOrder(ctx context.Context, data *domain.CheckoutData) error {
+	if data.OrderID == "" {
+		return nil
+	}
+
+	order, err := u.orderUsecase.GetOrder(data.OrderID)
+	if errors.Is(err, domain.ErrOrderNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if order.Status == domain.OrderStatusCancelled || order.Status == domain.OrderStatusExpired {
+		return nil
+	}
+	_, err = u.orderUsecase.CancelOrder(order.ID)
+	return err
+}
+
+// reserveStock makes sure the order's stock is held. Creating the order
+// already reserved it, so this normally finds that reservation; it fails
+// the saga if the reservation lapsed in the meantime.
+func (u *sagaUsecase) reserveStock(ctx context.Context, data *domain.CheckoutData) error {
+	reservation, err := u.inventoryClient.ReserveStock(ctx, &inventorypb.ReserveStockRequest{
+		OrderId: data.OrderID,
+		Items:   []*inventorypb.ReservationItem{{Sku: data.SKU, Quantity: int64(data.Quantity)}},
+	})
+	if err != nil {
+		return serviceError(err)
+	}
+	if reservation.Status != reservationStatusActive && reservation.Status != reservationStatusCommitted {
+		return fmt.Errorf("%w: stock reservation %s", domain.ErrSagaStepRejected, reservation.Status)
+	}
+	return nil
+}
+
+func (u *sagaUsecase) releaseStock(ctx context.Context, data *domain.CheckoutData) error {
+	_, err := u.inventoryClient.ReleaseReservation(ctx, &inventorypb.ReleaseReservationRequest{
+		OrderId: data.OrderID,
+		Reason:  "checkout compensated",
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
+
+func (u *sagaUsecase) authorizePayment(ctx context.Context, data *domain.CheckoutData) error {
+	if data.PaymentID != "" {
+		return nil
+	}
+
+	// A retry after a timeout gets the payment the first attempt created
+	// rather than a second authorization.
+	payment, err := u.paymentGRPCClient.AuthorizePayment(ctx, &paymentpb.AuthorizePaymentRequest{
+		OrderId:        data.OrderID,
+		Amount:         data.Amount,
+		IdempotencyKey: "checkout:" + data.OrderID,
+	})
+	if err != nil {
+		return serviceError(err)
+	}
+	if payment.Status != paymentStatusAuthorized {
+		return fmt.Errorf("%w: payment %s %s", domain.ErrSagaStepRejected, payment.Status, payment.FailureReason)
+	}
+	data.PaymentID = payment.Id
+	return nil
+}
+
+func (u *sagaUsecase) voidPayment(ctx context.Context, data *domain.CheckoutData) error {
+	if data.PaymentID == "" {
+		return nil
+	}
+
+	payment, err := u.paymentGRPCClient.GetPayment(ctx, &paymentpb.GetPaymentRequest{Id: data.PaymentID})
+	if err != nil {
+		return err
+	}
+	if payment.Status != paymentStatusAuthorized {
+		return nil
+	}
+	_, err = u.paymentGRPCClient.VoidPayment(ctx, &paymentpb.VoidPaymentRequest{Id: data.PaymentID})
+	return err
+}
+
+func (u *sagaUsecase) capturePayment(ctx context.Context, data *domain.CheckoutData) error {
+	payment, err := u.paymentGRPCClient.GetPayment(ctx, &paymentpb.GetPaymentRequest{Id: data.PaymentID})
+	if err != nil {
+		return serviceError(err)
+	}
+	if payment.Status == paymentStatusCaptured {
+		return nil
+	}
+
+	_, err = u.paymentGRPCClient.CapturePayment(ctx, &paymentpb.CapturePaymentRequest{Id: data.PaymentID})
+	return serviceError(err)
+}
+
+func (u *sagaUsecase) confirmOrder(ctx context.Context, data *domain.CheckoutData) error {
+	_, err := u.orderUsecase.UpdateOrderStatus(data.OrderID, domain.OrderStatusPaid)
+	return err
+}
+
+// serviceError treats payment and inventory service errors other than
+// transport failures as rejections: the service answered, and asking again
+// will not change its answer.
+func serviceError(err error) error {
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Canceled:
+		return err
+	default:
+		return fmt.Errorf("%w: %v", domain.ErrSagaStepRejected, err)
+	}
+}
@@ -0,0 +1,320 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	inventorypb "github.com/edwinjordan/golang_microservices/services/inventory/pkg/pb"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	paymentpb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCheckoutCompletes(t *testing.T) {
+	u, orders, payments, inventory := newTestSaga()
+
+	saga, err := u.StartCheckout("user-1", "TSHIRT-M", 2, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saga.Status != domain.SagaStatusCompleted {
+		t.Fatalf("saga is %s at %s (%s), want completed", saga.Status, saga.Step, saga.LastError)
+	}
+	if got := orders.orders[saga.Data.OrderID].Status; got != domain.OrderStatusPaid {
+		t.Errorf("order is %s, want paid", got)
+	}
+	if payments.status != paymentStatusCaptured {
+		t.Errorf("payment is %s, want captured", payments.status)
+	}
+	if inventory.released {
+		t.Error("stock was released")
+	}
+}
+
+func TestCheckoutRetriesTransientFailures(t *testing.T) {
+	u, orders, payments, _ := newTestSaga()
+	orders.createErrs = []error{errors.New("catalog unavailable")}
+	payments.captureErrs = []error{status.Error(codes.Unavailable, "payment gateway unavailable")}
+
+	saga, err := u.StartCheckout("user-1", "TSHIRT-M", 1, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectWaiting(t, saga, domain.SagaStepCreateOrder)
+
+	if err := u.advance(saga); err != nil {
+		t.Fatal(err)
+	}
+	expectWaiting(t, saga, domain.SagaStepCapturePayment)
+
+	if err := u.advance(saga); err != nil {
+		t.Fatal(err)
+	}
+	if saga.Status != domain.SagaStatusCompleted {
+		t.Fatalf("saga is %s at %s (%s), want completed", saga.Status, saga.Step, saga.LastError)
+	}
+
+	// Both attempts at creating the order used the ID chosen when the saga
+	// started.
+	if len(orders.requestedIDs) != 2 || orders.requestedIDs[0] != saga.Data.OrderID || orders.requestedIDs[1] != saga.Data.OrderID {
+		t.Errorf("CreateOrder was called with order IDs %v, want %s twice", orders.requestedIDs, saga.Data.OrderID)
+	}
+	if len(orders.orders) != 1 {
+		t.Errorf("%d orders were created, want 1", len(orders.orders))
+	}
+	if payments.voided {
+		t.Error("payment was voided")
+	}
+}
+
+func TestCheckoutRetriesCreateOrderWithoutDuplicating(t *testing.T) {
+	u, orders, _, _ := newTestSaga()
+	// The first attempt creates the order but its outcome is lost.
+	orders.createErrsAfter = []error{context.DeadlineExceeded}
+
+	saga, err := u.StartCheckout("user-1", "TSHIRT-M", 1, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectWaiting(t, saga, domain.SagaStepCreateOrder)
+
+	if err := u.advance(saga); err != nil {
+		t.Fatal(err)
+	}
+	if saga.Status != domain.SagaStatusCompleted {
+		t.Fatalf("saga is %s at %s (%s), want completed", saga.Status, saga.Step, saga.LastError)
+	}
+	if len(orders.orders) != 1 {
+		t.Errorf("%d orders were created, want 1", len(orders.orders))
+	}
+}
+
+func TestCheckoutCompensatesRejectedCapture(t *testing.T) {
+	u, orders, payments, inventory := newTestSaga()
+	payments.captureErrs = []error{status.Error(codes.FailedPrecondition, "payment declined: insufficient_funds")}
+
+	saga, err := u.StartCheckout("user-1", "TSHIRT-M", 1, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCompensated(t, saga, orders, payments, inventory)
+}
+
+func TestCheckoutCompensatesAfterMaxAttempts(t *testing.T) {
+	u, orders, payments, inventory := newTestSaga()
+	for i := 0; i < u.cfg.MaxAttempts; i++ {
+		payments.captureErrs = append(payments.captureErrs, status.Error(codes.Unavailable, "payment gateway unavailable"))
+	}
+
+	saga, err := u.StartCheckout("user-1", "TSHIRT-M", 1, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < u.cfg.MaxAttempts; i++ {
+		expectWaiting(t, saga, domain.SagaStepCapturePayment)
+		if err := u.advance(saga); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectCompensated(t, saga, orders, payments, inventory)
+}
+
+func TestCheckoutRejectedOrderHasNothingToUndo(t *testing.T) {
+	u, orders, payments, inventory := newTestSaga()
+	orders.createErrs = []error{domain.ErrOutOfStock}
+
+	saga, err := u.StartCheckout("user-1", "TSHIRT-M", 1, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saga.Status != domain.SagaStatusCompensated {
+		t.Fatalf("saga is %s at %s, want compensated", saga.Status, saga.Step)
+	}
+	if len(orders.orders) != 0 || payments.status != "" || inventory.released {
+		t.Errorf("a rejected order left %d orders, payment %q, released %v", len(orders.orders), payments.status, inventory.released)
+	}
+}
+
+func TestServiceErrorRetriesOnlyTransportFailures(t *testing.T) {
+	cases := []struct {
+		code     codes.Code
+		rejected bool
+	}{
+		{codes.Unavailable, false},
+		{codes.DeadlineExceeded, false},
+		{codes.Aborted, false},
+		{codes.FailedPrecondition, true},
+		{codes.InvalidArgument, true},
+		{codes.NotFound, true},
+	}
+	for _, tc := range cases {
+		err := serviceError(status.Error(tc.code, "test"))
+		if got := errors.Is(err, domain.ErrSagaStepRejected); got != tc.rejected {
+			t.Errorf("serviceError(%s) rejected = %v, want %v", tc.code, got, tc.rejected)
+		}
+	}
+	if err := serviceError(nil); err != nil {
+		t.Errorf("serviceError(nil) = %v", err)
+	}
+}
+
+func expectWaiting(t *testing.T, saga *domain.Saga, step string) {
+	t.Helper()
+	if saga.Status != domain.SagaStatusRunning || saga.Step != step || saga.Attempts == 0 {
+		t.Fatalf("saga is %s at %s after %d attempts (%s), want to be retrying %s", saga.Status, saga.Step, saga.Attempts, saga.LastError, step)
+	}
+}
+
+func expectCompensated(t *testing.T, saga *domain.Saga, orders *fakeOrders, payments *fakePayments, inventory *fakeInventory) {
+	t.Helper()
+	if saga.Status != domain.SagaStatusCompensated {
+		t.Fatalf("saga is %s at %s (%s), want compensated", saga.Status, saga.Step, saga.LastError)
+	}
+	if !payments.voided {
+		t.Error("payment was not voided")
+	}
+	if !inventory.released {
+		t.Error("stock was not released")
+	}
+	if got := orders.orders[saga.Data.OrderID].Status; got != domain.OrderStatusCancelled {
+		t.Errorf("order is %s, want cancelled", got)
+	}
+}
+
+func newTestSaga() (*sagaUsecase, *fakeOrders, *fakePayments, *fakeInventory) {
+	orders := &fakeOrders{orders: make(map[string]*domain.Order)}
+	payments := &fakePayments{}
+	inventory := &fakeInventory{}
+
+	u := NewSagaUsecase(&memorySagaRepo{}, orders, "localhost:0", "localhost:0", SagaConfig{
+		StepTimeout: time.Second,
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+	}).(*sagaUsecase)
+	u.paymentGRPCClient = payments
+	u.inventoryClient = inventory
+	return u, orders, payments, inventory
+}
+
+// memorySagaRepo keeps sagas in memory; the tests drive them directly.
+type memorySagaRepo struct {
+	domain.SagaRepository
+	steps []*domain.SagaStepLog
+}
+
+func (r *memorySagaRepo) Create(saga *domain.Saga) error {
+	saga.ID = "saga-1"
+	return nil
+}
+
+func (r *memorySagaRepo) Save(saga *domain.Saga, entry *domain.SagaStepLog) error {
+	r.steps = append(r.steps, entry)
+	return nil
+}
+
+// fakeOrders creates orders in memory. createErrs fail CreateOrder calls
+// before they do anything; createErrsAfter fail them after the order is
+// stored, as when the reply is lost.
+type fakeOrders struct {
+	domain.OrderUsecase
+	orders          map[string]*domain.Order
+	requestedIDs    []string
+	createErrs      []error
+	createErrsAfter []error
+}
+
+func (f *fakeOrders) CreateOrder(req domain.CreateOrderRequest) (*domain.Order, error) {
+	f.requestedIDs = append(f.requestedIDs, req.OrderID)
+	if len(f.createErrs) > 0 {
+		err := f.createErrs[0]
+		f.createErrs = f.createErrs[1:]
+		return nil, err
+	}
+	order, ok := f.orders[req.OrderID]
+	if !ok {
+		order = &domain.Order{ID: req.OrderID, UserID: req.UserID, SKU: req.SKU, Quantity: req.Quantity, Amount: 19.99, Status: domain.OrderStatusPending}
+		f.orders[order.ID] = order
+	}
+	if len(f.createErrsAfter) > 0 {
+		err := f.createErrsAfter[0]
+		f.createErrsAfter = f.createErrsAfter[1:]
+		return nil, err
+	}
+	return order, nil
+}
+
+func (f *fakeOrders) GetOrder(id string) (*domain.Order, error) {
+	order, ok := f.orders[id]
+	if !ok {
+		return nil, domain.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (f *fakeOrders) CancelOrder(id string) (*domain.Order, error) {
+	return f.UpdateOrderStatus(id, domain.OrderStatusCancelled)
+}
+
+func (f *fakeOrders) UpdateOrderStatus(id, status string) (*domain.Order, error) {
+	order, err := f.GetOrder(id)
+	if err != nil {
+		return nil, err
+	}
+	order.Status = status
+	return order, nil
+}
+
+// fakePayments holds the checkout's one payment. captureErrs fail
+// successive captures.
+type fakePayments struct {
+	paymentpb.PaymentServiceClient
+	status      string
+	captureErrs []error
+	voided      bool
+}
+
+func (f *fakePayments) AuthorizePayment(ctx context.Context, in *paymentpb.AuthorizePaymentRequest, opts ...grpc.CallOption) (*paymentpb.Payment, error) {
+	if f.status == "" {
+		f.status = paymentStatusAuthorized
+	}
+	return &paymentpb.Payment{Id: "payment-1", OrderId: in.OrderId, Amount: in.Amount, Status: f.status}, nil
+}
+
+func (f *fakePayments) GetPayment(ctx context.Context, in *paymentpb.GetPaymentRequest, opts ...grpc.CallOption) (*paymentpb.GetPaymentResponse, error) {
+	return &paymentpb.GetPaymentResponse{Id: in.Id, Status: f.status}, nil
+}
+
+func (f *fakePayments) CapturePayment(ctx context.Context, in *paymentpb.CapturePaymentRequest, opts ...grpc.CallOption) (*paymentpb.Payment, error) {
+	if len(f.captureErrs) > 0 {
+		err := f.captureErrs[0]
+		f.captureErrs = f.captureErrs[1:]
+		return nil, err
+	}
+	f.status = paymentStatusCaptured
+	return &paymentpb.Payment{Id: in.Id, Status: f.status}, nil
+}
+
+func (f *fakePayments) VoidPayment(ctx context.Context, in *paymentpb.VoidPaymentRequest, opts ...grpc.CallOption) (*paymentpb.Payment, error) {
+	f.status = "voided"
+	f.voided = true
+	return &paymentpb.Payment{Id: in.Id, Status: f.status}, nil
+}
+
+type fakeInventory struct {
+	inventorypb.InventoryServiceClient
+	released bool
+}
+
+func (f *fakeInventory) ReserveStock(ctx context.Context, in *inventorypb.ReserveStockRequest, opts ...grpc.CallOption) (*inventorypb.Reservation, error) {
+	return &inventorypb.Reservation{OrderId: in.OrderId, Status: reservationStatusActive}, nil
+}
+
+func (f *fakeInventory) ReleaseReservation(ctx context.Context, in *inventorypb.ReleaseReservationRequest, opts ...grpc.CallOption) (*inventorypb.Reservation, error) {
+	f.released = true
+	return &inventorypb.Reservation{OrderId: in.OrderId, Status: "released"}, nil
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/webhook"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+	"github.com/google/uuid"
+)
+
+// resolveTimeout bounds the DNS lookup made when a subscription is created.
+const resolveTimeout = 5 * time.Second
+
+type webhookUsecase struct {
+	subscriptionRepo domain.WebhookSubscriptionRepository
+	deliveryRepo     domain.WebhookDeliveryRepository
+	allowPrivateURLs bool
+}
+
+// NewWebhookUsecase only accepts subscription URLs that resolve to public
+// addresses, unless allowPrivateURLs is set for local development.
+func NewWebhookUsecase(subscriptionRepo domain.WebhookSubscriptionRepository, deliveryRepo domain.WebhookDeliveryRepository, allowPrivateURLs bool) domain.WebhookUsecase {
+	return &webhookUsecase{
+		subscriptionRepo: subscriptionRepo,
+		deliveryRepo:     deliveryRepo,
+		allowPrivateURLs: allowPrivateURLs,
+	}
+}
+
+func (u *webhookUsecase) CreateSubscription(rawURL string, eventTypes []string) (*domain.WebhookSubscription, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, domain.ErrInvalidWebhookURL
+	}
+	if !u.allowPrivateURLs {
+		ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+		defer cancel()
+		if err := webhook.CheckURL(ctx, parsed); err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidWebhookURL, err)
+		}
+	}
+	if len(eventTypes) == 0 {
+		return nil, errors.New("at least one event type is required")
+	}
+	for _, eventType := range eventTypes {
+		if !slices.Contains(domain.WebhookEventTypes, eventType) {
+			return nil, domain.ErrUnknownEventType
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	sub := &domain.WebhookSubscription{
+		URL:        parsed.String(),
+		EventTypes: slices.Compact(slices.Sorted(slices.Values(eventTypes))),
+		Secret:     "whsec_" + hex.EncodeToString(secret),
+	}
+	if err := u.subscriptionRepo.Create(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (u *webhookUsecase) ListSubscriptions() ([]*domain.WebhookSubscription, error) {
+	return u.subscriptionRepo.List()
+}
+
+func (u *webhookUsecase) DeleteSubscription(id string) error {
+	if id == "" {
+		return errors.New("id is required")
+	}
+	return u.subscriptionRepo.Delete(id)
+}
+
+func (u *webhookUsecase) Publish(eventType string, data any) error {
+	if !slices.Contains(domain.WebhookEventTypes, eventType) {
+		return domain.ErrUnknownEventType
+	}
+
+	subs, err := u.subscriptionRepo.ListByEventType(eventType)
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	event := domain.WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      raw,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]*domain.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		deliveries = append(deliveries, &domain.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      eventType,
+			Payload:        payload,
+		})
+	}
+	return u.deliveryRepo.CreateBatch(deliveries)
+}
+
+func (u *webhookUsecase) ListDeadLetters(limit int) ([]*domain.WebhookDelivery, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	return u.deliveryRepo.ListByStatus(domain.DeliveryStatusDead, limit)
+}
+
+func (u *webhookUsecase) ListAttempts(deliveryID string) ([]*domain.DeliveryAttempt, error) {
+	if deliveryID == "" {
+		return nil, errors.New("deliveryID is required")
+	}
+	return u.deliveryRepo.ListAttempts(deliveryID)
+}
+
+// RedriveDelivery moves a dead-lettered delivery back onto the queue.
+func (u *webhookUsecase) RedriveDelivery(id string) error {
+	if id == "" {
+		return errors.New("id is required")
+	}
+	return u.deliveryRepo.Requeue(id)
+}
@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+)
+
+// OrderExpirer expires orders left pending longer than the payment window.
+// Every replica may run it: orders are claimed with FOR UPDATE SKIP LOCKED.
+type OrderExpirer struct {
+	orderUsecase  domain.OrderUsecase
+	paymentWindow time.Duration
+	pollInterval  time.Duration
+	batchSize     int
+}
+
+func NewOrderExpirer(orderUsecase domain.OrderUsecase, paymentWindow, pollInterval time.Duration, batchSize int) *OrderExpirer {
+	return &OrderExpirer{
+		orderUsecase:  orderUsecase,
+		paymentWindow: paymentWindow,
+		pollInterval:  pollInterval,
+		batchSize:     batchSize,
+	}
+}
+
+// Run expires due orders until ctx is cancelled.
+func (e *OrderExpirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		e.expireDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expireDue works through full batches so a backlog clears in one tick.
+func (e *OrderExpirer) expireDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		orders, err := e.orderUsecase.ExpireUnpaid(time.Now().Add(-e.paymentWindow), e.batchSize)
+		if err != nil {
+			log.Printf("Failed to expire unpaid orders: %v", err)
+			return
+		}
+		if len(orders) > 0 {
+			log.Printf("Expired %d unpaid orders", len(orders))
+		}
+		if len(orders) < e.batchSize {
+			return
+		}
+	}
+}
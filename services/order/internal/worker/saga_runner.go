@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+)
+
+// SagaRunner resumes sagas whose step is due for a retry, including sagas
+// left behind by a crashed instance once their lease runs out.
+type SagaRunner struct {
+	sagaUsecase  domain.SagaUsecase
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewSagaRunner(sagaUsecase domain.SagaUsecase, pollInterval time.Duration, batchSize int) *SagaRunner {
+	return &SagaRunner{
+		sagaUsecase:  sagaUsecase,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+	}
+}
+
+// Run polls for due sagas until ctx is cancelled.
+func (r *SagaRunner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.sagaUsecase.RunDue(r.batchSize); err != nil {
+			log.Printf("Failed to run due sagas: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package worker contains the order service's background workers.
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/webhook"
+	"github.com/edwinjordan/golang_microservices/services/order/internal/domain"
+)
+
+// DispatcherConfig controls delivery polling and retries.
+type DispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	MaxAttempts  int
+	// Retries wait BaseBackoff * 2^(attempt-1), capped at MaxBackoff, with
+	// up to 20% jitter.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// AllowPrivateURLs lets deliveries reach loopback, link-local and
+	// private addresses. Only for local development.
+	AllowPrivateURLs bool
+}
+
+// WebhookDispatcher delivers queued webhook events to subscribers. Several
+// instances may run at once: deliveries are leased when claimed.
+type WebhookDispatcher struct {
+	cfg              DispatcherConfig
+	subscriptionRepo domain.WebhookSubscriptionRepository
+	deliveryRepo     domain.WebhookDeliveryRepository
+	client           *http.Client
+}
+
+// NewWebhookDispatcher checks the address of every connection it opens, so
+// a subscription whose host later resolves inside the network is refused
+// then rather than only when it was created.
+func NewWebhookDispatcher(subscriptionRepo domain.WebhookSubscriptionRepository, deliveryRepo domain.WebhookDeliveryRepository, cfg DispatcherConfig) *WebhookDispatcher {
+	client := webhook.NewClient(cfg.Timeout)
+	if cfg.AllowPrivateURLs {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+	return &WebhookDispatcher{
+		cfg:              cfg,
+		subscriptionRepo: subscriptionRepo,
+		deliveryRepo:     deliveryRepo,
+		client:           client,
+	}
+}
+
+// Run polls for due deliveries until ctx is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatchDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *WebhookDispatcher) dispatchDue(ctx context.Context) {
+	// The lease must outlast one HTTP attempt per claimed delivery.
+	lease := d.cfg.Timeout*time.Duration(d.cfg.BatchSize) + time.Minute
+	deliveries, err := d.deliveryRepo.ClaimDue(time.Now(), lease, d.cfg.BatchSize)
+	if err != nil {
+		log.Printf("Failed to claim webhook deliveries: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		d.attempt(ctx, delivery)
+	}
+}
+
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery *domain.WebhookDelivery) {
+	attempt := &domain.DeliveryAttempt{AttemptedAt: time.Now()}
+
+	sub, err := d.subscriptionRepo.GetByID(delivery.SubscriptionID)
+	if err == nil {
+		attempt.StatusCode, err = d.send(ctx, sub, delivery)
+	}
+	attempt.Duration = time.Since(attempt.AttemptedAt)
+
+	delivery.Attempts++
+	delivery.LastStatusCode = attempt.StatusCode
+	switch {
+	case err == nil:
+		now := time.Now()
+		delivery.Status = domain.DeliveryStatusSucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case errors.Is(err, domain.ErrSubscriptionNotFound) || delivery.Attempts >= d.cfg.MaxAttempts:
+		attempt.Error = err.Error()
+		delivery.Status = domain.DeliveryStatusDead
+		delivery.LastError = err.Error()
+	default:
+		attempt.Error = err.Error()
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+	}
+
+	if err := d.deliveryRepo.RecordAttempt(delivery, attempt); err != nil {
+		log.Printf("Failed to record attempt for webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// send POSTs the signed payload. Any 2xx response counts as delivered.
+func (d *WebhookDispatcher) send(ctx context.Context, sub *domain.WebhookSubscription, delivery *domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(sub.Secret, delivery.Payload, time.Now()))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	wait := d.cfg.BaseBackoff
+	for i := 1; i < attempts && wait < d.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, d.cfg.MaxBackoff)
+	return wait + time.Duration(rand.Int63n(int64(wait)/5+1))
+}
@@ -0,0 +1,49 @@
+// Command ledgercheck verifies the payment ledger's invariants: all postings
+// sum to zero, every journal entry balances, and each payment's captured and
+// refunded amounts match what it posted. It exits non-zero on any violation.
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/config"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/repository"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/usecase"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	cfg := config.LoadConfig()
+
+	db, err := sql.Open("postgres", cfg.GetDSN())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ledger := usecase.NewLedgerUsecase(repository.NewPostgresLedgerRepository(db), cfg.BaseCurrency)
+	check, err := ledger.Check()
+	if err != nil {
+		log.Fatalf("Ledger check failed: %v", err)
+	}
+
+	fmt.Printf("entries:            %d\n", check.Entries)
+	fmt.Printf("sum of postings:    %d\n", check.Total)
+	fmt.Printf("unbalanced entries: %d\n", len(check.UnbalancedEntries))
+	for _, id := range check.UnbalancedEntries {
+		fmt.Printf("  %s\n", id)
+	}
+	fmt.Printf("payment mismatches: %d\n", len(check.PaymentMismatches))
+	for _, id := range check.PaymentMismatches {
+		fmt.Printf("  %s\n", id)
+	}
+
+	if !check.OK() {
+		fmt.Println("FAIL")
+		os.Exit(1)
+	}
+	fmt.Println("OK")
+}
@@ -1,21 +1,35 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net"
 	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus"
+	"github.com/edwinjordan/golang_microservices/pkg/fx"
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
+	"github.com/edwinjordan/golang_microservices/pkg/restproxy"
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
 	"github.com/edwinjordan/golang_microservices/services/payment/internal/config"
+	eventsHandler "github.com/edwinjordan/golang_microservices/services/payment/internal/delivery/events"
 	grpcHandler "github.com/edwinjordan/golang_microservices/services/payment/internal/delivery/grpc"
 	httpHandler "github.com/edwinjordan/golang_microservices/services/payment/internal/delivery/http"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/gateway"
 	"github.com/edwinjordan/golang_microservices/services/payment/internal/repository"
 	"github.com/edwinjordan/golang_microservices/services/payment/internal/usecase"
 	pb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
+	"github.com/edwinjordan/golang_microservices/services/user/pkg/auth"
+	userpb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
@@ -48,7 +62,53 @@ func main() {
 
 	// Initialize layers
 	paymentRepo := repository.NewPostgresPaymentRepository(db)
-	paymentUsecase := usecase.NewPaymentUsecase(paymentRepo, cfg.OrderGRPCAddr)
+	refundRepo := repository.NewPostgresRefundRepository(db)
+	ledgerRepo := repository.NewPostgresLedgerRepository(db)
+	webhookRepo := repository.NewPostgresWebhookEventRepository(db)
+	paymentGateway := newGateway(cfg)
+	baseCurrency, err := fx.NormalizeCurrency(cfg.BaseCurrency)
+	if err != nil {
+		log.Fatalf("Invalid PAYMENT_BASE_CURRENCY: %v", err)
+	}
+	rates := newRateProvider(cfg, baseCurrency)
+	ledgerUsecase := usecase.NewLedgerUsecase(ledgerRepo, baseCurrency)
+	webhookUsecase := usecase.NewWebhookUsecase(webhookRepo, paymentRepo, ledgerUsecase, cfg.WebhookSecrets(), cfg.WebhookTolerance)
+	paymentUsecase := usecase.NewPaymentUsecase(paymentRepo, refundRepo, paymentGateway, ledgerUsecase, rates, cfg.OrderGRPCAddr, pagination.NewCodec(cfg.PageTokenSecret))
+
+	// Connect to event bus
+	bus, err := eventbus.Open(context.Background(), eventbus.Config{
+		Driver: cfg.EventBusDriver,
+		URL:    cfg.EventBusURL,
+		Stream: cfg.EventBusStream,
+		Name:   eventsHandler.Source,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to event bus: %v", err)
+	}
+	defer bus.Close()
+
+	// Subscribe to order events
+	subscriber := eventsHandler.NewSubscriber(paymentUsecase)
+	if err := subscriber.Register(context.Background(), bus, db); err != nil {
+		log.Fatalf("Failed to subscribe to events: %v", err)
+	}
+
+	// Start outbox relay
+	publisher := eventbus.OutboxPublisher(bus, eventsHandler.Source, eventsHandler.Translate)
+	relay := outbox.NewRelay(db, publisher, outbox.RelayConfig{
+		PollInterval: cfg.OutboxPollInterval,
+		BatchSize:    cfg.OutboxBatchSize,
+		MaxAttempts:  cfg.OutboxMaxAttempts,
+	})
+	go relay.Run(context.Background())
+
+	// Ledger reads check the caller's access token with the user service
+	userConn, err := grpc.NewClient(cfg.UserGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to connect to user service: %v", err)
+	}
+	defer userConn.Close()
+	introspector := auth.NewIntrospector(userpb.NewUserServiceClient(userConn), cfg.AuthCacheTTL)
 
 	// Start gRPC server
 	go func() {
@@ -57,8 +117,11 @@ func main() {
 			log.Fatalf("Failed to listen on gRPC port: %v", err)
 		}
 
-		grpcServer := grpc.NewServer()
-		paymentGRPCHandler := grpcHandler.NewPaymentGRPCHandler(paymentUsecase)
+		grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+			grpcHandler.UnaryAuthInterceptor(introspector),
+			validate.UnaryServerInterceptor(),
+		))
+		paymentGRPCHandler := grpcHandler.NewPaymentGRPCHandler(paymentUsecase, ledgerUsecase)
 		pb.RegisterPaymentServiceServer(grpcServer, paymentGRPCHandler)
 
 		log.Printf("gRPC server listening on port %s", cfg.GRPCPort)
@@ -69,30 +132,94 @@ func main() {
 
 	// Start HTTP server
 	router := gin.Default()
+	registerRoutes(router, introspector, paymentUsecase, ledgerUsecase, webhookUsecase)
+
+	// REST routes generated from the HTTP rules in proto/payment.proto, proxied
+	// to the gRPC server
+	restProxy, err := restproxy.New(context.Background(), "localhost:"+cfg.GRPCPort, pb.RegisterPaymentServiceHandlerFromEndpoint)
+	if err != nil {
+		log.Fatalf("Failed to start REST proxy: %v", err)
+	}
+	router.Any(restproxy.Prefix+"/*path", gin.WrapH(restProxy))
+
+	if err := openapi.Mount(router, httpHandler.API(), restproxy.Prefix); err != nil {
+		log.Fatalf("Failed to serve OpenAPI document: %v", err)
+	}
+
+	log.Printf("HTTP server listening on port %s", cfg.HTTPPort)
+	if err := router.Run(fmt.Sprintf(":%s", cfg.HTTPPort)); err != nil {
+		log.Fatalf("Failed to start HTTP server: %v", err)
+	}
+}
+
+// registerRoutes registers the hand-written HTTP routes. main adds the
+// generated REST routes and the OpenAPI document.
+func registerRoutes(router *gin.Engine, introspector *auth.Introspector, paymentUsecase domain.PaymentUsecase, ledgerUsecase domain.LedgerUsecase, webhookUsecase domain.WebhookUsecase) {
 	paymentHandler := httpHandler.NewPaymentHandler(paymentUsecase)
+	ledgerHandler := httpHandler.NewLedgerHandler(ledgerUsecase)
+	webhookHandler := httpHandler.NewWebhookHandler(webhookUsecase)
 
 	router.GET("/health", paymentHandler.Health)
 	router.POST("/payments", paymentHandler.ProcessPayment)
+	router.GET("/payments", paymentHandler.ListPayments)
+	router.POST("/payments/authorize", paymentHandler.AuthorizePayment)
+	router.POST("/payments/:id/capture", paymentHandler.CapturePayment)
+	router.POST("/payments/:id/void", paymentHandler.VoidPayment)
+	router.POST("/payments/:id/refunds", paymentHandler.RefundPayment)
+	router.GET("/payments/:id/refunds", paymentHandler.ListRefunds)
+	router.GET("/orders/:id/payments", paymentHandler.ListOrderPayments)
 	router.GET("/payments/:id", paymentHandler.GetPayment)
+	router.POST("/webhooks/:provider", webhookHandler.Receive)
 
-	log.Printf("HTTP server listening on port %s", cfg.HTTPPort)
-	if err := router.Run(fmt.Sprintf(":%s", cfg.HTTPPort)); err != nil {
-		log.Fatalf("Failed to start HTTP server: %v", err)
+	finance := router.Group("/", httpHandler.RequireAuth(introspector), httpHandler.RequireRole(auth.RoleFinance, auth.RoleAdmin))
+	finance.GET("/payments/:id/ledger", ledgerHandler.ListEntries)
+	finance.GET("/ledger/balances", ledgerHandler.Balances)
+}
+
+func newGateway(cfg *config.Config) domain.PaymentGateway {
+	switch cfg.Gateway {
+	case "fake":
+		log.Printf("Using fake payment gateway (latency=%s, decline_above=%.2f, failure_rate=%.2f)",
+			cfg.FakeGatewayLatency, cfg.FakeGatewayDeclineAbove, cfg.FakeGatewayFailureRate)
+		return gateway.NewFakeGateway(gateway.FakeConfig{
+			Latency:      cfg.FakeGatewayLatency,
+			DeclineAbove: cfg.FakeGatewayDeclineAbove,
+			FailureRate:  cfg.FakeGatewayFailureRate,
+			Seed:         cfg.FakeGatewaySeed,
+			FeePercent:   cfg.FakeGatewayFeePercent,
+			FeeFixed:     cfg.FakeGatewayFeeFixed,
+		})
+	default:
+		log.Fatalf("Unknown payment gateway %q", cfg.Gateway)
+		return nil
+	}
+}
+
+// newRateProvider loads the rates table named by PAYMENT_FX_RATES. Without
+// one only payments in the base currency can be taken.
+func newRateProvider(cfg *config.Config, baseCurrency string) fx.RateProvider {
+	if cfg.FXRates == "" {
+		log.Printf("No fx rates configured, only %s payments are accepted", baseCurrency)
+		rates, err := fx.NewTable(fx.TableConfig{Base: baseCurrency})
+		if err != nil {
+			log.Fatalf("Failed to create fx rates: %v", err)
+		}
+		return rates
+	}
+
+	rates, err := fx.LoadTable(cfg.FXRates)
+	if err != nil {
+		log.Fatalf("Failed to load fx rates: %v", err)
+	}
+	if _, err := rates.Rate(context.Background(), rates.Base(), baseCurrency); err != nil {
+		log.Fatalf("fx rates %s do not quote the base currency: %v", cfg.FXRates, err)
 	}
+	log.Printf("Using fx rates %s (base %s)", cfg.FXRates, rates.Base())
+	return rates
 }
 
 func initSchema(db *sql.DB) {
-	schema := `
-	CREATE TABLE IF NOT EXISTS payments (
-		id VARCHAR(36) PRIMARY KEY,
-		order_id VARCHAR(36) NOT NULL,
-		amount DECIMAL(10, 2) NOT NULL,
-		status VARCHAR(50) NOT NULL,
-		created_at TIMESTAMP NOT NULL,
-		updated_at TIMESTAMP NOT NULL
-	);
-	`
-	_, err := db.Exec(schema)
+	_, err := db.Exec(repository.Schema + outbox.Schema + eventbus.InboxSchema)
 	if err != nil {
 		log.Fatalf("Failed to initialize schema: %v", err)
 	}
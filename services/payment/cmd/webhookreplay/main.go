@@ -0,0 +1,77 @@
+// Command webhookreplay re-applies stored provider webhooks, either one event
+// by ID or every event whose processing failed.
+//
+//	go run ./cmd/webhookreplay -id <event id>
+//	go run ./cmd/webhookreplay -failed [-limit 100]
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/config"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/repository"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/usecase"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	id := flag.String("id", "", "replay a single event by ID")
+	failed := flag.Bool("failed", false, "replay all failed events")
+	limit := flag.Int("limit", 100, "maximum number of failed events to replay")
+	flag.Parse()
+
+	if (*id == "") == !*failed {
+		fmt.Fprintln(os.Stderr, "exactly one of -id or -failed is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig()
+	db, err := sql.Open("postgres", cfg.GetDSN())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	paymentRepo := repository.NewPostgresPaymentRepository(db)
+	ledgerUsecase := usecase.NewLedgerUsecase(repository.NewPostgresLedgerRepository(db), cfg.BaseCurrency)
+	webhooks := usecase.NewWebhookUsecase(repository.NewPostgresWebhookEventRepository(db), paymentRepo, ledgerUsecase,
+		cfg.WebhookSecrets(), cfg.WebhookTolerance)
+
+	ids := []string{*id}
+	if *failed {
+		events, err := webhooks.ListFailed(*limit)
+		if err != nil {
+			log.Fatalf("Failed to list failed events: %v", err)
+		}
+		ids = ids[:0]
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+	}
+
+	var failures int
+	for _, eventID := range ids {
+		event, err := webhooks.Replay(eventID)
+		if event == nil {
+			fmt.Printf("%s  error: %v\n", eventID, err)
+			failures++
+			continue
+		}
+		fmt.Printf("%s  %s %s -> %s\n", event.ID, event.Provider, event.Type, event.Status)
+		if event.Status == domain.WebhookStatusFailed {
+			fmt.Printf("    %s\n", event.Error)
+			failures++
+		}
+	}
+
+	fmt.Printf("replayed %d event(s), %d failed\n", len(ids), failures)
+	if failures > 0 {
+		os.Exit(1)
+	}
+}
@@ -3,32 +3,96 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
-	DBHost        string
-	DBPort        string
-	DBUser        string
-	DBPassword    string
-	DBName        string
-	HTTPPort      string
-	GRPCPort      string
-	OrderGRPCAddr string
+	DBHost          string
+	DBPort          string
+	DBUser          string
+	DBPassword      string
+	DBName          string
+	HTTPPort        string
+	GRPCPort        string
+	OrderGRPCAddr   string
+	UserGRPCAddr    string
+	PageTokenSecret string
+
+	// AuthCacheTTL is how long a checked access token is trusted before the
+	// user service is asked again.
+	AuthCacheTTL time.Duration
+
+	Gateway                 string
+	FakeGatewayLatency      time.Duration
+	FakeGatewayDeclineAbove float64
+	FakeGatewayFailureRate  float64
+	FakeGatewaySeed         int64
+	FakeGatewayFeePercent   float64
+	FakeGatewayFeeFixed     float64
+
+	// FXRates is the path of an fx rates table; BaseCurrency is the currency
+	// the ledger is kept in.
+	FXRates      string
+	BaseCurrency string
+
+	WebhookSecret    string
+	WebhookTolerance time.Duration
+
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+	OutboxMaxAttempts  int
+
+	EventBusDriver string
+	EventBusURL    string
+	EventBusStream string
 }
 
 func LoadConfig() *Config {
 	return &Config{
-		DBHost:        getEnv("PAYMENT_DB_HOST", "localhost"),
-		DBPort:        getEnv("PAYMENT_DB_PORT", "5432"),
-		DBUser:        getEnv("PAYMENT_DB_USER", "paymentservice"),
-		DBPassword:    getEnv("PAYMENT_DB_PASSWORD", "paymentpass123"),
-		DBName:        getEnv("PAYMENT_DB_NAME", "payments_db"),
-		HTTPPort:      getEnv("PAYMENT_SERVICE_HTTP_PORT", "8083"),
-		GRPCPort:      getEnv("PAYMENT_SERVICE_GRPC_PORT", "9093"),
-		OrderGRPCAddr: getEnv("ORDER_GRPC_ADDR", "localhost:9092"),
+		DBHost:          getEnv("PAYMENT_DB_HOST", "localhost"),
+		DBPort:          getEnv("PAYMENT_DB_PORT", "5432"),
+		DBUser:          getEnv("PAYMENT_DB_USER", "paymentservice"),
+		DBPassword:      getEnv("PAYMENT_DB_PASSWORD", "paymentpass123"),
+		DBName:          getEnv("PAYMENT_DB_NAME", "payments_db"),
+		HTTPPort:        getEnv("PAYMENT_SERVICE_HTTP_PORT", "8083"),
+		GRPCPort:        getEnv("PAYMENT_SERVICE_GRPC_PORT", "9093"),
+		OrderGRPCAddr:   getEnv("ORDER_GRPC_ADDR", "localhost:9092"),
+		UserGRPCAddr:    getEnv("USER_GRPC_ADDR", "localhost:9091"),
+		PageTokenSecret: getEnv("PAYMENT_PAGE_TOKEN_SECRET", "change-me-page-token-secret"),
+
+		AuthCacheTTL: getEnvDuration("PAYMENT_AUTH_CACHE_TTL", 30*time.Second),
+
+		Gateway:                 getEnv("PAYMENT_GATEWAY", "fake"),
+		FakeGatewayLatency:      getEnvDuration("PAYMENT_FAKE_GATEWAY_LATENCY", 0),
+		FakeGatewayDeclineAbove: getEnvFloat("PAYMENT_FAKE_GATEWAY_DECLINE_ABOVE", 0),
+		FakeGatewayFailureRate:  getEnvFloat("PAYMENT_FAKE_GATEWAY_FAILURE_RATE", 0),
+		FakeGatewaySeed:         getEnvInt("PAYMENT_FAKE_GATEWAY_SEED", 1),
+		FakeGatewayFeePercent:   getEnvFloat("PAYMENT_FAKE_GATEWAY_FEE_PERCENT", 2.9),
+		FakeGatewayFeeFixed:     getEnvFloat("PAYMENT_FAKE_GATEWAY_FEE_FIXED", 0.30),
+
+		FXRates:      getEnv("PAYMENT_FX_RATES", ""),
+		BaseCurrency: getEnv("PAYMENT_BASE_CURRENCY", "USD"),
+
+		WebhookSecret:    getEnv("PAYMENT_WEBHOOK_SECRET", "change-me-webhook-secret"),
+		WebhookTolerance: getEnvDuration("PAYMENT_WEBHOOK_TOLERANCE", 5*time.Minute),
+
+		OutboxPollInterval: getEnvDuration("PAYMENT_OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxBatchSize:    int(getEnvInt("PAYMENT_OUTBOX_BATCH_SIZE", 100)),
+		OutboxMaxAttempts:  int(getEnvInt("PAYMENT_OUTBOX_MAX_ATTEMPTS", 10)),
+
+		EventBusDriver: getEnv("EVENT_BUS_DRIVER", "memory"),
+		EventBusURL:    getEnv("EVENT_BUS_URL", "nats://localhost:4222"),
+		EventBusStream: getEnv("EVENT_BUS_STREAM", "EVENTS"),
 	}
 }
 
+// WebhookSecrets maps webhook providers to their signing secrets. Only the
+// configured gateway may deliver webhooks.
+func (c *Config) WebhookSecrets() map[string]string {
+	return map[string]string{c.Gateway: c.WebhookSecret}
+}
+
 func (c *Config) GetDSN() string {
 	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName)
@@ -40,3 +104,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,72 @@
+// Package events connects the payment service to the event bus: it
+// translates outbox events into bus payloads and reacts to order events.
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Source names the payment service on published envelopes.
+const Source = "payment-service"
+
+// Translate converts a payment outbox event into its bus payload.
+func Translate(event outbox.Event) (proto.Message, error) {
+	switch event.Type {
+	case domain.EventPaymentCreated, domain.EventPaymentUpdated:
+		var payment domain.Payment
+		if err := json.Unmarshal(event.Payload, &payment); err != nil {
+			return nil, err
+		}
+		if event.Type == domain.EventPaymentCreated {
+			return &eventspb.PaymentCreated{Payment: toPBPayment(&payment)}, nil
+		}
+		return &eventspb.PaymentUpdated{Payment: toPBPayment(&payment)}, nil
+	case domain.EventPaymentRefunded:
+		var refunded struct {
+			Payment domain.Payment `json:"payment"`
+			Refund  domain.Refund  `json:"refund"`
+		}
+		if err := json.Unmarshal(event.Payload, &refunded); err != nil {
+			return nil, err
+		}
+		return &eventspb.PaymentRefunded{
+			Payment: toPBPayment(&refunded.Payment),
+			Refund:  toPBRefund(&refunded.Refund),
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func toPBPayment(payment *domain.Payment) *eventspb.Payment {
+	return &eventspb.Payment{
+		Id:             payment.ID,
+		OrderId:        payment.OrderID,
+		UserId:         payment.UserID,
+		Amount:         payment.Amount,
+		CapturedAmount: payment.CapturedAmount,
+		RefundedAmount: payment.RefundedAmount,
+		Currency:       payment.Currency,
+		Status:         payment.Status,
+		FailureReason:  payment.FailureReason,
+		CreatedAt:      timestamppb.New(payment.CreatedAt),
+		UpdatedAt:      timestamppb.New(payment.UpdatedAt),
+	}
+}
+
+func toPBRefund(refund *domain.Refund) *eventspb.Refund {
+	return &eventspb.Refund{
+		Id:        refund.ID,
+		PaymentId: refund.PaymentID,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+		Status:    refund.Status,
+		CreatedAt: timestamppb.New(refund.CreatedAt),
+	}
+}
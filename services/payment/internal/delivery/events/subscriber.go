@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus"
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+)
+
+// Group is the payment service's consumer group.
+const Group = "payment-service"
+
+// Order statuses, mirroring the order service, that close an order.
+const (
+	orderStatusCancelled = "cancelled"
+	orderStatusExpired   = "expired"
+)
+
+type Subscriber struct {
+	paymentUsecase domain.PaymentUsecase
+}
+
+func NewSubscriber(paymentUsecase domain.PaymentUsecase) *Subscriber {
+	return &Subscriber{paymentUsecase: paymentUsecase}
+}
+
+// Register subscribes the payment service to the order events it reacts to.
+func (s *Subscriber) Register(ctx context.Context, bus eventbus.Bus, db *sql.DB) error {
+	return bus.Subscribe(ctx, Group, []string{eventbus.OrderUpdated}, eventbus.Dedupe(db, Group, s.Handle))
+}
+
+// Handle releases an order's payments when the order is cancelled or
+// expires: authorizations are voided and captures, which can only be late
+// ones, refunded.
+func (s *Subscriber) Handle(ctx context.Context, env *eventspb.Envelope) error {
+	msg, err := eventbus.Decode(env)
+	if err != nil {
+		return err
+	}
+
+	switch event := msg.(type) {
+	case *eventspb.OrderUpdated:
+		switch status := event.GetOrder().GetStatus(); status {
+		case orderStatusCancelled, orderStatusExpired:
+			return s.paymentUsecase.ReleaseOrderPayments(event.GetOrder().GetId(), "order "+status)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	pb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
+	"github.com/edwinjordan/golang_microservices/services/user/pkg/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// policies lists the methods that need a bearer access token in the
+// "authorization" metadata, which the REST proxy fills from the
+// Authorization header, and the roles allowed to call them.
+var policies = map[string][]string{
+	pb.PaymentService_GetLedgerBalances_FullMethodName: {auth.RoleFinance, auth.RoleAdmin},
+}
+
+// UnaryAuthInterceptor authenticates and authorizes calls to the methods in
+// policies against the user service.
+func UnaryAuthInterceptor(introspector *auth.Introspector) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		roles, ok := policies[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		principal, err := authenticate(ctx, introspector)
+		if err != nil {
+			return nil, err
+		}
+		if !principal.HasAnyRole(roles...) {
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authenticate(ctx context.Context, introspector *auth.Introspector) (*auth.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			continue
+		}
+		principal, err := introspector.Check(ctx, token)
+		if errors.Is(err, auth.ErrUnauthenticated) {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+		if err != nil {
+			log.Printf("Failed to check access token: %v", err)
+			return nil, status.Error(codes.Unavailable, "authentication unavailable")
+		}
+		return principal, nil
+	}
+	return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+}
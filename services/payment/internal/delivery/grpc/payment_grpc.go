@@ -2,46 +2,211 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
 	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
 	pb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type PaymentGRPCHandler struct {
 	pb.UnimplementedPaymentServiceServer
 	paymentUsecase domain.PaymentUsecase
+	ledgerUsecase  domain.LedgerUsecase
 }
 
-func NewPaymentGRPCHandler(paymentUsecase domain.PaymentUsecase) *PaymentGRPCHandler {
+func NewPaymentGRPCHandler(paymentUsecase domain.PaymentUsecase, ledgerUsecase domain.LedgerUsecase) *PaymentGRPCHandler {
 	return &PaymentGRPCHandler{
 		paymentUsecase: paymentUsecase,
+		ledgerUsecase:  ledgerUsecase,
 	}
 }
 
 func (h *PaymentGRPCHandler) ProcessPayment(ctx context.Context, req *pb.ProcessPaymentRequest) (*pb.ProcessPaymentResponse, error) {
-	payment, err := h.paymentUsecase.ProcessPayment(req.OrderId, req.Amount)
+	payment, err := h.paymentUsecase.ProcessPayment(req.OrderId, req.Amount, req.Currency)
 	if err != nil {
-		return nil, err
+		return nil, paymentError(err)
 	}
 
 	return &pb.ProcessPaymentResponse{
-		Id:      payment.ID,
-		OrderId: payment.OrderID,
-		Amount:  payment.Amount,
-		Status:  payment.Status,
+		Id:       payment.ID,
+		OrderId:  payment.OrderID,
+		Amount:   payment.Amount,
+		Status:   payment.Status,
+		Currency: payment.Currency,
 	}, nil
 }
 
 func (h *PaymentGRPCHandler) GetPayment(ctx context.Context, req *pb.GetPaymentRequest) (*pb.GetPaymentResponse, error) {
 	payment, err := h.paymentUsecase.GetPayment(req.Id)
 	if err != nil {
-		return nil, err
+		return nil, paymentError(err)
 	}
 
 	return &pb.GetPaymentResponse{
-		Id:      payment.ID,
-		OrderId: payment.OrderID,
-		Amount:  payment.Amount,
-		Status:  payment.Status,
+		Id:       payment.ID,
+		OrderId:  payment.OrderID,
+		Amount:   payment.Amount,
+		Status:   payment.Status,
+		Currency: payment.Currency,
 	}, nil
 }
+
+func (h *PaymentGRPCHandler) ListPayments(ctx context.Context, req *pb.ListPaymentsRequest) (*pb.ListPaymentsResponse, error) {
+	listReq := domain.ListPaymentsRequest{
+		OrderID:   req.OrderId,
+		UserID:    req.UserId,
+		Status:    req.Status,
+		OrderBy:   req.OrderBy,
+		PageSize:  int(req.PageSize),
+		PageToken: req.PageToken,
+	}
+	if req.CreatedAfter != nil {
+		t := req.CreatedAfter.AsTime()
+		listReq.CreatedAfter = &t
+	}
+	if req.CreatedBefore != nil {
+		t := req.CreatedBefore.AsTime()
+		listReq.CreatedBefore = &t
+	}
+
+	page, err := h.paymentUsecase.ListPayments(listReq)
+	if err != nil {
+		return nil, paymentError(err)
+	}
+
+	resp := &pb.ListPaymentsResponse{
+		Payments:      make([]*pb.Payment, 0, len(page.Payments)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, payment := range page.Payments {
+		resp.Payments = append(resp.Payments, toPBPayment(payment))
+	}
+	return resp, nil
+}
+
+func (h *PaymentGRPCHandler) AuthorizePayment(ctx context.Context, req *pb.AuthorizePaymentRequest) (*pb.Payment, error) {
+	payment, err := h.paymentUsecase.AuthorizePayment(req.OrderId, req.Amount, req.Currency, req.IdempotencyKey)
+	if err != nil {
+		return nil, paymentError(err)
+	}
+
+	return toPBPayment(payment), nil
+}
+
+func (h *PaymentGRPCHandler) CapturePayment(ctx context.Context, req *pb.CapturePaymentRequest) (*pb.Payment, error) {
+	payment, err := h.paymentUsecase.CapturePayment(req.Id, req.Amount)
+	if err != nil {
+		return nil, paymentError(err)
+	}
+
+	return toPBPayment(payment), nil
+}
+
+func (h *PaymentGRPCHandler) VoidPayment(ctx context.Context, req *pb.VoidPaymentRequest) (*pb.Payment, error) {
+	payment, err := h.paymentUsecase.VoidPayment(req.Id)
+	if err != nil {
+		return nil, paymentError(err)
+	}
+
+	return toPBPayment(payment), nil
+}
+
+func (h *PaymentGRPCHandler) RefundPayment(ctx context.Context, req *pb.RefundPaymentRequest) (*pb.Refund, error) {
+	refund, err := h.paymentUsecase.RefundPayment(req.PaymentId, req.Amount, req.Reason)
+	if err != nil {
+		return nil, paymentError(err)
+	}
+
+	return toPBRefund(refund), nil
+}
+
+func (h *PaymentGRPCHandler) ListRefunds(ctx context.Context, req *pb.ListRefundsRequest) (*pb.ListRefundsResponse, error) {
+	refunds, err := h.paymentUsecase.ListRefunds(req.PaymentId)
+	if err != nil {
+		return nil, paymentError(err)
+	}
+
+	resp := &pb.ListRefundsResponse{Refunds: make([]*pb.Refund, 0, len(refunds))}
+	for _, refund := range refunds {
+		resp.Refunds = append(resp.Refunds, toPBRefund(refund))
+	}
+	return resp, nil
+}
+
+func (h *PaymentGRPCHandler) GetLedgerBalances(ctx context.Context, req *pb.GetLedgerBalancesRequest) (*pb.GetLedgerBalancesResponse, error) {
+	balances, err := h.ledgerUsecase.Balances(domain.LedgerBalanceFilter{
+		OrderID: req.OrderId,
+		UserID:  req.UserId,
+	})
+	if err != nil {
+		return nil, paymentError(err)
+	}
+
+	resp := &pb.GetLedgerBalancesResponse{Balances: make([]*pb.AccountBalance, 0, len(balances))}
+	for _, balance := range balances {
+		resp.Balances = append(resp.Balances, &pb.AccountBalance{
+			Account:  balance.Account,
+			Debits:   balance.Debits,
+			Credits:  balance.Credits,
+			Balance:  balance.Balance,
+			Currency: balance.Currency,
+		})
+	}
+	return resp, nil
+}
+
+func toPBPayment(payment *domain.Payment) *pb.Payment {
+	return &pb.Payment{
+		Id:               payment.ID,
+		OrderId:          payment.OrderID,
+		UserId:           payment.UserID,
+		Amount:           payment.Amount,
+		Status:           payment.Status,
+		CreatedAt:        timestamppb.New(payment.CreatedAt),
+		UpdatedAt:        timestamppb.New(payment.UpdatedAt),
+		CapturedAmount:   payment.CapturedAmount,
+		GatewayReference: payment.GatewayReference,
+		FailureReason:    payment.FailureReason,
+		RefundedAmount:   payment.RefundedAmount,
+		Currency:         payment.Currency,
+		OrderAmount:      payment.OrderAmount,
+		OrderCurrency:    payment.OrderCurrency,
+		ExchangeRate:     payment.ExchangeRate,
+		BaseRate:         payment.BaseRate,
+	}
+}
+
+func toPBRefund(refund *domain.Refund) *pb.Refund {
+	return &pb.Refund{
+		Id:               refund.ID,
+		PaymentId:        refund.PaymentID,
+		Amount:           refund.Amount,
+		Reason:           refund.Reason,
+		Status:           refund.Status,
+		GatewayReference: refund.GatewayReference,
+		FailureReason:    refund.FailureReason,
+		CreatedAt:        timestamppb.New(refund.CreatedAt),
+		UpdatedAt:        timestamppb.New(refund.UpdatedAt),
+	}
+}
+
+func paymentError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrPaymentNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrOrderNotPayable), errors.Is(err, domain.ErrInvalidPaymentState),
+		errors.Is(err, domain.ErrRefundExceedsCaptured), errors.Is(err, domain.ErrPaymentDeclined):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrUnsupportedCurrency), errors.Is(err, domain.ErrAmountMismatch),
+		errors.Is(err, domain.ErrIdempotencyKeyReused):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrPaymentInProgress), errors.Is(err, domain.ErrPaymentConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, domain.ErrOrderStatusUnknown), errors.Is(err, domain.ErrGatewayUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/fx"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerHandler struct {
+	ledgerUsecase domain.LedgerUsecase
+}
+
+func NewLedgerHandler(ledgerUsecase domain.LedgerUsecase) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerUsecase: ledgerUsecase,
+	}
+}
+
+type LedgerBalancesQuery struct {
+	OrderID string `form:"order_id"`
+	UserID  string `form:"user_id"`
+}
+
+// Ledger amounts are returned in major units of the base currency; the
+// ledger itself stores cents.
+type AccountBalanceResponse struct {
+	Account  string  `json:"account"`
+	Currency string  `json:"currency"`
+	Debits   float64 `json:"debits"`
+	Credits  float64 `json:"credits"`
+	Balance  float64 `json:"balance"`
+}
+
+type LedgerBalancesResponse struct {
+	Balances []AccountBalanceResponse `json:"balances"`
+}
+
+// PostingResponse gives the amount in the base currency and, as
+// original_amount, in the entry's currency.
+type PostingResponse struct {
+	Account        string  `json:"account"`
+	Amount         float64 `json:"amount"`
+	OriginalAmount float64 `json:"original_amount"`
+}
+
+type JournalEntryResponse struct {
+	ID           string            `json:"id"`
+	Kind         string            `json:"kind"`
+	Description  string            `json:"description"`
+	Currency     string            `json:"currency"`
+	ExchangeRate float64           `json:"exchange_rate"`
+	Postings     []PostingResponse `json:"postings"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+type ListEntriesResponse struct {
+	Entries []JournalEntryResponse `json:"entries"`
+}
+
+func (h *LedgerHandler) Balances(c *gin.Context) {
+	var query LedgerBalancesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	balances, err := h.ledgerUsecase.Balances(domain.LedgerBalanceFilter{
+		OrderID: query.OrderID,
+		UserID:  query.UserID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := LedgerBalancesResponse{Balances: make([]AccountBalanceResponse, 0, len(balances))}
+	for _, balance := range balances {
+		resp.Balances = append(resp.Balances, AccountBalanceResponse{
+			Account:  balance.Account,
+			Currency: balance.Currency,
+			Debits:   fx.FromMinor(balance.Debits, balance.Currency),
+			Credits:  fx.FromMinor(balance.Credits, balance.Currency),
+			Balance:  fx.FromMinor(balance.Balance, balance.Currency),
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *LedgerHandler) ListEntries(c *gin.Context) {
+	entries, err := h.ledgerUsecase.ListEntries(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	baseCurrency := h.ledgerUsecase.BaseCurrency()
+	resp := ListEntriesResponse{Entries: make([]JournalEntryResponse, 0, len(entries))}
+	for _, entry := range entries {
+		item := JournalEntryResponse{
+			ID:           entry.ID,
+			Kind:         entry.Kind,
+			Description:  entry.Description,
+			Currency:     entry.Currency,
+			ExchangeRate: entry.ExchangeRate,
+			Postings:     make([]PostingResponse, 0, len(entry.Postings)),
+			CreatedAt:    entry.CreatedAt,
+		}
+		for _, posting := range entry.Postings {
+			item.Postings = append(item.Postings, PostingResponse{
+				Account:        posting.Account,
+				Amount:         fx.FromMinor(posting.Amount, baseCurrency),
+				OriginalAmount: fx.FromMinor(posting.OriginalAmount, entry.Currency),
+			})
+		}
+		resp.Entries = append(resp.Entries, item)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/edwinjordan/golang_microservices/services/user/pkg/auth"
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// RequireAuth rejects requests without a valid, unrevoked bearer token and
+// stores the resolved principal on the context.
+func RequireAuth(introspector *auth.Introspector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		principal, err := introspector.Check(c.Request.Context(), token)
+		if errors.Is(err, auth.ErrUnauthenticated) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to check access token: %v", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "authentication unavailable"})
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// RequireRole lets through callers holding any of roles. It must run after
+// RequireAuth.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := c.MustGet(principalKey).(*auth.Principal)
+		if !principal.HasAnyRole(roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+			return
+		}
+		c.Next()
+	}
+}
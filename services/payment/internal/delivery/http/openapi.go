@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	pb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
+)
+
+// API documents the payment service's HTTP routes. The service will not
+// start if a route is missing from it.
+func API() openapi.API {
+	return openapi.API{
+		Title:       "Payment Service",
+		Version:     "1.0",
+		Description: "Payments, refunds, the double-entry ledger and provider webhooks.",
+		Operations: []openapi.Operation{
+			{Method: http.MethodGet, Path: "/health", Tag: "health", Summary: "Health check", Response: openapi.HealthResponse{}},
+
+			// A declined or failed payment is returned with 402 instead of 201.
+			{Method: http.MethodPost, Path: "/payments", Tag: "payments", Summary: "Charge an order", Body: ProcessPaymentRequest{}, Rules: &pb.ProcessPaymentRequest{}, Status: http.StatusCreated, Response: PaymentResponse{}},
+			{Method: http.MethodGet, Path: "/payments", Tag: "payments", Summary: "List payments", Query: ListPaymentsQuery{}, Response: ListPaymentsResponse{}},
+			{Method: http.MethodPost, Path: "/payments/authorize", Tag: "payments", Summary: "Authorize an order's payment without capturing it", Body: AuthorizePaymentRequest{}, Rules: &pb.AuthorizePaymentRequest{}, Status: http.StatusCreated, Response: PaymentResponse{}},
+			{Method: http.MethodPost, Path: "/payments/:id/capture", Tag: "payments", Summary: "Capture an authorized payment, in full unless amount is set", Body: CapturePaymentRequest{}, BodyOptional: true, Rules: &pb.CapturePaymentRequest{}, Response: PaymentResponse{}},
+			{Method: http.MethodPost, Path: "/payments/:id/void", Tag: "payments", Summary: "Void an authorized payment", Response: PaymentResponse{}},
+			{Method: http.MethodPost, Path: "/payments/:id/refunds", Tag: "payments", Summary: "Refund a payment, in full unless amount is set", Body: RefundPaymentRequest{}, BodyOptional: true, Rules: &pb.RefundPaymentRequest{}, Status: http.StatusCreated, Response: RefundResponse{}},
+			{Method: http.MethodGet, Path: "/payments/:id/refunds", Tag: "payments", Summary: "List a payment's refunds", Response: ListRefundsResponse{}},
+			{Method: http.MethodGet, Path: "/orders/:id/payments", Tag: "payments", Summary: "List an order's payments", Query: ListPaymentsQuery{}, Response: ListPaymentsResponse{}},
+			{Method: http.MethodGet, Path: "/payments/:id", Tag: "payments", Summary: "Get a payment", Response: PaymentResponse{}},
+
+			{Method: http.MethodGet, Path: "/payments/:id/ledger", Tag: "ledger", Summary: "List a payment's journal entries (finance and admins only)", Response: ListEntriesResponse{}, Auth: true},
+			{Method: http.MethodGet, Path: "/ledger/balances", Tag: "ledger", Summary: "Get ledger account balances (finance and admins only)", Query: LedgerBalancesQuery{}, Response: LedgerBalancesResponse{}, Auth: true},
+
+			// The body is the provider's own payload, verified against the
+			// signature header.
+			{Method: http.MethodPost, Path: "/webhooks/:provider", Tag: "webhooks", Summary: "Receive a payment provider webhook", Body: map[string]any{}, Response: WebhookResponse{}},
+		},
+	}
+}
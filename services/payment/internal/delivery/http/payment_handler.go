@@ -1,9 +1,13 @@
 package http
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
 	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/payment/pkg/pb"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,37 +21,180 @@ func NewPaymentHandler(paymentUsecase domain.PaymentUsecase) *PaymentHandler {
 	}
 }
 
+// ProcessPaymentRequest takes the amount due in the order's currency and
+// charges it in currency, which defaults to the order's currency.
 type ProcessPaymentRequest struct {
-	OrderID string  `json:"order_id" binding:"required"`
-	Amount  float64 `json:"amount" binding:"required"`
+	OrderID  string  `json:"order_id"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+type AuthorizePaymentRequest struct {
+	OrderID  string  `json:"order_id"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	// IdempotencyKey makes retries return the payment the first request
+	// created.
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+type CapturePaymentRequest struct {
+	Amount float64 `json:"amount"`
+}
+
+type RefundPaymentRequest struct {
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
 }
 
 type PaymentResponse struct {
-	ID      string  `json:"id"`
-	OrderID string  `json:"order_id"`
-	Amount  float64 `json:"amount"`
-	Status  string  `json:"status"`
+	ID             string     `json:"id"`
+	OrderID        string     `json:"order_id"`
+	UserID         string     `json:"user_id"`
+	Amount         float64    `json:"amount"`
+	Currency       string     `json:"currency"`
+	OrderAmount    float64    `json:"order_amount"`
+	OrderCurrency  string     `json:"order_currency"`
+	ExchangeRate   float64    `json:"exchange_rate"`
+	CapturedAmount float64    `json:"captured_amount"`
+	RefundedAmount float64    `json:"refunded_amount"`
+	Status         string     `json:"status"`
+	FailureReason  string     `json:"failure_reason,omitempty"`
+	SettledAt      *time.Time `json:"settled_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type RefundResponse struct {
+	ID            string    `json:"id"`
+	PaymentID     string    `json:"payment_id"`
+	Amount        float64   `json:"amount"`
+	Reason        string    `json:"reason,omitempty"`
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ListRefundsResponse struct {
+	Refunds []RefundResponse `json:"refunds"`
+}
+
+type ListPaymentsQuery struct {
+	OrderID       string     `form:"order_id"`
+	UserID        string     `form:"user_id"`
+	Status        string     `form:"status"`
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	OrderBy       string     `form:"order_by"`
+	PageSize      int        `form:"page_size"`
+	PageToken     string     `form:"page_token"`
+}
+
+type ListPaymentsResponse struct {
+	Payments      []PaymentResponse `json:"payments"`
+	NextPageToken string            `json:"next_page_token,omitempty"`
 }
 
 func (h *PaymentHandler) ProcessPayment(c *gin.Context) {
 	var req ProcessPaymentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := validate.BindJSON(c, &req, &pb.ProcessPaymentRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	payment, err := h.paymentUsecase.ProcessPayment(req.OrderID, req.Amount, req.Currency)
+	if err != nil {
+		c.JSON(createStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(createdStatus(payment), toPaymentResponse(payment))
+}
+
+func (h *PaymentHandler) AuthorizePayment(c *gin.Context) {
+	var req AuthorizePaymentRequest
+	if err := validate.BindJSON(c, &req, &pb.AuthorizePaymentRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	payment, err := h.paymentUsecase.AuthorizePayment(req.OrderID, req.Amount, req.Currency, req.IdempotencyKey)
+	if err != nil {
+		c.JSON(createStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(createdStatus(payment), toPaymentResponse(payment))
+}
+
+func (h *PaymentHandler) CapturePayment(c *gin.Context) {
+	var req CapturePaymentRequest
+	msg := &pb.CapturePaymentRequest{Id: c.Param("id")}
+	var err error
+	if c.Request.ContentLength > 0 {
+		err = validate.BindJSON(c, &req, msg)
+	} else {
+		err = validate.Validate(msg)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	payment, err := h.paymentUsecase.CapturePayment(c.Param("id"), req.Amount)
+	if err != nil {
+		c.JSON(transitionStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toPaymentResponse(payment))
+}
+
+func (h *PaymentHandler) VoidPayment(c *gin.Context) {
+	payment, err := h.paymentUsecase.VoidPayment(c.Param("id"))
+	if err != nil {
+		c.JSON(transitionStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toPaymentResponse(payment))
+}
+
+func (h *PaymentHandler) RefundPayment(c *gin.Context) {
+	var req RefundPaymentRequest
+	msg := &pb.RefundPaymentRequest{PaymentId: c.Param("id")}
+	var err error
+	if c.Request.ContentLength > 0 {
+		err = validate.BindJSON(c, &req, msg)
+	} else {
+		err = validate.Validate(msg)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
 		return
 	}
 
-	payment, err := h.paymentUsecase.ProcessPayment(req.OrderID, req.Amount)
+	refund, err := h.paymentUsecase.RefundPayment(c.Param("id"), req.Amount, req.Reason)
+	if err != nil {
+		c.JSON(transitionStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toRefundResponse(refund))
+}
+
+func (h *PaymentHandler) ListRefunds(c *gin.Context) {
+	refunds, err := h.paymentUsecase.ListRefunds(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, PaymentResponse{
-		ID:      payment.ID,
-		OrderID: payment.OrderID,
-		Amount:  payment.Amount,
-		Status:  payment.Status,
-	})
+	resp := ListRefundsResponse{Refunds: make([]RefundResponse, 0, len(refunds))}
+	for _, refund := range refunds {
+		resp.Refunds = append(resp.Refunds, toRefundResponse(refund))
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *PaymentHandler) GetPayment(c *gin.Context) {
@@ -59,12 +206,57 @@ func (h *PaymentHandler) GetPayment(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, PaymentResponse{
-		ID:      payment.ID,
-		OrderID: payment.OrderID,
-		Amount:  payment.Amount,
-		Status:  payment.Status,
+	c.JSON(http.StatusOK, toPaymentResponse(payment))
+}
+
+func (h *PaymentHandler) ListPayments(c *gin.Context) {
+	var query ListPaymentsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.listPayments(c, query)
+}
+
+// ListOrderPayments is GET /orders/:id/payments, a convenience view of
+// ListPayments scoped to a single order.
+func (h *PaymentHandler) ListOrderPayments(c *gin.Context) {
+	var query ListPaymentsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.OrderID = c.Param("id")
+
+	h.listPayments(c, query)
+}
+
+func (h *PaymentHandler) listPayments(c *gin.Context, query ListPaymentsQuery) {
+	page, err := h.paymentUsecase.ListPayments(domain.ListPaymentsRequest{
+		OrderID:       query.OrderID,
+		UserID:        query.UserID,
+		Status:        query.Status,
+		CreatedAfter:  query.CreatedAfter,
+		CreatedBefore: query.CreatedBefore,
+		OrderBy:       query.OrderBy,
+		PageSize:      query.PageSize,
+		PageToken:     query.PageToken,
 	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := ListPaymentsResponse{
+		Payments:      make([]PaymentResponse, 0, len(page.Payments)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, payment := range page.Payments {
+		resp.Payments = append(resp.Payments, toPaymentResponse(payment))
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *PaymentHandler) Health(c *gin.Context) {
@@ -73,3 +265,72 @@ func (h *PaymentHandler) Health(c *gin.Context) {
 		"service": "payment-service",
 	})
 }
+
+func toPaymentResponse(payment *domain.Payment) PaymentResponse {
+	return PaymentResponse{
+		ID:             payment.ID,
+		OrderID:        payment.OrderID,
+		UserID:         payment.UserID,
+		Amount:         payment.Amount,
+		Currency:       payment.Currency,
+		OrderAmount:    payment.OrderAmount,
+		OrderCurrency:  payment.OrderCurrency,
+		ExchangeRate:   payment.ExchangeRate,
+		CapturedAmount: payment.CapturedAmount,
+		RefundedAmount: payment.RefundedAmount,
+		Status:         payment.Status,
+		FailureReason:  payment.FailureReason,
+		SettledAt:      payment.SettledAt,
+		CreatedAt:      payment.CreatedAt,
+	}
+}
+
+func toRefundResponse(refund *domain.Refund) RefundResponse {
+	return RefundResponse{
+		ID:            refund.ID,
+		PaymentID:     refund.PaymentID,
+		Amount:        refund.Amount,
+		Reason:        refund.Reason,
+		Status:        refund.Status,
+		FailureReason: refund.FailureReason,
+		CreatedAt:     refund.CreatedAt,
+	}
+}
+
+// createdStatus reports declined or failed gateway outcomes as 402 while
+// still returning the recorded payment.
+func createdStatus(payment *domain.Payment) int {
+	switch payment.Status {
+	case domain.PaymentStatusDeclined, domain.PaymentStatusFailed:
+		return http.StatusPaymentRequired
+	default:
+		return http.StatusCreated
+	}
+}
+
+func createStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrOrderNotPayable), errors.Is(err, domain.ErrPaymentInProgress),
+		errors.Is(err, domain.ErrPaymentConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrUnsupportedCurrency), errors.Is(err, domain.ErrAmountMismatch),
+		errors.Is(err, domain.ErrIdempotencyKeyReused):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func transitionStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPaymentState), errors.Is(err, domain.ErrRefundExceedsCaptured),
+		errors.Is(err, domain.ErrOrderNotPayable), errors.Is(err, domain.ErrPaymentConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrPaymentDeclined):
+		return http.StatusPaymentRequired
+	case errors.Is(err, domain.ErrOrderStatusUnknown), errors.Is(err, domain.ErrGatewayUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
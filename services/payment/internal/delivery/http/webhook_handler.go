@@ -0,0 +1,56 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/webhook"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookUsecase domain.WebhookUsecase
+}
+
+func NewWebhookHandler(webhookUsecase domain.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUsecase: webhookUsecase,
+	}
+}
+
+type WebhookResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// Receive is POST /webhooks/:provider. The body must be read raw: the
+// signature covers the exact bytes the provider sent.
+func (h *WebhookHandler) Receive(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.webhookUsecase.HandleWebhook(c.Param("provider"), payload, c.GetHeader(webhook.SignatureHeader))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, WebhookResponse{ID: event.ID, Status: event.Status})
+	case errors.Is(err, domain.ErrDuplicateWebhook):
+		c.JSON(http.StatusOK, WebhookResponse{ID: event.ID, Status: "duplicate"})
+	case errors.Is(err, domain.ErrWebhookInProgress):
+		// Another delivery is applying the event; if it fails, this one
+		// must not have been acknowledged.
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrUnknownWebhookProvider):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidWebhookSignature):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrMalformedWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		// The event is stored; a 5xx asks the provider to redeliver it.
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "context"
+
+// GatewayResult is the outcome of a gateway call that reached the provider.
+// A decline is a normal result (Approved false); transport or provider
+// errors are returned as errors instead.
+type GatewayResult struct {
+	Reference   string
+	Approved    bool
+	DeclineCode string
+	Message     string
+	// Fee is what the gateway charges for the call, if anything.
+	Fee float64
+}
+
+type AuthorizeRequest struct {
+	PaymentID string
+	OrderID   string
+	Amount    float64
+	Currency  string
+}
+
+// PaymentGateway is the card processor the payment service charges through.
+type PaymentGateway interface {
+	Authorize(ctx context.Context, req AuthorizeRequest) (*GatewayResult, error)
+	Capture(ctx context.Context, reference string, amount float64) (*GatewayResult, error)
+	Void(ctx context.Context, reference string) (*GatewayResult, error)
+	Refund(ctx context.Context, reference string, amount float64) (*GatewayResult, error)
+}
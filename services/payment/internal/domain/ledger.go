@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/fx"
+)
+
+// Ledger accounts. Postings are signed in minor units of the base currency:
+// debits are positive and credits negative, so every journal entry, and the
+// ledger as a whole, sums to zero.
+const (
+	// AccountGatewayHolds (asset) holds authorized but uncaptured funds.
+	AccountGatewayHolds = "gateway_holds"
+	// AccountCustomerAuthorizations (liability) offsets gateway holds.
+	AccountCustomerAuthorizations = "customer_authorizations"
+	// AccountGatewayReceivable (asset) is captured money owed by the gateway.
+	AccountGatewayReceivable = "gateway_receivable"
+	// AccountSales (revenue) is captured order revenue.
+	AccountSales = "sales"
+	// AccountRefunds (contra-revenue) is money returned to customers.
+	AccountRefunds = "refunds"
+	// AccountGatewayFees (expense) is what the gateway charges us.
+	AccountGatewayFees = "gateway_fees"
+	// AccountChargebacks (expense) is money clawed back by card disputes.
+	AccountChargebacks = "chargebacks"
+)
+
+const (
+	EntryKindAuthorization = "authorization"
+	EntryKindCapture       = "capture"
+	EntryKindVoid          = "void"
+	EntryKindRefund        = "refund"
+	EntryKindChargeback    = "chargeback"
+)
+
+var ErrUnbalancedEntry = errors.New("journal entry does not balance")
+
+// JournalEntry is one immutable ledger transaction. IdempotencyKey makes
+// re-posting the same money movement a no-op. Currency is the payment's
+// currency and ExchangeRate the rate its postings were converted to the base
+// currency at.
+type JournalEntry struct {
+	ID             string     `json:"id"`
+	IdempotencyKey string     `json:"idempotency_key"`
+	Kind           string     `json:"kind"`
+	PaymentID      string     `json:"payment_id"`
+	OrderID        string     `json:"order_id"`
+	UserID         string     `json:"user_id"`
+	Description    string     `json:"description"`
+	Currency       string     `json:"currency"`
+	ExchangeRate   float64    `json:"exchange_rate"`
+	Postings       []*Posting `json:"postings"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type Posting struct {
+	ID      string `json:"id"`
+	EntryID string `json:"entry_id"`
+	Account string `json:"account"`
+	// Amount is in minor units of the base currency; positive is a debit,
+	// negative a credit.
+	Amount int64 `json:"amount"`
+	// OriginalAmount is the same posting in minor units of the entry's
+	// currency.
+	OriginalAmount int64 `json:"original_amount"`
+}
+
+// Balanced reports whether the entry's postings sum to zero.
+func (e *JournalEntry) Balanced() bool {
+	var sum int64
+	for _, p := range e.Postings {
+		sum += p.Amount
+	}
+	return len(e.Postings) >= 2 && sum == 0
+}
+
+type AccountBalance struct {
+	Account  string `json:"account"`
+	Currency string `json:"currency"`
+	Debits   int64  `json:"debits"`
+	Credits  int64  `json:"credits"`
+	Balance  int64  `json:"balance"`
+}
+
+type LedgerBalanceFilter struct {
+	OrderID string
+	UserID  string
+}
+
+// LedgerCheck is the result of verifying the ledger's invariants.
+type LedgerCheck struct {
+	Entries int64 `json:"entries"`
+	Total   int64 `json:"total"`
+	// UnbalancedEntries lists entries whose postings do not sum to zero.
+	UnbalancedEntries []string `json:"unbalanced_entries"`
+	// PaymentMismatches lists payments whose captured or refunded amounts
+	// disagree with the original amounts of their ledger postings.
+	PaymentMismatches []string `json:"payment_mismatches"`
+}
+
+func (c *LedgerCheck) OK() bool {
+	return c.Total == 0 && len(c.UnbalancedEntries) == 0 && len(c.PaymentMismatches) == 0
+}
+
+type LedgerRepository interface {
+	ListEntries(paymentID string) ([]*JournalEntry, error)
+	Balances(filter LedgerBalanceFilter) ([]*AccountBalance, error)
+	Check() (*LedgerCheck, error)
+}
+
+type LedgerUsecase interface {
+	// BaseCurrency is the currency postings and balances are reported in.
+	BaseCurrency() string
+	// The Entry methods build the journal entry for a money movement. The
+	// payment and refund repositories post it in the same transaction as
+	// the payment change it records.
+	AuthorizationEntry(payment *Payment) (*JournalEntry, error)
+	CaptureEntry(payment *Payment, fee float64) (*JournalEntry, error)
+	VoidEntry(payment *Payment) (*JournalEntry, error)
+	RefundEntry(payment *Payment, refund *Refund) (*JournalEntry, error)
+	ChargebackEntry(payment *Payment, amount float64) (*JournalEntry, error)
+	ListEntries(paymentID string) ([]*JournalEntry, error)
+	Balances(filter LedgerBalanceFilter) ([]*AccountBalance, error)
+	Check() (*LedgerCheck, error)
+}
+
+// PaymentPostings sets a payment's captured and refunded amounts next to
+// the sales and refunds its ledger postings record, in minor units of the
+// payment's currency.
+type PaymentPostings struct {
+	PaymentID      string
+	Currency       string
+	CapturedAmount float64
+	RefundedAmount float64
+	Sales          int64
+	Refunds        int64
+}
+
+// Matches reports whether the payment agrees with its postings.
+func (p PaymentPostings) Matches() bool {
+	return fx.ToMinor(p.CapturedAmount, p.Currency) == p.Sales && fx.ToMinor(p.RefundedAmount, p.Currency) == p.Refunds
+}
@@ -0,0 +1,89 @@
+package domain
+
+import "testing"
+
+func TestJournalEntryBalanced(t *testing.T) {
+	tests := []struct {
+		name     string
+		postings []*Posting
+		want     bool
+	}{
+		{"debit and credit", []*Posting{
+			{Account: AccountGatewayHolds, Amount: 100},
+			{Account: AccountCustomerAuthorizations, Amount: -100},
+		}, true},
+		{"split credit", []*Posting{
+			{Account: AccountGatewayReceivable, Amount: 100},
+			{Account: AccountSales, Amount: -70},
+			{Account: AccountSales, Amount: -30},
+		}, true},
+		{"off by one", []*Posting{
+			{Account: AccountGatewayHolds, Amount: 100},
+			{Account: AccountCustomerAuthorizations, Amount: -99},
+		}, false},
+		{"debits only", []*Posting{
+			{Account: AccountGatewayHolds, Amount: 100},
+			{Account: AccountGatewayReceivable, Amount: 100},
+		}, false},
+		{"single posting", []*Posting{{Account: AccountGatewayHolds, Amount: 0}}, false},
+		{"no postings", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &JournalEntry{Postings: tt.postings}
+			if got := entry.Balanced(); got != tt.want {
+				t.Errorf("Balanced() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPaymentPostingsMatches covers the comparison Check makes between a
+// payment and the sales and refunds it posted, in the payment's minor units.
+func TestPaymentPostingsMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		postings PaymentPostings
+		want     bool
+	}{
+		{"captured and refunded", PaymentPostings{Currency: "USD", CapturedAmount: 100, RefundedAmount: 30.5, Sales: 10000, Refunds: 3050}, true},
+		{"authorized only", PaymentPostings{Currency: "USD"}, true},
+		{"zero decimal currency", PaymentPostings{Currency: "JPY", CapturedAmount: 12345, Sales: 12345}, true},
+		{"three decimal currency", PaymentPostings{Currency: "KWD", CapturedAmount: 10.125, Sales: 10125}, true},
+		{"capture not posted", PaymentPostings{Currency: "USD", CapturedAmount: 100}, false},
+		{"sales without capture", PaymentPostings{Currency: "USD", Sales: 10000}, false},
+		{"refund not posted", PaymentPostings{Currency: "USD", CapturedAmount: 100, RefundedAmount: 30, Sales: 10000}, false},
+		{"refund posted twice", PaymentPostings{Currency: "USD", CapturedAmount: 100, RefundedAmount: 30, Sales: 10000, Refunds: 6000}, false},
+		{"compared in the wrong minor unit", PaymentPostings{Currency: "JPY", CapturedAmount: 12345, Sales: 1234500}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.postings.Matches(); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLedgerCheckOK(t *testing.T) {
+	tests := []struct {
+		name  string
+		check LedgerCheck
+		want  bool
+	}{
+		{"clean", LedgerCheck{Entries: 3}, true},
+		{"ledger does not sum to zero", LedgerCheck{Entries: 3, Total: 1}, false},
+		{"unbalanced entry", LedgerCheck{Entries: 3, UnbalancedEntries: []string{"entry_1"}}, false},
+		{"payment mismatch", LedgerCheck{Entries: 3, PaymentMismatches: []string{"pay_1"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.check.OK(); got != tt.want {
+				t.Errorf("OK() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
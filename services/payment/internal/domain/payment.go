@@ -1,23 +1,149 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
+)
+
+// Payment statuses. A payment starts pending, is authorized (or declined /
+// failed) by the gateway, and is then either captured or voided.
+const (
+	PaymentStatusPending    = "pending"
+	PaymentStatusAuthorized = "authorized"
+	PaymentStatusCaptured   = "captured"
+	PaymentStatusVoided     = "voided"
+	PaymentStatusDeclined   = "declined"
+	PaymentStatusFailed     = "failed"
+
+	PaymentStatusPartiallyRefunded = "partially_refunded"
+	PaymentStatusRefunded          = "refunded"
+	PaymentStatusChargedBack       = "charged_back"
+)
+
+// Domain events written to the outbox by PaymentRepository and
+// RefundRepository.
+const (
+	AggregatePayment     = "payment"
+	EventPaymentCreated  = "payment.created"
+	EventPaymentUpdated  = "payment.updated"
+	EventPaymentRefunded = "payment.refunded"
+)
+
+var (
+	ErrPaymentNotFound     = errors.New("payment not found")
+	ErrPaymentDeclined     = errors.New("payment declined")
+	ErrInvalidPaymentState = errors.New("payment is not in a valid state for this operation")
+	// ErrOrderNotPayable is returned for payments against orders that are
+	// paid, cancelled or expired.
+	ErrOrderNotPayable = errors.New("order is not awaiting payment")
+	// ErrUnsupportedCurrency is returned for payments in a currency, or
+	// against an order in a currency, that the rate provider cannot quote.
+	ErrUnsupportedCurrency = errors.New("currency not supported")
+	// ErrAmountMismatch is returned for payments whose amount is not the
+	// order's total.
+	ErrAmountMismatch = errors.New("amount does not match the order total")
+	// ErrPaymentInProgress is returned when a request repeats the
+	// idempotency key of a payment that is still being authorized.
+	ErrPaymentInProgress = errors.New("a payment with this idempotency key is in progress")
+	// ErrIdempotencyKeyReused is returned when an idempotency key is
+	// repeated for a different order.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used for another order")
+	// ErrOrderStatusUnknown is returned for captures when the order service
+	// cannot say whether the order is still open.
+	ErrOrderStatusUnknown = errors.New("order status could not be checked")
+	// ErrPaymentConflict is returned when a payment changed status while it
+	// was being updated.
+	ErrPaymentConflict = errors.New("payment was changed by a concurrent request")
+	// ErrGatewayUnavailable wraps transport and provider errors from the
+	// gateway: the call may succeed if it is tried again.
+	ErrGatewayUnavailable = errors.New("payment gateway unavailable")
+)
+
+// Payment is a charge against an order. Amount, CapturedAmount and
+// RefundedAmount are in Currency, the currency the customer is charged in;
+// OrderAmount is what was due in the order's currency and ExchangeRate what
+// it was converted at. BaseRate converts Currency to the ledger's base
+// currency and is fixed when the payment is created.
 type Payment struct {
-	ID        string    `json:"id" db:"id"`
-	OrderID   string    `json:"order_id" db:"order_id"`
-	Amount    float64   `json:"amount" db:"amount"`
-	Status    string    `json:"status" db:"status"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID               string  `json:"id" db:"id"`
+	OrderID          string  `json:"order_id" db:"order_id"`
+	UserID           string  `json:"user_id" db:"user_id"`
+	Amount           float64 `json:"amount" db:"amount"`
+	Currency         string  `json:"currency" db:"currency"`
+	OrderAmount      float64 `json:"order_amount" db:"order_amount"`
+	OrderCurrency    string  `json:"order_currency" db:"order_currency"`
+	ExchangeRate     float64 `json:"exchange_rate" db:"exchange_rate"`
+	BaseRate         float64 `json:"base_rate" db:"base_rate"`
+	CapturedAmount   float64 `json:"captured_amount" db:"captured_amount"`
+	RefundedAmount   float64 `json:"refunded_amount" db:"refunded_amount"`
+	Status           string  `json:"status" db:"status"`
+	GatewayReference string  `json:"gateway_reference" db:"gateway_reference"`
+	FailureReason    string  `json:"failure_reason,omitempty" db:"failure_reason"`
+	// IdempotencyKey is set by callers that may retry an authorization; a
+	// repeated key returns the payment created for it.
+	IdempotencyKey string `json:"-" db:"idempotency_key"`
+	// SettledAt is set when the gateway reports the captured funds as paid out.
+	SettledAt *time.Time `json:"settled_at,omitempty" db:"settled_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type PaymentFilter struct {
+	OrderID       string
+	UserID        string
+	Status        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Page          pagination.Page
+}
+
+type ListPaymentsRequest struct {
+	OrderID       string
+	UserID        string
+	Status        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	OrderBy       string
+	PageSize      int
+	PageToken     string
+}
+
+type PaymentPage struct {
+	Payments      []*Payment `json:"payments"`
+	NextPageToken string     `json:"next_page_token"`
 }
 
 type PaymentRepository interface {
 	Create(payment *Payment) error
 	GetByID(id string) (*Payment, error)
-	Update(payment *Payment) error
+	GetByGatewayReference(reference string) (*Payment, error)
+	GetByIdempotencyKey(key string) (*Payment, error)
+	// Update saves the payment and posts entries to the ledger in the same
+	// transaction, provided its stored status is still from; otherwise it
+	// fails with ErrPaymentConflict and writes nothing. An entry whose
+	// idempotency key was already posted is skipped.
+	Update(payment *Payment, from string, entries ...*JournalEntry) error
+	List(filter PaymentFilter) ([]*Payment, error)
 }
 
 type PaymentUsecase interface {
-	ProcessPayment(orderID string, amount float64) (*Payment, error)
+	// ProcessPayment and AuthorizePayment take the amount due in the order's
+	// currency and charge it in currency, converted at the current rate. An
+	// empty currency charges in the order's currency.
+	ProcessPayment(orderID string, amount float64, currency string) (*Payment, error)
+	// AuthorizePayment with a non-empty idempotencyKey returns the payment
+	// already created for the key instead of authorizing again.
+	AuthorizePayment(orderID string, amount float64, currency, idempotencyKey string) (*Payment, error)
+	CapturePayment(id string, amount float64) (*Payment, error)
+	VoidPayment(id string) (*Payment, error)
+	// ReleaseOrderPayments voids the order's authorized payments and
+	// refunds its captured ones. It runs when an order is cancelled or
+	// expires.
+	ReleaseOrderPayments(orderID, reason string) error
+	RefundPayment(paymentID string, amount float64, reason string) (*Refund, error)
+	ListRefunds(paymentID string) ([]*Refund, error)
 	GetPayment(id string) (*Payment, error)
+	ListPayments(req ListPaymentsRequest) (*PaymentPage, error)
 }
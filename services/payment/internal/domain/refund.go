@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+)
+
+var ErrRefundExceedsCaptured = errors.New("refund exceeds the captured amount")
+
+type Refund struct {
+	ID               string    `json:"id" db:"id"`
+	PaymentID        string    `json:"payment_id" db:"payment_id"`
+	Amount           float64   `json:"amount" db:"amount"`
+	Reason           string    `json:"reason" db:"reason"`
+	Status           string    `json:"status" db:"status"`
+	GatewayReference string    `json:"gateway_reference" db:"gateway_reference"`
+	FailureReason    string    `json:"failure_reason,omitempty" db:"failure_reason"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type RefundRepository interface {
+	// CreatePending reserves amount against the payment's captured total and
+	// inserts a pending refund. Pending and succeeded refunds both count, so
+	// concurrent requests cannot over-refund.
+	CreatePending(refund *Refund) error
+	// Complete records the gateway outcome and, on success, adds the amount
+	// to the payment's refunded total, updates its status and posts entry to
+	// the ledger.
+	Complete(refund *Refund, entry *JournalEntry) (*Payment, error)
+	ListByPayment(paymentID string) ([]*Refund, error)
+}
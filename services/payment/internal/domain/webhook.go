@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Asynchronous gateway notifications delivered to POST /webhooks/:provider.
+const (
+	WebhookEventSettled    = "payment.settled"
+	WebhookEventFailed     = "payment.failed"
+	WebhookEventChargeback = "payment.chargeback"
+)
+
+// Webhook event processing statuses. Failed events are retried when the
+// provider redelivers them and by the replay tool. An event is processing
+// while one request holds the claim to apply it.
+const (
+	WebhookStatusReceived   = "received"
+	WebhookStatusProcessing = "processing"
+	WebhookStatusApplied    = "applied"
+	WebhookStatusIgnored    = "ignored"
+	WebhookStatusFailed     = "failed"
+)
+
+var (
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	ErrUnknownWebhookProvider  = errors.New("unknown webhook provider")
+	ErrMalformedWebhook        = errors.New("malformed webhook payload")
+	ErrDuplicateWebhook        = errors.New("webhook event already received")
+	ErrWebhookInProgress       = errors.New("webhook event is being processed")
+	ErrChargebackTooLarge      = errors.New("chargeback exceeds the captured amount less refunds")
+)
+
+// WebhookClaimTimeout is how long a claim on an event lasts. A claim older
+// than this is taken to belong to a request that died mid-way, and the event
+// can be claimed again.
+const WebhookClaimTimeout = 5 * time.Minute
+
+// WebhookEvent is a provider notification stored exactly as received, so it
+// can be audited and replayed.
+type WebhookEvent struct {
+	ID               string     `json:"id"`
+	Provider         string     `json:"provider"`
+	EventID          string     `json:"event_id"`
+	Type             string     `json:"type"`
+	GatewayReference string     `json:"gateway_reference"`
+	Amount           float64    `json:"amount"`
+	Reason           string     `json:"reason,omitempty"`
+	Payload          []byte     `json:"-"`
+	Status           string     `json:"status"`
+	Error            string     `json:"error,omitempty"`
+	Attempts         int        `json:"attempts"`
+	ReceivedAt       time.Time  `json:"received_at"`
+	ClaimedAt        *time.Time `json:"claimed_at,omitempty"`
+	ProcessedAt      *time.Time `json:"processed_at,omitempty"`
+}
+
+type WebhookEventRepository interface {
+	// Create stores a new event already claimed for processing, returning
+	// ErrDuplicateWebhook if the provider's event ID has been seen before.
+	Create(event *WebhookEvent) error
+	GetByID(id string) (*WebhookEvent, error)
+	GetByEventID(provider, eventID string) (*WebhookEvent, error)
+	// Claim moves the event to processing if its stored status is one of
+	// from, or if an earlier claim is older than WebhookClaimTimeout. It
+	// fails with ErrWebhookInProgress otherwise, so only one request
+	// applies an event at a time.
+	Claim(event *WebhookEvent, from ...string) error
+	// MarkProcessed records the outcome of an attempt to apply the event.
+	MarkProcessed(event *WebhookEvent) error
+	ListByStatus(status string, limit int) ([]*WebhookEvent, error)
+}
+
+type WebhookUsecase interface {
+	// HandleWebhook verifies, stores and applies a raw provider
+	// notification. Redelivered events that previously failed are applied
+	// again; other duplicates are acknowledged without side effects, and a
+	// duplicate of an event being applied fails with ErrWebhookInProgress.
+	HandleWebhook(provider string, payload []byte, signature string) (*WebhookEvent, error)
+	// Replay applies a stored event again, whatever its status, unless
+	// another request is applying it.
+	Replay(id string) (*WebhookEvent, error)
+	ListFailed(limit int) ([]*WebhookEvent, error)
+}
@@ -0,0 +1,204 @@
+// Package gateway contains PaymentGateway implementations.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	"github.com/google/uuid"
+)
+
+// FakeConfig controls the fake gateway's behaviour. All decisions are
+// deterministic for a given configuration and call sequence.
+type FakeConfig struct {
+	// Latency is added to every call.
+	Latency time.Duration
+	// DeclineAbove declines authorizations above this amount (0 disables).
+	DeclineAbove float64
+	// FailureRate is the fraction of calls (0..1) that fail with a
+	// transport-style error, drawn from a PRNG seeded with Seed.
+	FailureRate float64
+	Seed        int64
+	// FeePercent and FeeFixed are charged on every capture, like a card
+	// processor's "2.9% + 30c".
+	FeePercent float64
+	FeeFixed   float64
+}
+
+// Magic amounts decline like the well-known test cards: any amount whose
+// cents are .51 is declined for insufficient funds, .05 for do-not-honor.
+var declineCents = map[int64]string{
+	51: "insufficient_funds",
+	5:  "do_not_honor",
+}
+
+// authPrefix starts the references of authorizations. The authorized amount
+// follows the last underscore, so an authorization can be rebuilt from its
+// reference after a restart.
+const authPrefix = "fake_auth_"
+
+type authorization struct {
+	amount   float64
+	captured float64
+	refunded float64
+	voided   bool
+}
+
+// FakeGateway tracks authorizations in memory. One it has not seen since it
+// started is rebuilt from its reference: as uncaptured when it is captured
+// or voided, and as captured in full when it is refunded. The payment
+// service's own records keep it from doing either out of turn.
+type FakeGateway struct {
+	cfg FakeConfig
+
+	mu    sync.Mutex
+	rng   *rand.Rand
+	auths map[string]*authorization
+}
+
+func NewFakeGateway(cfg FakeConfig) domain.PaymentGateway {
+	return &FakeGateway{
+		cfg:   cfg,
+		rng:   rand.New(rand.NewSource(cfg.Seed)),
+		auths: make(map[string]*authorization),
+	}
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.GatewayResult, error) {
+	if err := g.simulate(ctx); err != nil {
+		return nil, err
+	}
+
+	cents := int64(math.Round(req.Amount*100)) % 100
+	if code, ok := declineCents[cents]; ok {
+		return &domain.GatewayResult{DeclineCode: code, Message: "card declined"}, nil
+	}
+	if g.cfg.DeclineAbove > 0 && req.Amount > g.cfg.DeclineAbove {
+		return &domain.GatewayResult{DeclineCode: "amount_limit_exceeded", Message: "amount exceeds limit"}, nil
+	}
+
+	ref := authPrefix + uuid.New().String() + "_" + strconv.FormatFloat(req.Amount, 'f', -1, 64)
+	g.mu.Lock()
+	g.auths[ref] = &authorization{amount: req.Amount}
+	g.mu.Unlock()
+
+	return &domain.GatewayResult{Reference: ref, Approved: true}, nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, reference string, amount float64) (*domain.GatewayResult, error) {
+	if err := g.simulate(ctx); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, err := g.authorization(reference, false)
+	if err != nil {
+		return nil, err
+	}
+	if auth.voided || auth.captured > 0 {
+		return &domain.GatewayResult{Reference: reference, DeclineCode: "invalid_state", Message: "authorization already settled"}, nil
+	}
+	if amount > auth.amount {
+		return &domain.GatewayResult{Reference: reference, DeclineCode: "amount_too_large", Message: "capture exceeds authorized amount"}, nil
+	}
+
+	auth.captured = amount
+	fee := math.Round((amount*g.cfg.FeePercent/100+g.cfg.FeeFixed)*100) / 100
+	return &domain.GatewayResult{Reference: reference, Approved: true, Fee: fee}, nil
+}
+
+func (g *FakeGateway) Void(ctx context.Context, reference string) (*domain.GatewayResult, error) {
+	if err := g.simulate(ctx); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, err := g.authorization(reference, false)
+	if err != nil {
+		return nil, err
+	}
+	if auth.captured > 0 {
+		return &domain.GatewayResult{Reference: reference, DeclineCode: "invalid_state", Message: "authorization already captured"}, nil
+	}
+
+	auth.voided = true
+	return &domain.GatewayResult{Reference: reference, Approved: true}, nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, reference string, amount float64) (*domain.GatewayResult, error) {
+	if err := g.simulate(ctx); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, err := g.authorization(reference, true)
+	if err != nil {
+		return nil, err
+	}
+	if auth.refunded+amount > auth.captured+0.005 {
+		return &domain.GatewayResult{Reference: reference, DeclineCode: "amount_too_large", Message: "refund exceeds captured amount"}, nil
+	}
+
+	auth.refunded += amount
+	return &domain.GatewayResult{Reference: "fake_refund_" + uuid.New().String(), Approved: true}, nil
+}
+
+// authorization returns the authorization for reference, rebuilding it from
+// the reference if the gateway has not seen it; captured says whether a
+// rebuilt one is taken to be captured in full. g.mu must be held.
+func (g *FakeGateway) authorization(reference string, captured bool) (*authorization, error) {
+	if auth, ok := g.auths[reference]; ok {
+		return auth, nil
+	}
+
+	i := strings.LastIndexByte(reference, '_')
+	if !strings.HasPrefix(reference, authPrefix) || i < len(authPrefix) {
+		return nil, fmt.Errorf("unknown authorization %s", reference)
+	}
+	amount, err := strconv.ParseFloat(reference[i+1:], 64)
+	if err != nil || amount <= 0 {
+		return nil, fmt.Errorf("unknown authorization %s", reference)
+	}
+
+	auth := &authorization{amount: amount}
+	if captured {
+		auth.captured = amount
+	}
+	g.auths[reference] = auth
+	return auth, nil
+}
+
+// simulate applies the configured latency and random failures.
+func (g *FakeGateway) simulate(ctx context.Context) error {
+	if g.cfg.Latency > 0 {
+		select {
+		case <-time.After(g.cfg.Latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if g.cfg.FailureRate > 0 {
+		g.mu.Lock()
+		roll := g.rng.Float64()
+		g.mu.Unlock()
+		if roll < g.cfg.FailureRate {
+			return errors.New("gateway unavailable")
+		}
+	}
+	return nil
+}
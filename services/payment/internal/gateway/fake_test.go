@@ -0,0 +1,158 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+)
+
+func TestFakeGatewayAuthorizeDeclines(t *testing.T) {
+	g := NewFakeGateway(FakeConfig{DeclineAbove: 1000})
+	cases := []struct {
+		amount float64
+		code   string
+	}{
+		{10, ""},
+		{10.51, "insufficient_funds"},
+		{99.05, "do_not_honor"},
+		{1000, ""},
+		{1000.5, "amount_limit_exceeded"},
+	}
+
+	for _, tc := range cases {
+		result, err := g.Authorize(context.Background(), domain.AuthorizeRequest{Amount: tc.amount, Currency: "USD"})
+		if err != nil {
+			t.Fatalf("Authorize(%v): %v", tc.amount, err)
+		}
+		if result.Approved != (tc.code == "") || result.DeclineCode != tc.code {
+			t.Errorf("Authorize(%v) = approved %v, decline code %q; want code %q", tc.amount, result.Approved, result.DeclineCode, tc.code)
+		}
+		if result.Approved && result.Reference == "" {
+			t.Errorf("Authorize(%v) approved without a reference", tc.amount)
+		}
+	}
+}
+
+func TestFakeGatewayCaptureAndRefund(t *testing.T) {
+	ctx := context.Background()
+	g := NewFakeGateway(FakeConfig{FeePercent: 2.9, FeeFixed: 0.30})
+	auth := authorize(t, g, 10)
+
+	if result := call(t)(g.Capture(ctx, auth, 10.01)); result.DeclineCode != "amount_too_large" {
+		t.Errorf("capture above the authorization: decline code %q, want amount_too_large", result.DeclineCode)
+	}
+	result := call(t)(g.Capture(ctx, auth, 8))
+	if !result.Approved || result.Fee != 0.53 {
+		t.Errorf("capture: approved %v, fee %v; want approved with fee 0.53", result.Approved, result.Fee)
+	}
+	if result := call(t)(g.Capture(ctx, auth, 8)); result.DeclineCode != "invalid_state" {
+		t.Errorf("second capture: decline code %q, want invalid_state", result.DeclineCode)
+	}
+	if result := call(t)(g.Void(ctx, auth)); result.DeclineCode != "invalid_state" {
+		t.Errorf("void after capture: decline code %q, want invalid_state", result.DeclineCode)
+	}
+
+	if result := call(t)(g.Refund(ctx, auth, 5)); !result.Approved {
+		t.Errorf("refund: declined with %q", result.DeclineCode)
+	}
+	if result := call(t)(g.Refund(ctx, auth, 3.01)); result.DeclineCode != "amount_too_large" {
+		t.Errorf("refund above the captured amount: decline code %q, want amount_too_large", result.DeclineCode)
+	}
+	if result := call(t)(g.Refund(ctx, auth, 3)); !result.Approved {
+		t.Errorf("refund of the rest: declined with %q", result.DeclineCode)
+	}
+}
+
+func TestFakeGatewayVoid(t *testing.T) {
+	ctx := context.Background()
+	g := NewFakeGateway(FakeConfig{})
+	auth := authorize(t, g, 10)
+
+	if result := call(t)(g.Void(ctx, auth)); !result.Approved {
+		t.Fatalf("void: declined with %q", result.DeclineCode)
+	}
+	if result := call(t)(g.Capture(ctx, auth, 10)); result.DeclineCode != "invalid_state" {
+		t.Errorf("capture after void: decline code %q, want invalid_state", result.DeclineCode)
+	}
+}
+
+func TestFakeGatewayRebuildsAuthorizationsAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	captured := authorize(t, NewFakeGateway(FakeConfig{}), 25.5)
+	uncaptured := authorize(t, NewFakeGateway(FakeConfig{}), 12)
+
+	restarted := NewFakeGateway(FakeConfig{})
+	if result := call(t)(restarted.Refund(ctx, captured, 25.5)); !result.Approved {
+		t.Errorf("refund after restart: declined with %q", result.DeclineCode)
+	}
+	if result := call(t)(restarted.Refund(ctx, captured, 0.01)); result.DeclineCode != "amount_too_large" {
+		t.Errorf("refund beyond the rebuilt amount: decline code %q, want amount_too_large", result.DeclineCode)
+	}
+	if result := call(t)(restarted.Capture(ctx, uncaptured, 12.01)); result.DeclineCode != "amount_too_large" {
+		t.Errorf("capture beyond the rebuilt amount: decline code %q, want amount_too_large", result.DeclineCode)
+	}
+	if result := call(t)(restarted.Capture(ctx, uncaptured, 12)); !result.Approved {
+		t.Errorf("capture after restart: declined with %q", result.DeclineCode)
+	}
+
+	for _, reference := range []string{"", "fake_auth_", "fake_auth_x_0", "fake_auth_x_abc", "other_1"} {
+		if _, err := restarted.Capture(ctx, reference, 1); err == nil {
+			t.Errorf("Capture(%q) succeeded, want an unknown authorization error", reference)
+		}
+	}
+}
+
+func TestFakeGatewayFailuresAreSeeded(t *testing.T) {
+	failures := func(seed int64) []bool {
+		g := NewFakeGateway(FakeConfig{FailureRate: 0.5, Seed: seed})
+		var failed []bool
+		for i := 0; i < 50; i++ {
+			_, err := g.Authorize(context.Background(), domain.AuthorizeRequest{Amount: 10, Currency: "USD"})
+			failed = append(failed, err != nil)
+		}
+		return failed
+	}
+
+	first, second := failures(7), failures(7)
+	count := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("call %d: failed %v with seed 7, then %v", i, first[i], second[i])
+		}
+		if first[i] {
+			count++
+		}
+	}
+	if count == 0 || count == len(first) {
+		t.Errorf("%d of %d calls failed at a 0.5 failure rate", count, len(first))
+	}
+
+	g := NewFakeGateway(FakeConfig{FailureRate: 1})
+	if _, err := g.Capture(context.Background(), "fake_auth_x_10", 10); err == nil {
+		t.Error("Capture succeeded at a failure rate of 1")
+	}
+}
+
+func authorize(t *testing.T, g domain.PaymentGateway, amount float64) string {
+	t.Helper()
+	result, err := g.Authorize(context.Background(), domain.AuthorizeRequest{Amount: amount, Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Approved {
+		t.Fatalf("Authorize(%v) declined with %q", amount, result.DeclineCode)
+	}
+	return result.Reference
+}
+
+// call fails the test if a gateway call returned an error.
+func call(t *testing.T) func(*domain.GatewayResult, error) *domain.GatewayResult {
+	return func(result *domain.GatewayResult, err error) *domain.GatewayResult {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+}
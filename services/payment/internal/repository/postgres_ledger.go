@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PostgresLedgerRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresLedgerRepository(db *sql.DB) domain.LedgerRepository {
+	return &PostgresLedgerRepository{db: db}
+}
+
+// postEntry inserts the entry and its postings in tx. An entry whose
+// idempotency key was already posted is silently skipped.
+func postEntry(tx *sql.Tx, entry *domain.JournalEntry) error {
+	if !entry.Balanced() {
+		return domain.ErrUnbalancedEntry
+	}
+
+	entry.ID = uuid.New().String()
+	entry.CreatedAt = time.Now()
+
+	query := `INSERT INTO journal_entries (id, idempotency_key, kind, payment_id, order_id, user_id, description, currency, exchange_rate, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (idempotency_key) DO NOTHING`
+	res, err := tx.Exec(query, entry.ID, entry.IdempotencyKey, entry.Kind, entry.PaymentID, entry.OrderID,
+		entry.UserID, entry.Description, entry.Currency, entry.ExchangeRate, entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		// Already posted.
+		return nil
+	}
+
+	for _, posting := range entry.Postings {
+		posting.ID = uuid.New().String()
+		posting.EntryID = entry.ID
+		query := `INSERT INTO ledger_postings (id, entry_id, account, amount, original_amount) VALUES ($1, $2, $3, $4, $5)`
+		if _, err := tx.Exec(query, posting.ID, posting.EntryID, posting.Account, posting.Amount, posting.OriginalAmount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PostgresLedgerRepository) ListEntries(paymentID string) ([]*domain.JournalEntry, error) {
+	query := `SELECT e.id, e.idempotency_key, e.kind, e.payment_id, e.order_id, e.user_id, e.description, e.currency,
+			e.exchange_rate, e.created_at, p.id, p.account, p.amount, COALESCE(p.original_amount, p.amount)
+		FROM journal_entries e JOIN ledger_postings p ON p.entry_id = e.id
+		WHERE e.payment_id = $1
+		ORDER BY e.created_at, e.id, p.amount DESC, p.account`
+	rows, err := r.db.Query(query, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.JournalEntry
+	for rows.Next() {
+		var entry domain.JournalEntry
+		posting := &domain.Posting{}
+		err := rows.Scan(&entry.ID, &entry.IdempotencyKey, &entry.Kind, &entry.PaymentID, &entry.OrderID,
+			&entry.UserID, &entry.Description, &entry.Currency, &entry.ExchangeRate, &entry.CreatedAt,
+			&posting.ID, &posting.Account, &posting.Amount, &posting.OriginalAmount)
+		if err != nil {
+			return nil, err
+		}
+		posting.EntryID = entry.ID
+
+		if n := len(entries); n == 0 || entries[n-1].ID != entry.ID {
+			entries = append(entries, &entry)
+		}
+		last := entries[len(entries)-1]
+		last.Postings = append(last.Postings, posting)
+	}
+	return entries, rows.Err()
+}
+
+func (r *PostgresLedgerRepository) Balances(filter domain.LedgerBalanceFilter) ([]*domain.AccountBalance, error) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.OrderID != "" {
+		conds = append(conds, "e.order_id = "+arg(filter.OrderID))
+	}
+	if filter.UserID != "" {
+		conds = append(conds, "e.user_id = "+arg(filter.UserID))
+	}
+
+	query := `SELECT p.account,
+			COALESCE(SUM(p.amount) FILTER (WHERE p.amount > 0), 0),
+			COALESCE(-SUM(p.amount) FILTER (WHERE p.amount < 0), 0),
+			SUM(p.amount)
+		FROM ledger_postings p JOIN journal_entries e ON e.id = p.entry_id`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " GROUP BY p.account ORDER BY p.account"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []*domain.AccountBalance
+	for rows.Next() {
+		balance := &domain.AccountBalance{}
+		if err := rows.Scan(&balance.Account, &balance.Debits, &balance.Credits, &balance.Balance); err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+// Check verifies that the ledger sums to zero, that every entry balances on
+// its own, and that each payment's captured and refunded totals agree with
+// the sales and refunds it posted. Payments are compared in their own
+// currency, against the postings' original amounts; postings from before
+// currencies were recorded have none and fall back to the amount.
+func (r *PostgresLedgerRepository) Check() (*domain.LedgerCheck, error) {
+	check := &domain.LedgerCheck{}
+
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM journal_entries`).Scan(&check.Entries)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM ledger_postings`).Scan(&check.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	check.UnbalancedEntries, err = r.ids(`SELECT e.id
+		FROM journal_entries e LEFT JOIN ledger_postings p ON p.entry_id = e.id
+		GROUP BY e.id
+		HAVING COALESCE(SUM(p.amount), 0) <> 0 OR COUNT(p.id) < 2
+		ORDER BY e.id`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Minor units differ by currency, so the amounts are compared in Go.
+	rows, err := r.db.Query(`SELECT pay.id, pay.currency, pay.captured_amount, pay.refunded_amount, COALESCE(l.sales, 0), COALESCE(l.refunds, 0)
+		FROM payments pay
+		LEFT JOIN (
+			SELECT e.payment_id,
+				-SUM(COALESCE(p.original_amount, p.amount)) FILTER (WHERE p.account = $1) AS sales,
+				SUM(COALESCE(p.original_amount, p.amount)) FILTER (WHERE p.account = $2) AS refunds
+			FROM journal_entries e JOIN ledger_postings p ON p.entry_id = e.id
+			GROUP BY e.payment_id
+		) l ON l.payment_id = pay.id
+		WHERE pay.captured_amount <> 0 OR pay.refunded_amount <> 0 OR l.payment_id IS NOT NULL
+		ORDER BY pay.id`, domain.AccountSales, domain.AccountRefunds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p domain.PaymentPostings
+		if err := rows.Scan(&p.PaymentID, &p.Currency, &p.CapturedAmount, &p.RefundedAmount, &p.Sales, &p.Refunds); err != nil {
+			return nil, err
+		}
+		if !p.Matches() {
+			check.PaymentMismatches = append(check.PaymentMismatches, p.PaymentID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return check, nil
+}
+
+func (r *PostgresLedgerRepository) ids(query string, args ...any) ([]string, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// openTestDB connects to the Postgres database named by
+// PAYMENT_TEST_DATABASE_URL and creates the schema. The ledger is
+// append-only, so the entries these tests post, including the deliberately
+// broken ones, stay behind: use a database of its own.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("PAYMENT_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("PAYMENT_TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(Schema + outbox.Schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// capture authorizes and captures a payment of 100.00 USD, posting the
+// ledger entries a capture makes.
+func capture(t *testing.T, payments domain.PaymentRepository) *domain.Payment {
+	t.Helper()
+	payment := &domain.Payment{
+		OrderID:  uuid.New().String(),
+		UserID:   uuid.New().String(),
+		Amount:   100,
+		Currency: "USD",
+		BaseRate: 1,
+		Status:   domain.PaymentStatusAuthorized,
+	}
+	if err := payments.Create(payment); err != nil {
+		t.Fatal(err)
+	}
+	payment.Status = domain.PaymentStatusCaptured
+	payment.CapturedAmount = 100
+	entry := &domain.JournalEntry{
+		IdempotencyKey: "capture:" + payment.ID,
+		Kind:           domain.EntryKindCapture,
+		PaymentID:      payment.ID,
+		OrderID:        payment.OrderID,
+		UserID:         payment.UserID,
+		Description:    "Capture",
+		Currency:       "USD",
+		ExchangeRate:   1,
+		Postings: []*domain.Posting{
+			{Account: domain.AccountGatewayReceivable, Amount: 10000, OriginalAmount: 10000},
+			{Account: domain.AccountSales, Amount: -10000, OriginalAmount: -10000},
+		},
+	}
+	if err := payments.Update(payment, domain.PaymentStatusAuthorized, entry); err != nil {
+		t.Fatal(err)
+	}
+	return payment
+}
+
+func TestLedgerCheck(t *testing.T) {
+	db := openTestDB(t)
+	payments := NewPostgresPaymentRepository(db)
+	ledger := NewPostgresLedgerRepository(db)
+
+	matched := capture(t, payments)
+
+	// A payment whose captured amount was changed without posting it.
+	mismatched := capture(t, payments)
+	if _, err := db.Exec(`UPDATE payments SET captured_amount = 120 WHERE id = $1`, mismatched.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// An entry written around postEntry, whose postings do not balance.
+	unbalanced := uuid.New().String()
+	_, err := db.Exec(`INSERT INTO journal_entries (id, idempotency_key, kind, payment_id, order_id, user_id, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		unbalanced, "test:"+unbalanced, domain.EntryKindCapture, matched.ID, matched.OrderID, matched.UserID, "Unbalanced", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`INSERT INTO ledger_postings (id, entry_id, account, amount, original_amount)
+		VALUES ($1, $2, $3, 500, 500), ($4, $2, $5, -400, -400)`,
+		uuid.New().String(), unbalanced, domain.AccountGatewayReceivable, uuid.New().String(), domain.AccountGatewayFees)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := ledger.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.OK() {
+		t.Fatal("OK() = true with an unbalanced entry and a mismatched payment")
+	}
+	if !slices.Contains(check.UnbalancedEntries, unbalanced) {
+		t.Errorf("UnbalancedEntries %v does not include %s", check.UnbalancedEntries, unbalanced)
+	}
+	if !slices.Contains(check.PaymentMismatches, mismatched.ID) {
+		t.Errorf("PaymentMismatches %v does not include %s", check.PaymentMismatches, mismatched.ID)
+	}
+	if slices.Contains(check.PaymentMismatches, matched.ID) {
+		t.Errorf("PaymentMismatches includes %s, whose capture was posted", matched.ID)
+	}
+	entries, err := ledger.ListEntries(matched.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.ID != unbalanced && slices.Contains(check.UnbalancedEntries, entry.ID) {
+			t.Errorf("UnbalancedEntries includes the balanced %s entry %s", entry.Kind, entry.ID)
+		}
+	}
+}
+
+func TestPostEntryRejectsUnbalancedEntries(t *testing.T) {
+	db := openTestDB(t)
+	payments := NewPostgresPaymentRepository(db)
+
+	payment := &domain.Payment{
+		OrderID:  uuid.New().String(),
+		UserID:   uuid.New().String(),
+		Amount:   100,
+		Currency: "USD",
+		Status:   domain.PaymentStatusAuthorized,
+	}
+	if err := payments.Create(payment); err != nil {
+		t.Fatal(err)
+	}
+	payment.Status = domain.PaymentStatusCaptured
+	entry := &domain.JournalEntry{
+		IdempotencyKey: "capture:" + payment.ID,
+		Kind:           domain.EntryKindCapture,
+		PaymentID:      payment.ID,
+		Postings: []*domain.Posting{
+			{Account: domain.AccountGatewayReceivable, Amount: 10000},
+			{Account: domain.AccountSales, Amount: -9999},
+		},
+	}
+	if err := payments.Update(payment, domain.PaymentStatusAuthorized, entry); !errors.Is(err, domain.ErrUnbalancedEntry) {
+		t.Fatalf("err = %v, want ErrUnbalancedEntry", err)
+	}
+
+	stored, err := payments.GetByID(payment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != domain.PaymentStatusAuthorized {
+		t.Errorf("status = %s, want the update rolled back", stored.Status)
+	}
+}
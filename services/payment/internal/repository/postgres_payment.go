@@ -2,8 +2,12 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
 	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
 	"github.com/google/uuid"
 )
@@ -16,29 +20,163 @@ func NewPostgresPaymentRepository(db *sql.DB) domain.PaymentRepository {
 	return &PostgresPaymentRepository{db: db}
 }
 
+const paymentColumns = `id, order_id, user_id, amount, currency, order_amount, order_currency, exchange_rate, base_rate, captured_amount, refunded_amount, status, gateway_reference, failure_reason, COALESCE(idempotency_key, ''), settled_at, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPayment(row rowScanner, payment *domain.Payment) error {
+	return row.Scan(&payment.ID, &payment.OrderID, &payment.UserID, &payment.Amount, &payment.Currency, &payment.OrderAmount,
+		&payment.OrderCurrency, &payment.ExchangeRate, &payment.BaseRate, &payment.CapturedAmount, &payment.RefundedAmount, &payment.Status, &payment.GatewayReference, &payment.FailureReason, &payment.IdempotencyKey, &payment.SettledAt, &payment.CreatedAt, &payment.UpdatedAt)
+}
+
 func (r *PostgresPaymentRepository) Create(payment *domain.Payment) error {
 	payment.ID = uuid.New().String()
 	payment.CreatedAt = time.Now()
 	payment.UpdatedAt = time.Now()
 
-	query := `INSERT INTO payments (id, order_id, amount, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := r.db.Exec(query, payment.ID, payment.OrderID, payment.Amount, payment.Status, payment.CreatedAt, payment.UpdatedAt)
-	return err
+	query := `INSERT INTO payments (id, order_id, user_id, amount, currency, order_amount, order_currency, exchange_rate, base_rate, captured_amount, status, gateway_reference, failure_reason, idempotency_key, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''), $15, $16)
+		ON CONFLICT (idempotency_key) DO NOTHING`
+	return r.withTx(func(tx *sql.Tx) error {
+		res, err := tx.Exec(query, payment.ID, payment.OrderID, payment.UserID, payment.Amount, payment.Currency, payment.OrderAmount, payment.OrderCurrency, payment.ExchangeRate, payment.BaseRate, payment.CapturedAmount, payment.Status, payment.GatewayReference, payment.FailureReason, payment.IdempotencyKey, payment.CreatedAt, payment.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			// A concurrent request created the payment for this key.
+			return domain.ErrPaymentInProgress
+		}
+		return outbox.Add(tx, domain.AggregatePayment, payment.ID, domain.EventPaymentCreated, payment)
+	})
 }
 
 func (r *PostgresPaymentRepository) GetByID(id string) (*domain.Payment, error) {
 	payment := &domain.Payment{}
-	query := `SELECT id, order_id, amount, status, created_at, updated_at FROM payments WHERE id = $1`
-	err := r.db.QueryRow(query, id).Scan(&payment.ID, &payment.OrderID, &payment.Amount, &payment.Status, &payment.CreatedAt, &payment.UpdatedAt)
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE id = $1`
+	err := scanPayment(r.db.QueryRow(query, id), payment)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrPaymentNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return payment, nil
 }
 
-func (r *PostgresPaymentRepository) Update(payment *domain.Payment) error {
+func (r *PostgresPaymentRepository) GetByGatewayReference(reference string) (*domain.Payment, error) {
+	payment := &domain.Payment{}
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE gateway_reference = $1`
+	err := scanPayment(r.db.QueryRow(query, reference), payment)
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (r *PostgresPaymentRepository) GetByIdempotencyKey(key string) (*domain.Payment, error) {
+	payment := &domain.Payment{}
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE idempotency_key = $1`
+	err := scanPayment(r.db.QueryRow(query, key), payment)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (r *PostgresPaymentRepository) Update(payment *domain.Payment, from string, entries ...*domain.JournalEntry) error {
 	payment.UpdatedAt = time.Now()
-	query := `UPDATE payments SET status = $1, updated_at = $2 WHERE id = $3`
-	_, err := r.db.Exec(query, payment.Status, payment.UpdatedAt, payment.ID)
-	return err
+	query := `UPDATE payments SET status = $1, captured_amount = $2, gateway_reference = $3, failure_reason = $4, settled_at = $5, updated_at = $6 WHERE id = $7 AND status = $8`
+	return r.withTx(func(tx *sql.Tx) error {
+		res, err := tx.Exec(query, payment.Status, payment.CapturedAmount, payment.GatewayReference, payment.FailureReason, payment.SettledAt, payment.UpdatedAt, payment.ID, from)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return domain.ErrPaymentConflict
+		}
+		for _, entry := range entries {
+			if err := postEntry(tx, entry); err != nil {
+				return err
+			}
+		}
+		return outbox.Add(tx, domain.AggregatePayment, payment.ID, domain.EventPaymentUpdated, payment)
+	})
+}
+
+// withTx runs fn in a transaction, so that a write, its ledger entries and
+// its outbox event are committed together.
+func (r *PostgresPaymentRepository) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// List returns payments matching the filter using keyset pagination on
+// (created_at, id).
+func (r *PostgresPaymentRepository) List(filter domain.PaymentFilter) ([]*domain.Payment, error) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.OrderID != "" {
+		conds = append(conds, "order_id = "+arg(filter.OrderID))
+	}
+	if filter.UserID != "" {
+		conds = append(conds, "user_id = "+arg(filter.UserID))
+	}
+	if filter.Status != "" {
+		conds = append(conds, "status = "+arg(filter.Status))
+	}
+	if filter.CreatedAfter != nil {
+		conds = append(conds, "created_at >= "+arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		conds = append(conds, "created_at < "+arg(*filter.CreatedBefore))
+	}
+
+	direction, cmp := filter.Page.Sort.Direction()
+	if after := filter.Page.After; after != nil {
+		conds = append(conds, fmt.Sprintf("(created_at, id) %s (%s, %s)", cmp, arg(after.CreatedAt), arg(after.ID)))
+	}
+
+	query := `SELECT ` + paymentColumns + ` FROM payments`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT %s", direction, direction, arg(filter.Page.Limit()))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*domain.Payment
+	for rows.Next() {
+		payment := &domain.Payment{}
+		if err := scanPayment(rows, payment); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, rows.Err()
 }
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PostgresRefundRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRefundRepository(db *sql.DB) domain.RefundRepository {
+	return &PostgresRefundRepository{db: db}
+}
+
+// refundEpsilon absorbs float rounding when comparing NUMERIC(19, 4) sums.
+const refundEpsilon = 0.00005
+
+func (r *PostgresRefundRepository) CreatePending(refund *domain.Refund) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the payment row so concurrent refunds are serialised.
+	var captured float64
+	var status string
+	err = tx.QueryRow(`SELECT captured_amount, status FROM payments WHERE id = $1 FOR UPDATE`, refund.PaymentID).Scan(&captured, &status)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case domain.PaymentStatusCaptured, domain.PaymentStatusPartiallyRefunded:
+	default:
+		return domain.ErrInvalidPaymentState
+	}
+
+	var reserved float64
+	err = tx.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id = $1 AND status IN ($2, $3)`,
+		refund.PaymentID, domain.RefundStatusPending, domain.RefundStatusSucceeded).Scan(&reserved)
+	if err != nil {
+		return err
+	}
+
+	// An amount of zero refunds whatever is left.
+	if refund.Amount == 0 {
+		refund.Amount = captured - reserved
+	}
+	if refund.Amount <= 0 || reserved+refund.Amount > captured+refundEpsilon {
+		return domain.ErrRefundExceedsCaptured
+	}
+
+	refund.ID = uuid.New().String()
+	refund.Status = domain.RefundStatusPending
+	refund.CreatedAt = time.Now()
+	refund.UpdatedAt = refund.CreatedAt
+
+	query := `INSERT INTO refunds (id, payment_id, amount, reason, status, gateway_reference, failure_reason, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, '', '', $6, $7)`
+	if _, err := tx.Exec(query, refund.ID, refund.PaymentID, refund.Amount, refund.Reason, refund.Status, refund.CreatedAt, refund.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresRefundRepository) Complete(refund *domain.Refund, entry *domain.JournalEntry) (*domain.Payment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	refund.UpdatedAt = time.Now()
+	query := `UPDATE refunds SET status = $1, gateway_reference = $2, failure_reason = $3, updated_at = $4 WHERE id = $5`
+	if _, err := tx.Exec(query, refund.Status, refund.GatewayReference, refund.FailureReason, refund.UpdatedAt, refund.ID); err != nil {
+		return nil, err
+	}
+
+	payment := &domain.Payment{}
+	if err := scanPayment(tx.QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE id = $1 FOR UPDATE`, refund.PaymentID), payment); err != nil {
+		return nil, err
+	}
+
+	if refund.Status == domain.RefundStatusSucceeded {
+		payment.RefundedAmount += refund.Amount
+		payment.Status = domain.PaymentStatusPartiallyRefunded
+		if payment.RefundedAmount >= payment.CapturedAmount-refundEpsilon {
+			payment.Status = domain.PaymentStatusRefunded
+		}
+		payment.UpdatedAt = refund.UpdatedAt
+
+		query = `UPDATE payments SET refunded_amount = $1, status = $2, updated_at = $3 WHERE id = $4`
+		if _, err := tx.Exec(query, payment.RefundedAmount, payment.Status, payment.UpdatedAt, payment.ID); err != nil {
+			return nil, err
+		}
+		if err := postEntry(tx, entry); err != nil {
+			return nil, err
+		}
+
+		event := map[string]any{"payment": payment, "refund": refund}
+		if err := outbox.Add(tx, domain.AggregatePayment, payment.ID, domain.EventPaymentRefunded, event); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (r *PostgresRefundRepository) ListByPayment(paymentID string) ([]*domain.Refund, error) {
+	query := `SELECT id, payment_id, amount, reason, status, gateway_reference, failure_reason, created_at, updated_at
+		FROM refunds WHERE payment_id = $1 ORDER BY created_at, id`
+	rows, err := r.db.Query(query, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []*domain.Refund
+	for rows.Next() {
+		refund := &domain.Refund{}
+		err := rows.Scan(&refund.ID, &refund.PaymentID, &refund.Amount, &refund.Reason, &refund.Status,
+			&refund.GatewayReference, &refund.FailureReason, &refund.CreatedAt, &refund.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, rows.Err()
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PostgresWebhookEventRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresWebhookEventRepository(db *sql.DB) domain.WebhookEventRepository {
+	return &PostgresWebhookEventRepository{db: db}
+}
+
+const webhookEventColumns = `id, provider, event_id, type, gateway_reference, amount, reason, payload, status, error, attempts, received_at, claimed_at, processed_at`
+
+func scanWebhookEvent(row rowScanner, event *domain.WebhookEvent) error {
+	return row.Scan(&event.ID, &event.Provider, &event.EventID, &event.Type, &event.GatewayReference, &event.Amount,
+		&event.Reason, &event.Payload, &event.Status, &event.Error, &event.Attempts, &event.ReceivedAt, &event.ClaimedAt, &event.ProcessedAt)
+}
+
+func (r *PostgresWebhookEventRepository) Create(event *domain.WebhookEvent) error {
+	event.ID = uuid.New().String()
+	event.Status = domain.WebhookStatusProcessing
+	event.ReceivedAt = time.Now()
+	event.ClaimedAt = &event.ReceivedAt
+
+	query := `INSERT INTO webhook_events (` + webhookEventColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (provider, event_id) DO NOTHING`
+	res, err := r.db.Exec(query, event.ID, event.Provider, event.EventID, event.Type, event.GatewayReference, event.Amount,
+		event.Reason, event.Payload, event.Status, event.Error, event.Attempts, event.ReceivedAt, event.ClaimedAt, event.ProcessedAt)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return domain.ErrDuplicateWebhook
+	}
+	return nil
+}
+
+func (r *PostgresWebhookEventRepository) GetByID(id string) (*domain.WebhookEvent, error) {
+	event := &domain.WebhookEvent{}
+	query := `SELECT ` + webhookEventColumns + ` FROM webhook_events WHERE id = $1`
+	if err := scanWebhookEvent(r.db.QueryRow(query, id), event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (r *PostgresWebhookEventRepository) GetByEventID(provider, eventID string) (*domain.WebhookEvent, error) {
+	event := &domain.WebhookEvent{}
+	query := `SELECT ` + webhookEventColumns + ` FROM webhook_events WHERE provider = $1 AND event_id = $2`
+	if err := scanWebhookEvent(r.db.QueryRow(query, provider, eventID), event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (r *PostgresWebhookEventRepository) Claim(event *domain.WebhookEvent, from ...string) error {
+	now := time.Now()
+	query := `UPDATE webhook_events SET status = $1, claimed_at = $2
+		WHERE id = $3 AND (status = ANY($4) OR (status = $1 AND claimed_at < $5))`
+	res, err := r.db.Exec(query, domain.WebhookStatusProcessing, now, event.ID, pq.Array(from), now.Add(-domain.WebhookClaimTimeout))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return domain.ErrWebhookInProgress
+	}
+	event.Status = domain.WebhookStatusProcessing
+	event.ClaimedAt = &now
+	return nil
+}
+
+func (r *PostgresWebhookEventRepository) MarkProcessed(event *domain.WebhookEvent) error {
+	now := time.Now()
+	event.ProcessedAt = &now
+	event.Attempts++
+
+	query := `UPDATE webhook_events SET status = $1, error = $2, attempts = $3, processed_at = $4 WHERE id = $5`
+	_, err := r.db.Exec(query, event.Status, event.Error, event.Attempts, event.ProcessedAt, event.ID)
+	return err
+}
+
+func (r *PostgresWebhookEventRepository) ListByStatus(status string, limit int) ([]*domain.WebhookEvent, error) {
+	query := `SELECT ` + webhookEventColumns + ` FROM webhook_events WHERE status = $1 ORDER BY received_at, id LIMIT $2`
+	rows, err := r.db.Query(query, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*domain.WebhookEvent
+	for rows.Next() {
+		event := &domain.WebhookEvent{}
+		if err := scanWebhookEvent(rows, event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,131 @@
+package repository
+
+// Schema creates the tables the payment, refund, ledger and webhook
+// repositories use. It is safe to run on every start.
+const Schema = `
+	CREATE TABLE IF NOT EXISTS payments (
+		id VARCHAR(36) PRIMARY KEY,
+		order_id VARCHAR(36) NOT NULL,
+		amount NUMERIC(19, 4) NOT NULL,
+		status VARCHAR(50) NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS user_id VARCHAR(36) NOT NULL DEFAULT '';
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS captured_amount NUMERIC(19, 4) NOT NULL DEFAULT 0;
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS gateway_reference VARCHAR(255) NOT NULL DEFAULT '';
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS failure_reason VARCHAR(255) NOT NULL DEFAULT '';
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount NUMERIC(19, 4) NOT NULL DEFAULT 0;
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS settled_at TIMESTAMP;
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS order_amount NUMERIC(19, 4) NOT NULL DEFAULT 0;
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS order_currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(18, 8) NOT NULL DEFAULT 1;
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS base_rate DECIMAL(18, 8) NOT NULL DEFAULT 1;
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255);
+
+	CREATE INDEX IF NOT EXISTS idx_payments_order_id_created_at ON payments (order_id, created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_payments_user_id_created_at ON payments (user_id, created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_payments_created_at ON payments (created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_payments_gateway_reference ON payments (gateway_reference);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_idempotency_key ON payments (idempotency_key);
+	-- Money was DECIMAL(10, 2), which overflows for currencies such as IDR
+	-- and drops the third decimal of KWD.
+	ALTER TABLE payments
+		ALTER COLUMN amount TYPE NUMERIC(19, 4),
+		ALTER COLUMN captured_amount TYPE NUMERIC(19, 4),
+		ALTER COLUMN refunded_amount TYPE NUMERIC(19, 4),
+		ALTER COLUMN order_amount TYPE NUMERIC(19, 4);
+
+	CREATE TABLE IF NOT EXISTS refunds (
+		id VARCHAR(36) PRIMARY KEY,
+		payment_id VARCHAR(36) NOT NULL REFERENCES payments (id),
+		amount NUMERIC(19, 4) NOT NULL CHECK (amount > 0),
+		reason VARCHAR(255) NOT NULL DEFAULT '',
+		status VARCHAR(50) NOT NULL,
+		gateway_reference VARCHAR(255) NOT NULL DEFAULT '',
+		failure_reason VARCHAR(255) NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_refunds_payment_id ON refunds (payment_id, created_at);
+	ALTER TABLE refunds ALTER COLUMN amount TYPE NUMERIC(19, 4);
+
+	CREATE TABLE IF NOT EXISTS ledger_accounts (
+		code VARCHAR(50) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		type VARCHAR(20) NOT NULL
+	);
+
+	INSERT INTO ledger_accounts (code, name, type) VALUES
+		('gateway_holds', 'Authorized funds held at the gateway', 'asset'),
+		('customer_authorizations', 'Customer authorizations', 'liability'),
+		('gateway_receivable', 'Captured funds receivable from the gateway', 'asset'),
+		('sales', 'Sales', 'revenue'),
+		('refunds', 'Refunds', 'contra_revenue'),
+		('gateway_fees', 'Gateway fees', 'expense'),
+		('chargebacks', 'Chargebacks', 'expense')
+	ON CONFLICT (code) DO NOTHING;
+
+	CREATE TABLE IF NOT EXISTS journal_entries (
+		id VARCHAR(36) PRIMARY KEY,
+		idempotency_key VARCHAR(255) NOT NULL UNIQUE,
+		kind VARCHAR(50) NOT NULL,
+		payment_id VARCHAR(36) NOT NULL,
+		order_id VARCHAR(36) NOT NULL,
+		user_id VARCHAR(36) NOT NULL,
+		description VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_journal_entries_payment_id ON journal_entries (payment_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_journal_entries_order_id ON journal_entries (order_id);
+	CREATE INDEX IF NOT EXISTS idx_journal_entries_user_id ON journal_entries (user_id);
+	ALTER TABLE journal_entries ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+	ALTER TABLE journal_entries ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(18, 8) NOT NULL DEFAULT 1;
+
+	CREATE TABLE IF NOT EXISTS ledger_postings (
+		id VARCHAR(36) PRIMARY KEY,
+		entry_id VARCHAR(36) NOT NULL REFERENCES journal_entries (id),
+		account VARCHAR(50) NOT NULL REFERENCES ledger_accounts (code),
+		amount BIGINT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_ledger_postings_entry_id ON ledger_postings (entry_id);
+	-- original_amount is the posting in the entry's currency; amount is in
+	-- the base currency. It is NULL for postings made before currencies.
+	ALTER TABLE ledger_postings ADD COLUMN IF NOT EXISTS original_amount BIGINT;
+
+	CREATE TABLE IF NOT EXISTS webhook_events (
+		id VARCHAR(36) PRIMARY KEY,
+		provider VARCHAR(50) NOT NULL,
+		event_id VARCHAR(255) NOT NULL,
+		type VARCHAR(100) NOT NULL,
+		gateway_reference VARCHAR(255) NOT NULL DEFAULT '',
+		amount NUMERIC(19, 4) NOT NULL DEFAULT 0,
+		reason VARCHAR(255) NOT NULL DEFAULT '',
+		payload BYTEA NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		attempts INT NOT NULL DEFAULT 0,
+		received_at TIMESTAMP NOT NULL,
+		processed_at TIMESTAMP,
+		UNIQUE (provider, event_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_webhook_events_status ON webhook_events (status, received_at);
+	ALTER TABLE webhook_events ALTER COLUMN amount TYPE NUMERIC(19, 4);
+	ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP;
+
+	-- The ledger is append-only: corrections are new entries, never edits.
+	CREATE OR REPLACE FUNCTION ledger_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'ledger tables are append-only';
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS journal_entries_append_only ON journal_entries;
+	CREATE TRIGGER journal_entries_append_only BEFORE UPDATE OR DELETE ON journal_entries
+		FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+	DROP TRIGGER IF EXISTS ledger_postings_append_only ON ledger_postings;
+	CREATE TRIGGER ledger_postings_append_only BEFORE UPDATE OR DELETE ON ledger_postings
+		FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+	`
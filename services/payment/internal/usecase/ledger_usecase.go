@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"errors"
+	"math"
+
+	"github.com/edwinjordan/golang_microservices/pkg/fx"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+)
+
+type ledgerUsecase struct {
+	ledgerRepo   domain.LedgerRepository
+	baseCurrency string
+}
+
+// NewLedgerUsecase returns a ledger that books postings in baseCurrency,
+// converted at each payment's BaseRate.
+func NewLedgerUsecase(ledgerRepo domain.LedgerRepository, baseCurrency string) domain.LedgerUsecase {
+	return &ledgerUsecase{ledgerRepo: ledgerRepo, baseCurrency: baseCurrency}
+}
+
+func (u *ledgerUsecase) BaseCurrency() string {
+	return u.baseCurrency
+}
+
+// AuthorizationEntry places a memo hold for the authorized amount.
+func (u *ledgerUsecase) AuthorizationEntry(payment *domain.Payment) (*domain.JournalEntry, error) {
+	amount := fx.ToMinor(payment.Amount, payment.Currency)
+	return u.entry(payment, "authorization:"+payment.ID, domain.EntryKindAuthorization, "Authorization hold",
+		debit(domain.AccountGatewayHolds, amount),
+		credit(domain.AccountCustomerAuthorizations, amount),
+	)
+}
+
+// CaptureEntry releases the hold, recognises the captured amount as sales
+// and books the gateway fee against the receivable.
+func (u *ledgerUsecase) CaptureEntry(payment *domain.Payment, fee float64) (*domain.JournalEntry, error) {
+	held := fx.ToMinor(payment.Amount, payment.Currency)
+	captured := fx.ToMinor(payment.CapturedAmount, payment.Currency)
+	postings := []*domain.Posting{
+		debit(domain.AccountCustomerAuthorizations, held),
+		credit(domain.AccountGatewayHolds, held),
+		debit(domain.AccountGatewayReceivable, captured),
+		credit(domain.AccountSales, captured),
+	}
+	if feeAmount := fx.ToMinor(fee, payment.Currency); feeAmount > 0 {
+		postings = append(postings,
+			debit(domain.AccountGatewayFees, feeAmount),
+			credit(domain.AccountGatewayReceivable, feeAmount),
+		)
+	}
+	return u.entry(payment, "capture:"+payment.ID, domain.EntryKindCapture, "Capture", postings...)
+}
+
+// VoidEntry releases the hold without moving money.
+func (u *ledgerUsecase) VoidEntry(payment *domain.Payment) (*domain.JournalEntry, error) {
+	amount := fx.ToMinor(payment.Amount, payment.Currency)
+	return u.entry(payment, "void:"+payment.ID, domain.EntryKindVoid, "Authorization voided",
+		debit(domain.AccountCustomerAuthorizations, amount),
+		credit(domain.AccountGatewayHolds, amount),
+	)
+}
+
+func (u *ledgerUsecase) RefundEntry(payment *domain.Payment, refund *domain.Refund) (*domain.JournalEntry, error) {
+	amount := fx.ToMinor(refund.Amount, payment.Currency)
+	description := "Refund"
+	if refund.Reason != "" {
+		description += ": " + refund.Reason
+	}
+	return u.entry(payment, "refund:"+refund.ID, domain.EntryKindRefund, description,
+		debit(domain.AccountRefunds, amount),
+		credit(domain.AccountGatewayReceivable, amount),
+	)
+}
+
+func (u *ledgerUsecase) ChargebackEntry(payment *domain.Payment, amount float64) (*domain.JournalEntry, error) {
+	minor := fx.ToMinor(amount, payment.Currency)
+	return u.entry(payment, "chargeback:"+payment.ID, domain.EntryKindChargeback, "Chargeback",
+		debit(domain.AccountChargebacks, minor),
+		credit(domain.AccountGatewayReceivable, minor),
+	)
+}
+
+func (u *ledgerUsecase) ListEntries(paymentID string) ([]*domain.JournalEntry, error) {
+	if paymentID == "" {
+		return nil, errors.New("paymentID is required")
+	}
+	return u.ledgerRepo.ListEntries(paymentID)
+}
+
+func (u *ledgerUsecase) Balances(filter domain.LedgerBalanceFilter) ([]*domain.AccountBalance, error) {
+	balances, err := u.ledgerRepo.Balances(filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, balance := range balances {
+		balance.Currency = u.baseCurrency
+	}
+	return balances, nil
+}
+
+func (u *ledgerUsecase) Check() (*domain.LedgerCheck, error) {
+	return u.ledgerRepo.Check()
+}
+
+// entry books postings given in minor units of the payment's currency. Each
+// one is converted to minor units of the base currency on its own; debits
+// and credits of the same amount convert to the same amount, so a balanced
+// entry stays balanced.
+func (u *ledgerUsecase) entry(payment *domain.Payment, key, kind, description string, postings ...*domain.Posting) (*domain.JournalEntry, error) {
+	rate := payment.BaseRate
+	if rate == 0 {
+		rate = 1
+	}
+	scale := math.Pow10(fx.MinorUnits(u.baseCurrency) - fx.MinorUnits(payment.Currency))
+	for _, posting := range postings {
+		posting.OriginalAmount = posting.Amount
+		posting.Amount = int64(math.Round(float64(posting.Amount) * rate * scale))
+	}
+
+	entry := &domain.JournalEntry{
+		IdempotencyKey: key,
+		Kind:           kind,
+		PaymentID:      payment.ID,
+		OrderID:        payment.OrderID,
+		UserID:         payment.UserID,
+		Description:    description,
+		Currency:       payment.Currency,
+		ExchangeRate:   rate,
+		Postings:       postings,
+	}
+	if !entry.Balanced() {
+		return nil, domain.ErrUnbalancedEntry
+	}
+	return entry, nil
+}
+
+func debit(account string, amount int64) *domain.Posting {
+	return &domain.Posting{Account: account, Amount: amount}
+}
+
+func credit(account string, amount int64) *domain.Posting {
+	return &domain.Posting{Account: account, Amount: -amount}
+}
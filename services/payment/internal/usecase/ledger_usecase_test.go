@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+)
+
+func ledgerPayment(currency string, amount, baseRate float64) *domain.Payment {
+	return &domain.Payment{
+		ID:             "pay_1",
+		OrderID:        "order_1",
+		UserID:         "user_1",
+		Amount:         amount,
+		Currency:       currency,
+		BaseRate:       baseRate,
+		CapturedAmount: amount,
+	}
+}
+
+// TestLedgerEntriesBalance builds every kind of entry for payments in
+// currencies with 0, 2 and 3 decimal places and checks that each one sums
+// to zero in both the base currency and the payment's own.
+func TestLedgerEntriesBalance(t *testing.T) {
+	payments := []*domain.Payment{
+		ledgerPayment("USD", 123.45, 0),
+		ledgerPayment("EUR", 99.99, 1.0837),
+		ledgerPayment("JPY", 12345, 0.00667),
+		ledgerPayment("KWD", 10.125, 3.2571),
+	}
+	u := NewLedgerUsecase(nil, "USD")
+
+	for _, payment := range payments {
+		refund := &domain.Refund{ID: "ref_1", Amount: payment.Amount / 3, Reason: "damaged"}
+		builders := []struct {
+			name     string
+			kind     string
+			key      string
+			postings int
+			build    func() (*domain.JournalEntry, error)
+		}{
+			{"authorization", domain.EntryKindAuthorization, "authorization:pay_1", 2, func() (*domain.JournalEntry, error) {
+				return u.AuthorizationEntry(payment)
+			}},
+			{"capture", domain.EntryKindCapture, "capture:pay_1", 4, func() (*domain.JournalEntry, error) {
+				return u.CaptureEntry(payment, 0)
+			}},
+			{"capture with fee", domain.EntryKindCapture, "capture:pay_1", 6, func() (*domain.JournalEntry, error) {
+				return u.CaptureEntry(payment, payment.Amount*0.029)
+			}},
+			{"void", domain.EntryKindVoid, "void:pay_1", 2, func() (*domain.JournalEntry, error) {
+				return u.VoidEntry(payment)
+			}},
+			{"refund", domain.EntryKindRefund, "refund:ref_1", 2, func() (*domain.JournalEntry, error) {
+				return u.RefundEntry(payment, refund)
+			}},
+			{"chargeback", domain.EntryKindChargeback, "chargeback:pay_1", 2, func() (*domain.JournalEntry, error) {
+				return u.ChargebackEntry(payment, payment.Amount/7)
+			}},
+		}
+
+		for _, tt := range builders {
+			t.Run(payment.Currency+" "+tt.name, func(t *testing.T) {
+				entry, err := tt.build()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if entry.Kind != tt.kind || entry.IdempotencyKey != tt.key {
+					t.Errorf("kind %q key %q, want %q %q", entry.Kind, entry.IdempotencyKey, tt.kind, tt.key)
+				}
+				if entry.PaymentID != payment.ID || entry.OrderID != payment.OrderID || entry.UserID != payment.UserID {
+					t.Errorf("entry is for payment %q order %q user %q", entry.PaymentID, entry.OrderID, entry.UserID)
+				}
+				if entry.Currency != payment.Currency {
+					t.Errorf("Currency = %q, want %q", entry.Currency, payment.Currency)
+				}
+				if len(entry.Postings) != tt.postings {
+					t.Fatalf("%d postings, want %d", len(entry.Postings), tt.postings)
+				}
+
+				var base, original int64
+				for _, p := range entry.Postings {
+					if p.Amount == 0 || p.OriginalAmount == 0 {
+						t.Errorf("zero posting to %s: %+v", p.Account, p)
+					}
+					base += p.Amount
+					original += p.OriginalAmount
+				}
+				if base != 0 || original != 0 {
+					t.Errorf("postings sum to %d (base) and %d (original), want 0", base, original)
+				}
+				if !entry.Balanced() {
+					t.Error("Balanced() = false")
+				}
+			})
+		}
+	}
+}
+
+func TestCaptureEntryConvertsToBaseCurrency(t *testing.T) {
+	u := NewLedgerUsecase(nil, "USD")
+	payment := ledgerPayment("EUR", 100, 1.1)
+
+	entry, err := u.CaptureEntry(payment, 2.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.ExchangeRate != 1.1 {
+		t.Errorf("ExchangeRate = %v, want 1.1", entry.ExchangeRate)
+	}
+
+	want := []domain.Posting{
+		{Account: domain.AccountCustomerAuthorizations, Amount: 11000, OriginalAmount: 10000},
+		{Account: domain.AccountGatewayHolds, Amount: -11000, OriginalAmount: -10000},
+		{Account: domain.AccountGatewayReceivable, Amount: 11000, OriginalAmount: 10000},
+		{Account: domain.AccountSales, Amount: -11000, OriginalAmount: -10000},
+		{Account: domain.AccountGatewayFees, Amount: 319, OriginalAmount: 290},
+		{Account: domain.AccountGatewayReceivable, Amount: -319, OriginalAmount: -290},
+	}
+	for i, p := range entry.Postings {
+		if *p != want[i] {
+			t.Errorf("posting %d = %+v, want %+v", i, *p, want[i])
+		}
+	}
+}
+
+func TestEntryUsesUnitRateWithoutBaseRate(t *testing.T) {
+	u := NewLedgerUsecase(nil, "USD")
+
+	entry, err := u.AuthorizationEntry(ledgerPayment("USD", 50, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.ExchangeRate != 1 {
+		t.Errorf("ExchangeRate = %v, want 1", entry.ExchangeRate)
+	}
+	if got := entry.Postings[0]; got.Amount != 5000 || got.OriginalAmount != 5000 {
+		t.Errorf("posting = %+v, want 5000 in both currencies", *got)
+	}
+}
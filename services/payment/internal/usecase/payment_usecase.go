@@ -3,20 +3,35 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 
-	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+	"github.com/edwinjordan/golang_microservices/pkg/fx"
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
 	orderpb "github.com/edwinjordan/golang_microservices/services/order/pkg/pb"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+// Order statuses reported by the order service.
+const (
+	orderStatusPending   = "pending"
+	orderStatusCancelled = "cancelled"
+	orderStatusExpired   = "expired"
+)
+
 type paymentUsecase struct {
 	paymentRepo     domain.PaymentRepository
+	refundRepo      domain.RefundRepository
 	orderGRPCClient orderpb.OrderServiceClient
+	paginator       *pagination.Paginator
+	gateway         domain.PaymentGateway
+	ledger          domain.LedgerUsecase
+	rates           fx.RateProvider
 }
 
-func NewPaymentUsecase(paymentRepo domain.PaymentRepository, orderGRPCAddr string) domain.PaymentUsecase {
+func NewPaymentUsecase(paymentRepo domain.PaymentRepository, refundRepo domain.RefundRepository, gateway domain.PaymentGateway, ledger domain.LedgerUsecase, rates fx.RateProvider, orderGRPCAddr string, pageTokens *pagination.Codec) domain.PaymentUsecase {
 	// Connect to order service
 	conn, err := grpc.NewClient(orderGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -27,37 +42,327 @@ func NewPaymentUsecase(paymentRepo domain.PaymentRepository, orderGRPCAddr strin
 
 	return &paymentUsecase{
 		paymentRepo:     paymentRepo,
+		refundRepo:      refundRepo,
 		orderGRPCClient: orderClient,
+		paginator:       pagination.NewPaginator(pageTokens, pagination.Options{}),
+		gateway:         gateway,
+		ledger:          ledger,
+		rates:           rates,
 	}
 }
 
-func (u *paymentUsecase) ProcessPayment(orderID string, amount float64) (*domain.Payment, error) {
+// ProcessPayment is a one-step sale: authorize and immediately capture the
+// full amount.
+func (u *paymentUsecase) ProcessPayment(orderID string, amount float64, currency string) (*domain.Payment, error) {
+	payment, err := u.AuthorizePayment(orderID, amount, currency, "")
+	if err != nil || payment.Status != domain.PaymentStatusAuthorized {
+		return payment, err
+	}
+
+	return u.CapturePayment(payment.ID, 0)
+}
+
+func (u *paymentUsecase) AuthorizePayment(orderID string, amount float64, currency, idempotencyKey string) (*domain.Payment, error) {
 	if orderID == "" || amount <= 0 {
 		return nil, errors.New("orderID and amount are required")
 	}
 
+	if idempotencyKey != "" {
+		payment, err := u.paymentRepo.GetByIdempotencyKey(idempotencyKey)
+		switch {
+		case errors.Is(err, domain.ErrPaymentNotFound):
+		case err != nil:
+			return nil, err
+		case payment.OrderID != orderID:
+			return nil, domain.ErrIdempotencyKeyReused
+		case payment.Status == domain.PaymentStatusPending:
+			return nil, domain.ErrPaymentInProgress
+		default:
+			return payment, nil
+		}
+	}
+
 	// Validate order exists via gRPC
+	var userID, orderCurrency string
 	if u.orderGRPCClient != nil {
-		_, err := u.orderGRPCClient.GetOrder(context.Background(), &orderpb.GetOrderRequest{Id: orderID})
+		order, err := u.orderGRPCClient.GetOrder(context.Background(), &orderpb.GetOrderRequest{Id: orderID})
 		if err != nil {
 			return nil, errors.New("invalid order")
 		}
+		if order.Status != orderStatusPending {
+			return nil, domain.ErrOrderNotPayable
+		}
+		if fx.ToMinor(amount, order.Currency) != fx.ToMinor(order.Amount, order.Currency) {
+			return nil, domain.ErrAmountMismatch
+		}
+		userID = order.UserId
+		orderCurrency = order.Currency
+	}
+
+	payment, err := u.newPayment(orderID, userID, amount, orderCurrency, currency)
+	if err != nil {
+		return nil, err
+	}
+	payment.IdempotencyKey = idempotencyKey
+
+	err = u.paymentRepo.Create(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := u.gateway.Authorize(context.Background(), domain.AuthorizeRequest{
+		PaymentID: payment.ID,
+		OrderID:   orderID,
+		Amount:    payment.Amount,
+		Currency:  payment.Currency,
+	})
+	switch {
+	case err != nil:
+		payment.Status = domain.PaymentStatusFailed
+		payment.FailureReason = err.Error()
+	case !result.Approved:
+		payment.Status = domain.PaymentStatusDeclined
+		payment.FailureReason = result.DeclineCode
+	default:
+		payment.Status = domain.PaymentStatusAuthorized
+		payment.GatewayReference = result.Reference
+	}
+
+	var entries []*domain.JournalEntry
+	if payment.Status == domain.PaymentStatusAuthorized {
+		entry, err := u.ledger.AuthorizationEntry(payment)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := u.paymentRepo.Update(payment, domain.PaymentStatusPending, entries...); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// newPayment prices a pending payment: amount, due in orderCurrency, is
+// converted to currency at the current rate, and the rate from currency to
+// the ledger's base currency is recorded alongside.
+func (u *paymentUsecase) newPayment(orderID, userID string, amount float64, orderCurrency, currency string) (*domain.Payment, error) {
+	if orderCurrency == "" {
+		orderCurrency = u.ledger.BaseCurrency()
+	}
+	if currency == "" {
+		currency = orderCurrency
+	}
+	currency, err := fx.NormalizeCurrency(currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUnsupportedCurrency, err)
+	}
+
+	ctx := context.Background()
+	exchangeRate, err := u.rates.Rate(ctx, orderCurrency, currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUnsupportedCurrency, err)
+	}
+	baseRate, err := u.rates.Rate(ctx, currency, u.ledger.BaseCurrency())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUnsupportedCurrency, err)
 	}
 
 	payment := &domain.Payment{
-		OrderID: orderID,
-		Amount:  amount,
-		Status:  "completed",
+		OrderID:       orderID,
+		UserID:        userID,
+		Amount:        fx.Convert(amount, exchangeRate, currency),
+		Currency:      currency,
+		OrderAmount:   amount,
+		OrderCurrency: orderCurrency,
+		ExchangeRate:  exchangeRate,
+		BaseRate:      baseRate,
+		Status:        domain.PaymentStatusPending,
+	}
+	if payment.Amount <= 0 {
+		return nil, errors.New("amount is too small to charge in " + currency)
 	}
+	return payment, nil
+}
 
-	err := u.paymentRepo.Create(payment)
+// CapturePayment captures an authorized payment. An amount of zero captures
+// the full authorized amount. A declined capture leaves the payment as it
+// is stored: a concurrent capture may already have succeeded.
+func (u *paymentUsecase) CapturePayment(id string, amount float64) (*domain.Payment, error) {
+	payment, err := u.GetPayment(id)
 	if err != nil {
 		return nil, err
 	}
+	if payment.Status != domain.PaymentStatusAuthorized {
+		return nil, domain.ErrInvalidPaymentState
+	}
 
+	if amount == 0 {
+		amount = payment.Amount
+	}
+	if amount < 0 || amount > payment.Amount {
+		return nil, errors.New("capture amount must be between 0 and the authorized amount")
+	}
+	closed, err := u.orderClosed(payment.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if closed {
+		return nil, domain.ErrOrderNotPayable
+	}
+
+	result, err := u.gateway.Capture(context.Background(), payment.GatewayReference, amount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrGatewayUnavailable, err)
+	}
+	if !result.Approved {
+		return payment, fmt.Errorf("%w: %s", domain.ErrPaymentDeclined, result.DeclineCode)
+	}
+
+	payment.Status = domain.PaymentStatusCaptured
+	payment.CapturedAmount = amount
+	payment.FailureReason = ""
+	entry, err := u.ledger.CaptureEntry(payment, result.Fee)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.paymentRepo.Update(payment, domain.PaymentStatusAuthorized, entry); err != nil {
+		return nil, err
+	}
 	return payment, nil
 }
 
+// VoidPayment releases an authorization that has not been captured.
+func (u *paymentUsecase) VoidPayment(id string) (*domain.Payment, error) {
+	payment, err := u.GetPayment(id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.PaymentStatusAuthorized {
+		return nil, domain.ErrInvalidPaymentState
+	}
+
+	result, err := u.gateway.Void(context.Background(), payment.GatewayReference)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrGatewayUnavailable, err)
+	}
+	if !result.Approved {
+		return payment, domain.ErrPaymentDeclined
+	}
+
+	payment.Status = domain.PaymentStatusVoided
+	entry, err := u.ledger.VoidEntry(payment)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.paymentRepo.Update(payment, domain.PaymentStatusAuthorized, entry); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// ReleaseOrderPayments voids the order's authorized payments and refunds its
+// captured ones once the order is cancelled or expires.
+func (u *paymentUsecase) ReleaseOrderPayments(orderID, reason string) error {
+	page, err := u.paginator.Prepare("", 0, "", nil)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range []string{domain.PaymentStatusAuthorized, domain.PaymentStatusCaptured, domain.PaymentStatusPartiallyRefunded} {
+		payments, err := u.paymentRepo.List(domain.PaymentFilter{OrderID: orderID, Status: status, Page: page})
+		if err != nil {
+			return err
+		}
+
+		for _, payment := range payments {
+			if status == domain.PaymentStatusAuthorized {
+				_, err = u.VoidPayment(payment.ID)
+			} else {
+				_, err = u.RefundPayment(payment.ID, 0, reason)
+			}
+			if err != nil && !errors.Is(err, domain.ErrInvalidPaymentState) && !errors.Is(err, domain.ErrRefundExceedsCaptured) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RefundPayment refunds part or all of a captured payment. An amount of zero
+// refunds whatever has not been refunded yet. The refund is reserved before
+// the gateway is called, so the total can never exceed the captured amount.
+func (u *paymentUsecase) RefundPayment(paymentID string, amount float64, reason string) (*domain.Refund, error) {
+	if paymentID == "" {
+		return nil, errors.New("paymentID is required")
+	}
+	if amount < 0 {
+		return nil, errors.New("refund amount must not be negative")
+	}
+
+	payment, err := u.GetPayment(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &domain.Refund{
+		PaymentID: payment.ID,
+		Amount:    amount,
+		Reason:    reason,
+	}
+	if err := u.refundRepo.CreatePending(refund); err != nil {
+		return nil, err
+	}
+
+	result, gatewayErr := u.gateway.Refund(context.Background(), payment.GatewayReference, refund.Amount)
+	switch {
+	case gatewayErr != nil:
+		refund.Status = domain.RefundStatusFailed
+		refund.FailureReason = gatewayErr.Error()
+	case !result.Approved:
+		refund.Status = domain.RefundStatusFailed
+		refund.FailureReason = result.DeclineCode
+	default:
+		refund.Status = domain.RefundStatusSucceeded
+		refund.GatewayReference = result.Reference
+	}
+
+	entry, err := u.ledger.RefundEntry(payment, refund)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.refundRepo.Complete(refund, entry); err != nil {
+		return nil, err
+	}
+	if gatewayErr != nil {
+		return refund, fmt.Errorf("%w: %v", domain.ErrGatewayUnavailable, gatewayErr)
+	}
+	if refund.Status == domain.RefundStatusFailed {
+		return refund, domain.ErrPaymentDeclined
+	}
+	return refund, nil
+}
+
+// orderClosed reports whether the order was cancelled or expired. If the
+// order service cannot be reached it fails with ErrOrderStatusUnknown so the
+// capture is refused rather than charging for an order that may be closed.
+func (u *paymentUsecase) orderClosed(orderID string) (bool, error) {
+	if u.orderGRPCClient == nil {
+		return false, nil
+	}
+	order, err := u.orderGRPCClient.GetOrder(context.Background(), &orderpb.GetOrderRequest{Id: orderID})
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", domain.ErrOrderStatusUnknown, err)
+	}
+	return order.Status == orderStatusCancelled || order.Status == orderStatusExpired, nil
+}
+
+func (u *paymentUsecase) ListRefunds(paymentID string) ([]*domain.Refund, error) {
+	if paymentID == "" {
+		return nil, errors.New("paymentID is required")
+	}
+	return u.refundRepo.ListByPayment(paymentID)
+}
+
 func (u *paymentUsecase) GetPayment(id string) (*domain.Payment, error) {
 	if id == "" {
 		return nil, errors.New("id is required")
@@ -70,3 +375,32 @@ func (u *paymentUsecase) GetPayment(id string) (*domain.Payment, error) {
 
 	return payment, nil
 }
+
+func (u *paymentUsecase) ListPayments(req domain.ListPaymentsRequest) (*domain.PaymentPage, error) {
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && req.CreatedAfter.After(*req.CreatedBefore) {
+		return nil, errors.New("created_after must be before created_before")
+	}
+
+	filter := domain.PaymentFilter{
+		OrderID:       req.OrderID,
+		UserID:        req.UserID,
+		Status:        req.Status,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+	}
+	page, err := u.paginator.Prepare(req.OrderBy, req.PageSize, req.PageToken, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.Page = page
+	payments, err := u.paymentRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, next := pagination.Trim(u.paginator, page, payments, func(p *domain.Payment) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+	})
+	return &domain.PaymentPage{Payments: payments, NextPageToken: next}, nil
+}
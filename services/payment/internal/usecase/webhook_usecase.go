@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/fx"
+	"github.com/edwinjordan/golang_microservices/pkg/webhook"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+)
+
+type webhookUsecase struct {
+	eventRepo   domain.WebhookEventRepository
+	paymentRepo domain.PaymentRepository
+	ledger      domain.LedgerUsecase
+	secrets     map[string]string
+	tolerance   time.Duration
+}
+
+// NewWebhookUsecase accepts webhooks from the providers in secrets, keyed by
+// provider name. Signatures older or newer than tolerance are rejected.
+func NewWebhookUsecase(eventRepo domain.WebhookEventRepository, paymentRepo domain.PaymentRepository, ledger domain.LedgerUsecase, secrets map[string]string, tolerance time.Duration) domain.WebhookUsecase {
+	return &webhookUsecase{
+		eventRepo:   eventRepo,
+		paymentRepo: paymentRepo,
+		ledger:      ledger,
+		secrets:     secrets,
+		tolerance:   tolerance,
+	}
+}
+
+// webhookPayload is the provider-neutral notification body.
+type webhookPayload struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Reference string  `json:"reference"`
+		Amount    float64 `json:"amount"`
+		Reason    string  `json:"reason"`
+	} `json:"data"`
+}
+
+// ignoredError marks events that are valid but have nothing to apply.
+type ignoredError struct{ reason string }
+
+func (e ignoredError) Error() string { return e.reason }
+
+func (u *webhookUsecase) HandleWebhook(provider string, payload []byte, signature string) (*domain.WebhookEvent, error) {
+	secret, ok := u.secrets[provider]
+	if !ok || secret == "" {
+		return nil, domain.ErrUnknownWebhookProvider
+	}
+	if err := webhook.Verify(secret, payload, signature, u.tolerance, time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidWebhookSignature, err)
+	}
+
+	var body webhookPayload
+	if err := json.Unmarshal(payload, &body); err != nil || body.ID == "" || body.Type == "" {
+		return nil, domain.ErrMalformedWebhook
+	}
+
+	event := &domain.WebhookEvent{
+		Provider:         provider,
+		EventID:          body.ID,
+		Type:             body.Type,
+		GatewayReference: body.Data.Reference,
+		Amount:           body.Data.Amount,
+		Reason:           body.Data.Reason,
+		Payload:          payload,
+	}
+	err := u.eventRepo.Create(event)
+	if errors.Is(err, domain.ErrDuplicateWebhook) {
+		existing, err := u.eventRepo.GetByEventID(provider, body.ID)
+		if err != nil {
+			return nil, err
+		}
+		// Only retry events that never finished or failed to apply, and
+		// only if no concurrent delivery has claimed them.
+		if existing.Status == domain.WebhookStatusApplied || existing.Status == domain.WebhookStatusIgnored {
+			return existing, domain.ErrDuplicateWebhook
+		}
+		if err := u.eventRepo.Claim(existing, domain.WebhookStatusReceived, domain.WebhookStatusFailed); err != nil {
+			return existing, err
+		}
+		event = existing
+	} else if err != nil {
+		return nil, err
+	}
+
+	return u.process(event)
+}
+
+func (u *webhookUsecase) Replay(id string) (*domain.WebhookEvent, error) {
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+
+	event, err := u.eventRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.eventRepo.Claim(event, domain.WebhookStatusReceived, domain.WebhookStatusApplied,
+		domain.WebhookStatusIgnored, domain.WebhookStatusFailed); err != nil {
+		return event, err
+	}
+	return u.process(event)
+}
+
+func (u *webhookUsecase) ListFailed(limit int) ([]*domain.WebhookEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return u.eventRepo.ListByStatus(domain.WebhookStatusFailed, limit)
+}
+
+// process applies an event the caller has claimed and records the outcome.
+// A failed event is returned together with the error that caused it.
+func (u *webhookUsecase) process(event *domain.WebhookEvent) (*domain.WebhookEvent, error) {
+	applyErr := u.apply(event)
+
+	var ignored ignoredError
+	switch {
+	case applyErr == nil:
+		event.Status = domain.WebhookStatusApplied
+		event.Error = ""
+	case errors.As(applyErr, &ignored):
+		event.Status = domain.WebhookStatusIgnored
+		event.Error = ignored.reason
+		applyErr = nil
+	default:
+		event.Status = domain.WebhookStatusFailed
+		event.Error = applyErr.Error()
+	}
+
+	if err := u.eventRepo.MarkProcessed(event); err != nil {
+		return nil, err
+	}
+	return event, applyErr
+}
+
+func (u *webhookUsecase) apply(event *domain.WebhookEvent) error {
+	switch event.Type {
+	case domain.WebhookEventSettled, domain.WebhookEventFailed, domain.WebhookEventChargeback:
+	default:
+		return ignoredError{reason: fmt.Sprintf("unsupported event type %q", event.Type)}
+	}
+
+	payment, err := u.paymentRepo.GetByGatewayReference(event.GatewayReference)
+	if err != nil {
+		return err
+	}
+
+	switch event.Type {
+	case domain.WebhookEventSettled:
+		return u.applySettled(payment)
+	case domain.WebhookEventFailed:
+		return u.applyFailed(payment, event)
+	default:
+		return u.applyChargeback(payment, event)
+	}
+}
+
+func (u *webhookUsecase) applySettled(payment *domain.Payment) error {
+	switch payment.Status {
+	case domain.PaymentStatusCaptured, domain.PaymentStatusPartiallyRefunded,
+		domain.PaymentStatusRefunded, domain.PaymentStatusChargedBack:
+	default:
+		return domain.ErrInvalidPaymentState
+	}
+	if payment.SettledAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	payment.SettledAt = &now
+	return u.paymentRepo.Update(payment, payment.Status)
+}
+
+// applyFailed handles an authorization the gateway later reports as failed.
+func (u *webhookUsecase) applyFailed(payment *domain.Payment, event *domain.WebhookEvent) error {
+	switch payment.Status {
+	case domain.PaymentStatusFailed:
+		return nil
+	case domain.PaymentStatusPending, domain.PaymentStatusAuthorized:
+	default:
+		return domain.ErrInvalidPaymentState
+	}
+
+	from := payment.Status
+	payment.Status = domain.PaymentStatusFailed
+	payment.FailureReason = event.Reason
+	if payment.FailureReason == "" {
+		payment.FailureReason = "gateway_failure"
+	}
+	var entries []*domain.JournalEntry
+	if from == domain.PaymentStatusAuthorized {
+		entry, err := u.ledger.VoidEntry(payment)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	return u.paymentRepo.Update(payment, from, entries...)
+}
+
+func (u *webhookUsecase) applyChargeback(payment *domain.Payment, event *domain.WebhookEvent) error {
+	switch payment.Status {
+	case domain.PaymentStatusChargedBack:
+		return nil
+	case domain.PaymentStatusCaptured, domain.PaymentStatusPartiallyRefunded:
+	default:
+		return domain.ErrInvalidPaymentState
+	}
+
+	// A chargeback can only take back what the customer still has with us.
+	remaining := payment.CapturedAmount - payment.RefundedAmount
+	amount := event.Amount
+	if amount <= 0 {
+		amount = remaining
+	}
+	if fx.ToMinor(amount, payment.Currency) > fx.ToMinor(remaining, payment.Currency) {
+		return fmt.Errorf("%w: %v of %v %s", domain.ErrChargebackTooLarge, amount, remaining, payment.Currency)
+	}
+
+	from := payment.Status
+	payment.Status = domain.PaymentStatusChargedBack
+	payment.FailureReason = event.Reason
+	entry, err := u.ledger.ChargebackEntry(payment, amount)
+	if err != nil {
+		return err
+	}
+	return u.paymentRepo.Update(payment, from, entry)
+}
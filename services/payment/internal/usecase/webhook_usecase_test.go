@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/webhook"
+	"github.com/edwinjordan/golang_microservices/services/payment/internal/domain"
+)
+
+// memoryWebhookEvents keeps events in memory with the same claim rules as
+// the Postgres repository.
+type memoryWebhookEvents struct {
+	events map[string]*domain.WebhookEvent
+}
+
+func (r *memoryWebhookEvents) Create(event *domain.WebhookEvent) error {
+	for _, e := range r.events {
+		if e.Provider == event.Provider && e.EventID == event.EventID {
+			return domain.ErrDuplicateWebhook
+		}
+	}
+	now := time.Now()
+	event.ID = event.Provider + ":" + event.EventID
+	event.Status = domain.WebhookStatusProcessing
+	event.ReceivedAt = now
+	event.ClaimedAt = &now
+	stored := *event
+	r.events[event.ID] = &stored
+	return nil
+}
+
+func (r *memoryWebhookEvents) GetByID(id string) (*domain.WebhookEvent, error) {
+	e, ok := r.events[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	event := *e
+	return &event, nil
+}
+
+func (r *memoryWebhookEvents) GetByEventID(provider, eventID string) (*domain.WebhookEvent, error) {
+	return r.GetByID(provider + ":" + eventID)
+}
+
+func (r *memoryWebhookEvents) Claim(event *domain.WebhookEvent, from ...string) error {
+	stored := r.events[event.ID]
+	now := time.Now()
+	claimable := stored.Status == domain.WebhookStatusProcessing && stored.ClaimedAt.Before(now.Add(-domain.WebhookClaimTimeout))
+	for _, status := range from {
+		claimable = claimable || stored.Status == status
+	}
+	if !claimable {
+		return domain.ErrWebhookInProgress
+	}
+	stored.Status, stored.ClaimedAt = domain.WebhookStatusProcessing, &now
+	event.Status, event.ClaimedAt = stored.Status, stored.ClaimedAt
+	return nil
+}
+
+func (r *memoryWebhookEvents) MarkProcessed(event *domain.WebhookEvent) error {
+	event.Attempts++
+	stored := *event
+	r.events[event.ID] = &stored
+	return nil
+}
+
+func (r *memoryWebhookEvents) ListByStatus(status string, limit int) ([]*domain.WebhookEvent, error) {
+	return nil, nil
+}
+
+// memoryPayments holds one payment and counts the updates applied to it.
+type memoryPayments struct {
+	domain.PaymentRepository
+	payment *domain.Payment
+	updates int
+}
+
+func (r *memoryPayments) GetByGatewayReference(reference string) (*domain.Payment, error) {
+	if r.payment == nil || r.payment.GatewayReference != reference {
+		return nil, domain.ErrPaymentNotFound
+	}
+	payment := *r.payment
+	return &payment, nil
+}
+
+func (r *memoryPayments) Update(payment *domain.Payment, from string, entries ...*domain.JournalEntry) error {
+	if r.payment.Status != from {
+		return domain.ErrPaymentConflict
+	}
+	stored := *payment
+	r.payment = &stored
+	r.updates++
+	return nil
+}
+
+func newTestWebhooks(payment *domain.Payment) (domain.WebhookUsecase, *memoryWebhookEvents, *memoryPayments) {
+	events := &memoryWebhookEvents{events: map[string]*domain.WebhookEvent{}}
+	payments := &memoryPayments{payment: payment}
+	u := NewWebhookUsecase(events, payments, NewLedgerUsecase(nil, "USD"), map[string]string{"fake": "secret"}, 5*time.Minute)
+	return u, events, payments
+}
+
+func capturedPayment() *domain.Payment {
+	return &domain.Payment{
+		ID:               "pay_1",
+		Amount:           100,
+		Currency:         "USD",
+		CapturedAmount:   100,
+		RefundedAmount:   30,
+		Status:           domain.PaymentStatusPartiallyRefunded,
+		GatewayReference: "ref_1",
+	}
+}
+
+// deliver sends a signed provider notification to HandleWebhook.
+func deliver(u domain.WebhookUsecase, id, eventType string, amount float64) (*domain.WebhookEvent, error) {
+	body := map[string]any{"id": id, "type": eventType, "data": map[string]any{"reference": "ref_1", "amount": amount}}
+	payload, _ := json.Marshal(body)
+	return u.HandleWebhook("fake", payload, webhook.Sign("secret", payload, time.Now()))
+}
+
+func TestHandleWebhookDuplicateDelivery(t *testing.T) {
+	u, _, payments := newTestWebhooks(capturedPayment())
+
+	event, err := deliver(u, "evt_1", domain.WebhookEventChargeback, 0)
+	if err != nil || event.Status != domain.WebhookStatusApplied {
+		t.Fatalf("first delivery: status %v, err %v; want applied", event, err)
+	}
+	event, err = deliver(u, "evt_1", domain.WebhookEventChargeback, 0)
+	if !errors.Is(err, domain.ErrDuplicateWebhook) || event.Status != domain.WebhookStatusApplied {
+		t.Errorf("second delivery: status %v, err %v; want the applied event and ErrDuplicateWebhook", event, err)
+	}
+	if payments.updates != 1 || payments.payment.Status != domain.PaymentStatusChargedBack {
+		t.Errorf("payment updated %d times to %s, want once to charged_back", payments.updates, payments.payment.Status)
+	}
+}
+
+func TestHandleWebhookConcurrentDelivery(t *testing.T) {
+	u, events, payments := newTestWebhooks(capturedPayment())
+	// The first delivery has stored and claimed the event and is still
+	// applying it.
+	first := &domain.WebhookEvent{Provider: "fake", EventID: "evt_1", Type: domain.WebhookEventSettled, GatewayReference: "ref_1"}
+	if err := events.Create(first); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := deliver(u, "evt_1", domain.WebhookEventSettled, 0); !errors.Is(err, domain.ErrWebhookInProgress) {
+		t.Errorf("delivery while claimed: err %v, want ErrWebhookInProgress", err)
+	}
+	if _, err := u.Replay(first.ID); !errors.Is(err, domain.ErrWebhookInProgress) {
+		t.Errorf("replay while claimed: err %v, want ErrWebhookInProgress", err)
+	}
+	if payments.updates != 0 {
+		t.Errorf("payment updated %d times while the event was claimed", payments.updates)
+	}
+
+	// A claim left behind by a request that died is taken over.
+	expired := time.Now().Add(-domain.WebhookClaimTimeout - time.Second)
+	events.events[first.ID].ClaimedAt = &expired
+	event, err := deliver(u, "evt_1", domain.WebhookEventSettled, 0)
+	if err != nil || event.Status != domain.WebhookStatusApplied || payments.updates != 1 {
+		t.Errorf("delivery after the claim expired: status %v, err %v, %d updates; want applied once", event, err, payments.updates)
+	}
+}
+
+func TestHandleWebhookRetriesFailedEvents(t *testing.T) {
+	u, _, payments := newTestWebhooks(nil)
+
+	// The event arrives before the payment is recorded.
+	event, err := deliver(u, "evt_1", domain.WebhookEventSettled, 0)
+	if err == nil || event.Status != domain.WebhookStatusFailed {
+		t.Fatalf("delivery before the payment exists: status %v, err %v; want failed", event, err)
+	}
+
+	payments.payment = capturedPayment()
+	event, err = deliver(u, "evt_1", domain.WebhookEventSettled, 0)
+	if err != nil || event.Status != domain.WebhookStatusApplied || event.Attempts != 2 {
+		t.Errorf("redelivery: status %v, err %v; want applied on the second attempt", event, err)
+	}
+	if payments.payment.SettledAt == nil {
+		t.Error("redelivery did not settle the payment")
+	}
+}
+
+func TestHandleWebhookChargebackAmount(t *testing.T) {
+	cases := []struct {
+		amount float64
+		ok     bool
+	}{
+		{0, true},
+		{70, true},
+		{25, true},
+		{70.01, false},
+		{100, false},
+	}
+
+	for _, tc := range cases {
+		u, _, payments := newTestWebhooks(capturedPayment())
+		event, err := deliver(u, "evt_1", domain.WebhookEventChargeback, tc.amount)
+		if tc.ok {
+			if err != nil || payments.payment.Status != domain.PaymentStatusChargedBack {
+				t.Errorf("chargeback of %v: err %v, payment %s; want charged_back", tc.amount, err, payments.payment.Status)
+			}
+			continue
+		}
+		if !errors.Is(err, domain.ErrChargebackTooLarge) || event.Status != domain.WebhookStatusFailed || payments.updates != 0 {
+			t.Errorf("chargeback of %v: status %s, err %v, %d updates; want failed with ErrChargebackTooLarge", tc.amount, event.Status, err, payments.updates)
+		}
+	}
+}
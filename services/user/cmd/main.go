@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net"
 	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus"
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
+	"github.com/edwinjordan/golang_microservices/pkg/restproxy"
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
 	"github.com/edwinjordan/golang_microservices/services/user/internal/config"
+	eventsHandler "github.com/edwinjordan/golang_microservices/services/user/internal/delivery/events"
 	grpcHandler "github.com/edwinjordan/golang_microservices/services/user/internal/delivery/grpc"
 	httpHandler "github.com/edwinjordan/golang_microservices/services/user/internal/delivery/http"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
 	"github.com/edwinjordan/golang_microservices/services/user/internal/repository"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/security"
 	"github.com/edwinjordan/golang_microservices/services/user/internal/usecase"
 	pb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
 	"github.com/gin-gonic/gin"
@@ -46,9 +56,48 @@ func main() {
 	// Initialize database schema
 	initSchema(db)
 
+	secretCipher, err := security.NewSecretCipher(cfg.TOTPEncryptionKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize TOTP cipher: %v", err)
+	}
+
+	tokenSigner, err := security.NewTokenSigner(cfg.TokenSigningKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize token signer: %v", err)
+	}
+
 	// Initialize layers
 	userRepo := repository.NewPostgresUserRepository(db)
-	userUsecase := usecase.NewUserUsecase(userRepo)
+	twoFactorRepo := repository.NewPostgresTwoFactorRepository(db)
+	sessionRepo := repository.NewPostgresSessionRepository(db)
+	addressRepo := repository.NewPostgresAddressRepository(db)
+	preferencesRepo := repository.NewPostgresNotificationPreferencesRepository(db)
+	twoFactorUsecase := usecase.NewTwoFactorUsecase(userRepo, twoFactorRepo, secretCipher, cfg.TOTPIssuer)
+	sessionUsecase := usecase.NewSessionUsecase(sessionRepo, userRepo, tokenSigner, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	userUsecase := usecase.NewUserUsecase(userRepo, twoFactorUsecase, sessionUsecase, pagination.NewCodec(cfg.PageTokenSecret))
+	addressUsecase := usecase.NewAddressUsecase(addressRepo)
+	preferencesUsecase := usecase.NewNotificationPreferencesUsecase(preferencesRepo)
+
+	// Connect to event bus
+	bus, err := eventbus.Open(context.Background(), eventbus.Config{
+		Driver: cfg.EventBusDriver,
+		URL:    cfg.EventBusURL,
+		Stream: cfg.EventBusStream,
+		Name:   eventsHandler.Source,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to event bus: %v", err)
+	}
+	defer bus.Close()
+
+	// Start outbox relay
+	publisher := eventbus.OutboxPublisher(bus, eventsHandler.Source, eventsHandler.Translate)
+	relay := outbox.NewRelay(db, publisher, outbox.RelayConfig{
+		PollInterval: cfg.OutboxPollInterval,
+		BatchSize:    cfg.OutboxBatchSize,
+		MaxAttempts:  cfg.OutboxMaxAttempts,
+	})
+	go relay.Run(context.Background())
 
 	// Start gRPC server
 	go func() {
@@ -57,8 +106,11 @@ func main() {
 			log.Fatalf("Failed to listen on gRPC port: %v", err)
 		}
 
-		grpcServer := grpc.NewServer()
-		userGRPCHandler := grpcHandler.NewUserGRPCHandler(userUsecase)
+		grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+			grpcHandler.UnaryAuthInterceptor(sessionUsecase),
+			validate.UnaryServerInterceptor(),
+		))
+		userGRPCHandler := grpcHandler.NewUserGRPCHandler(userUsecase, twoFactorUsecase, sessionUsecase, addressUsecase, preferencesUsecase)
 		pb.RegisterUserServiceServer(grpcServer, userGRPCHandler)
 
 		log.Printf("gRPC server listening on port %s", cfg.GRPCPort)
@@ -69,11 +121,19 @@ func main() {
 
 	// Start HTTP server
 	router := gin.Default()
-	userHandler := httpHandler.NewUserHandler(userUsecase)
+	registerRoutes(router, userUsecase, twoFactorUsecase, sessionUsecase, addressUsecase, preferencesUsecase)
 
-	router.GET("/health", userHandler.Health)
-	router.POST("/users", userHandler.CreateUser)
-	router.GET("/users/:id", userHandler.GetUser)
+	// REST routes generated from the HTTP rules in proto/user.proto, proxied
+	// to the gRPC server
+	restProxy, err := restproxy.New(context.Background(), "localhost:"+cfg.GRPCPort, pb.RegisterUserServiceHandlerFromEndpoint)
+	if err != nil {
+		log.Fatalf("Failed to start REST proxy: %v", err)
+	}
+	router.Any(restproxy.Prefix+"/*path", gin.WrapH(restProxy))
+
+	if err := openapi.Mount(router, httpHandler.API(), restproxy.Prefix); err != nil {
+		log.Fatalf("Failed to serve OpenAPI document: %v", err)
+	}
 
 	log.Printf("HTTP server listening on port %s", cfg.HTTPPort)
 	if err := router.Run(fmt.Sprintf(":%s", cfg.HTTPPort)); err != nil {
@@ -81,6 +141,46 @@ func main() {
 	}
 }
 
+// registerRoutes registers the hand-written HTTP routes. main adds the
+// generated REST routes and the OpenAPI document.
+func registerRoutes(router *gin.Engine, userUsecase domain.UserUsecase, twoFactorUsecase domain.TwoFactorUsecase, sessionUsecase domain.SessionUsecase, addressUsecase domain.AddressUsecase, preferencesUsecase domain.NotificationPreferencesUsecase) {
+	userHandler := httpHandler.NewUserHandler(userUsecase, twoFactorUsecase, sessionUsecase)
+	addressHandler := httpHandler.NewAddressHandler(addressUsecase)
+	preferencesHandler := httpHandler.NewNotificationPreferencesHandler(preferencesUsecase)
+
+	router.GET("/health", userHandler.Health)
+	router.POST("/users", userHandler.CreateUser)
+	router.GET("/users/:id", userHandler.GetUser)
+	router.POST("/login", userHandler.Login)
+	router.POST("/token/refresh", userHandler.RefreshToken)
+
+	authorized := router.Group("/", httpHandler.RequireAuth(sessionUsecase))
+	authorized.POST("/logout", userHandler.Logout)
+	authorized.GET("/sessions", userHandler.ListSessions)
+	authorized.DELETE("/sessions", userHandler.RevokeAllSessions)
+	authorized.DELETE("/sessions/:session_id", userHandler.RevokeSession)
+
+	admin := authorized.Group("/", httpHandler.RequireRole(domain.RoleAdmin))
+	admin.GET("/users", userHandler.ListUsers)
+	admin.POST("/users/:id/disable", userHandler.DisableUser)
+	admin.PUT("/users/:id/role", userHandler.SetUserRole)
+
+	// Routes acting on the caller's own account
+	account := authorized.Group("/users/:id", httpHandler.RequireSelf())
+	account.POST("/2fa/totp", userHandler.EnrollTOTP)
+	account.POST("/2fa/totp/confirm", userHandler.ConfirmTOTP)
+	account.DELETE("/2fa/totp", userHandler.DisableTOTP)
+	account.POST("/2fa/recovery-codes", userHandler.RegenerateRecoveryCodes)
+	account.POST("/addresses", addressHandler.CreateAddress)
+	account.GET("/addresses", addressHandler.ListAddresses)
+	account.GET("/addresses/:address_id", addressHandler.GetAddress)
+	account.PUT("/addresses/:address_id", addressHandler.UpdateAddress)
+	account.DELETE("/addresses/:address_id", addressHandler.DeleteAddress)
+	account.POST("/addresses/:address_id/default", addressHandler.SetDefaultAddress)
+	account.GET("/notification-preferences", preferencesHandler.GetNotificationPreferences)
+	account.PUT("/notification-preferences", preferencesHandler.UpdateNotificationPreferences)
+}
+
 func initSchema(db *sql.DB) {
 	schema := `
 	CREATE TABLE IF NOT EXISTS users (
@@ -90,8 +190,94 @@ func initSchema(db *sql.DB) {
 		created_at TIMESTAMP NOT NULL,
 		updated_at TIMESTAMP NOT NULL
 	);
+
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS password_hash VARCHAR(255) NOT NULL DEFAULT '';
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'customer';
+
+	CREATE INDEX IF NOT EXISTS idx_users_created_at ON users (created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_users_name ON users (name, id);
+
+	CREATE TABLE IF NOT EXISTS user_totp (
+		user_id VARCHAR(36) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		encrypted_secret BYTEA NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT FALSE,
+		last_used_step BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL,
+		confirmed_at TIMESTAMP
+	);
+
+	ALTER TABLE user_totp ADD COLUMN IF NOT EXISTS failed_attempts INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE user_totp ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+
+	CREATE TABLE IF NOT EXISTS user_recovery_codes (
+		id VARCHAR(36) PRIMARY KEY,
+		user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		code_hash VARCHAR(64) NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
+
+	CREATE TABLE IF NOT EXISTS sessions (
+		id VARCHAR(36) PRIMARY KEY,
+		user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		user_agent VARCHAR(512) NOT NULL DEFAULT '',
+		ip_address VARCHAR(64) NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		last_seen_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP,
+		revoked_reason VARCHAR(64) NOT NULL DEFAULT ''
+	);
+
+	ALTER TABLE sessions ADD COLUMN IF NOT EXISTS two_factor BOOLEAN NOT NULL DEFAULT FALSE;
+
+	CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+	CREATE TABLE IF NOT EXISTS refresh_tokens (
+		token_hash VARCHAR(64) PRIMARY KEY,
+		session_id VARCHAR(36) NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens (session_id);
+
+	CREATE TABLE IF NOT EXISTS addresses (
+		id VARCHAR(36) PRIMARY KEY,
+		user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		label VARCHAR(64) NOT NULL DEFAULT '',
+		recipient_name VARCHAR(255) NOT NULL,
+		line1 VARCHAR(255) NOT NULL,
+		line2 VARCHAR(255) NOT NULL DEFAULT '',
+		city VARCHAR(128) NOT NULL,
+		region VARCHAR(128) NOT NULL DEFAULT '',
+		postal_code VARCHAR(32) NOT NULL,
+		country VARCHAR(2) NOT NULL,
+		phone VARCHAR(32) NOT NULL DEFAULT '',
+		is_default BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_addresses_user_id ON addresses (user_id, created_at, id);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_addresses_user_default ON addresses (user_id) WHERE is_default;
+
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id VARCHAR(36) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		locale VARCHAR(16) NOT NULL,
+		email_enabled BOOLEAN NOT NULL,
+		sms_enabled BOOLEAN NOT NULL,
+		phone VARCHAR(32) NOT NULL DEFAULT '',
+		order_updates BOOLEAN NOT NULL,
+		payment_updates BOOLEAN NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
 	`
-	_, err := db.Exec(schema)
+	_, err := db.Exec(schema + outbox.Schema)
 	if err != nil {
 		log.Fatalf("Failed to initialize schema: %v", err)
 	}
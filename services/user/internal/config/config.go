@@ -3,27 +3,57 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	HTTPPort   string
-	GRPCPort   string
+	DBHost            string
+	DBPort            string
+	DBUser            string
+	DBPassword        string
+	DBName            string
+	HTTPPort          string
+	GRPCPort          string
+	TOTPEncryptionKey string
+	TOTPIssuer        string
+	TokenSigningKey   string
+	AccessTokenTTL    time.Duration
+	RefreshTokenTTL   time.Duration
+	PageTokenSecret   string
+
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+	OutboxMaxAttempts  int
+
+	EventBusDriver string
+	EventBusURL    string
+	EventBusStream string
 }
 
 func LoadConfig() *Config {
 	return &Config{
-		DBHost:     getEnv("USER_DB_HOST", "localhost"),
-		DBPort:     getEnv("USER_DB_PORT", "5432"),
-		DBUser:     getEnv("USER_DB_USER", "userservice"),
-		DBPassword: getEnv("USER_DB_PASSWORD", "userpass123"),
-		DBName:     getEnv("USER_DB_NAME", "users_db"),
-		HTTPPort:   getEnv("USER_SERVICE_HTTP_PORT", "8081"),
-		GRPCPort:   getEnv("USER_SERVICE_GRPC_PORT", "9091"),
+		DBHost:            getEnv("USER_DB_HOST", "localhost"),
+		DBPort:            getEnv("USER_DB_PORT", "5432"),
+		DBUser:            getEnv("USER_DB_USER", "userservice"),
+		DBPassword:        getEnv("USER_DB_PASSWORD", "userpass123"),
+		DBName:            getEnv("USER_DB_NAME", "users_db"),
+		HTTPPort:          getEnv("USER_SERVICE_HTTP_PORT", "8081"),
+		GRPCPort:          getEnv("USER_SERVICE_GRPC_PORT", "9091"),
+		TOTPEncryptionKey: getEnv("USER_TOTP_ENCRYPTION_KEY", "change-me-totp-key"),
+		TOTPIssuer:        getEnv("USER_TOTP_ISSUER", "golang_microservices"),
+		TokenSigningKey:   getEnv("USER_TOKEN_SIGNING_KEY", "change-me-token-key"),
+		AccessTokenTTL:    getEnvDuration("USER_ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:   getEnvDuration("USER_REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		PageTokenSecret:   getEnv("USER_PAGE_TOKEN_SECRET", "change-me-page-token-secret"),
+
+		OutboxPollInterval: getEnvDuration("USER_OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxBatchSize:    getEnvInt("USER_OUTBOX_BATCH_SIZE", 100),
+		OutboxMaxAttempts:  getEnvInt("USER_OUTBOX_MAX_ATTEMPTS", 10),
+
+		EventBusDriver: getEnv("EVENT_BUS_DRIVER", "memory"),
+		EventBusURL:    getEnv("EVENT_BUS_URL", "nats://localhost:4222"),
+		EventBusStream: getEnv("EVENT_BUS_STREAM", "EVENTS"),
 	}
 }
 
@@ -38,3 +68,21 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
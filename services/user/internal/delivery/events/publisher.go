@@ -0,0 +1,45 @@
+// Package events connects the user service to the event bus.
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/edwinjordan/golang_microservices/pkg/eventbus/eventspb"
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Source names the user service on published envelopes.
+const Source = "user-service"
+
+// Translate converts a user outbox event into its bus payload.
+func Translate(event outbox.Event) (proto.Message, error) {
+	switch event.Type {
+	case domain.EventUserCreated, domain.EventUserUpdated:
+		var user domain.User
+		if err := json.Unmarshal(event.Payload, &user); err != nil {
+			return nil, err
+		}
+		if event.Type == domain.EventUserCreated {
+			return &eventspb.UserCreated{User: toPBUser(&user)}, nil
+		}
+		return &eventspb.UserUpdated{User: toPBUser(&user)}, nil
+	case domain.EventUserDeleted:
+		return &eventspb.UserDeleted{UserId: event.AggregateID}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func toPBUser(user *domain.User) *eventspb.User {
+	return &eventspb.User{
+		Id:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Disabled:  user.Disabled(),
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}
+}
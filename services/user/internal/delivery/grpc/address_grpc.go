@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *UserGRPCHandler) CreateAddress(ctx context.Context, req *pb.CreateAddressRequest) (*pb.Address, error) {
+	address, err := h.addressUsecase.CreateAddress(req.UserId, toAddressInput(req.Address))
+	if err != nil {
+		return nil, addressError(err)
+	}
+	return toPBAddress(address), nil
+}
+
+func (h *UserGRPCHandler) GetAddress(ctx context.Context, req *pb.GetAddressRequest) (*pb.Address, error) {
+	address, err := h.addressUsecase.GetAddress(req.UserId, req.Id)
+	if err != nil {
+		return nil, addressError(err)
+	}
+	return toPBAddress(address), nil
+}
+
+func (h *UserGRPCHandler) GetDefaultAddress(ctx context.Context, req *pb.GetDefaultAddressRequest) (*pb.Address, error) {
+	address, err := h.addressUsecase.GetDefaultAddress(req.UserId)
+	if err != nil {
+		return nil, addressError(err)
+	}
+	return toPBAddress(address), nil
+}
+
+func (h *UserGRPCHandler) ListAddresses(ctx context.Context, req *pb.ListAddressesRequest) (*pb.ListAddressesResponse, error) {
+	addresses, err := h.addressUsecase.ListAddresses(req.UserId)
+	if err != nil {
+		return nil, addressError(err)
+	}
+
+	resp := &pb.ListAddressesResponse{Addresses: make([]*pb.Address, 0, len(addresses))}
+	for _, address := range addresses {
+		resp.Addresses = append(resp.Addresses, toPBAddress(address))
+	}
+	return resp, nil
+}
+
+func (h *UserGRPCHandler) UpdateAddress(ctx context.Context, req *pb.UpdateAddressRequest) (*pb.Address, error) {
+	address, err := h.addressUsecase.UpdateAddress(req.UserId, req.Id, toAddressInput(req.Address))
+	if err != nil {
+		return nil, addressError(err)
+	}
+	return toPBAddress(address), nil
+}
+
+func (h *UserGRPCHandler) DeleteAddress(ctx context.Context, req *pb.DeleteAddressRequest) (*pb.DeleteAddressResponse, error) {
+	if err := h.addressUsecase.DeleteAddress(req.UserId, req.Id); err != nil {
+		return nil, addressError(err)
+	}
+	return &pb.DeleteAddressResponse{}, nil
+}
+
+func (h *UserGRPCHandler) SetDefaultAddress(ctx context.Context, req *pb.SetDefaultAddressRequest) (*pb.Address, error) {
+	address, err := h.addressUsecase.SetDefaultAddress(req.UserId, req.Id)
+	if err != nil {
+		return nil, addressError(err)
+	}
+	return toPBAddress(address), nil
+}
+
+func toAddressInput(input *pb.AddressInput) domain.AddressInput {
+	return domain.AddressInput{
+		Label:         input.GetLabel(),
+		RecipientName: input.GetRecipientName(),
+		Line1:         input.GetLine1(),
+		Line2:         input.GetLine2(),
+		City:          input.GetCity(),
+		Region:        input.GetRegion(),
+		PostalCode:    input.GetPostalCode(),
+		Country:       input.GetCountry(),
+		Phone:         input.GetPhone(),
+		IsDefault:     input.GetIsDefault(),
+	}
+}
+
+func toPBAddress(address *domain.Address) *pb.Address {
+	return &pb.Address{
+		Id:            address.ID,
+		UserId:        address.UserID,
+		Label:         address.Label,
+		RecipientName: address.RecipientName,
+		Line1:         address.Line1,
+		Line2:         address.Line2,
+		City:          address.City,
+		Region:        address.Region,
+		PostalCode:    address.PostalCode,
+		Country:       address.Country,
+		Phone:         address.Phone,
+		IsDefault:     address.IsDefault,
+		CreatedAt:     timestamppb.New(address.CreatedAt),
+		UpdatedAt:     timestamppb.New(address.UpdatedAt),
+	}
+}
+
+// addressError maps address book failures to gRPC codes so the order
+// service can tell an unknown address from an outage.
+func addressError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrAddressNotFound),
+		errors.Is(err, domain.ErrNoDefaultAddress),
+		errors.Is(err, domain.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrInvalidAddress):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrTooManyAddresses):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
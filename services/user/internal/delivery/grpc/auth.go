@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/edwinjordan/golang_microservices/pkg/restproxy"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// policy decides whether an authenticated caller may make a request.
+type policy func(principal *domain.Principal, req any) error
+
+// policies lists the methods that need a bearer access token in the
+// "authorization" metadata, which the REST proxy fills from the
+// Authorization header. Other methods are left to the services calling them.
+var policies = map[string]policy{
+	pb.UserService_EnrollTOTP_FullMethodName:                    self,
+	pb.UserService_ConfirmTOTP_FullMethodName:                   self,
+	pb.UserService_DisableTOTP_FullMethodName:                   self,
+	pb.UserService_RegenerateRecoveryCodes_FullMethodName:       self,
+	pb.UserService_ListSessions_FullMethodName:                  self,
+	pb.UserService_RevokeSession_FullMethodName:                 self,
+	pb.UserService_RevokeAllSessions_FullMethodName:             self,
+	pb.UserService_DisableUser_FullMethodName:                   role(domain.RoleAdmin),
+	pb.UserService_SetUserRole_FullMethodName:                   role(domain.RoleAdmin),
+	pb.UserService_ListUsers_FullMethodName:                     role(domain.RoleAdmin),
+	pb.UserService_CreateAddress_FullMethodName:                 self,
+	pb.UserService_ListAddresses_FullMethodName:                 self,
+	pb.UserService_UpdateAddress_FullMethodName:                 self,
+	pb.UserService_DeleteAddress_FullMethodName:                 self,
+	pb.UserService_SetDefaultAddress_FullMethodName:             self,
+	pb.UserService_UpdateNotificationPreferences_FullMethodName: self,
+}
+
+// proxiedPolicies lists methods other services call without a token, such
+// as the order service reading shipping addresses or the notification
+// service reading preferences, but that outside clients reaching them
+// through the REST proxy may only call with one.
+var proxiedPolicies = map[string]policy{
+	pb.UserService_GetAddress_FullMethodName:                 self,
+	pb.UserService_GetDefaultAddress_FullMethodName:          self,
+	pb.UserService_GetNotificationPreferences_FullMethodName: self,
+}
+
+// UnaryAuthInterceptor authenticates and authorizes calls to the methods in
+// policies, and proxied calls to those in proxiedPolicies.
+func UnaryAuthInterceptor(sessionUsecase domain.SessionUsecase) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		authorize, ok := policies[info.FullMethod]
+		if !ok && restproxy.Proxied(ctx) {
+			authorize, ok = proxiedPolicies[info.FullMethod]
+		}
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		principal, err := authenticate(ctx, sessionUsecase)
+		if err != nil {
+			return nil, err
+		}
+		if err := authorize(principal, req); err != nil {
+			return nil, authError(err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authenticate(ctx context.Context, sessionUsecase domain.SessionUsecase) (*domain.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			continue
+		}
+		principal, err := sessionUsecase.Authenticate(token)
+		if err != nil {
+			return nil, authError(err)
+		}
+		return principal, nil
+	}
+	return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+}
+
+// self allows callers acting on their own account, the request's user_id.
+func self(principal *domain.Principal, req any) error {
+	owned, ok := req.(interface{ GetUserId() string })
+	if !ok || owned.GetUserId() != principal.UserID {
+		return domain.ErrPermissionDenied
+	}
+	return nil
+}
+
+// role allows callers holding r.
+func role(r domain.Role) policy {
+	return func(principal *domain.Principal, req any) error {
+		if !principal.HasRole(r) {
+			return domain.ErrPermissionDenied
+		}
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *UserGRPCHandler) GetNotificationPreferences(ctx context.Context, req *pb.GetNotificationPreferencesRequest) (*pb.NotificationPreferences, error) {
+	preferences, err := h.preferencesUsecase.GetNotificationPreferences(req.UserId)
+	if err != nil {
+		return nil, notificationPreferencesError(err)
+	}
+	return toPBNotificationPreferences(preferences), nil
+}
+
+func (h *UserGRPCHandler) UpdateNotificationPreferences(ctx context.Context, req *pb.UpdateNotificationPreferencesRequest) (*pb.NotificationPreferences, error) {
+	preferences, err := h.preferencesUsecase.UpdateNotificationPreferences(req.UserId, domain.NotificationPreferencesInput{
+		Locale:         req.Locale,
+		EmailEnabled:   req.EmailEnabled,
+		SMSEnabled:     req.SmsEnabled,
+		Phone:          req.Phone,
+		OrderUpdates:   req.OrderUpdates,
+		PaymentUpdates: req.PaymentUpdates,
+	})
+	if err != nil {
+		return nil, notificationPreferencesError(err)
+	}
+	return toPBNotificationPreferences(preferences), nil
+}
+
+func toPBNotificationPreferences(preferences *domain.NotificationPreferences) *pb.NotificationPreferences {
+	resp := &pb.NotificationPreferences{
+		UserId:         preferences.UserID,
+		Locale:         preferences.Locale,
+		EmailEnabled:   preferences.EmailEnabled,
+		SmsEnabled:     preferences.SMSEnabled,
+		Phone:          preferences.Phone,
+		OrderUpdates:   preferences.OrderUpdates,
+		PaymentUpdates: preferences.PaymentUpdates,
+	}
+	if !preferences.UpdatedAt.IsZero() {
+		resp.UpdatedAt = timestamppb.New(preferences.UpdatedAt)
+	}
+	return resp
+}
+
+func notificationPreferencesError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrInvalidNotificationPreferences):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
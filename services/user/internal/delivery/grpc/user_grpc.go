@@ -2,26 +2,38 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
 	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
 	pb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type UserGRPCHandler struct {
 	pb.UnimplementedUserServiceServer
-	userUsecase domain.UserUsecase
+	userUsecase        domain.UserUsecase
+	twoFactorUsecase   domain.TwoFactorUsecase
+	sessionUsecase     domain.SessionUsecase
+	addressUsecase     domain.AddressUsecase
+	preferencesUsecase domain.NotificationPreferencesUsecase
 }
 
-func NewUserGRPCHandler(userUsecase domain.UserUsecase) *UserGRPCHandler {
+func NewUserGRPCHandler(userUsecase domain.UserUsecase, twoFactorUsecase domain.TwoFactorUsecase, sessionUsecase domain.SessionUsecase, addressUsecase domain.AddressUsecase, preferencesUsecase domain.NotificationPreferencesUsecase) *UserGRPCHandler {
 	return &UserGRPCHandler{
-		userUsecase: userUsecase,
+		userUsecase:        userUsecase,
+		twoFactorUsecase:   twoFactorUsecase,
+		sessionUsecase:     sessionUsecase,
+		addressUsecase:     addressUsecase,
+		preferencesUsecase: preferencesUsecase,
 	}
 }
 
 func (h *UserGRPCHandler) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
 	user, err := h.userUsecase.GetUser(req.Id)
 	if err != nil {
-		return nil, err
+		return nil, userError(err)
 	}
 
 	return &pb.GetUserResponse{
@@ -32,7 +44,7 @@ func (h *UserGRPCHandler) GetUser(ctx context.Context, req *pb.GetUserRequest) (
 }
 
 func (h *UserGRPCHandler) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
-	user, err := h.userUsecase.CreateUser(req.Name, req.Email)
+	user, err := h.userUsecase.CreateUser(req.Name, req.Email, req.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -55,3 +67,218 @@ func (h *UserGRPCHandler) ValidateUser(ctx context.Context, req *pb.ValidateUser
 		Name:  name,
 	}, nil
 }
+
+func (h *UserGRPCHandler) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	user, twoFactor, err := h.userUsecase.Login(req.Email, req.Password, req.Code)
+	if err != nil {
+		return nil, authError(err)
+	}
+
+	tokens, err := h.sessionUsecase.StartSession(user.ID, req.UserAgent, req.IpAddress, twoFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.LoginResponse{
+		Id:     user.ID,
+		Name:   user.Name,
+		Email:  user.Email,
+		Tokens: toPBTokenPair(tokens),
+	}, nil
+}
+
+func (h *UserGRPCHandler) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	enrollment, err := h.twoFactorUsecase.EnrollTOTP(req.UserId, req.Password)
+	if err != nil {
+		return nil, authError(err)
+	}
+
+	return &pb.EnrollTOTPResponse{
+		Secret:     enrollment.Secret,
+		OtpauthUrl: enrollment.OTPAuthURL,
+	}, nil
+}
+
+func (h *UserGRPCHandler) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	codes, err := h.twoFactorUsecase.ConfirmTOTP(req.UserId, req.Code)
+	if err != nil {
+		return nil, authError(err)
+	}
+
+	return &pb.ConfirmTOTPResponse{RecoveryCodes: codes}, nil
+}
+
+func (h *UserGRPCHandler) DisableTOTP(ctx context.Context, req *pb.DisableTOTPRequest) (*pb.DisableTOTPResponse, error) {
+	if err := h.twoFactorUsecase.DisableTOTP(req.UserId, req.Password, req.Code); err != nil {
+		return nil, authError(err)
+	}
+
+	return &pb.DisableTOTPResponse{}, nil
+}
+
+func (h *UserGRPCHandler) RegenerateRecoveryCodes(ctx context.Context, req *pb.RegenerateRecoveryCodesRequest) (*pb.RegenerateRecoveryCodesResponse, error) {
+	codes, err := h.twoFactorUsecase.RegenerateRecoveryCodes(req.UserId, req.Code)
+	if err != nil {
+		return nil, authError(err)
+	}
+
+	return &pb.RegenerateRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (h *UserGRPCHandler) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	tokens, err := h.sessionUsecase.Refresh(req.RefreshToken)
+	if err != nil {
+		return nil, authError(err)
+	}
+
+	return &pb.RefreshTokenResponse{Tokens: toPBTokenPair(tokens)}, nil
+}
+
+func (h *UserGRPCHandler) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	principal, err := h.sessionUsecase.Authenticate(req.AccessToken)
+	if err != nil {
+		if isAuthError(err) {
+			return &pb.ValidateTokenResponse{Valid: false}, nil
+		}
+		return nil, err
+	}
+
+	resp := &pb.ValidateTokenResponse{
+		Valid:     true,
+		UserId:    principal.UserID,
+		SessionId: principal.SessionID,
+		ExpiresAt: timestamppb.New(principal.ExpiresAt),
+	}
+	if principal.HasRole(principal.Role) {
+		resp.Roles = []string{string(principal.Role)}
+	}
+	return resp, nil
+}
+
+func (h *UserGRPCHandler) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	sessions, err := h.sessionUsecase.ListSessions(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListSessionsResponse{Sessions: make([]*pb.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, &pb.Session{
+			Id:         session.ID,
+			UserId:     session.UserID,
+			UserAgent:  session.UserAgent,
+			IpAddress:  session.IPAddress,
+			CreatedAt:  timestamppb.New(session.CreatedAt),
+			LastSeenAt: timestamppb.New(session.LastSeenAt),
+			ExpiresAt:  timestamppb.New(session.ExpiresAt),
+		})
+	}
+	return resp, nil
+}
+
+func (h *UserGRPCHandler) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	if err := h.sessionUsecase.RevokeSession(req.UserId, req.SessionId); err != nil {
+		return nil, userError(err)
+	}
+
+	return &pb.RevokeSessionResponse{}, nil
+}
+
+func (h *UserGRPCHandler) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeAllSessionsResponse, error) {
+	if err := h.sessionUsecase.RevokeAllSessions(req.UserId); err != nil {
+		return nil, err
+	}
+
+	return &pb.RevokeAllSessionsResponse{}, nil
+}
+
+func (h *UserGRPCHandler) DisableUser(ctx context.Context, req *pb.DisableUserRequest) (*pb.DisableUserResponse, error) {
+	if err := h.userUsecase.DisableUser(req.Id); err != nil {
+		return nil, userError(err)
+	}
+
+	return &pb.DisableUserResponse{}, nil
+}
+
+func (h *UserGRPCHandler) SetUserRole(ctx context.Context, req *pb.SetUserRoleRequest) (*pb.SetUserRoleResponse, error) {
+	err := h.userUsecase.SetUserRole(req.Id, domain.Role(req.Role))
+	switch {
+	case errors.Is(err, domain.ErrInvalidRole):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrTwoFactorMandatory):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, userError(err)
+	}
+
+	return &pb.SetUserRoleResponse{}, nil
+}
+
+func (h *UserGRPCHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	page, err := h.userUsecase.ListUsers(domain.ListUsersRequest{
+		Email:     req.Email,
+		OrderBy:   req.OrderBy,
+		PageSize:  int(req.PageSize),
+		PageToken: req.PageToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListUsersResponse{
+		Users:         make([]*pb.User, 0, len(page.Users)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, user := range page.Users {
+		resp.Users = append(resp.Users, &pb.User{
+			Id:        user.ID,
+			Name:      user.Name,
+			Email:     user.Email,
+			CreatedAt: timestamppb.New(user.CreatedAt),
+		})
+	}
+	return resp, nil
+}
+
+func toPBTokenPair(tokens *domain.TokenPair) *pb.TokenPair {
+	return &pb.TokenPair{
+		SessionId:             tokens.SessionID,
+		AccessToken:           tokens.AccessToken,
+		AccessTokenExpiresAt:  timestamppb.New(tokens.AccessTokenExpiresAt),
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(tokens.RefreshTokenExpiresAt),
+	}
+}
+
+// userError maps missing users and sessions to NotFound.
+func userError(err error) error {
+	if errors.Is(err, domain.ErrUserNotFound) || errors.Is(err, domain.ErrSessionNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return err
+}
+
+// authError maps credential failures to Unauthenticated, refusals to
+// PermissionDenied and two-factor lockouts to ResourceExhausted, so callers
+// can tell them apart from infrastructure errors.
+func authError(err error) error {
+	switch {
+	case isAuthError(err):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, domain.ErrPermissionDenied), errors.Is(err, domain.ErrTwoFactorMandatory):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrTwoFactorLocked):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return err
+}
+
+func isAuthError(err error) bool {
+	return errors.Is(err, domain.ErrInvalidCredentials) ||
+		errors.Is(err, domain.ErrTOTPRequired) ||
+		errors.Is(err, domain.ErrInvalidTOTPCode) ||
+		errors.Is(err, domain.ErrInvalidToken) ||
+		errors.Is(err, domain.ErrSessionRevoked) ||
+		errors.Is(err, domain.ErrRefreshTokenReused) ||
+		errors.Is(err, domain.ErrUserDisabled)
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"github.com/gin-gonic/gin"
+)
+
+type AddressHandler struct {
+	addressUsecase domain.AddressUsecase
+}
+
+func NewAddressHandler(addressUsecase domain.AddressUsecase) *AddressHandler {
+	return &AddressHandler{addressUsecase: addressUsecase}
+}
+
+type AddressRequest struct {
+	Label         string `json:"label"`
+	RecipientName string `json:"recipient_name"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2"`
+	City          string `json:"city"`
+	Region        string `json:"region"`
+	PostalCode    string `json:"postal_code"`
+	Country       string `json:"country"`
+	Phone         string `json:"phone"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+type AddressResponse struct {
+	ID            string    `json:"id"`
+	Label         string    `json:"label"`
+	RecipientName string    `json:"recipient_name"`
+	Line1         string    `json:"line1"`
+	Line2         string    `json:"line2"`
+	City          string    `json:"city"`
+	Region        string    `json:"region"`
+	PostalCode    string    `json:"postal_code"`
+	Country       string    `json:"country"`
+	Phone         string    `json:"phone"`
+	IsDefault     bool      `json:"is_default"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type ListAddressesResponse struct {
+	Addresses []AddressResponse `json:"addresses"`
+}
+
+func (h *AddressHandler) CreateAddress(c *gin.Context) {
+	var req AddressRequest
+	if err := validate.BindJSON(c, &req, &pb.AddressInput{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	address, err := h.addressUsecase.CreateAddress(c.Param("id"), req.input())
+	if err != nil {
+		c.JSON(addressStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toAddressResponse(address))
+}
+
+func (h *AddressHandler) ListAddresses(c *gin.Context) {
+	addresses, err := h.addressUsecase.ListAddresses(c.Param("id"))
+	if err != nil {
+		c.JSON(addressStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := make([]AddressResponse, 0, len(addresses))
+	for _, address := range addresses {
+		resp = append(resp, toAddressResponse(address))
+	}
+
+	c.JSON(http.StatusOK, ListAddressesResponse{Addresses: resp})
+}
+
+func (h *AddressHandler) GetAddress(c *gin.Context) {
+	address, err := h.addressUsecase.GetAddress(c.Param("id"), c.Param("address_id"))
+	if err != nil {
+		c.JSON(addressStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toAddressResponse(address))
+}
+
+func (h *AddressHandler) UpdateAddress(c *gin.Context) {
+	var req AddressRequest
+	if err := validate.BindJSON(c, &req, &pb.AddressInput{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	address, err := h.addressUsecase.UpdateAddress(c.Param("id"), c.Param("address_id"), req.input())
+	if err != nil {
+		c.JSON(addressStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toAddressResponse(address))
+}
+
+func (h *AddressHandler) DeleteAddress(c *gin.Context) {
+	if err := h.addressUsecase.DeleteAddress(c.Param("id"), c.Param("address_id")); err != nil {
+		c.JSON(addressStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AddressHandler) SetDefaultAddress(c *gin.Context) {
+	address, err := h.addressUsecase.SetDefaultAddress(c.Param("id"), c.Param("address_id"))
+	if err != nil {
+		c.JSON(addressStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toAddressResponse(address))
+}
+
+func (r AddressRequest) input() domain.AddressInput {
+	return domain.AddressInput{
+		Label:         r.Label,
+		RecipientName: r.RecipientName,
+		Line1:         r.Line1,
+		Line2:         r.Line2,
+		City:          r.City,
+		Region:        r.Region,
+		PostalCode:    r.PostalCode,
+		Country:       r.Country,
+		Phone:         r.Phone,
+		IsDefault:     r.IsDefault,
+	}
+}
+
+func toAddressResponse(address *domain.Address) AddressResponse {
+	return AddressResponse{
+		ID:            address.ID,
+		Label:         address.Label,
+		RecipientName: address.RecipientName,
+		Line1:         address.Line1,
+		Line2:         address.Line2,
+		City:          address.City,
+		Region:        address.Region,
+		PostalCode:    address.PostalCode,
+		Country:       address.Country,
+		Phone:         address.Phone,
+		IsDefault:     address.IsDefault,
+		CreatedAt:     address.CreatedAt,
+		UpdatedAt:     address.UpdatedAt,
+	}
+}
+
+func addressStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrAddressNotFound), errors.Is(err, domain.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrTooManyAddresses):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidAddress):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// RequireAuth rejects requests without a valid, unrevoked bearer token and
+// stores the resolved principal on the context.
+func RequireAuth(sessionUsecase domain.SessionUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		principal, err := sessionUsecase.Authenticate(token)
+		if err != nil {
+			c.AbortWithStatusJSON(authStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// RequireSelf lets callers act only on their own account, the :id in the
+// path. It must run after RequireAuth.
+func RequireSelf() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if currentPrincipal(c).UserID != c.Param("id") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": domain.ErrPermissionDenied.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole lets through callers holding role. It must run after
+// RequireAuth.
+func RequireRole(role domain.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !currentPrincipal(c).HasRole(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": domain.ErrPermissionDenied.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+func currentPrincipal(c *gin.Context) *domain.Principal {
+	principal, _ := c.MustGet(principalKey).(*domain.Principal)
+	return principal
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationPreferencesHandler struct {
+	preferencesUsecase domain.NotificationPreferencesUsecase
+}
+
+func NewNotificationPreferencesHandler(preferencesUsecase domain.NotificationPreferencesUsecase) *NotificationPreferencesHandler {
+	return &NotificationPreferencesHandler{preferencesUsecase: preferencesUsecase}
+}
+
+// NotificationPreferencesRequest replaces all of a user's preferences.
+type NotificationPreferencesRequest struct {
+	Locale         string `json:"locale"`
+	EmailEnabled   bool   `json:"email_enabled"`
+	SMSEnabled     bool   `json:"sms_enabled"`
+	Phone          string `json:"phone"`
+	OrderUpdates   bool   `json:"order_updates"`
+	PaymentUpdates bool   `json:"payment_updates"`
+}
+
+type NotificationPreferencesResponse struct {
+	UserID         string     `json:"user_id"`
+	Locale         string     `json:"locale"`
+	EmailEnabled   bool       `json:"email_enabled"`
+	SMSEnabled     bool       `json:"sms_enabled"`
+	Phone          string     `json:"phone"`
+	OrderUpdates   bool       `json:"order_updates"`
+	PaymentUpdates bool       `json:"payment_updates"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// GetNotificationPreferences returns the user's preferences, or the defaults
+// if they have not set any.
+func (h *NotificationPreferencesHandler) GetNotificationPreferences(c *gin.Context) {
+	preferences, err := h.preferencesUsecase.GetNotificationPreferences(c.Param("id"))
+	if err != nil {
+		c.JSON(notificationPreferencesStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toNotificationPreferencesResponse(preferences))
+}
+
+func (h *NotificationPreferencesHandler) UpdateNotificationPreferences(c *gin.Context) {
+	var req NotificationPreferencesRequest
+	if err := validate.BindJSON(c, &req, &pb.UpdateNotificationPreferencesRequest{UserId: c.Param("id")}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	preferences, err := h.preferencesUsecase.UpdateNotificationPreferences(c.Param("id"), domain.NotificationPreferencesInput{
+		Locale:         req.Locale,
+		EmailEnabled:   req.EmailEnabled,
+		SMSEnabled:     req.SMSEnabled,
+		Phone:          req.Phone,
+		OrderUpdates:   req.OrderUpdates,
+		PaymentUpdates: req.PaymentUpdates,
+	})
+	if err != nil {
+		c.JSON(notificationPreferencesStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toNotificationPreferencesResponse(preferences))
+}
+
+func toNotificationPreferencesResponse(preferences *domain.NotificationPreferences) NotificationPreferencesResponse {
+	resp := NotificationPreferencesResponse{
+		UserID:         preferences.UserID,
+		Locale:         preferences.Locale,
+		EmailEnabled:   preferences.EmailEnabled,
+		SMSEnabled:     preferences.SMSEnabled,
+		Phone:          preferences.Phone,
+		OrderUpdates:   preferences.OrderUpdates,
+		PaymentUpdates: preferences.PaymentUpdates,
+	}
+	if !preferences.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &preferences.UpdatedAt
+	}
+	return resp
+}
+
+func notificationPreferencesStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidNotificationPreferences):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/edwinjordan/golang_microservices/pkg/openapi"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+)
+
+// API documents the user service's HTTP routes. The service will not start
+// if a route is missing from it.
+func API() openapi.API {
+	return openapi.API{
+		Title:       "User Service",
+		Version:     "1.0",
+		Description: "Accounts, sessions, two-factor authentication, addresses and notification preferences.",
+		Operations: []openapi.Operation{
+			{Method: http.MethodGet, Path: "/health", Tag: "health", Summary: "Health check", Response: openapi.HealthResponse{}},
+
+			{Method: http.MethodPost, Path: "/users", Tag: "users", Summary: "Create a user", Body: CreateUserRequest{}, Rules: &pb.CreateUserRequest{}, Status: http.StatusCreated, Response: UserResponse{}},
+			{Method: http.MethodGet, Path: "/users", Tag: "users", Summary: "List users (admins only)", Query: ListUsersQuery{}, Response: ListUsersResponse{}, Auth: true},
+			{Method: http.MethodGet, Path: "/users/:id", Tag: "users", Summary: "Get a user", Response: UserResponse{}},
+			{Method: http.MethodPost, Path: "/users/:id/disable", Tag: "users", Summary: "Disable a user and revoke their sessions (admins only)", Status: http.StatusNoContent, Auth: true},
+			{Method: http.MethodPut, Path: "/users/:id/role", Tag: "users", Summary: "Set a user's role; finance and admin need two-factor enabled (admins only)", Body: SetUserRoleRequest{}, Rules: &pb.SetUserRoleRequest{}, Status: http.StatusNoContent, Auth: true},
+
+			{Method: http.MethodPost, Path: "/login", Tag: "sessions", Summary: "Log in", Body: LoginRequest{}, Rules: &pb.LoginRequest{}, Response: LoginResponse{}},
+			{Method: http.MethodPost, Path: "/token/refresh", Tag: "sessions", Summary: "Exchange a refresh token for new tokens", Body: RefreshTokenRequest{}, Rules: &pb.RefreshTokenRequest{}, Response: domain.TokenPair{}},
+			{Method: http.MethodPost, Path: "/logout", Tag: "sessions", Summary: "Revoke the current session", Status: http.StatusNoContent, Auth: true},
+			{Method: http.MethodGet, Path: "/sessions", Tag: "sessions", Summary: "List the caller's sessions", Response: ListSessionsResponse{}, Auth: true},
+			{Method: http.MethodDelete, Path: "/sessions", Tag: "sessions", Summary: "Revoke all the caller's sessions", Status: http.StatusNoContent, Auth: true},
+			{Method: http.MethodDelete, Path: "/sessions/:session_id", Tag: "sessions", Summary: "Revoke one of the caller's sessions", Status: http.StatusNoContent, Auth: true},
+
+			{Method: http.MethodPost, Path: "/users/:id/2fa/totp", Tag: "two-factor", Summary: "Start TOTP enrollment", Body: EnrollTOTPRequest{}, Rules: &pb.EnrollTOTPRequest{}, Status: http.StatusCreated, Response: TOTPEnrollmentResponse{}, Auth: true},
+			{Method: http.MethodPost, Path: "/users/:id/2fa/totp/confirm", Tag: "two-factor", Summary: "Confirm TOTP enrollment", Body: TOTPCodeRequest{}, Rules: &pb.ConfirmTOTPRequest{}, Response: RecoveryCodesResponse{}, Auth: true},
+			{Method: http.MethodDelete, Path: "/users/:id/2fa/totp", Tag: "two-factor", Summary: "Disable TOTP", Body: DisableTOTPRequest{}, Rules: &pb.DisableTOTPRequest{}, Status: http.StatusNoContent, Auth: true},
+			{Method: http.MethodPost, Path: "/users/:id/2fa/recovery-codes", Tag: "two-factor", Summary: "Regenerate recovery codes", Body: TOTPCodeRequest{}, Rules: &pb.RegenerateRecoveryCodesRequest{}, Response: RecoveryCodesResponse{}, Auth: true},
+
+			{Method: http.MethodPost, Path: "/users/:id/addresses", Tag: "addresses", Summary: "Add an address", Body: AddressRequest{}, Rules: &pb.AddressInput{}, Status: http.StatusCreated, Response: AddressResponse{}, Auth: true},
+			{Method: http.MethodGet, Path: "/users/:id/addresses", Tag: "addresses", Summary: "List a user's addresses", Response: ListAddressesResponse{}, Auth: true},
+			{Method: http.MethodGet, Path: "/users/:id/addresses/:address_id", Tag: "addresses", Summary: "Get an address", Response: AddressResponse{}, Auth: true},
+			{Method: http.MethodPut, Path: "/users/:id/addresses/:address_id", Tag: "addresses", Summary: "Replace an address", Body: AddressRequest{}, Rules: &pb.AddressInput{}, Response: AddressResponse{}, Auth: true},
+			{Method: http.MethodDelete, Path: "/users/:id/addresses/:address_id", Tag: "addresses", Summary: "Delete an address", Status: http.StatusNoContent, Auth: true},
+			{Method: http.MethodPost, Path: "/users/:id/addresses/:address_id/default", Tag: "addresses", Summary: "Make an address the default", Response: AddressResponse{}, Auth: true},
+
+			{Method: http.MethodGet, Path: "/users/:id/notification-preferences", Tag: "notification preferences", Summary: "Get notification preferences", Response: NotificationPreferencesResponse{}, Auth: true},
+			{Method: http.MethodPut, Path: "/users/:id/notification-preferences", Tag: "notification preferences", Summary: "Replace notification preferences", Body: NotificationPreferencesRequest{}, Rules: &pb.UpdateNotificationPreferencesRequest{}, Response: NotificationPreferencesResponse{}, Auth: true},
+		},
+	}
+}
@@ -1,25 +1,101 @@
 package http
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/validate"
 	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	pb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
-	userUsecase domain.UserUsecase
+	userUsecase      domain.UserUsecase
+	twoFactorUsecase domain.TwoFactorUsecase
+	sessionUsecase   domain.SessionUsecase
 }
 
-func NewUserHandler(userUsecase domain.UserUsecase) *UserHandler {
+func NewUserHandler(userUsecase domain.UserUsecase, twoFactorUsecase domain.TwoFactorUsecase, sessionUsecase domain.SessionUsecase) *UserHandler {
 	return &UserHandler{
-		userUsecase: userUsecase,
+		userUsecase:      userUsecase,
+		twoFactorUsecase: twoFactorUsecase,
+		sessionUsecase:   sessionUsecase,
 	}
 }
 
 type CreateUserRequest struct {
-	Name  string `json:"name" binding:"required"`
-	Email string `json:"email" binding:"required"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type EnrollTOTPRequest struct {
+	Password string `json:"password"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type SetUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type ListUsersQuery struct {
+	Email     string `form:"email"`
+	OrderBy   string `form:"order_by"`
+	PageSize  int    `form:"page_size"`
+	PageToken string `form:"page_token"`
+}
+
+type ListUsersResponse struct {
+	Users         []UserResponse `json:"users"`
+	NextPageToken string         `json:"next_page_token,omitempty"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LoginResponse struct {
+	User   UserResponse      `json:"user"`
+	Tokens *domain.TokenPair `json:"tokens"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+type ListSessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
 }
 
 type UserResponse struct {
@@ -30,12 +106,12 @@ type UserResponse struct {
 
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var req CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := validate.BindJSON(c, &req, &pb.CreateUserRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
 		return
 	}
 
-	user, err := h.userUsecase.CreateUser(req.Name, req.Email)
+	user, err := h.userUsecase.CreateUser(req.Name, req.Email, req.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -64,9 +140,274 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 	})
 }
 
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	var query ListUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.userUsecase.ListUsers(domain.ListUsersRequest{
+		Email:     query.Email,
+		OrderBy:   query.OrderBy,
+		PageSize:  query.PageSize,
+		PageToken: query.PageToken,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := ListUsersResponse{
+		Users:         make([]UserResponse, 0, len(page.Users)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, user := range page.Users {
+		resp.Users = append(resp.Users, UserResponse{
+			ID:    user.ID,
+			Name:  user.Name,
+			Email: user.Email,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *UserHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := validate.BindJSON(c, &req, &pb.LoginRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	user, twoFactor, err := h.userUsecase.Login(req.Email, req.Password, req.Code)
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.sessionUsecase.StartSession(user.ID, c.Request.UserAgent(), c.ClientIP(), twoFactor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, LoginResponse{
+		User: UserResponse{
+			ID:    user.ID,
+			Name:  user.Name,
+			Email: user.Email,
+		},
+		Tokens: tokens,
+	})
+}
+
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := validate.BindJSON(c, &req, &pb.RefreshTokenRequest{}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	tokens, err := h.sessionUsecase.Refresh(req.RefreshToken)
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *UserHandler) Logout(c *gin.Context) {
+	principal := currentPrincipal(c)
+
+	if err := h.sessionUsecase.RevokeSession(principal.UserID, principal.SessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	principal := currentPrincipal(c)
+
+	sessions, err := h.sessionUsecase.ListSessions(principal.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == principal.SessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, ListSessionsResponse{Sessions: resp})
+}
+
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	principal := currentPrincipal(c)
+
+	if err := h.sessionUsecase.RevokeSession(principal.UserID, c.Param("session_id")); err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *UserHandler) RevokeAllSessions(c *gin.Context) {
+	principal := currentPrincipal(c)
+
+	if err := h.sessionUsecase.RevokeAllSessions(principal.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *UserHandler) DisableUser(c *gin.Context) {
+	if err := h.userUsecase.DisableUser(c.Param("id")); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *UserHandler) SetUserRole(c *gin.Context) {
+	var req SetUserRoleRequest
+	if err := validate.BindJSON(c, &req, &pb.SetUserRoleRequest{Id: c.Param("id")}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	err := h.userUsecase.SetUserRole(c.Param("id"), domain.Role(req.Role))
+	switch {
+	case errors.Is(err, domain.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, domain.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, domain.ErrTwoFactorMandatory):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *UserHandler) EnrollTOTP(c *gin.Context) {
+	var req EnrollTOTPRequest
+	if err := validate.BindJSON(c, &req, &pb.EnrollTOTPRequest{UserId: c.Param("id")}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	enrollment, err := h.twoFactorUsecase.EnrollTOTP(c.Param("id"), req.Password)
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, TOTPEnrollmentResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURL: enrollment.OTPAuthURL,
+	})
+}
+
+func (h *UserHandler) ConfirmTOTP(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := validate.BindJSON(c, &req, &pb.ConfirmTOTPRequest{UserId: c.Param("id")}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	codes, err := h.twoFactorUsecase.ConfirmTOTP(c.Param("id"), req.Code)
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *UserHandler) DisableTOTP(c *gin.Context) {
+	var req DisableTOTPRequest
+	if err := validate.BindJSON(c, &req, &pb.DisableTOTPRequest{UserId: c.Param("id")}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	if err := h.twoFactorUsecase.DisableTOTP(c.Param("id"), req.Password, req.Code); err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := validate.BindJSON(c, &req, &pb.RegenerateRecoveryCodesRequest{UserId: c.Param("id")}); err != nil {
+		c.JSON(http.StatusBadRequest, validate.ErrorBody(err))
+		return
+	}
+
+	codes, err := h.twoFactorUsecase.RegenerateRecoveryCodes(c.Param("id"), req.Code)
+	if err != nil {
+		c.JSON(authStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
 func (h *UserHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "ok",
 		"service": "user-service",
 	})
 }
+
+func authStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredentials),
+		errors.Is(err, domain.ErrTOTPRequired),
+		errors.Is(err, domain.ErrInvalidTOTPCode),
+		errors.Is(err, domain.ErrInvalidToken),
+		errors.Is(err, domain.ErrSessionRevoked),
+		errors.Is(err, domain.ErrRefreshTokenReused):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrUserDisabled),
+		errors.Is(err, domain.ErrPermissionDenied),
+		errors.Is(err, domain.ErrTwoFactorMandatory):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrTwoFactorLocked):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadRequest
+	}
+}
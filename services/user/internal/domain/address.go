@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrAddressNotFound  = errors.New("address not found")
+	ErrNoDefaultAddress = errors.New("user has no default address")
+	ErrInvalidAddress   = errors.New("invalid address")
+	ErrTooManyAddresses = errors.New("address book is full")
+)
+
+// MaxAddressesPerUser bounds a user's address book.
+const MaxAddressesPerUser = 20
+
+// Address is an entry in a user's address book. At most one address per user
+// is the default, which orders ship to when they do not name an address.
+type Address struct {
+	ID            string `json:"id" db:"id"`
+	UserID        string `json:"user_id" db:"user_id"`
+	Label         string `json:"label" db:"label"`
+	RecipientName string `json:"recipient_name" db:"recipient_name"`
+	Line1         string `json:"line1" db:"line1"`
+	Line2         string `json:"line2" db:"line2"`
+	City          string `json:"city" db:"city"`
+	Region        string `json:"region" db:"region"`
+	PostalCode    string `json:"postal_code" db:"postal_code"`
+	// Country is an ISO 3166-1 alpha-2 code.
+	Country   string    `json:"country" db:"country"`
+	Phone     string    `json:"phone" db:"phone"`
+	IsDefault bool      `json:"is_default" db:"is_default"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AddressInput holds the editable fields of an address.
+type AddressInput struct {
+	Label         string
+	RecipientName string
+	Line1         string
+	Line2         string
+	City          string
+	Region        string
+	PostalCode    string
+	Country       string
+	Phone         string
+	IsDefault     bool
+}
+
+type AddressRepository interface {
+	// Create stores the address. It becomes the default when it asks to or
+	// when it is the user's first address. It fails with ErrTooManyAddresses
+	// once the user has MaxAddressesPerUser addresses.
+	Create(address *Address) error
+	GetByID(userID, id string) (*Address, error)
+	GetDefault(userID string) (*Address, error)
+	ListByUser(userID string) ([]*Address, error)
+	Update(address *Address) error
+	// Delete removes the address; if it was the default, the user's oldest
+	// remaining address takes over.
+	Delete(userID, id string) error
+	SetDefault(userID, id string) error
+}
+
+type AddressUsecase interface {
+	CreateAddress(userID string, input AddressInput) (*Address, error)
+	GetAddress(userID, id string) (*Address, error)
+	GetDefaultAddress(userID string) (*Address, error)
+	ListAddresses(userID string) ([]*Address, error)
+	UpdateAddress(userID, id string, input AddressInput) (*Address, error)
+	DeleteAddress(userID, id string) error
+	SetDefaultAddress(userID, id string) (*Address, error)
+}
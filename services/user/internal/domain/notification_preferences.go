@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidNotificationPreferences = errors.New("invalid notification preferences")
+
+// DefaultLocale is the locale of users who have not chosen one.
+const DefaultLocale = "en"
+
+// NotificationPreferences says how a user wants to hear from us. Account
+// notifications (such as the welcome message) are sent on every enabled
+// channel; order and payment updates can be turned off on their own.
+type NotificationPreferences struct {
+	UserID string `json:"user_id"`
+	// Locale is a BCP 47 language tag such as "en" or "id-ID".
+	Locale       string `json:"locale"`
+	EmailEnabled bool   `json:"email_enabled"`
+	SMSEnabled   bool   `json:"sms_enabled"`
+	// Phone is the E.164 number SMS go to; required when SMS are enabled.
+	Phone          string    `json:"phone"`
+	OrderUpdates   bool      `json:"order_updates"`
+	PaymentUpdates bool      `json:"payment_updates"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// DefaultNotificationPreferences are the preferences of a user who has not
+// stored any: email only, in DefaultLocale, for everything.
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:         userID,
+		Locale:         DefaultLocale,
+		EmailEnabled:   true,
+		OrderUpdates:   true,
+		PaymentUpdates: true,
+	}
+}
+
+// NotificationPreferencesInput holds the editable fields of a user's
+// notification preferences.
+type NotificationPreferencesInput struct {
+	Locale         string
+	EmailEnabled   bool
+	SMSEnabled     bool
+	Phone          string
+	OrderUpdates   bool
+	PaymentUpdates bool
+}
+
+type NotificationPreferencesRepository interface {
+	// Get returns the user's stored preferences, or the defaults if they
+	// have none. It fails with ErrUserNotFound for unknown users.
+	Get(userID string) (*NotificationPreferences, error)
+	Save(preferences *NotificationPreferences) error
+}
+
+type NotificationPreferencesUsecase interface {
+	GetNotificationPreferences(userID string) (*NotificationPreferences, error)
+	UpdateNotificationPreferences(userID string, input NotificationPreferencesInput) (*NotificationPreferences, error)
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrSessionRevoked     = errors.New("session has been revoked")
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	ErrUserDisabled       = errors.New("user is disabled")
+	ErrSessionNotFound    = errors.New("session not found")
+)
+
+// Session is a logged-in device. Every refresh token issued for it belongs to
+// the same family, so reuse of an already rotated token revokes the session.
+type Session struct {
+	ID            string     `json:"id" db:"id"`
+	UserID        string     `json:"user_id" db:"user_id"`
+	UserAgent     string     `json:"user_agent" db:"user_agent"`
+	IPAddress     string     `json:"ip_address" db:"ip_address"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt    time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason string     `json:"revoked_reason,omitempty" db:"revoked_reason"`
+	// TwoFactor is set when the login that started the session verified a
+	// second factor.
+	TwoFactor bool `json:"two_factor" db:"two_factor"`
+}
+
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+type RefreshToken struct {
+	TokenHash string     `json:"-" db:"token_hash"`
+	SessionID string     `json:"session_id" db:"session_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+}
+
+type TokenPair struct {
+	SessionID             string    `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+// Principal is the authenticated caller behind an access token.
+type Principal struct {
+	UserID    string    `json:"user_id"`
+	SessionID string    `json:"session_id"`
+	Role      Role      `json:"role"`
+	TwoFactor bool      `json:"two_factor"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// HasRole reports whether the caller holds role. Roles that require two-factor
+// authentication only count in sessions that verified a second factor.
+func (p *Principal) HasRole(role Role) bool {
+	return p.Role == role && (p.TwoFactor || !role.RequiresTwoFactor())
+}
+
+type SessionRepository interface {
+	Create(session *Session) error
+	GetByID(id string) (*Session, error)
+	ListActiveByUser(userID string) ([]*Session, error)
+	Touch(id string) error
+	Revoke(id, reason string) error
+	RevokeAllForUser(userID, reason string) error
+	SaveRefreshToken(token *RefreshToken) error
+	UseRefreshToken(tokenHash string) (*RefreshToken, bool, error)
+}
+
+type SessionUsecase interface {
+	StartSession(userID, userAgent, ipAddress string, twoFactor bool) (*TokenPair, error)
+	Refresh(refreshToken string) (*TokenPair, error)
+	ListSessions(userID string) ([]*Session, error)
+	RevokeSession(userID, sessionID string) error
+	RevokeAllSessions(userID string) error
+	Authenticate(accessToken string) (*Principal, error)
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTwoFactorLocked is returned while a user is locked out after too many
+// invalid two-factor codes.
+var ErrTwoFactorLocked = errors.New("too many invalid two-factor codes, try again later")
+
+// Invalid codes tolerated before a user is locked out of two-factor
+// verification, and for how long.
+const (
+	MaxTwoFactorFailures = 5
+	TwoFactorLockout     = 15 * time.Minute
+)
+
+// TOTPSecret is a user's enrolled authenticator secret. The secret itself is
+// only ever persisted encrypted; Enabled flips once the user has proven they
+// can generate codes from it.
+type TOTPSecret struct {
+	UserID          string     `json:"user_id" db:"user_id"`
+	EncryptedSecret []byte     `json:"-" db:"encrypted_secret"`
+	Enabled         bool       `json:"enabled" db:"enabled"`
+	LastUsedStep    int64      `json:"-" db:"last_used_step"`
+	FailedAttempts  int        `json:"-" db:"failed_attempts"`
+	LockedUntil     *time.Time `json:"-" db:"locked_until"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	ConfirmedAt     *time.Time `json:"confirmed_at" db:"confirmed_at"`
+}
+
+// Locked reports whether verification is refused at now.
+func (s *TOTPSecret) Locked(now time.Time) bool {
+	return s.LockedUntil != nil && now.Before(*s.LockedUntil)
+}
+
+// TOTPEnrollment is returned when a user starts enrolling so the secret can be
+// loaded into an authenticator app.
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type TwoFactorRepository interface {
+	SaveSecret(secret *TOTPSecret) error
+	GetSecret(userID string) (*TOTPSecret, error)
+	Enable(userID string) error
+	MarkStepUsed(userID string, step int64) (bool, error)
+	// RecordFailure counts an invalid code. The MaxTwoFactorFailures-th in a
+	// row locks the user out until lockedUntil and starts the count again.
+	RecordFailure(userID string, lockedUntil time.Time) error
+	ResetFailures(userID string) error
+	Delete(userID string) error
+	ReplaceRecoveryCodes(userID string, codeHashes []string) error
+	ConsumeRecoveryCode(userID, codeHash string) (bool, error)
+}
+
+type TwoFactorUsecase interface {
+	EnrollTOTP(userID, password string) (*TOTPEnrollment, error)
+	ConfirmTOTP(userID, code string) ([]string, error)
+	// DisableTOTP fails with ErrTwoFactorMandatory for roles that require it.
+	DisableTOTP(userID, password, code string) error
+	RegenerateRecoveryCodes(userID, code string) ([]string, error)
+	IsEnabled(userID string) (bool, error)
+	VerifyCode(userID, code string) error
+}
@@ -1,24 +1,107 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrTOTPRequired       = errors.New("two-factor code required")
+	ErrInvalidTOTPCode    = errors.New("invalid two-factor code")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrTwoFactorMandatory = errors.New("two-factor authentication is mandatory for this role")
+	ErrInvalidRole        = errors.New("invalid role")
+)
+
+// Role is what a user may do beyond managing their own account.
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleFinance  Role = "finance"
+	RoleAdmin    Role = "admin"
+)
+
+// Valid reports whether r is one of the roles above.
+func (r Role) Valid() bool {
+	return r == RoleCustomer || r == RoleFinance || r == RoleAdmin
+}
+
+// RequiresTwoFactor reports whether the role's privileges are only granted
+// to sessions that logged in with a second factor.
+func (r Role) RequiresTwoFactor() bool {
+	return r == RoleFinance || r == RoleAdmin
+}
 
 type User struct {
-	ID        string    `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Email     string    `json:"email" db:"email"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID           string     `json:"id" db:"id"`
+	Name         string     `json:"name" db:"name"`
+	Email        string     `json:"email" db:"email"`
+	PasswordHash string     `json:"-" db:"password_hash"`
+	Role         Role       `json:"role" db:"role"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
+// Domain events written to the outbox by UserRepository.
+const (
+	AggregateUser    = "user"
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+)
+
+// Sort fields accepted by ListUsers.
+const (
+	UserSortCreatedAt = "created_at"
+	UserSortName      = "name"
+)
+
+type UserFilter struct {
+	Email string
+	Page  pagination.Page
+}
+
+type ListUsersRequest struct {
+	Email     string
+	OrderBy   string
+	PageSize  int
+	PageToken string
+}
+
+type UserPage struct {
+	Users         []*User `json:"users"`
+	NextPageToken string  `json:"next_page_token"`
 }
 
 type UserRepository interface {
 	Create(user *User) error
 	GetByID(id string) (*User, error)
+	GetByEmail(email string) (*User, error)
 	Update(user *User) error
 	Delete(id string) error
+	List(filter UserFilter) ([]*User, error)
 }
 
 type UserUsecase interface {
-	CreateUser(name, email string) (*User, error)
+	CreateUser(name, email, password string) (*User, error)
 	GetUser(id string) (*User, error)
 	ValidateUser(id string) (bool, string, error)
+	// Login reports whether a second factor was verified, which is the case
+	// for every login of a user who has enabled two-factor authentication.
+	Login(email, password, code string) (*User, bool, error)
+	DisableUser(id string) error
+	// SetUserRole fails with ErrTwoFactorMandatory when granting a role that
+	// requires two-factor authentication to a user who has not enabled it.
+	SetUserRole(id string, role Role) error
+	ListUsers(req ListUsersRequest) (*UserPage, error)
 }
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PostgresAddressRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAddressRepository(db *sql.DB) domain.AddressRepository {
+	return &PostgresAddressRepository{db: db}
+}
+
+const addressColumns = `id, user_id, label, recipient_name, line1, line2, city, region, postal_code, country, phone, is_default, created_at, updated_at`
+
+func (r *PostgresAddressRepository) Create(address *domain.Address) error {
+	address.ID = uuid.New().String()
+	address.CreatedAt = time.Now()
+	address.UpdatedAt = address.CreatedAt
+
+	return r.withTx(func(tx *sql.Tx) error {
+		// Serialize address book changes per user so the default stays
+		// unique, the first address reliably becomes it and the size limit
+		// holds.
+		if err := lockUser(tx, address.UserID); err != nil {
+			return err
+		}
+
+		var others int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM addresses WHERE user_id = $1`, address.UserID).Scan(&others); err != nil {
+			return err
+		}
+		if others >= domain.MaxAddressesPerUser {
+			return domain.ErrTooManyAddresses
+		}
+		if others == 0 {
+			address.IsDefault = true
+		}
+		if address.IsDefault {
+			if err := clearDefault(tx, address.UserID); err != nil {
+				return err
+			}
+		}
+
+		query := `INSERT INTO addresses (` + addressColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+		_, err := tx.Exec(query, address.ID, address.UserID, address.Label, address.RecipientName, address.Line1, address.Line2,
+			address.City, address.Region, address.PostalCode, address.Country, address.Phone, address.IsDefault,
+			address.CreatedAt, address.UpdatedAt)
+		return err
+	})
+}
+
+func (r *PostgresAddressRepository) GetByID(userID, id string) (*domain.Address, error) {
+	query := `SELECT ` + addressColumns + ` FROM addresses WHERE id = $1 AND user_id = $2`
+	address, err := scanAddress(r.db.QueryRow(query, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAddressNotFound
+	}
+	return address, err
+}
+
+func (r *PostgresAddressRepository) GetDefault(userID string) (*domain.Address, error) {
+	query := `SELECT ` + addressColumns + ` FROM addresses WHERE user_id = $1 AND is_default`
+	address, err := scanAddress(r.db.QueryRow(query, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNoDefaultAddress
+	}
+	return address, err
+}
+
+func (r *PostgresAddressRepository) ListByUser(userID string) ([]*domain.Address, error) {
+	query := `SELECT ` + addressColumns + ` FROM addresses WHERE user_id = $1 ORDER BY is_default DESC, created_at, id`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []*domain.Address
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
+
+// Update writes the editable fields. The default flag is changed through
+// SetDefault only.
+func (r *PostgresAddressRepository) Update(address *domain.Address) error {
+	address.UpdatedAt = time.Now()
+
+	query := `UPDATE addresses SET label = $1, recipient_name = $2, line1 = $3, line2 = $4, city = $5, region = $6,
+		postal_code = $7, country = $8, phone = $9, updated_at = $10
+		WHERE id = $11 AND user_id = $12`
+	res, err := r.db.Exec(query, address.Label, address.RecipientName, address.Line1, address.Line2, address.City,
+		address.Region, address.PostalCode, address.Country, address.Phone, address.UpdatedAt, address.ID, address.UserID)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, domain.ErrAddressNotFound)
+}
+
+func (r *PostgresAddressRepository) Delete(userID, id string) error {
+	return r.withTx(func(tx *sql.Tx) error {
+		if err := lockUser(tx, userID); err != nil {
+			return err
+		}
+
+		var wasDefault bool
+		err := tx.QueryRow(`DELETE FROM addresses WHERE id = $1 AND user_id = $2 RETURNING is_default`, id, userID).Scan(&wasDefault)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrAddressNotFound
+		}
+		if err != nil || !wasDefault {
+			return err
+		}
+
+		query := `UPDATE addresses SET is_default = TRUE, updated_at = $1
+			WHERE id = (SELECT id FROM addresses WHERE user_id = $2 ORDER BY created_at, id LIMIT 1)`
+		_, err = tx.Exec(query, time.Now(), userID)
+		return err
+	})
+}
+
+func (r *PostgresAddressRepository) SetDefault(userID, id string) error {
+	return r.withTx(func(tx *sql.Tx) error {
+		if err := lockUser(tx, userID); err != nil {
+			return err
+		}
+		if err := clearDefault(tx, userID); err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(`UPDATE addresses SET is_default = TRUE, updated_at = $1 WHERE id = $2 AND user_id = $3`, time.Now(), id, userID)
+		if err != nil {
+			return err
+		}
+		return requireRow(res, domain.ErrAddressNotFound)
+	})
+}
+
+// lockUser locks the user's row, which guards their whole address book.
+func lockUser(tx *sql.Tx, userID string) error {
+	var id string
+	err := tx.QueryRow(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrUserNotFound
+	}
+	return err
+}
+
+func clearDefault(tx *sql.Tx, userID string) error {
+	_, err := tx.Exec(`UPDATE addresses SET is_default = FALSE, updated_at = $1 WHERE user_id = $2 AND is_default`, time.Now(), userID)
+	return err
+}
+
+// requireRow returns notFound when res affected no rows.
+func requireRow(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func (r *PostgresAddressRepository) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanAddress(row rowScanner) (*domain.Address, error) {
+	address := &domain.Address{}
+	err := row.Scan(&address.ID, &address.UserID, &address.Label, &address.RecipientName, &address.Line1, &address.Line2,
+		&address.City, &address.Region, &address.PostalCode, &address.Country, &address.Phone, &address.IsDefault,
+		&address.CreatedAt, &address.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+)
+
+type PostgresNotificationPreferencesRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresNotificationPreferencesRepository(db *sql.DB) domain.NotificationPreferencesRepository {
+	return &PostgresNotificationPreferencesRepository{db: db}
+}
+
+func (r *PostgresNotificationPreferencesRepository) Get(userID string) (*domain.NotificationPreferences, error) {
+	query := `SELECT p.user_id, p.locale, p.email_enabled, p.sms_enabled, p.phone, p.order_updates, p.payment_updates, p.updated_at
+		FROM users u LEFT JOIN notification_preferences p ON p.user_id = u.id
+		WHERE u.id = $1`
+
+	var (
+		storedUserID, locale, phone               sql.NullString
+		emailEnabled, smsEnabled, orders, payment sql.NullBool
+		updatedAt                                 sql.NullTime
+	)
+	err := r.db.QueryRow(query, userID).Scan(&storedUserID, &locale, &emailEnabled, &smsEnabled, &phone, &orders, &payment, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !storedUserID.Valid {
+		return domain.DefaultNotificationPreferences(userID), nil
+	}
+
+	return &domain.NotificationPreferences{
+		UserID:         storedUserID.String,
+		Locale:         locale.String,
+		EmailEnabled:   emailEnabled.Bool,
+		SMSEnabled:     smsEnabled.Bool,
+		Phone:          phone.String,
+		OrderUpdates:   orders.Bool,
+		PaymentUpdates: payment.Bool,
+		UpdatedAt:      updatedAt.Time,
+	}, nil
+}
+
+func (r *PostgresNotificationPreferencesRepository) Save(preferences *domain.NotificationPreferences) error {
+	preferences.UpdatedAt = time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(tx, preferences.UserID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO notification_preferences (user_id, locale, email_enabled, sms_enabled, phone, order_updates, payment_updates, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id) DO UPDATE SET locale = EXCLUDED.locale, email_enabled = EXCLUDED.email_enabled,
+			sms_enabled = EXCLUDED.sms_enabled, phone = EXCLUDED.phone, order_updates = EXCLUDED.order_updates,
+			payment_updates = EXCLUDED.payment_updates, updated_at = EXCLUDED.updated_at`
+	_, err = tx.Exec(query, preferences.UserID, preferences.Locale, preferences.EmailEnabled, preferences.SMSEnabled,
+		preferences.Phone, preferences.OrderUpdates, preferences.PaymentUpdates, preferences.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PostgresSessionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresSessionRepository(db *sql.DB) domain.SessionRepository {
+	return &PostgresSessionRepository{db: db}
+}
+
+const sessionColumns = `id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at, revoked_reason, two_factor`
+
+func (r *PostgresSessionRepository) Create(session *domain.Session) error {
+	session.ID = uuid.New().String()
+	session.CreatedAt = time.Now()
+	session.LastSeenAt = session.CreatedAt
+
+	query := `INSERT INTO sessions (id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_reason, two_factor) VALUES ($1, $2, $3, $4, $5, $6, $7, '', $8)`
+	_, err := r.db.Exec(query, session.ID, session.UserID, session.UserAgent, session.IPAddress, session.CreatedAt, session.LastSeenAt, session.ExpiresAt, session.TwoFactor)
+	return err
+}
+
+func (r *PostgresSessionRepository) GetByID(id string) (*domain.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1`
+	session, err := scanSession(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrSessionNotFound
+	}
+	return session, err
+}
+
+func (r *PostgresSessionRepository) ListActiveByUser(userID string) ([]*domain.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC`
+	rows, err := r.db.Query(query, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*domain.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *PostgresSessionRepository) Touch(id string) error {
+	query := `UPDATE sessions SET last_seen_at = $1 WHERE id = $2`
+	_, err := r.db.Exec(query, time.Now(), id)
+	return err
+}
+
+func (r *PostgresSessionRepository) Revoke(id, reason string) error {
+	query := `UPDATE sessions SET revoked_at = $1, revoked_reason = $2 WHERE id = $3 AND revoked_at IS NULL`
+	_, err := r.db.Exec(query, time.Now(), reason, id)
+	return err
+}
+
+func (r *PostgresSessionRepository) RevokeAllForUser(userID, reason string) error {
+	query := `UPDATE sessions SET revoked_at = $1, revoked_reason = $2 WHERE user_id = $3 AND revoked_at IS NULL`
+	_, err := r.db.Exec(query, time.Now(), reason, userID)
+	return err
+}
+
+func (r *PostgresSessionRepository) SaveRefreshToken(token *domain.RefreshToken) error {
+	token.CreatedAt = time.Now()
+
+	query := `INSERT INTO refresh_tokens (token_hash, session_id, created_at, expires_at) VALUES ($1, $2, $3, $4)`
+	_, err := r.db.Exec(query, token.TokenHash, token.SessionID, token.CreatedAt, token.ExpiresAt)
+	return err
+}
+
+// UseRefreshToken atomically marks a token as used. The boolean reports
+// whether this call was the first use; false with a non-nil token means the
+// token had already been rotated and is being replayed.
+func (r *PostgresSessionRepository) UseRefreshToken(tokenHash string) (*domain.RefreshToken, bool, error) {
+	now := time.Now()
+	token := &domain.RefreshToken{TokenHash: tokenHash}
+
+	query := `UPDATE refresh_tokens SET used_at = $1 WHERE token_hash = $2 AND used_at IS NULL
+		RETURNING session_id, created_at, expires_at, used_at`
+	err := r.db.QueryRow(query, now, tokenHash).Scan(&token.SessionID, &token.CreatedAt, &token.ExpiresAt, &token.UsedAt)
+	if err == nil {
+		return token, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	query = `SELECT session_id, created_at, expires_at, used_at FROM refresh_tokens WHERE token_hash = $1`
+	err = r.db.QueryRow(query, tokenHash).Scan(&token.SessionID, &token.CreatedAt, &token.ExpiresAt, &token.UsedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return token, false, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row rowScanner) (*domain.Session, error) {
+	session := &domain.Session{}
+	err := row.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress, &session.CreatedAt,
+		&session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt, &session.RevokedReason, &session.TwoFactor)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
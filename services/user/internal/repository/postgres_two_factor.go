@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PostgresTwoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTwoFactorRepository(db *sql.DB) domain.TwoFactorRepository {
+	return &PostgresTwoFactorRepository{db: db}
+}
+
+// SaveSecret stores a pending (not yet enabled) secret, replacing any earlier
+// unconfirmed enrollment for the user.
+func (r *PostgresTwoFactorRepository) SaveSecret(secret *domain.TOTPSecret) error {
+	secret.CreatedAt = time.Now()
+	secret.Enabled = false
+	secret.ConfirmedAt = nil
+
+	query := `INSERT INTO user_totp (user_id, encrypted_secret, enabled, last_used_step, created_at, confirmed_at)
+		VALUES ($1, $2, FALSE, 0, $3, NULL)
+		ON CONFLICT (user_id) DO UPDATE
+		SET encrypted_secret = EXCLUDED.encrypted_secret, enabled = FALSE, last_used_step = 0,
+			created_at = EXCLUDED.created_at, confirmed_at = NULL
+		WHERE user_totp.enabled = FALSE`
+	_, err := r.db.Exec(query, secret.UserID, secret.EncryptedSecret, secret.CreatedAt)
+	return err
+}
+
+func (r *PostgresTwoFactorRepository) GetSecret(userID string) (*domain.TOTPSecret, error) {
+	secret := &domain.TOTPSecret{}
+	query := `SELECT user_id, encrypted_secret, enabled, last_used_step, failed_attempts, locked_until, created_at, confirmed_at FROM user_totp WHERE user_id = $1`
+	err := r.db.QueryRow(query, userID).Scan(&secret.UserID, &secret.EncryptedSecret, &secret.Enabled, &secret.LastUsedStep, &secret.FailedAttempts, &secret.LockedUntil, &secret.CreatedAt, &secret.ConfirmedAt)
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (r *PostgresTwoFactorRepository) Enable(userID string) error {
+	query := `UPDATE user_totp SET enabled = TRUE, confirmed_at = $1 WHERE user_id = $2`
+	_, err := r.db.Exec(query, time.Now(), userID)
+	return err
+}
+
+// MarkStepUsed records the time step of an accepted code. It reports false
+// when that step (or a later one) was already used, which rejects replays.
+func (r *PostgresTwoFactorRepository) MarkStepUsed(userID string, step int64) (bool, error) {
+	query := `UPDATE user_totp SET last_used_step = $1 WHERE user_id = $2 AND last_used_step < $1`
+	res, err := r.db.Exec(query, step, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// RecordFailure increments the count in a single statement so concurrent
+// guesses are all counted.
+func (r *PostgresTwoFactorRepository) RecordFailure(userID string, lockedUntil time.Time) error {
+	query := `UPDATE user_totp SET
+			failed_attempts = CASE WHEN failed_attempts + 1 >= $1 THEN 0 ELSE failed_attempts + 1 END,
+			locked_until = CASE WHEN failed_attempts + 1 >= $1 THEN $2 ELSE locked_until END
+		WHERE user_id = $3`
+	_, err := r.db.Exec(query, domain.MaxTwoFactorFailures, lockedUntil, userID)
+	return err
+}
+
+func (r *PostgresTwoFactorRepository) ResetFailures(userID string) error {
+	query := `UPDATE user_totp SET failed_attempts = 0, locked_until = NULL WHERE user_id = $1`
+	_, err := r.db.Exec(query, userID)
+	return err
+}
+
+func (r *PostgresTwoFactorRepository) Delete(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresTwoFactorRepository) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	query := `INSERT INTO user_recovery_codes (id, user_id, code_hash, created_at) VALUES ($1, $2, $3, $4)`
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(query, uuid.New().String(), userID, hash, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ConsumeRecoveryCode marks a matching unused code as used in a single
+// statement so the same code cannot be redeemed twice concurrently.
+func (r *PostgresTwoFactorRepository) ConsumeRecoveryCode(userID, codeHash string) (bool, error) {
+	query := `UPDATE user_recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`
+	res, err := r.db.Exec(query, time.Now(), userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
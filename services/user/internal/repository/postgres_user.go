@@ -2,8 +2,11 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/outbox"
 	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
 	"github.com/google/uuid"
 )
@@ -21,15 +24,29 @@ func (r *PostgresUserRepository) Create(user *domain.User) error {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	query := `INSERT INTO users (id, name, email, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`
-	_, err := r.db.Exec(query, user.ID, user.Name, user.Email, user.CreatedAt, user.UpdatedAt)
-	return err
+	return r.withTx(func(tx *sql.Tx) error {
+		query := `INSERT INTO users (id, name, email, password_hash, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+		if _, err := tx.Exec(query, user.ID, user.Name, user.Email, user.PasswordHash, user.Role, user.CreatedAt, user.UpdatedAt); err != nil {
+			return err
+		}
+		return outbox.Add(tx, domain.AggregateUser, user.ID, domain.EventUserCreated, user)
+	})
 }
 
 func (r *PostgresUserRepository) GetByID(id string) (*domain.User, error) {
 	user := &domain.User{}
-	query := `SELECT id, name, email, created_at, updated_at FROM users WHERE id = $1`
-	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+	query := `SELECT id, name, email, password_hash, role, disabled_at, created_at, updated_at FROM users WHERE id = $1`
+	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.Role, &user.DisabledAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *PostgresUserRepository) GetByEmail(email string) (*domain.User, error) {
+	user := &domain.User{}
+	query := `SELECT id, name, email, password_hash, role, disabled_at, created_at, updated_at FROM users WHERE email = $1`
+	err := r.db.QueryRow(query, email).Scan(&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.Role, &user.DisabledAt, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -38,13 +55,86 @@ func (r *PostgresUserRepository) GetByID(id string) (*domain.User, error) {
 
 func (r *PostgresUserRepository) Update(user *domain.User) error {
 	user.UpdatedAt = time.Now()
-	query := `UPDATE users SET name = $1, email = $2, updated_at = $3 WHERE id = $4`
-	_, err := r.db.Exec(query, user.Name, user.Email, user.UpdatedAt, user.ID)
-	return err
+	return r.withTx(func(tx *sql.Tx) error {
+		query := `UPDATE users SET name = $1, email = $2, password_hash = $3, role = $4, disabled_at = $5, updated_at = $6 WHERE id = $7`
+		if _, err := tx.Exec(query, user.Name, user.Email, user.PasswordHash, user.Role, user.DisabledAt, user.UpdatedAt, user.ID); err != nil {
+			return err
+		}
+		return outbox.Add(tx, domain.AggregateUser, user.ID, domain.EventUserUpdated, user)
+	})
 }
 
 func (r *PostgresUserRepository) Delete(id string) error {
-	query := `DELETE FROM users WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+	return r.withTx(func(tx *sql.Tx) error {
+		query := `DELETE FROM users WHERE id = $1`
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+		return outbox.Add(tx, domain.AggregateUser, id, domain.EventUserDeleted, map[string]string{"id": id})
+	})
+}
+
+// withTx runs fn in a transaction, so that a write and its outbox event are
+// committed together.
+func (r *PostgresUserRepository) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// List returns users using keyset pagination on (sort column, id).
+func (r *PostgresUserRepository) List(filter domain.UserFilter) ([]*domain.User, error) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Email != "" {
+		conds = append(conds, "email = "+arg(filter.Email))
+	}
+
+	column := "created_at"
+	if filter.Page.Sort.Field == domain.UserSortName {
+		column = "name"
+	}
+	direction, cmp := filter.Page.Sort.Direction()
+
+	if after := filter.Page.After; after != nil {
+		var value any = after.CreatedAt
+		if column == "name" {
+			value = after.Key
+		}
+		conds = append(conds, fmt.Sprintf("(%s, id) %s (%s, %s)", column, cmp, arg(value), arg(after.ID)))
+	}
+
+	query := `SELECT id, name, email, password_hash, role, disabled_at, created_at, updated_at FROM users`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", column, direction, direction, arg(filter.Page.Limit()))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user := &domain.User{}
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.Role, &user.DisabledAt, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
 }
@@ -0,0 +1,74 @@
+package security
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecretCipherRoundTrip(t *testing.T) {
+	c, err := NewSecretCipher("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("JBSWY3DPEHPK3PXP")
+	a, err := c.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Error("encrypting twice gave the same ciphertext, nonces are reused")
+	}
+	if bytes.Contains(a, plaintext) {
+		t.Error("ciphertext contains the plaintext")
+	}
+
+	got, err := c.Decrypt(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("Decrypt = %q, want %q", got, plaintext)
+	}
+}
+
+func TestSecretCipherRejectsTampering(t *testing.T) {
+	c, err := NewSecretCipher("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := c.Encrypt([]byte("JBSWY3DPEHPK3PXP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range ciphertext {
+		tampered := bytes.Clone(ciphertext)
+		tampered[i] ^= 0x01
+		if _, err := c.Decrypt(tampered); err == nil {
+			t.Fatalf("Decrypt accepted a ciphertext with byte %d flipped", i)
+		}
+	}
+
+	if _, err := c.Decrypt(ciphertext[:4]); err == nil {
+		t.Error("Decrypt accepted a truncated ciphertext")
+	}
+
+	other, err := NewSecretCipher("another passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Decrypt(ciphertext); err == nil {
+		t.Error("Decrypt accepted a ciphertext under another key")
+	}
+}
+
+func TestNewSecretCipherRequiresPassphrase(t *testing.T) {
+	if _, err := NewSecretCipher(""); err == nil {
+		t.Error("NewSecretCipher accepted an empty passphrase")
+	}
+}
@@ -0,0 +1,66 @@
+package security
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPasswordHash(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == "correct horse" {
+		t.Fatal("HashPassword returned the password")
+	}
+
+	if !CheckPassword(hash, "correct horse") {
+		t.Error("CheckPassword rejected the password")
+	}
+	if CheckPassword(hash, "correct horse battery") {
+		t.Error("CheckPassword accepted a different password")
+	}
+	if CheckPassword("", "") {
+		t.Error("CheckPassword accepted an empty hash")
+	}
+	if CheckPassword("not a bcrypt hash", "correct horse") {
+		t.Error("CheckPassword accepted a malformed hash")
+	}
+}
+
+var recoveryCodePattern = regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if !recoveryCodePattern.MatchString(code) {
+			t.Errorf("code %q is not of the form xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+}
+
+// TestHashRecoveryCode checks that the ways a user might type a code back
+// in hash the same.
+func TestHashRecoveryCode(t *testing.T) {
+	want := HashRecoveryCode("abcde-fghij")
+	for _, typed := range []string{"abcdefghij", "ABCDE-FGHIJ", " abcde-fghij\n", "abc-de-fghij"} {
+		if got := HashRecoveryCode(typed); got != want {
+			t.Errorf("HashRecoveryCode(%q) differs from HashRecoveryCode(%q)", typed, "abcde-fghij")
+		}
+	}
+	if HashRecoveryCode("abcde-fghik") == want {
+		t.Error("different codes hash the same")
+	}
+}
@@ -0,0 +1,112 @@
+package security
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenSignerRoundTrip(t *testing.T) {
+	s, err := NewTokenSigner("signing-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	claims := AccessClaims{UserID: "user-1", SessionID: "session-1", IssuedAt: now.Unix(), ExpiresAt: now.Add(15 * time.Minute).Unix()}
+	token, err := s.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, accessTokenVersion+".") {
+		t.Errorf("token %q lacks the %s prefix", token, accessTokenVersion)
+	}
+
+	got, err := s.Verify(token, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != claims {
+		t.Errorf("Verify = %+v, want %+v", *got, claims)
+	}
+}
+
+func TestTokenSignerRejects(t *testing.T) {
+	s, err := NewTokenSigner("signing-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewTokenSigner("another-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	claims := AccessClaims{UserID: "user-1", SessionID: "session-1", IssuedAt: now.Unix(), ExpiresAt: now.Add(15 * time.Minute).Unix()}
+	token, err := s.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+
+	forged := claims
+	forged.UserID = "user-2"
+	payload, err := json.Marshal(forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherToken, err := other.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		token string
+		now   time.Time
+	}{
+		{"expired", token, now.Add(15 * time.Minute)},
+		{"changed claims", parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2], now},
+		{"other key", otherToken, now},
+		{"unknown version", "v2." + parts[1] + "." + parts[2], now},
+		{"missing signature", parts[0] + "." + parts[1], now},
+		{"bad signature encoding", parts[0] + "." + parts[1] + ".!!", now},
+		{"empty", "", now},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := s.Verify(c.token, c.now); err == nil {
+				t.Error("Verify accepted the token")
+			}
+		})
+	}
+}
+
+func TestNewTokenSignerRequiresKey(t *testing.T) {
+	if _, err := NewTokenSigner(""); err == nil {
+		t.Error("NewTokenSigner accepted an empty key")
+	}
+}
+
+func TestGenerateRefreshToken(t *testing.T) {
+	token, hash, err := GenerateRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != HashRefreshToken(token) {
+		t.Error("returned hash does not match HashRefreshToken")
+	}
+	if strings.Contains(hash, token) {
+		t.Error("hash contains the token")
+	}
+
+	again, _, err := GenerateRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == token {
+		t.Error("two generated refresh tokens are equal")
+	}
+}
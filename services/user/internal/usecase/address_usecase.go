@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+)
+
+type addressUsecase struct {
+	addressRepo domain.AddressRepository
+}
+
+func NewAddressUsecase(addressRepo domain.AddressRepository) domain.AddressUsecase {
+	return &addressUsecase{addressRepo: addressRepo}
+}
+
+func (u *addressUsecase) CreateAddress(userID string, input domain.AddressInput) (*domain.Address, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+
+	address := &domain.Address{UserID: userID, IsDefault: input.IsDefault}
+	if err := applyAddressInput(address, input); err != nil {
+		return nil, err
+	}
+
+	if err := u.addressRepo.Create(address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (u *addressUsecase) GetAddress(userID, id string) (*domain.Address, error) {
+	if userID == "" || id == "" {
+		return nil, errors.New("user id and address id are required")
+	}
+
+	return u.addressRepo.GetByID(userID, id)
+}
+
+func (u *addressUsecase) GetDefaultAddress(userID string) (*domain.Address, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+
+	return u.addressRepo.GetDefault(userID)
+}
+
+func (u *addressUsecase) ListAddresses(userID string) ([]*domain.Address, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+
+	return u.addressRepo.ListByUser(userID)
+}
+
+// UpdateAddress replaces the address's fields. Making it the default is done
+// through SetDefaultAddress; a true IsDefault here does the same.
+func (u *addressUsecase) UpdateAddress(userID, id string, input domain.AddressInput) (*domain.Address, error) {
+	address, err := u.GetAddress(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyAddressInput(address, input); err != nil {
+		return nil, err
+	}
+
+	if err := u.addressRepo.Update(address); err != nil {
+		return nil, err
+	}
+	if input.IsDefault && !address.IsDefault {
+		return u.SetDefaultAddress(userID, id)
+	}
+	return address, nil
+}
+
+func (u *addressUsecase) DeleteAddress(userID, id string) error {
+	if userID == "" || id == "" {
+		return errors.New("user id and address id are required")
+	}
+
+	return u.addressRepo.Delete(userID, id)
+}
+
+func (u *addressUsecase) SetDefaultAddress(userID, id string) (*domain.Address, error) {
+	if userID == "" || id == "" {
+		return nil, errors.New("user id and address id are required")
+	}
+
+	if err := u.addressRepo.SetDefault(userID, id); err != nil {
+		return nil, err
+	}
+	return u.addressRepo.GetByID(userID, id)
+}
+
+// applyAddressInput validates input and copies it onto address. Country
+// codes are stored upper case.
+func applyAddressInput(address *domain.Address, input domain.AddressInput) error {
+	address.Label = strings.TrimSpace(input.Label)
+	address.RecipientName = strings.TrimSpace(input.RecipientName)
+	address.Line1 = strings.TrimSpace(input.Line1)
+	address.Line2 = strings.TrimSpace(input.Line2)
+	address.City = strings.TrimSpace(input.City)
+	address.Region = strings.TrimSpace(input.Region)
+	address.PostalCode = strings.TrimSpace(input.PostalCode)
+	address.Country = strings.ToUpper(strings.TrimSpace(input.Country))
+	address.Phone = strings.TrimSpace(input.Phone)
+
+	if address.RecipientName == "" || address.Line1 == "" || address.City == "" || address.PostalCode == "" {
+		return fmt.Errorf("%w: recipient_name, line1, city and postal_code are required", domain.ErrInvalidAddress)
+	}
+	if len(address.Country) != 2 || strings.Trim(address.Country, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return fmt.Errorf("%w: country must be a two-letter ISO 3166-1 code", domain.ErrInvalidAddress)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+)
+
+type notificationPreferencesUsecase struct {
+	preferencesRepo domain.NotificationPreferencesRepository
+}
+
+func NewNotificationPreferencesUsecase(preferencesRepo domain.NotificationPreferencesRepository) domain.NotificationPreferencesUsecase {
+	return &notificationPreferencesUsecase{preferencesRepo: preferencesRepo}
+}
+
+func (u *notificationPreferencesUsecase) GetNotificationPreferences(userID string) (*domain.NotificationPreferences, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+
+	return u.preferencesRepo.Get(userID)
+}
+
+// UpdateNotificationPreferences replaces the user's preferences. An empty
+// locale means DefaultLocale.
+func (u *notificationPreferencesUsecase) UpdateNotificationPreferences(userID string, input domain.NotificationPreferencesInput) (*domain.NotificationPreferences, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+
+	locale, err := normalizeLocale(input.Locale)
+	if err != nil {
+		return nil, err
+	}
+	preferences := &domain.NotificationPreferences{
+		UserID:         userID,
+		Locale:         locale,
+		EmailEnabled:   input.EmailEnabled,
+		SMSEnabled:     input.SMSEnabled,
+		Phone:          strings.TrimSpace(input.Phone),
+		OrderUpdates:   input.OrderUpdates,
+		PaymentUpdates: input.PaymentUpdates,
+	}
+	if preferences.Phone != "" && !validPhone(preferences.Phone) {
+		return nil, fmt.Errorf("%w: phone must be an E.164 number such as +6281234567890", domain.ErrInvalidNotificationPreferences)
+	}
+	if preferences.SMSEnabled && preferences.Phone == "" {
+		return nil, fmt.Errorf("%w: a phone number is required for SMS", domain.ErrInvalidNotificationPreferences)
+	}
+
+	if err := u.preferencesRepo.Save(preferences); err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+// normalizeLocale accepts a language ("en") or language and region
+// ("id-ID", "pt_br") and returns it as a lower-case language and upper-case
+// region joined by a hyphen.
+func normalizeLocale(locale string) (string, error) {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if locale == "" {
+		return domain.DefaultLocale, nil
+	}
+
+	language, region, hasRegion := strings.Cut(locale, "-")
+	language = strings.ToLower(language)
+	region = strings.ToUpper(region)
+	if !isLetters(language, 2, 3) || (hasRegion && !isLetters(region, 2, 2)) {
+		return "", fmt.Errorf("%w: locale must be a language tag such as en or id-ID", domain.ErrInvalidNotificationPreferences)
+	}
+	if hasRegion {
+		return language + "-" + region, nil
+	}
+	return language, nil
+}
+
+func isLetters(s string, minLen, maxLen int) bool {
+	if len(s) < minLen || len(s) > maxLen {
+		return false
+	}
+	for _, r := range strings.ToLower(s) {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// validPhone reports whether phone is in E.164 form: a plus sign and up to
+// 15 digits, the first of which is not zero.
+func validPhone(phone string) bool {
+	digits, ok := strings.CutPrefix(phone, "+")
+	if !ok || len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/security"
+)
+
+type sessionUsecase struct {
+	sessionRepo     domain.SessionRepository
+	userRepo        domain.UserRepository
+	signer          *security.TokenSigner
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+}
+
+func NewSessionUsecase(sessionRepo domain.SessionRepository, userRepo domain.UserRepository, signer *security.TokenSigner, accessTokenTTL, refreshTokenTTL time.Duration) domain.SessionUsecase {
+	return &sessionUsecase{
+		sessionRepo:     sessionRepo,
+		userRepo:        userRepo,
+		signer:          signer,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+func (u *sessionUsecase) StartSession(userID, userAgent, ipAddress string, twoFactor bool) (*domain.TokenPair, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+
+	session := &domain.Session{
+		UserID:    userID,
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+		ExpiresAt: time.Now().Add(u.refreshTokenTTL),
+		TwoFactor: twoFactor,
+	}
+	if err := u.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return u.issueTokens(session)
+}
+
+// Refresh rotates the presented refresh token. Presenting a token that was
+// already rotated means it leaked, so the whole session is revoked.
+func (u *sessionUsecase) Refresh(refreshToken string) (*domain.TokenPair, error) {
+	if refreshToken == "" {
+		return nil, errors.New("refresh token is required")
+	}
+
+	token, firstUse, err := u.sessionRepo.UseRefreshToken(security.HashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if !firstUse {
+		log.Printf("Refresh token reuse detected, revoking session %s", token.SessionID)
+		if err := u.sessionRepo.Revoke(token.SessionID, "refresh_token_reuse"); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrRefreshTokenReused
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return nil, domain.ErrInvalidToken
+	}
+
+	session, err := u.sessionRepo.GetByID(token.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !session.Active(time.Now()) {
+		return nil, domain.ErrSessionRevoked
+	}
+
+	user, err := u.userRepo.GetByID(session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
+
+	if err := u.sessionRepo.Touch(session.ID); err != nil {
+		return nil, err
+	}
+
+	return u.issueTokens(session)
+}
+
+func (u *sessionUsecase) ListSessions(userID string) ([]*domain.Session, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+
+	return u.sessionRepo.ListActiveByUser(userID)
+}
+
+func (u *sessionUsecase) RevokeSession(userID, sessionID string) error {
+	if userID == "" || sessionID == "" {
+		return errors.New("user id and session id are required")
+	}
+
+	session, err := u.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return domain.ErrSessionNotFound
+	}
+
+	return u.sessionRepo.Revoke(sessionID, "logout")
+}
+
+func (u *sessionUsecase) RevokeAllSessions(userID string) error {
+	if userID == "" {
+		return errors.New("user id is required")
+	}
+
+	return u.sessionRepo.RevokeAllForUser(userID, "revoke_all")
+}
+
+// Authenticate verifies an access token and checks that its session and user
+// are still active, so revocations take effect before the token expires.
+func (u *sessionUsecase) Authenticate(accessToken string) (*domain.Principal, error) {
+	claims, err := u.signer.Verify(accessToken, time.Now())
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	session, err := u.sessionRepo.GetByID(claims.SessionID)
+	if err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !session.Active(time.Now()) || session.UserID != claims.UserID {
+		return nil, domain.ErrSessionRevoked
+	}
+
+	user, err := u.userRepo.GetByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
+
+	return &domain.Principal{
+		UserID:    claims.UserID,
+		SessionID: claims.SessionID,
+		Role:      user.Role,
+		TwoFactor: session.TwoFactor,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}, nil
+}
+
+func (u *sessionUsecase) issueTokens(session *domain.Session) (*domain.TokenPair, error) {
+	now := time.Now()
+	accessExpiresAt := now.Add(u.accessTokenTTL)
+
+	accessToken, err := u.signer.Sign(security.AccessClaims{
+		UserID:    session.UserID,
+		SessionID: session.ID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: accessExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshHash, err := security.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	err = u.sessionRepo.SaveRefreshToken(&domain.RefreshToken{
+		TokenHash: refreshHash,
+		SessionID: session.ID,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TokenPair{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+	}, nil
+}
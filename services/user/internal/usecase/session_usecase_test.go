@@ -0,0 +1,241 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/security"
+	"github.com/google/uuid"
+)
+
+// memorySessions mirrors PostgresSessionRepository, including the
+// first-use semantics of UseRefreshToken.
+type memorySessions struct {
+	sessions map[string]*domain.Session
+	tokens   map[string]*domain.RefreshToken
+}
+
+func newMemorySessions() *memorySessions {
+	return &memorySessions{
+		sessions: make(map[string]*domain.Session),
+		tokens:   make(map[string]*domain.RefreshToken),
+	}
+}
+
+func (m *memorySessions) Create(session *domain.Session) error {
+	session.ID = uuid.New().String()
+	session.CreatedAt = time.Now()
+	session.LastSeenAt = session.CreatedAt
+	stored := *session
+	m.sessions[session.ID] = &stored
+	return nil
+}
+
+func (m *memorySessions) GetByID(id string) (*domain.Session, error) {
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (m *memorySessions) ListActiveByUser(userID string) ([]*domain.Session, error) {
+	var active []*domain.Session
+	for _, session := range m.sessions {
+		if session.UserID == userID && session.Active(time.Now()) {
+			copied := *session
+			active = append(active, &copied)
+		}
+	}
+	return active, nil
+}
+
+func (m *memorySessions) Touch(id string) error {
+	m.sessions[id].LastSeenAt = time.Now()
+	return nil
+}
+
+func (m *memorySessions) Revoke(id, reason string) error {
+	if session, ok := m.sessions[id]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+		session.RevokedReason = reason
+	}
+	return nil
+}
+
+func (m *memorySessions) RevokeAllForUser(userID, reason string) error {
+	for id, session := range m.sessions {
+		if session.UserID == userID {
+			m.Revoke(id, reason)
+		}
+	}
+	return nil
+}
+
+func (m *memorySessions) SaveRefreshToken(token *domain.RefreshToken) error {
+	token.CreatedAt = time.Now()
+	stored := *token
+	m.tokens[token.TokenHash] = &stored
+	return nil
+}
+
+func (m *memorySessions) UseRefreshToken(tokenHash string) (*domain.RefreshToken, bool, error) {
+	token, ok := m.tokens[tokenHash]
+	if !ok {
+		return nil, false, domain.ErrInvalidToken
+	}
+	copied := *token
+	if token.UsedAt != nil {
+		return &copied, false, nil
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	copied.UsedAt = &now
+	return &copied, true, nil
+}
+
+type memoryUsers struct {
+	domain.UserRepository
+	users map[string]*domain.User
+}
+
+func (m *memoryUsers) GetByID(id string) (*domain.User, error) {
+	user, ok := m.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func newSessionFixture(t *testing.T) (domain.SessionUsecase, *memorySessions, *memoryUsers) {
+	t.Helper()
+	signer, err := security.NewTokenSigner("test-signing-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := newMemorySessions()
+	users := &memoryUsers{users: map[string]*domain.User{
+		"user-1": {ID: "user-1", Role: domain.RoleCustomer},
+	}}
+	return NewSessionUsecase(sessions, users, signer, 15*time.Minute, 24*time.Hour), sessions, users
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	u, _, _ := newSessionFixture(t)
+
+	first, err := u.StartSession("user-1", "test", "127.0.0.1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := u.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.SessionID != first.SessionID {
+		t.Errorf("SessionID = %s, want the original %s", second.SessionID, first.SessionID)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("refresh token was not rotated")
+	}
+
+	third, err := u.Refresh(second.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Authenticate(third.AccessToken); err != nil {
+		t.Errorf("Authenticate after two rotations: %v", err)
+	}
+}
+
+// TestRefreshReuseRevokesFamily replays a rotated refresh token, as an
+// attacker who copied it would. The whole session, every token issued for
+// it, must stop working, including the legitimate client's latest ones.
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	u, sessions, _ := newSessionFixture(t)
+
+	first, err := u.StartSession("user-1", "test", "127.0.0.1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := u.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := u.StartSession("user-1", "other device", "127.0.0.2", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := u.Refresh(first.RefreshToken); !errors.Is(err, domain.ErrRefreshTokenReused) {
+		t.Fatalf("replayed token: err = %v, want ErrRefreshTokenReused", err)
+	}
+
+	session := sessions.sessions[first.SessionID]
+	if session.RevokedAt == nil || session.RevokedReason != "refresh_token_reuse" {
+		t.Fatalf("session revoked at %v for %q, want revoked for refresh_token_reuse", session.RevokedAt, session.RevokedReason)
+	}
+	if _, err := u.Refresh(second.RefreshToken); !errors.Is(err, domain.ErrSessionRevoked) {
+		t.Errorf("latest refresh token: err = %v, want ErrSessionRevoked", err)
+	}
+	if _, err := u.Authenticate(second.AccessToken); !errors.Is(err, domain.ErrSessionRevoked) {
+		t.Errorf("latest access token: err = %v, want ErrSessionRevoked", err)
+	}
+	if _, err := u.Refresh(first.RefreshToken); !errors.Is(err, domain.ErrRefreshTokenReused) {
+		t.Errorf("second replay: err = %v, want ErrRefreshTokenReused", err)
+	}
+
+	// Only the compromised session is revoked.
+	if _, err := u.Refresh(other.RefreshToken); err != nil {
+		t.Errorf("other session: %v", err)
+	}
+}
+
+func TestRefreshRejects(t *testing.T) {
+	u, sessions, users := newSessionFixture(t)
+
+	if _, err := u.Refresh("not-a-token"); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("unknown token: err = %v, want ErrInvalidToken", err)
+	}
+
+	tokens, err := u.StartSession("user-1", "test", "127.0.0.1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions.tokens[security.HashRefreshToken(tokens.RefreshToken)].ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := u.Refresh(tokens.RefreshToken); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expired token: err = %v, want ErrInvalidToken", err)
+	}
+
+	tokens, err = u.StartSession("user-1", "test", "127.0.0.1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	users.users["user-1"].DisabledAt = &now
+	if _, err := u.Refresh(tokens.RefreshToken); !errors.Is(err, domain.ErrUserDisabled) {
+		t.Errorf("disabled user: err = %v, want ErrUserDisabled", err)
+	}
+}
+
+func TestAuthenticateGrantsPrivilegedRolesOnlyWithTwoFactor(t *testing.T) {
+	u, _, users := newSessionFixture(t)
+	users.users["user-1"].Role = domain.RoleFinance
+
+	for _, twoFactor := range []bool{false, true} {
+		tokens, err := u.StartSession("user-1", "test", "127.0.0.1", twoFactor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		principal, err := u.Authenticate(tokens.AccessToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := principal.HasRole(domain.RoleFinance); got != twoFactor {
+			t.Errorf("two-factor %v: HasRole(finance) = %v", twoFactor, got)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/security"
+)
+
+const recoveryCodeCount = 10
+
+type twoFactorUsecase struct {
+	userRepo      domain.UserRepository
+	twoFactorRepo domain.TwoFactorRepository
+	cipher        *security.SecretCipher
+	issuer        string
+}
+
+func NewTwoFactorUsecase(userRepo domain.UserRepository, twoFactorRepo domain.TwoFactorRepository, cipher *security.SecretCipher, issuer string) domain.TwoFactorUsecase {
+	return &twoFactorUsecase{
+		userRepo:      userRepo,
+		twoFactorRepo: twoFactorRepo,
+		cipher:        cipher,
+		issuer:        issuer,
+	}
+}
+
+// EnrollTOTP starts enrollment after re-checking the user's password, so a
+// stolen access token alone cannot attach an attacker's authenticator.
+func (u *twoFactorUsecase) EnrollTOTP(userID, password string) (*domain.TOTPEnrollment, error) {
+	if userID == "" || password == "" {
+		return nil, errors.New("user id and password are required")
+	}
+
+	user, err := u.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !security.CheckPassword(user.PasswordHash, password) {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	enabled, err := u.IsEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := u.cipher.Encrypt([]byte(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.twoFactorRepo.SaveSecret(&domain.TOTPSecret{
+		UserID:          userID,
+		EncryptedSecret: encrypted,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURL: security.TOTPURL(u.issuer, user.Email, secret),
+	}, nil
+}
+
+func (u *twoFactorUsecase) ConfirmTOTP(userID, code string) ([]string, error) {
+	if userID == "" || code == "" {
+		return nil, errors.New("user id and code are required")
+	}
+
+	secret, err := u.twoFactorRepo.GetSecret(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("two-factor enrollment not started")
+		}
+		return nil, err
+	}
+	if secret.Enabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	if err := u.throttle(secret, func() error { return u.checkTOTP(secret, code) }); err != nil {
+		return nil, err
+	}
+
+	if err := u.twoFactorRepo.Enable(userID); err != nil {
+		return nil, err
+	}
+
+	return u.issueRecoveryCodes(userID)
+}
+
+func (u *twoFactorUsecase) DisableTOTP(userID, password, code string) error {
+	if userID == "" || password == "" || code == "" {
+		return errors.New("user id, password and code are required")
+	}
+
+	user, err := u.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if !security.CheckPassword(user.PasswordHash, password) {
+		return domain.ErrInvalidCredentials
+	}
+	if user.Role.RequiresTwoFactor() {
+		return domain.ErrTwoFactorMandatory
+	}
+
+	if err := u.VerifyCode(userID, code); err != nil {
+		return err
+	}
+
+	return u.twoFactorRepo.Delete(userID)
+}
+
+func (u *twoFactorUsecase) RegenerateRecoveryCodes(userID, code string) ([]string, error) {
+	if userID == "" || code == "" {
+		return nil, errors.New("user id and code are required")
+	}
+
+	if err := u.VerifyCode(userID, code); err != nil {
+		return nil, err
+	}
+
+	return u.issueRecoveryCodes(userID)
+}
+
+func (u *twoFactorUsecase) IsEnabled(userID string) (bool, error) {
+	secret, err := u.twoFactorRepo.GetSecret(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return secret.Enabled, nil
+}
+
+// VerifyCode accepts either a current TOTP code or an unused recovery code.
+// Invalid codes count towards a lockout; see throttle.
+func (u *twoFactorUsecase) VerifyCode(userID, code string) error {
+	if code == "" {
+		return domain.ErrTOTPRequired
+	}
+
+	secret, err := u.twoFactorRepo.GetSecret(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("two-factor authentication is not enabled")
+		}
+		return err
+	}
+	if !secret.Enabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	return u.throttle(secret, func() error {
+		if len(code) == 6 {
+			return u.checkTOTP(secret, code)
+		}
+
+		ok, err := u.twoFactorRepo.ConsumeRecoveryCode(userID, security.HashRecoveryCode(code))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return domain.ErrInvalidTOTPCode
+		}
+		return nil
+	})
+}
+
+// throttle runs check unless the user is locked out. Six-digit codes can be
+// guessed, so every invalid one is recorded and domain.MaxTwoFactorFailures
+// in a row lock the user out for domain.TwoFactorLockout; a valid code
+// clears the count.
+func (u *twoFactorUsecase) throttle(secret *domain.TOTPSecret, check func() error) error {
+	now := time.Now()
+	if secret.Locked(now) {
+		return domain.ErrTwoFactorLocked
+	}
+
+	err := check()
+	switch {
+	case errors.Is(err, domain.ErrInvalidTOTPCode):
+		if err := u.twoFactorRepo.RecordFailure(secret.UserID, now.Add(domain.TwoFactorLockout)); err != nil {
+			return err
+		}
+		return domain.ErrInvalidTOTPCode
+	case err != nil:
+		return err
+	case secret.FailedAttempts > 0:
+		return u.twoFactorRepo.ResetFailures(secret.UserID)
+	}
+	return nil
+}
+
+func (u *twoFactorUsecase) checkTOTP(secret *domain.TOTPSecret, code string) error {
+	plain, err := u.cipher.Decrypt(secret.EncryptedSecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := security.ValidateTOTP(string(plain), code, time.Now())
+	if !ok {
+		return domain.ErrInvalidTOTPCode
+	}
+
+	fresh, err := u.twoFactorRepo.MarkStepUsed(secret.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return domain.ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+func (u *twoFactorUsecase) issueRecoveryCodes(userID string) ([]string, error) {
+	codes, err := security.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = security.HashRecoveryCode(code)
+	}
+
+	if err := u.twoFactorRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/security"
+)
+
+// memoryTwoFactor keeps one user's secret and applies the lockout the way
+// the Postgres repository does.
+type memoryTwoFactor struct {
+	domain.TwoFactorRepository
+	secret *domain.TOTPSecret
+}
+
+func (m *memoryTwoFactor) GetSecret(userID string) (*domain.TOTPSecret, error) {
+	secret := *m.secret
+	return &secret, nil
+}
+
+func (m *memoryTwoFactor) MarkStepUsed(userID string, step int64) (bool, error) {
+	if m.secret.LastUsedStep >= step {
+		return false, nil
+	}
+	m.secret.LastUsedStep = step
+	return true, nil
+}
+
+func (m *memoryTwoFactor) RecordFailure(userID string, lockedUntil time.Time) error {
+	m.secret.FailedAttempts++
+	if m.secret.FailedAttempts >= domain.MaxTwoFactorFailures {
+		m.secret.FailedAttempts = 0
+		m.secret.LockedUntil = &lockedUntil
+	}
+	return nil
+}
+
+func (m *memoryTwoFactor) ResetFailures(userID string) error {
+	m.secret.FailedAttempts = 0
+	m.secret.LockedUntil = nil
+	return nil
+}
+
+func (m *memoryTwoFactor) ConsumeRecoveryCode(userID, codeHash string) (bool, error) {
+	return false, nil
+}
+
+func newTwoFactorFixture(t *testing.T) (*twoFactorUsecase, *memoryTwoFactor, string) {
+	t.Helper()
+	cipher, err := security.NewSecretCipher("test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := cipher.Encrypt([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &memoryTwoFactor{secret: &domain.TOTPSecret{UserID: "user-1", EncryptedSecret: encrypted, Enabled: true}}
+	return &twoFactorUsecase{twoFactorRepo: repo, cipher: cipher}, repo, secret
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := security.TOTPCode(secret, time.Now().Unix()/30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func wrongCode(t *testing.T, secret string) string {
+	t.Helper()
+	code := currentCode(t, secret)
+	return code[:5] + string('0'+(code[5]-'0'+5)%10)
+}
+
+func TestVerifyCodeLocksOutAfterRepeatedFailures(t *testing.T) {
+	u, repo, secret := newTwoFactorFixture(t)
+
+	for i := 1; i <= domain.MaxTwoFactorFailures; i++ {
+		if err := u.VerifyCode("user-1", wrongCode(t, secret)); !errors.Is(err, domain.ErrInvalidTOTPCode) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidTOTPCode", i, err)
+		}
+	}
+	if repo.secret.LockedUntil == nil {
+		t.Fatal("user not locked out after MaxTwoFactorFailures invalid codes")
+	}
+
+	// While locked out even a valid code is refused, and not consumed.
+	if err := u.VerifyCode("user-1", currentCode(t, secret)); !errors.Is(err, domain.ErrTwoFactorLocked) {
+		t.Fatalf("err = %v, want ErrTwoFactorLocked", err)
+	}
+	if err := u.VerifyCode("user-1", "abcde-fghij"); !errors.Is(err, domain.ErrTwoFactorLocked) {
+		t.Fatalf("recovery code: err = %v, want ErrTwoFactorLocked", err)
+	}
+
+	expired := time.Now().Add(-time.Second)
+	repo.secret.LockedUntil = &expired
+	if err := u.VerifyCode("user-1", currentCode(t, secret)); err != nil {
+		t.Fatalf("after the lockout: %v", err)
+	}
+}
+
+func TestVerifyCodeResetsFailuresOnSuccess(t *testing.T) {
+	u, repo, secret := newTwoFactorFixture(t)
+
+	for i := 1; i < domain.MaxTwoFactorFailures; i++ {
+		if err := u.VerifyCode("user-1", wrongCode(t, secret)); !errors.Is(err, domain.ErrInvalidTOTPCode) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidTOTPCode", i, err)
+		}
+	}
+	code := currentCode(t, secret)
+	if err := u.VerifyCode("user-1", code); err != nil {
+		t.Fatal(err)
+	}
+	if repo.secret.FailedAttempts != 0 {
+		t.Errorf("FailedAttempts = %d after a valid code, want 0", repo.secret.FailedAttempts)
+	}
+
+	// A replayed code fails and counts like any other invalid one.
+	if err := u.VerifyCode("user-1", code); !errors.Is(err, domain.ErrInvalidTOTPCode) {
+		t.Fatalf("replay: err = %v, want ErrInvalidTOTPCode", err)
+	}
+	if repo.secret.FailedAttempts != 1 {
+		t.Errorf("FailedAttempts = %d after a replay, want 1", repo.secret.FailedAttempts)
+	}
+}
@@ -1,22 +1,35 @@
 package usecase
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/edwinjordan/golang_microservices/pkg/pagination"
 	"github.com/edwinjordan/golang_microservices/services/user/internal/domain"
+	"github.com/edwinjordan/golang_microservices/services/user/internal/security"
 )
 
 type userUsecase struct {
-	userRepo domain.UserRepository
+	userRepo  domain.UserRepository
+	twoFactor domain.TwoFactorUsecase
+	sessions  domain.SessionUsecase
+	paginator *pagination.Paginator
 }
 
-func NewUserUsecase(userRepo domain.UserRepository) domain.UserUsecase {
+func NewUserUsecase(userRepo domain.UserRepository, twoFactor domain.TwoFactorUsecase, sessions domain.SessionUsecase, pageTokens *pagination.Codec) domain.UserUsecase {
 	return &userUsecase{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		twoFactor: twoFactor,
+		sessions:  sessions,
+		paginator: pagination.NewPaginator(pageTokens, pagination.Options{
+			SortFields: []string{domain.UserSortCreatedAt, domain.UserSortName},
+		}),
 	}
 }
 
-func (u *userUsecase) CreateUser(name, email string) (*domain.User, error) {
+func (u *userUsecase) CreateUser(name, email, password string) (*domain.User, error) {
 	if name == "" || email == "" {
 		return nil, errors.New("name and email are required")
 	}
@@ -24,6 +37,15 @@ func (u *userUsecase) CreateUser(name, email string) (*domain.User, error) {
 	user := &domain.User{
 		Name:  name,
 		Email: email,
+		Role:  domain.RoleCustomer,
+	}
+
+	if password != "" {
+		hash, err := security.HashPassword(password)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
 	}
 
 	err := u.userRepo.Create(user)
@@ -40,6 +62,9 @@ func (u *userUsecase) GetUser(id string) (*domain.User, error) {
 	}
 
 	user, err := u.userRepo.GetByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +77,125 @@ func (u *userUsecase) ValidateUser(id string) (bool, string, error) {
 	if err != nil {
 		return false, "", err
 	}
+	if user.Disabled() {
+		return false, "", domain.ErrUserDisabled
+	}
 
 	return true, user.Name, nil
 }
+
+// Login checks the password and, when the user has enrolled an
+// authenticator, the accompanying TOTP or recovery code.
+func (u *userUsecase) Login(email, password, code string) (*domain.User, bool, error) {
+	if email == "" || password == "" {
+		return nil, false, errors.New("email and password are required")
+	}
+
+	user, err := u.userRepo.GetByEmail(email)
+	if err != nil {
+		return nil, false, domain.ErrInvalidCredentials
+	}
+	if !security.CheckPassword(user.PasswordHash, password) {
+		return nil, false, domain.ErrInvalidCredentials
+	}
+	if user.Disabled() {
+		return nil, false, domain.ErrUserDisabled
+	}
+
+	// A finance or admin user who has not enabled two-factor authentication
+	// still gets a session, so they can enroll, but without the role's
+	// privileges: Principal.HasRole withholds them from sessions that did
+	// not verify a second factor.
+	enabled, err := u.twoFactor.IsEnabled(user.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if enabled {
+		if err := u.twoFactor.VerifyCode(user.ID, code); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return user, enabled, nil
+}
+
+// DisableUser blocks further logins and revokes every session so existing
+// access tokens stop being accepted by the revocation check.
+func (u *userUsecase) DisableUser(id string) error {
+	if id == "" {
+		return errors.New("id is required")
+	}
+
+	user, err := u.userRepo.GetByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if user.Disabled() {
+		return nil
+	}
+
+	now := time.Now()
+	user.DisabledAt = &now
+	if err := u.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	return u.sessions.RevokeAllSessions(id)
+}
+
+// SetUserRole grants role to a user. Sessions pick the change up on their
+// next request since Authenticate reads the role from the user.
+func (u *userUsecase) SetUserRole(id string, role domain.Role) error {
+	if id == "" {
+		return errors.New("id is required")
+	}
+	if !role.Valid() {
+		return fmt.Errorf("%w: %q", domain.ErrInvalidRole, role)
+	}
+
+	user, err := u.userRepo.GetByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if user.Role == role {
+		return nil
+	}
+
+	if role.RequiresTwoFactor() {
+		enabled, err := u.twoFactor.IsEnabled(id)
+		if err != nil {
+			return err
+		}
+		if !enabled {
+			return domain.ErrTwoFactorMandatory
+		}
+	}
+
+	user.Role = role
+	return u.userRepo.Update(user)
+}
+
+func (u *userUsecase) ListUsers(req domain.ListUsersRequest) (*domain.UserPage, error) {
+	filter := domain.UserFilter{Email: req.Email}
+	page, err := u.paginator.Prepare(req.OrderBy, req.PageSize, req.PageToken, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.Page = page
+	users, err := u.userRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	users, next := pagination.Trim(u.paginator, page, users, func(user *domain.User) pagination.Cursor {
+		return pagination.Cursor{Key: user.Name, CreatedAt: user.CreatedAt, ID: user.ID}
+	})
+	return &domain.UserPage{Users: users, NextPageToken: next}, nil
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/edwinjordan/golang_microservices/services/user/pkg/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeUserService answers ValidateToken from responses and counts calls.
+type fakeUserService struct {
+	pb.UserServiceClient
+	responses map[string]*pb.ValidateTokenResponse
+	err       error
+	calls     int
+}
+
+func (f *fakeUserService) ValidateToken(_ context.Context, in *pb.ValidateTokenRequest, _ ...grpc.CallOption) (*pb.ValidateTokenResponse, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	if resp, ok := f.responses[in.AccessToken]; ok {
+		return resp, nil
+	}
+	return &pb.ValidateTokenResponse{Valid: false}, nil
+}
+
+func validResponse(expiresAt time.Time) *pb.ValidateTokenResponse {
+	return &pb.ValidateTokenResponse{
+		Valid:     true,
+		UserId:    "user-1",
+		SessionId: "session-1",
+		Roles:     []string{"customer"},
+		ExpiresAt: timestamppb.New(expiresAt),
+	}
+}
+
+// expire makes the cached entry for token stale, as if the TTL had passed.
+func expire(i *Introspector, token string) {
+	key := sha256.Sum256([]byte(token))
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	entry := i.entries[key]
+	entry.expiresAt = time.Now().Add(-time.Second)
+	i.entries[key] = entry
+}
+
+func TestCheckCachesWithinTTL(t *testing.T) {
+	client := &fakeUserService{responses: map[string]*pb.ValidateTokenResponse{
+		"good": validResponse(time.Now().Add(time.Hour)),
+	}}
+	i := NewIntrospector(client, time.Minute)
+
+	for range 3 {
+		principal, err := i.Check(context.Background(), "good")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if principal.UserID != "user-1" || principal.SessionID != "session-1" || !principal.HasRole("customer") {
+			t.Fatalf("principal = %+v", principal)
+		}
+	}
+	if client.calls != 1 {
+		t.Errorf("ValidateToken called %d times, want 1", client.calls)
+	}
+}
+
+// TestCheckSeesRevocationAfterTTL revokes the session behind a cached token:
+// the cached principal is served until the entry expires, then the token is
+// rejected.
+func TestCheckSeesRevocationAfterTTL(t *testing.T) {
+	client := &fakeUserService{responses: map[string]*pb.ValidateTokenResponse{
+		"good": validResponse(time.Now().Add(time.Hour)),
+	}}
+	i := NewIntrospector(client, time.Minute)
+
+	if _, err := i.Check(context.Background(), "good"); err != nil {
+		t.Fatal(err)
+	}
+	delete(client.responses, "good")
+
+	if _, err := i.Check(context.Background(), "good"); err != nil {
+		t.Errorf("within TTL: err = %v, want the cached principal", err)
+	}
+
+	expire(i, "good")
+	if _, err := i.Check(context.Background(), "good"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("after TTL: err = %v, want ErrUnauthenticated", err)
+	}
+	if client.calls != 2 {
+		t.Errorf("ValidateToken called %d times, want 2", client.calls)
+	}
+
+	// The rejection is cached too.
+	if _, err := i.Check(context.Background(), "good"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("cached rejection: err = %v, want ErrUnauthenticated", err)
+	}
+	if client.calls != 2 {
+		t.Errorf("ValidateToken called %d times, want 2", client.calls)
+	}
+}
+
+func TestCheckCapsCacheAtTokenExpiry(t *testing.T) {
+	expiresAt := time.Now().Add(time.Second)
+	client := &fakeUserService{responses: map[string]*pb.ValidateTokenResponse{
+		"short": validResponse(expiresAt),
+	}}
+	i := NewIntrospector(client, time.Hour)
+
+	if _, err := i.Check(context.Background(), "short"); err != nil {
+		t.Fatal(err)
+	}
+	entry := i.entries[sha256.Sum256([]byte("short"))]
+	if !entry.expiresAt.Equal(expiresAt) {
+		t.Errorf("cache entry expires at %v, want the token's expiry %v", entry.expiresAt, expiresAt)
+	}
+}
+
+func TestCheckDoesNotCacheErrors(t *testing.T) {
+	unavailable := errors.New("user service unavailable")
+	client := &fakeUserService{err: unavailable}
+	i := NewIntrospector(client, time.Minute)
+
+	if _, err := i.Check(context.Background(), "good"); !errors.Is(err, unavailable) {
+		t.Fatalf("err = %v, want %v", err, unavailable)
+	}
+
+	client.err = nil
+	client.responses = map[string]*pb.ValidateTokenResponse{"good": validResponse(time.Now().Add(time.Hour))}
+	if _, err := i.Check(context.Background(), "good"); err != nil {
+		t.Errorf("after recovery: %v", err)
+	}
+	if client.calls != 2 {
+		t.Errorf("ValidateToken called %d times, want 2", client.calls)
+	}
+}
+
+func TestCheckRejectsEmptyToken(t *testing.T) {
+	client := &fakeUserService{}
+	i := NewIntrospector(client, time.Minute)
+
+	if _, err := i.Check(context.Background(), ""); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("err = %v, want ErrUnauthenticated", err)
+	}
+	if client.calls != 0 {
+		t.Errorf("ValidateToken called %d times, want 0", client.calls)
+	}
+}